@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	criterionMet     = "met"
+	criterionUnmet   = "unmet"
+	criterionUnknown = "unknown"
+
+	criterionBasisAgent = "agent"
+	criterionBasisDiff  = "diff"
+)
+
+var (
+	taskListItemPattern    = regexp.MustCompile(`^\s*[-*+]\s+\[[ xX]\]\s+(.+)$`)
+	bulletItemPattern      = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.+)$`)
+	criteriaHeadingPattern = regexp.MustCompile(`(?i)^\s*(?:#{1,6}\s*|\*\*|__)?\s*(acceptance criteria|definition of done|requirements)\b`)
+	markdownHeadingPattern = regexp.MustCompile(`^\s*(?:#{1,6}\s|\*\*[^*]+\*\*\s*:?\s*$)`)
+	criterionReportPattern = regexp.MustCompile(`(?im)^\W*criterion\s+(\d+)\s*[:\-]\s*(not done|not met|unmet|done|met|satisfied|partial)\b`)
+	backtickTermPattern    = regexp.MustCompile("`([^`]+)`")
+	identifierTermPattern  = regexp.MustCompile(`\b[A-Za-z_]\w*(?:[./]\w+)+\b|\b\w*_\w+\b|\b[a-z]+[A-Z]\w*\b`)
+)
+
+type criterionResult struct {
+	Text   string `json:"text"`
+	Status string `json:"status"`
+	Basis  string `json:"basis,omitempty"`
+}
+
+// parseAcceptanceCriteria collects markdown task-list items anywhere in the
+// body plus the bullets under an "Acceptance criteria" / "Definition of done"
+// / "Requirements" heading.
+func parseAcceptanceCriteria(body string) []string {
+	var items []string
+	seen := make(map[string]struct{})
+	add := func(text string) {
+		text = strings.TrimSpace(text)
+		key := strings.ToLower(text)
+		if _, ok := seen[key]; ok || text == "" {
+			return
+		}
+		seen[key] = struct{}{}
+		items = append(items, text)
+	}
+
+	inSection := false
+	for _, line := range strings.Split(body, "\n") {
+		if m := taskListItemPattern.FindStringSubmatch(line); m != nil {
+			add(m[1])
+			continue
+		}
+		if criteriaHeadingPattern.MatchString(line) {
+			inSection = true
+			continue
+		}
+		if inSection && markdownHeadingPattern.MatchString(line) {
+			inSection = false
+			continue
+		}
+		if inSection {
+			if m := bulletItemPattern.FindStringSubmatch(line); m != nil {
+				add(m[1])
+			}
+		}
+	}
+	return items
+}
+
+func appendAcceptanceCriteria(prompt string, criteria []string) string {
+	if len(criteria) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(prompt, "\n"))
+	b.WriteString("\n\n## Acceptance Criteria\n\nAddress every item below. End your final message with one line per item, `CRITERION <n>: done` or `CRITERION <n>: not done`.\n\n")
+	for i, item := range criteria {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, item)
+	}
+	return b.String()
+}
+
+// evaluateCriteria marks each criterion from the agent's own CRITERION lines
+// when it gave one, and otherwise from the diff: a criterion that names code
+// (`backticked` terms, paths, identifiers) is met when every such term shows
+// up in the diff. Everything else stays unknown.
+func evaluateCriteria(criteria []string, agentOutput, diff string) []criterionResult {
+	reported := make(map[int]string)
+	for _, m := range criterionReportPattern.FindAllStringSubmatch(agentOutput, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(m[2]) {
+		case "done", "met", "satisfied":
+			reported[n] = criterionMet
+		default:
+			reported[n] = criterionUnmet
+		}
+	}
+
+	results := make([]criterionResult, 0, len(criteria))
+	for i, text := range criteria {
+		res := criterionResult{Text: text, Status: criterionUnknown}
+		if status, ok := reported[i+1]; ok {
+			res.Status = status
+			res.Basis = criterionBasisAgent
+		} else if terms := criterionTerms(text); len(terms) > 0 {
+			res.Status = criterionMet
+			res.Basis = criterionBasisDiff
+			for _, term := range terms {
+				if !strings.Contains(diff, term) {
+					res.Status = criterionUnmet
+					break
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func criterionTerms(text string) []string {
+	var terms []string
+	for _, m := range backtickTermPattern.FindAllStringSubmatch(text, -1) {
+		terms = append(terms, m[1])
+	}
+	if len(terms) > 0 {
+		return terms
+	}
+	for _, term := range identifierTermPattern.FindAllString(text, -1) {
+		if len(term) >= 4 {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// checkAcceptanceCriteria reports how the committed change lines up with the
+// issue's criteria. It never fails the issue.
+func (r *runner) checkAcceptanceCriteria(criteria []string, startHead, logOutput string, attempt *issueAttempt) {
+	if len(criteria) == 0 {
+		return
+	}
+	diff, err := r.gitOutput("diff", startHead+"..HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not diff the change to check acceptance criteria: %v\n", err)
+	}
+	results := evaluateCriteria(criteria, logOutput, diff)
+	attempt.criteria = results
+	met := 0
+	for _, res := range results {
+		if res.Status == criterionMet {
+			met++
+		}
+	}
+	r.printf(r.colors.Blue, "Acceptance criteria: %d/%d met\n", met, len(results))
+	for _, res := range results {
+		color := r.colors.Yellow
+		switch res.Status {
+		case criterionMet:
+			color = r.colors.Green
+		case criterionUnmet:
+			color = r.colors.Red
+		}
+		basis := ""
+		if res.Basis != "" {
+			basis = " (" + res.Basis + ")"
+		}
+		r.printf(color, "  [%s] %s%s\n", res.Status, res.Text, basis)
+	}
+}
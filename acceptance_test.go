@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAcceptanceCriteria(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "task list anywhere",
+			body: "Intro\n\n- [ ] Add `--foo` flag\n* [x] Update docs\n- plain bullet\n",
+			want: []string{"Add `--foo` flag", "Update docs"},
+		},
+		{
+			name: "section ends at next heading",
+			body: "## Acceptance Criteria\n- Returns 404 for missing ids\n1. Logs the request id\n\n## Notes\n- not a criterion\n",
+			want: []string{"Returns 404 for missing ids", "Logs the request id"},
+		},
+		{
+			name: "bold heading and dedupe",
+			body: "**Definition of done:**\n- Tests pass\n- [ ] tests pass\n",
+			want: []string{"Tests pass"},
+		},
+		{
+			name: "none",
+			body: "Just a description.\n- a bullet",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := parseAcceptanceCriteria(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseAcceptanceCriteria() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendAcceptanceCriteria(t *testing.T) {
+	t.Parallel()
+
+	if got := appendAcceptanceCriteria("prompt\n", nil); got != "prompt\n" {
+		t.Fatalf("no criteria changed the prompt: %q", got)
+	}
+	got := appendAcceptanceCriteria("prompt\n", []string{"First", "Second"})
+	for _, want := range []string{"## Acceptance Criteria", "CRITERION <n>: done", "1. First\n", "2. Second\n"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestEvaluateCriteria(t *testing.T) {
+	t.Parallel()
+
+	criteria := []string{
+		"Add the `--dry-run` flag",
+		"Rename parseConfig",
+		"Update internal/db/store.go",
+		"Make it faster",
+		"Write docs",
+	}
+	agent := "All done.\nCRITERION 5: not done\n- criterion 4: done\n"
+	diff := "+\t--dry-run\n+func parseConfig() {}\n"
+
+	got := evaluateCriteria(criteria, agent, diff)
+	want := []criterionResult{
+		{Text: criteria[0], Status: criterionMet, Basis: criterionBasisDiff},
+		{Text: criteria[1], Status: criterionMet, Basis: criterionBasisDiff},
+		{Text: criteria[2], Status: criterionUnmet, Basis: criterionBasisDiff},
+		{Text: criteria[3], Status: criterionMet, Basis: criterionBasisAgent},
+		{Text: criteria[4], Status: criterionUnmet, Basis: criterionBasisAgent},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("evaluateCriteria() = %+v\nwant %+v", got, want)
+	}
+
+	if got := evaluateCriteria([]string{"Make it nicer"}, "", ""); got[0].Status != criterionUnknown {
+		t.Fatalf("criterion without terms = %+v", got[0])
+	}
+}
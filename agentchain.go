@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	onLimitWait   = "wait"
+	onLimitSwitch = "switch"
+)
+
+// parseAgentChain splits an --agent value such as "claude,codex,gemini" into
+// the agent to start with and the agents to fall back to, in order.
+func parseAgentChain(value string) (string, []string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		agent := strings.ToLower(strings.TrimSpace(part))
+		if !isSupportedAgent(agent) {
+			return "", nil, fmt.Errorf("must be one of: %s (got %q)", strings.Join(knownAgents(), ", "), part)
+		}
+		if seen[agent] {
+			return "", nil, fmt.Errorf("lists %s twice", agent)
+		}
+		seen[agent] = true
+		chain = append(chain, agent)
+	}
+	return chain[0], chain[1:], nil
+}
+
+// fallbackFailures are the failures the next agent in the chain may do
+// better on. Fetch and git failures would fail the same way for any agent.
+var fallbackFailures = map[failureCategory]bool{
+	failureAgentCrash:   true,
+	failureTimeout:      true,
+	failureNoChanges:    true,
+	failureBuild:        true,
+	failureVerification: true,
+	failureLint:         true,
+	failureGate:         true,
+	failureUnclassified: true,
+}
+
+// processIssue runs the issue with the --agent chain: when an agent fails,
+// its changes are rolled back and the next agent gets the issue. With
+// --on-limit switch, an agent at its session limit hands the issue, with its
+// partial work, to the next agent. Issues with an agent of their own (issue
+// file, plan) do not fall back.
+func (r *runner) processIssue(idx, total int, entry issueEntry) issueResult {
+	if entry.Agent != "" || len(r.opts.FallbackAgents) == 0 || r.opts.DryRun {
+		return r.runIssue(idx, total, entry)
+	}
+	r.locked(func() {
+		if r.limitedAgents == nil {
+			r.limitedAgents = make(map[string]time.Time)
+		}
+	})
+	chain := r.agentChain()
+	var result issueResult
+	for i, agent := range chain {
+		worker := r
+		if i < len(chain)-1 {
+			// The next agent starts from the same commit as this one.
+			scoped := *r
+			scoped.opts.RollbackOnFailure = true
+			scoped.switchOnLimit = r.opts.OnLimit == onLimitSwitch
+			worker = &scoped
+		}
+		entry.Agent = agent
+		result = worker.runIssue(idx, total, entry)
+		if result == resultRetry && worker.switchOnLimit {
+			r.printf(r.colors.Yellow, "%s is at its session limit; switching to %s for #%s\n", agentDisplayName(agent), agentDisplayName(chain[i+1]), entry.ID)
+			continue
+		}
+		if result != resultFailed {
+			break
+		}
+		var failure failureCategory
+		r.locked(func() { failure = r.failures[entry.ID] })
+		if i == len(chain)-1 || !fallbackFailures[failure] {
+			return result
+		}
+		r.printf(r.colors.Yellow, "%s failed on #%s (%s); falling back to %s\n", agentDisplayName(agent), entry.ID, failure, agentDisplayName(chain[i+1]))
+	}
+	if result == resultSuccess && entry.Agent != r.opts.Agent {
+		r.locked(func() {
+			delete(r.failures, entry.ID)
+			r.fallbacks[entry.ID] = entry.Agent
+		})
+	}
+	return result
+}
+
+// agentChain is the --agent list without the agents that were switched away
+// from at a session limit that has not reset yet. The last agent always
+// stays; it waits for its reset as usual.
+func (r *runner) agentChain() []string {
+	all := append([]string{r.opts.Agent}, r.opts.FallbackAgents...)
+	now := r.now()
+	var chain []string
+	r.locked(func() {
+		for _, agent := range all[:len(all)-1] {
+			if until, ok := r.limitedAgents[agent]; !ok || !now.Before(until) {
+				chain = append(chain, agent)
+			}
+		}
+	})
+	return append(chain, all[len(all)-1])
+}
+
+func (r *runner) printFallbackSummary() {
+	if len(r.fallbacks) == 0 {
+		return
+	}
+	issues := make([]string, 0, len(r.fallbacks))
+	for issue := range r.fallbacks {
+		issues = append(issues, issue)
+	}
+	sort.Strings(issues)
+	r.printf(r.colors.Yellow, "Done by a fallback agent:\n")
+	for _, issue := range issues {
+		r.printf(r.colors.Yellow, "  #%s: %s\n", issue, agentDisplayName(r.fallbacks[issue]))
+	}
+}
+
+// agentModel is an agent with the model it runs, as an issue entry or the
+// command line sets them.
+type agentModel struct {
+	Agent string
+	Model string
+}
+
+func (a agentModel) String() string {
+	if a.Model == "" {
+		return agentDisplayName(a.Agent)
+	}
+	return fmt.Sprintf("%s (%s)", agentDisplayName(a.Agent), a.Model)
+}
+
+// agentOutcome is the agent and model that last ran an issue and how it
+// ended.
+type agentOutcome struct {
+	agentModel
+	Result issueResult
+}
+
+// recordAgentOutcome keeps the agent that finished an issue for the per-agent
+// summary. After a fallback, the last agent in the chain is the one counted.
+func (r *runner) recordAgentOutcome(issue string, result issueResult) {
+	if result != resultSuccess && result != resultFailed {
+		return
+	}
+	r.locked(func() {
+		if r.outcomes != nil {
+			r.outcomes[issue] = agentOutcome{agentModel: agentModel{Agent: r.opts.Agent, Model: r.opts.Model}, Result: result}
+		}
+	})
+}
+
+// queueAgents counts the issues of the queue that are still to run by the
+// agent and model that run them: the entry's own, else --agent and --model.
+func (r *runner) queueAgents(issues []issueEntry) map[agentModel]int {
+	counts := make(map[agentModel]int)
+	for _, entry := range issues {
+		if r.isCompleted(entry.ID) || r.isSkipped(entry.ID) {
+			continue
+		}
+		scoped := r.forIssue(entry)
+		counts[agentModel{Agent: scoped.opts.Agent, Model: scoped.opts.Model}]++
+	}
+	return counts
+}
+
+// agentBreakdown lists agents with the most issues first, as "Codex: 2,
+// Claude (opus): 1".
+func agentBreakdown(counts map[agentModel]int) string {
+	agents := make([]agentModel, 0, len(counts))
+	for agent := range counts {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if counts[agents[i]] != counts[agents[j]] {
+			return counts[agents[i]] > counts[agents[j]]
+		}
+		return agents[i].String() < agents[j].String()
+	})
+	parts := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		parts = append(parts, fmt.Sprintf("%s: %d", agent, counts[agent]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// agentSummary is one "<agent>: n succeeded, n failed" line per agent and
+// model, or nothing when a single one ran the whole queue.
+func (r *runner) agentSummary() []string {
+	succeeded := make(map[agentModel]int)
+	failed := make(map[agentModel]int)
+	r.locked(func() {
+		for _, outcome := range r.outcomes {
+			if outcome.Result == resultSuccess {
+				succeeded[outcome.agentModel]++
+			} else {
+				failed[outcome.agentModel]++
+			}
+		}
+	})
+	names := make(map[string]agentModel)
+	for agent := range succeeded {
+		names[agent.String()] = agent
+	}
+	for agent := range failed {
+		names[agent.String()] = agent
+	}
+	if len(names) < 2 {
+		return nil
+	}
+	keys := make([]string, 0, len(names))
+	for name := range names {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, name := range keys {
+		agent := names[name]
+		lines = append(lines, fmt.Sprintf("%s: %d succeeded, %d failed", name, succeeded[agent], failed[agent]))
+	}
+	return lines
+}
+
+func (r *runner) printAgentSummary() {
+	lines := r.agentSummary()
+	if len(lines) == 0 {
+		return
+	}
+	r.printf(r.colors.Blue, "By agent:\n")
+	for _, line := range lines {
+		r.printf(r.colors.Blue, "  %s\n", line)
+	}
+}
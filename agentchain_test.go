@@ -0,0 +1,200 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAgentChain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value     string
+		first     string
+		fallbacks []string
+		wantErr   bool
+	}{
+		{value: "claude", first: "claude"},
+		{value: "Claude, codex,gemini", first: "claude", fallbacks: []string{"codex", "gemini"}},
+		{value: "claude,nope", wantErr: true},
+		{value: "claude,claude", wantErr: true},
+		{value: "claude,", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			first, fallbacks, err := parseAgentChain(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAgentChain(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if first != tt.first || strings.Join(fallbacks, ",") != strings.Join(tt.fallbacks, ",") {
+				t.Fatalf("parseAgentChain(%q) = %q, %v", tt.value, first, fallbacks)
+			}
+		})
+	}
+}
+
+func TestAgentChainFallsBackAfterFailedVerification(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	claude := writeFakeBin(t, "claude", `cat >/dev/null
+echo wrong > wrong.txt
+git add wrong.txt
+git commit -q -m "feat: wrong greeting (#5)"`)
+	codex := writeFakeBin(t, "codex", `echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+
+	opts := options{
+		Agent:          "claude",
+		FallbackAgents: []string{"codex"},
+		ClaudeBin:      claude,
+		CodexBin:       codex,
+		GHBin:          gh,
+		VerifyCmd:      "test -f greeting.txt && test ! -f wrong.txt",
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("result = %v", result)
+	}
+
+	if got := r.fallbacks["5"]; got != "codex" {
+		t.Fatalf("fallback agent = %q, want codex", got)
+	}
+	if len(r.failures) != 0 {
+		t.Fatalf("failures = %v, want none after the fallback succeeded", r.failures)
+	}
+	subjects := runGit(t, repo, "log", "--pretty=format:%s")
+	if strings.Contains(subjects, "wrong greeting") {
+		t.Fatalf("failed attempt was not rolled back:\n%s", subjects)
+	}
+	if st, ok := r.state.get("5"); !ok || st.Agent != "codex" || st.Attempts != 2 {
+		t.Fatalf("state = %+v", st)
+	}
+}
+
+func TestAgentChainStopsOnFetchFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := writeFakeBin(t, "gh", `echo "not found" >&2; exit 1`)
+	codex := writeFakeBin(t, "codex", `echo "codex should not run" >&2; exit 1`)
+
+	opts := options{
+		Agent:          "claude",
+		FallbackAgents: []string{"codex"},
+		CodexBin:       codex,
+		GHBin:          gh,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultFailed {
+		t.Fatalf("result = %v", result)
+	}
+	if r.failures["5"] != failureFetch || len(r.fallbacks) != 0 {
+		t.Fatalf("failures = %v, fallbacks = %v", r.failures, r.fallbacks)
+	}
+}
+
+func TestOnLimitSwitchHandsPartialWorkToNextAgent(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	claude := writeFakeBin(t, "claude", `cat >/dev/null
+echo draft > draft.txt
+echo "You hit your usage limit. It resets at 5:00 PM UTC."
+exit 1`)
+	codex := writeFakeBin(t, "codex", `test -f draft.txt || exit 1
+echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+
+	opts := options{
+		Agent:          "claude",
+		FallbackAgents: []string{"codex"},
+		OnLimit:        onLimitSwitch,
+		ClaudeBin:      claude,
+		CodexBin:       codex,
+		GHBin:          gh,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("result = %v", result)
+	}
+
+	if r.fallbacks["5"] != "codex" {
+		t.Fatalf("fallbacks = %v", r.fallbacks)
+	}
+	if !strings.Contains(runGit(t, repo, "log", "--pretty=format:%s"), "session limit hit") {
+		t.Fatal("partial work of the limited agent was not committed")
+	}
+	if chain := r.agentChain(); strings.Join(chain, ",") != "codex" {
+		t.Fatalf("chain = %v, want the limited agent left out until its reset", chain)
+	}
+}
+
+func TestOnLimitOptions(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseArgs([]string{"--on-limit", "sleep"}); err == nil {
+		t.Fatal("unknown --on-limit value should fail")
+	}
+	opts, err := parseArgs([]string{"--on-limit", "switch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "agent list") {
+		t.Fatalf("validateOptions() = %v, want the agent list requirement", err)
+	}
+	opts, err = parseArgs([]string{"--on-limit", "switch", "--agent", "claude,codex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err != nil {
+		t.Fatalf("validateOptions() = %v", err)
+	}
+}
+
+func TestPerIssueAgentBreakdown(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add file", "add a file")
+	claude := writeFakeBin(t, "claude", `cat >/dev/null
+echo claude > claude.txt
+git add claude.txt
+git commit -q -m "feat: add claude.txt"`)
+	codex := writeFakeBin(t, "codex", `echo broken >&2; exit 1`)
+
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: claude,
+		CodexBin:  codex,
+		GHBin:     gh,
+	}
+	r := newTestRunner(t, repo, opts)
+	issues := []issueEntry{{ID: "12", Agent: "codex"}, {ID: "13"}, {ID: "14", Agent: "codex"}}
+	if got := agentBreakdown(r.queueAgents(issues)); got != "Codex: 2, Claude: 1" {
+		t.Fatalf("banner breakdown = %q", got)
+	}
+
+	if result := r.processIssue(1, 2, issues[0]); result != resultFailed {
+		t.Fatalf("#12 result = %v", result)
+	}
+	if lines := r.agentSummary(); lines != nil {
+		t.Fatalf("summary with one agent = %v, want none", lines)
+	}
+	if result := r.processIssue(2, 2, issues[1]); result != resultSuccess {
+		t.Fatalf("#13 result = %v", result)
+	}
+	want := "Claude: 1 succeeded, 0 failed\nCodex: 0 succeeded, 1 failed"
+	if got := strings.Join(r.agentSummary(), "\n"); got != want {
+		t.Fatalf("summary = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// aiderIgnore keeps aider's chat history and repo-map cache out of git
+// status, so they are not taken for the agent's changes.
+const aiderIgnore = ".aider*"
+
+// aider retries rate limits itself and prints litellm's error once it gives
+// up.
+var aiderRateLimitPattern = regexp.MustCompile(`(?i)(litellm\.RateLimitError|API provider has rate limited you)`)
+
+// aiderArgs runs aider once on the prompt and exits. aider commits each edit
+// itself; --no-gitignore stops it from editing .gitignore, which
+// ignoreAiderFiles covers instead.
+func (r *runner) aiderArgs(prompt string, sampling []string) []string {
+	args := []string{
+		"--yes-always",
+		"--no-pretty",
+		"--no-stream",
+		"--no-check-update",
+		"--no-gitignore",
+	}
+	if r.opts.Model != "" {
+		args = append(args, "--model", r.opts.Model)
+	}
+	args = append(args, sampling...)
+	return append(args, "--message", prompt)
+}
+
+// ignoreAiderFiles adds .aider* to the repository's local exclude file.
+func (r *runner) ignoreAiderFiles() error {
+	path, err := r.gitOutput("rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.repoRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == aiderIgnore {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		prefix = "\n"
+	}
+	if _, err := f.WriteString(prefix + aiderIgnore + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
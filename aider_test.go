@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAiderCommand(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	r := &runner{repoRoot: repo, opts: options{Agent: "aider", AiderBin: "/opt/aider", Model: "sonnet"}}
+	cmd, err := r.buildAgentCommand("fix #5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(cmd.Args[1:], " ")
+	if cmd.Path != "/opt/aider" || !strings.Contains(args, "--yes-always") || !strings.Contains(args, "--model sonnet") || !strings.HasSuffix(args, "--message fix #5") {
+		t.Fatalf("command = %s %s", cmd.Path, args)
+	}
+
+	// The chat history aider writes must not show up as changes.
+	if err := os.WriteFile(filepath.Join(repo, ".aider.chat.history.md"), []byte("chat"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, err := r.workingTreeDirty(); err != nil || dirty {
+		t.Fatalf("workingTreeDirty() = %v, %v; aider files should be excluded", dirty, err)
+	}
+	if err := r.ignoreAiderFiles(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(repo, ".git", "info", "exclude"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), aiderIgnore+"\n"); n != 1 {
+		t.Fatalf("exclude has %d %s lines, want 1:\n%s", n, aiderIgnore, data)
+	}
+}
+
+func TestAiderCommitsAreDetected(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	aider := writeFakeBin(t, "aider", `echo chat > .aider.chat.history.md
+echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+
+	opts := options{
+		Agent:    "aider",
+		AiderBin: aider,
+		GHBin:    gh,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("result = %v", result)
+	}
+	if files := runGit(t, repo, "show", "--name-only", "--pretty=format:", "HEAD"); strings.TrimSpace(files) != "greeting.txt" {
+		t.Fatalf("HEAD files = %q", files)
+	}
+}
@@ -0,0 +1,736 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commandAPIAgent = "api-agent"
+
+	apiProviderAnthropic = "anthropic"
+	apiProviderOpenAI    = "openai"
+
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	defaultOpenAIBaseURL    = "https://api.openai.com/v1"
+	anthropicAPIVersion     = "2023-06-01"
+
+	apiMaxTurns        = 200
+	apiMaxRetries      = 3
+	apiMaxRetryWait    = time.Minute
+	apiToolOutputLimit = 30000
+	apiCommandTimeout  = 10 * time.Minute
+
+	// defaultAPIMaxTokens caps each Anthropic reply unless --api-max-tokens
+	// sets another limit.
+	defaultAPIMaxTokens = 16384
+)
+
+var apiProviders = []string{apiProviderAnthropic, apiProviderOpenAI}
+
+// apiKeyEnv is the variable each provider's key is read from.
+var apiKeyEnv = map[string]string{
+	apiProviderAnthropic: "ANTHROPIC_API_KEY",
+	apiProviderOpenAI:    "OPENAI_API_KEY",
+}
+
+var (
+	// apiRateLimitPattern is how api-agent reports a rate limit it could not
+	// wait out itself.
+	apiRateLimitPattern = regexp.MustCompile(`API rate limit \(HTTP 429\)`)
+	apiRetryPattern     = regexp.MustCompile(`try again in (\d+) seconds`)
+)
+
+const apiSystemPrompt = `You are a coding agent working in a git repository, which is your current directory. Use the tools to read and change files and to run commands (tests, builds, git). Work until the task is done, then reply with a short summary of what you changed.`
+
+// contextFilesForAPI are added to the system prompt when the repository
+// has them, as the vendor CLIs do with their own.
+var contextFilesForAPI = []string{"AGENTS.md", "CLAUDE.md"}
+
+type apiTool struct {
+	Name        string
+	Description string
+	Params      []string
+}
+
+var apiTools = []apiTool{
+	{Name: "bash", Description: "Run a shell command in the repository and return its output and exit status.", Params: []string{"command"}},
+	{Name: "read_file", Description: "Read a file of the repository.", Params: []string{"path"}},
+	{Name: "write_file", Description: "Create or overwrite a file of the repository with content.", Params: []string{"path", "content"}},
+	{Name: "edit_file", Description: "Replace the one occurrence of old_text in a file with new_text.", Params: []string{"path", "old_text", "new_text"}},
+}
+
+func (t apiTool) schema() map[string]any {
+	props := make(map[string]any, len(t.Params))
+	for _, param := range t.Params {
+		props[param] = map[string]any{"type": "string"}
+	}
+	return map[string]any{"type": "object", "properties": props, "required": t.Params}
+}
+
+type apiToolCall struct {
+	ID    string
+	Name  string
+	Input string
+	// Truncated is set when the reply hit its token limit in the middle of
+	// the call's arguments, which are then incomplete JSON.
+	Truncated bool
+}
+
+type apiToolResult struct {
+	ID      string
+	Output  string
+	IsError bool
+}
+
+// apiTurn is one assistant reply: its text, the tools it calls and what it
+// cost.
+type apiTurn struct {
+	Text         string
+	Calls        []apiToolCall
+	InputTokens  int
+	OutputTokens int
+}
+
+// apiClient keeps the conversation in its provider's format.
+type apiClient interface {
+	next(ctx context.Context, out io.Writer) (apiTurn, error)
+	addResults(results []apiToolResult)
+}
+
+// apiStatusError is a response the client gave up on.
+type apiStatusError struct {
+	Status     int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *apiStatusError) Error() string {
+	body := strings.TrimSpace(e.Body)
+	if e.Status != http.StatusTooManyRequests {
+		return fmt.Sprintf("API request failed (HTTP %d): %s", e.Status, body)
+	}
+	msg := "API rate limit (HTTP 429): " + body
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf("; try again in %d seconds", int(math.Ceil(e.RetryAfter.Seconds())))
+	}
+	return msg
+}
+
+type apiHTTP struct {
+	client *http.Client
+	out    io.Writer
+	sleep  func(time.Duration)
+}
+
+// post sends body as JSON, retrying rate limits and overloaded or failing
+// servers a few times when they ask for a short wait.
+func (h *apiHTTP) post(ctx context.Context, url string, headers map[string]string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := h.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		statusErr := &apiStatusError{Status: resp.StatusCode, Body: string(text), RetryAfter: retryAfter(resp.Header)}
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		wait := statusErr.RetryAfter
+		if wait == 0 {
+			wait = time.Duration(1<<attempt) * 2 * time.Second
+		}
+		if !retryable || attempt >= apiMaxRetries || wait > apiMaxRetryWait {
+			return nil, statusErr
+		}
+		fmt.Fprintf(h.out, "\nAPI returned HTTP %d, retrying in %s\n", resp.StatusCode, wait)
+		h.sleep(wait)
+	}
+}
+
+// retryAfter is the wait a response asks for: Retry-After in seconds, or
+// OpenAI's X-Ratelimit-Reset-Requests, a duration such as "6m0s" or "20ms"
+// (plain seconds are accepted too).
+func retryAfter(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Retry-After"))); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	reset := strings.TrimSpace(header.Get("X-Ratelimit-Reset-Requests"))
+	if d, err := time.ParseDuration(reset); err == nil && d > 0 {
+		return d
+	}
+	if seconds, err := strconv.Atoi(reset); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// readSSE calls handle with the data of every server-sent event until the
+// stream ends or sends [DONE].
+func readSSE(body io.Reader, handle func(data []byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+		if data == "" {
+			continue
+		}
+		if err := handle([]byte(data)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// apiRequest is what every request of a conversation shares.
+type apiRequest struct {
+	baseURL     string
+	key         string
+	model       string
+	temperature string
+	maxTokens   int
+}
+
+// body adds the model and the temperature, when one was given, to a
+// request body.
+func (req apiRequest) body(fields map[string]any) map[string]any {
+	fields["model"] = req.model
+	if t, err := strconv.ParseFloat(req.temperature, 64); err == nil {
+		fields["temperature"] = t
+	}
+	return fields
+}
+
+type anthropicClient struct {
+	apiRequest
+	http     *apiHTTP
+	system   string
+	messages []map[string]any
+}
+
+func (c *anthropicClient) next(ctx context.Context, out io.Writer) (apiTurn, error) {
+	tools := make([]map[string]any, 0, len(apiTools))
+	for _, tool := range apiTools {
+		tools = append(tools, map[string]any{"name": tool.Name, "description": tool.Description, "input_schema": tool.schema()})
+	}
+	body := c.body(map[string]any{
+		"max_tokens": c.maxTokens,
+		"system":     c.system,
+		"messages":   c.messages,
+		"tools":      tools,
+		"stream":     true,
+	})
+	headers := map[string]string{"x-api-key": c.key, "anthropic-version": anthropicAPIVersion}
+	resp, err := c.http.post(ctx, strings.TrimRight(c.baseURL, "/")+"/v1/messages", headers, body)
+	if err != nil {
+		return apiTurn{}, err
+	}
+	defer resp.Body.Close()
+
+	type block struct {
+		Type  string
+		ID    string
+		Name  string
+		Text  strings.Builder
+		Input strings.Builder
+	}
+	var turn apiTurn
+	var blocks []*block
+	var stopReason string
+	err = readSSE(resp.Body, func(data []byte) error {
+		var event struct {
+			Type         string `json:"type"`
+			Index        int    `json:"index"`
+			ContentBlock struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"content_block"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+				StopReason  string `json:"stop_reason"`
+			} `json:"delta"`
+			Message struct {
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("decode stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			turn.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			for len(blocks) <= event.Index {
+				blocks = append(blocks, &block{})
+			}
+			blocks[event.Index].Type = event.ContentBlock.Type
+			blocks[event.Index].ID = event.ContentBlock.ID
+			blocks[event.Index].Name = event.ContentBlock.Name
+		case "content_block_delta":
+			if event.Index >= len(blocks) {
+				return fmt.Errorf("stream delta for unknown block %d", event.Index)
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				blocks[event.Index].Text.WriteString(event.Delta.Text)
+				fmt.Fprint(out, event.Delta.Text)
+			case "input_json_delta":
+				blocks[event.Index].Input.WriteString(event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			turn.OutputTokens = event.Usage.OutputTokens
+			stopReason = event.Delta.StopReason
+		case "error":
+			if event.Error.Type == "rate_limit_error" {
+				return &apiStatusError{Status: http.StatusTooManyRequests, Body: event.Error.Message}
+			}
+			return fmt.Errorf("API error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return turn, err
+	}
+
+	content := make([]map[string]any, 0, len(blocks))
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if b.Text.Len() > 0 {
+				turn.Text += b.Text.String()
+				content = append(content, map[string]any{"type": "text", "text": b.Text.String()})
+			}
+		case "tool_use":
+			call := newAPIToolCall(b.ID, b.Name, b.Input.String(), stopReason == "max_tokens")
+			turn.Calls = append(turn.Calls, call)
+			input := call.Input
+			if call.Truncated {
+				// The cut-off arguments cannot be sent back as JSON; the
+				// call still needs its result, which reports the cut.
+				input = "{}"
+			}
+			content = append(content, map[string]any{"type": "tool_use", "id": b.ID, "name": b.Name, "input": json.RawMessage(input)})
+		}
+	}
+	if len(content) > 0 {
+		c.messages = append(c.messages, map[string]any{"role": "assistant", "content": content})
+	}
+	return turn, nil
+}
+
+func (c *anthropicClient) addResults(results []apiToolResult) {
+	content := make([]map[string]any, 0, len(results))
+	for _, res := range results {
+		content = append(content, map[string]any{"type": "tool_result", "tool_use_id": res.ID, "content": res.Output, "is_error": res.IsError})
+	}
+	c.messages = append(c.messages, map[string]any{"role": "user", "content": content})
+}
+
+type openAIClient struct {
+	apiRequest
+	http     *apiHTTP
+	messages []map[string]any
+}
+
+func (c *openAIClient) next(ctx context.Context, out io.Writer) (apiTurn, error) {
+	tools := make([]map[string]any, 0, len(apiTools))
+	for _, tool := range apiTools {
+		tools = append(tools, map[string]any{"type": "function", "function": map[string]any{"name": tool.Name, "description": tool.Description, "parameters": tool.schema()}})
+	}
+	body := c.body(map[string]any{
+		"messages":       c.messages,
+		"tools":          tools,
+		"stream":         true,
+		"stream_options": map[string]any{"include_usage": true},
+	})
+	if c.maxTokens > 0 {
+		body["max_completion_tokens"] = c.maxTokens
+	}
+	headers := map[string]string{"Authorization": "Bearer " + c.key}
+	resp, err := c.http.post(ctx, strings.TrimRight(c.baseURL, "/")+"/chat/completions", headers, body)
+	if err != nil {
+		return apiTurn{}, err
+	}
+	defer resp.Body.Close()
+
+	type call struct {
+		ID   string
+		Name string
+		Args strings.Builder
+	}
+	var turn apiTurn
+	var text strings.Builder
+	var calls []*call
+	var finishReason string
+	err = readSSE(resp.Body, func(data []byte) error {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("API error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			turn.InputTokens, turn.OutputTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				fmt.Fprint(out, choice.Delta.Content)
+			}
+			for _, delta := range choice.Delta.ToolCalls {
+				for len(calls) <= delta.Index {
+					calls = append(calls, &call{})
+				}
+				tc := calls[delta.Index]
+				if delta.ID != "" {
+					tc.ID = delta.ID
+				}
+				if delta.Function.Name != "" {
+					tc.Name = delta.Function.Name
+				}
+				tc.Args.WriteString(delta.Function.Arguments)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return turn, err
+	}
+
+	turn.Text = text.String()
+	message := map[string]any{"role": "assistant", "content": turn.Text}
+	var toolCalls []map[string]any
+	for _, tc := range calls {
+		call := newAPIToolCall(tc.ID, tc.Name, tc.Args.String(), finishReason == "length")
+		turn.Calls = append(turn.Calls, call)
+		toolCalls = append(toolCalls, map[string]any{"id": tc.ID, "type": "function", "function": map[string]any{"name": tc.Name, "arguments": call.Input}})
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+	}
+	c.messages = append(c.messages, message)
+	return turn, nil
+}
+
+// newAPIToolCall is a tool call as the stream left it. When the reply hit its
+// token limit and the arguments are not valid JSON, the call is marked
+// truncated instead of being run with half its input.
+func newAPIToolCall(id, name, input string, limited bool) apiToolCall {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		input = "{}"
+	}
+	return apiToolCall{ID: id, Name: name, Input: input, Truncated: limited && !json.Valid([]byte(input))}
+}
+
+func (c *openAIClient) addResults(results []apiToolResult) {
+	for _, res := range results {
+		c.messages = append(c.messages, map[string]any{"role": "tool", "tool_call_id": res.ID, "content": res.Output})
+	}
+}
+
+// apiAgent runs the tool loop: the model replies, the tools it calls run in
+// root, and their results go back until it stops calling tools.
+type apiAgent struct {
+	client apiClient
+	root   string
+	out    io.Writer
+}
+
+func (a *apiAgent) run(ctx context.Context) (input, output int, err error) {
+	for turn := 0; turn < apiMaxTurns; turn++ {
+		reply, err := a.client.next(ctx, a.out)
+		input += reply.InputTokens
+		output += reply.OutputTokens
+		if err != nil {
+			return input, output, err
+		}
+		if len(reply.Calls) == 0 {
+			fmt.Fprintln(a.out)
+			return input, output, nil
+		}
+		if reply.Text != "" {
+			fmt.Fprintln(a.out)
+		}
+		results := make([]apiToolResult, 0, len(reply.Calls))
+		for _, call := range reply.Calls {
+			text, failed := a.runTool(ctx, call)
+			results = append(results, apiToolResult{ID: call.ID, Output: text, IsError: failed})
+		}
+		a.client.addResults(results)
+	}
+	return input, output, fmt.Errorf("stopped after %d turns", apiMaxTurns)
+}
+
+// runTool runs one tool call and returns what the model gets back, and
+// whether the call failed.
+func (a *apiAgent) runTool(ctx context.Context, call apiToolCall) (string, bool) {
+	if call.Truncated {
+		return fmt.Sprintf("the %s call was cut off at the reply's token limit and did not run; split the work into smaller calls (e.g. write a large file in parts)", call.Name), true
+	}
+	var input struct {
+		Command string `json:"command"`
+		Path    string `json:"path"`
+		Content string `json:"content"`
+		OldText string `json:"old_text"`
+		NewText string `json:"new_text"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &input); err != nil {
+		return fmt.Sprintf("invalid arguments for %s: %v", call.Name, err), true
+	}
+	if call.Name == "bash" {
+		fmt.Fprintf(a.out, "$ %s\n", input.Command)
+		return a.runCommand(ctx, input.Command)
+	}
+	fmt.Fprintf(a.out, "[%s %s]\n", call.Name, input.Path)
+	path, err := a.resolve(input.Path)
+	if err != nil {
+		return err.Error(), true
+	}
+	switch call.Name {
+	case "read_file":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err.Error(), true
+		}
+		return truncateToolOutput(string(data)), false
+	case "write_file":
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err.Error(), true
+		}
+		if err := os.WriteFile(path, []byte(input.Content), 0o644); err != nil {
+			return err.Error(), true
+		}
+		return fmt.Sprintf("wrote %d bytes to %s", len(input.Content), input.Path), false
+	case "edit_file":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err.Error(), true
+		}
+		switch n := strings.Count(string(data), input.OldText); {
+		case input.OldText == "":
+			return "old_text is empty", true
+		case n == 0:
+			return "old_text not found in " + input.Path, true
+		case n > 1:
+			return fmt.Sprintf("old_text occurs %d times in %s; include more context", n, input.Path), true
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err.Error(), true
+		}
+		edited := strings.Replace(string(data), input.OldText, input.NewText, 1)
+		if err := os.WriteFile(path, []byte(edited), info.Mode().Perm()); err != nil {
+			return err.Error(), true
+		}
+		return "edited " + input.Path, false
+	}
+	return "unknown tool " + call.Name, true
+}
+
+func (a *apiAgent) runCommand(ctx context.Context, command string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, apiCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = a.root
+	out, err := cmd.CombinedOutput()
+	text := truncateToolOutput(string(out))
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return text, false
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("%s\n[exit status %d]", text, exitErr.ExitCode()), true
+	default:
+		return fmt.Sprintf("%s\n[%v]", text, err), true
+	}
+}
+
+// resolve keeps file tools inside the repository.
+func (a *apiAgent) resolve(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("path is empty")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(a.root, path)
+	}
+	rel, err := filepath.Rel(a.root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository", path)
+	}
+	return filepath.Join(a.root, rel), nil
+}
+
+func truncateToolOutput(text string) string {
+	if len(text) <= apiToolOutputLimit {
+		return text
+	}
+	half := apiToolOutputLimit / 2
+	return text[:half] + fmt.Sprintf("\n[... %d bytes omitted ...]\n", len(text)-apiToolOutputLimit) + text[len(text)-half:]
+}
+
+func apiSystemPromptFor(root string) string {
+	system := apiSystemPrompt
+	for _, name := range contextFilesForAPI {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err == nil && len(bytes.TrimSpace(data)) > 0 {
+			system += "\n\n# " + name + "\n\n" + strings.TrimSpace(string(data))
+		}
+	}
+	return system
+}
+
+func newAPIClient(provider string, req apiRequest, system, prompt string, h *apiHTTP) apiClient {
+	if provider == apiProviderOpenAI {
+		if req.baseURL == "" {
+			req.baseURL = defaultOpenAIBaseURL
+		}
+		return &openAIClient{apiRequest: req, http: h, messages: []map[string]any{
+			{"role": "system", "content": system},
+			{"role": "user", "content": prompt},
+		}}
+	}
+	if req.baseURL == "" {
+		req.baseURL = defaultAnthropicBaseURL
+	}
+	if req.maxTokens <= 0 {
+		req.maxTokens = defaultAPIMaxTokens
+	}
+	return &anthropicClient{apiRequest: req, http: h, system: system, messages: []map[string]any{
+		{"role": "user", "content": prompt},
+	}}
+}
+
+// runAPIAgent is the api agent: ghir runs itself with api-agent, the prompt
+// on stdin and the repository as its directory, so the run is logged, timed
+// out and checked like any agent CLI. The last line is the token usage as
+// JSON.
+func runAPIAgent(opts options) int {
+	key := os.Getenv(apiKeyEnv[opts.APIProvider])
+	if key == "" {
+		fmt.Fprintf(os.Stderr, "error: %s is not set\n", apiKeyEnv[opts.APIProvider])
+		return 1
+	}
+	prompt, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return exitCode(fmt.Errorf("read prompt: %w", err))
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return exitCode(err)
+	}
+	h := &apiHTTP{client: &http.Client{}, out: os.Stdout, sleep: time.Sleep}
+	req := apiRequest{baseURL: opts.APIBaseURL, key: key, model: opts.Model, temperature: opts.Temperature, maxTokens: opts.APIMaxTokens}
+	agent := &apiAgent{
+		client: newAPIClient(opts.APIProvider, req, apiSystemPromptFor(root), string(prompt), h),
+		root:   root,
+		out:    os.Stdout,
+	}
+	input, output, err := agent.run(context.Background())
+	usage, _ := json.Marshal(map[string]any{"type": "result", "usage": map[string]int{"input_tokens": input, "output_tokens": output}})
+	fmt.Println(string(usage))
+	return exitCode(err)
+}
+
+// ghirExecutable is this binary, which --agent api runs.
+func ghirExecutable() string {
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return os.Args[0]
+}
+
+// apiAgentArgs runs ghir's own api-agent command for --agent api.
+func (r *runner) apiAgentArgs() []string {
+	args := []string{commandAPIAgent, "--api-provider", r.opts.APIProvider, "--model", r.opts.Model}
+	if r.opts.APIBaseURL != "" {
+		args = append(args, "--api-base-url", r.opts.APIBaseURL)
+	}
+	if r.opts.APIMaxTokens > 0 {
+		args = append(args, "--api-max-tokens", strconv.Itoa(r.opts.APIMaxTokens))
+	}
+	return args
+}
+
+// waitDurationAPI waits for the Retry-After the API sent, when it sent one.
+func waitDurationAPI(logOutput string, now time.Time, bufferSec int) (int, time.Time) {
+	wait := defaultFallbackWaitSec
+	if match := apiRetryPattern.FindStringSubmatch(logOutput); match != nil {
+		if seconds, err := strconv.Atoi(match[1]); err == nil && seconds > 0 {
+			wait = seconds + bufferSec
+		}
+	}
+	return wait, now.Add(time.Duration(wait) * time.Second)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI serves one canned stream per request and keeps the request bodies.
+type fakeAPI struct {
+	mu       sync.Mutex
+	streams  []string
+	requests []map[string]any
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body map[string]any
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	f.requests = append(f.requests, body)
+	if len(f.streams) == 0 {
+		http.Error(w, "no more replies", http.StatusInternalServerError)
+		return
+	}
+	stream := f.streams[0]
+	f.streams = f.streams[1:]
+	w.Header().Set("Content-Type", "text/event-stream")
+	_, _ = io.WriteString(w, stream)
+}
+
+func sseEvents(events ...string) string {
+	var b strings.Builder
+	for _, event := range events {
+		fmt.Fprintf(&b, "data: %s\n\n", event)
+	}
+	return b.String()
+}
+
+func TestAPIAgentToolLoop(t *testing.T) {
+	t.Parallel()
+
+	anthropic := []string{
+		sseEvents(
+			`{"type":"message_start","message":{"usage":{"input_tokens":100}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Writing the file."}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"write_file"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"notes/hello.txt\", "}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"content\": \"hello\\n\"}"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+			`{"type":"message_stop"}`,
+		),
+		sseEvents(
+			`{"type":"message_start","message":{"usage":{"input_tokens":150}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"tu_2","name":"bash"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"command\": \"cat notes/hello.txt\"}"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":10}}`,
+		),
+		sseEvents(
+			`{"type":"message_start","message":{"usage":{"input_tokens":200}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Done."}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}`,
+		),
+	}
+	openai := []string{
+		sseEvents(
+			`{"choices":[{"delta":{"content":"Writing the file."}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"write_file","arguments":"{\"path\": \"notes/hello.txt\", "}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"content\": \"hello\\n\"}"}}]}}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":100,"completion_tokens":20}}`,
+			`[DONE]`,
+		),
+		sseEvents(
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_2","function":{"name":"bash","arguments":"{\"command\": \"cat notes/hello.txt\"}"}}]}}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":150,"completion_tokens":10}}`,
+			`[DONE]`,
+		),
+		sseEvents(
+			`{"choices":[{"delta":{"content":"Done."}}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":200,"completion_tokens":5}}`,
+			`[DONE]`,
+		),
+	}
+
+	tests := []struct {
+		provider string
+		streams  []string
+	}{
+		{provider: apiProviderAnthropic, streams: anthropic},
+		{provider: apiProviderOpenAI, streams: openai},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.provider, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{streams: tt.streams}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			root := t.TempDir()
+			var out bytes.Buffer
+			h := &apiHTTP{client: server.Client(), out: &out, sleep: func(time.Duration) {}}
+			req := apiRequest{baseURL: server.URL, key: "test-key", model: "test-model", temperature: "0.2"}
+			agent := &apiAgent{client: newAPIClient(tt.provider, req, apiSystemPrompt, "Add notes/hello.txt", h), root: root, out: &out}
+			input, output, err := agent.run(context.Background())
+			if err != nil {
+				t.Fatalf("run: %v\n%s", err, out.String())
+			}
+			if input != 450 || output != 35 {
+				t.Fatalf("tokens = %d in, %d out", input, output)
+			}
+			if data, err := os.ReadFile(filepath.Join(root, "notes", "hello.txt")); err != nil || string(data) != "hello\n" {
+				t.Fatalf("hello.txt = %q, %v", data, err)
+			}
+			for _, want := range []string{"Writing the file.", "[write_file notes/hello.txt]", "$ cat notes/hello.txt", "Done."} {
+				if !strings.Contains(out.String(), want) {
+					t.Fatalf("output lacks %q:\n%s", want, out.String())
+				}
+			}
+
+			if len(api.requests) != 3 {
+				t.Fatalf("requests = %d", len(api.requests))
+			}
+			first := api.requests[0]
+			if first["model"] != "test-model" || first["temperature"] != 0.2 || first["stream"] != true {
+				t.Fatalf("first request = %v", first)
+			}
+			// The last request carries the output of the bash call.
+			last, _ := json.Marshal(api.requests[2]["messages"])
+			if !strings.Contains(string(last), `hello\n`) {
+				t.Fatalf("tool result missing from the conversation: %s", last)
+			}
+		})
+	}
+}
+
+func TestAPIAgentRateLimit(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, `{"type":"error","error":{"type":"rate_limit_error"}}`, http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Retry-After", "900")
+		http.Error(w, `{"type":"error","error":{"type":"rate_limit_error"}}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	var out bytes.Buffer
+	h := &apiHTTP{client: server.Client(), out: &out, sleep: func(d time.Duration) { slept = append(slept, d) }}
+	client := newAPIClient(apiProviderAnthropic, apiRequest{baseURL: server.URL, model: "m"}, "", "hi", h)
+	_, err := client.next(context.Background(), &out)
+	if err == nil || !strings.Contains(err.Error(), "API rate limit (HTTP 429)") || !strings.Contains(err.Error(), "try again in 900 seconds") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 5*time.Second {
+		t.Fatalf("slept = %v", slept)
+	}
+
+	logOutput := "error: " + err.Error()
+	if !detectSessionLimit(logOutput, "api", 1) {
+		t.Fatal("rate limit not detected as a session limit")
+	}
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	if wait, _ := waitDuration(logOutput, now, 60, "api"); wait != 960 {
+		t.Fatalf("wait = %d", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   time.Duration
+	}{
+		{name: "retry-after seconds", header: "Retry-After", value: "5", want: 5 * time.Second},
+		{name: "reset duration", header: "X-Ratelimit-Reset-Requests", value: "6m0s", want: 6 * time.Minute},
+		{name: "reset milliseconds", header: "X-Ratelimit-Reset-Requests", value: "20ms", want: 20 * time.Millisecond},
+		{name: "reset seconds", header: "X-Ratelimit-Reset-Requests", value: "7", want: 7 * time.Second},
+		{name: "unparseable", header: "X-Ratelimit-Reset-Requests", value: "soon"},
+		{name: "date", header: "Retry-After", value: "Wed, 21 Oct 2026 07:28:00 GMT"},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set(tt.header, tt.value)
+		if got := retryAfter(header); got != tt.want {
+			t.Errorf("%s: retryAfter(%s: %s) = %v, want %v", tt.name, tt.header, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAPIAgentTruncatedToolCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		provider string
+		streams  []string
+	}{
+		{
+			provider: apiProviderAnthropic,
+			streams: []string{
+				sseEvents(
+					`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"tu_1","name":"write_file"}}`,
+					`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"big.txt\", \"content\": \"aaa"}}`,
+					`{"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"output_tokens":64}}`,
+				),
+				sseEvents(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+					`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Giving up."}}`),
+			},
+		},
+		{
+			provider: apiProviderOpenAI,
+			streams: []string{
+				sseEvents(
+					`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"write_file","arguments":"{\"path\": \"big.txt\", \"content\": \"aaa"}}]}}]}`,
+					`{"choices":[{"delta":{},"finish_reason":"length"}]}`,
+					`[DONE]`,
+				),
+				sseEvents(`{"choices":[{"delta":{"content":"Giving up."}}]}`, `[DONE]`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.provider, func(t *testing.T) {
+			t.Parallel()
+
+			api := &fakeAPI{streams: tt.streams}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			root := t.TempDir()
+			var out bytes.Buffer
+			h := &apiHTTP{client: server.Client(), out: &out, sleep: func(time.Duration) {}}
+			req := apiRequest{baseURL: server.URL, model: "m", maxTokens: 64}
+			agent := &apiAgent{client: newAPIClient(tt.provider, req, "", "write big.txt", h), root: root, out: &out}
+			if _, _, err := agent.run(context.Background()); err != nil {
+				t.Fatalf("run: %v\n%s", err, out.String())
+			}
+			if _, err := os.Stat(filepath.Join(root, "big.txt")); !os.IsNotExist(err) {
+				t.Fatalf("the cut-off call ran: %v", err)
+			}
+			if len(api.requests) != 2 {
+				t.Fatalf("requests = %d, want the cut reported back", len(api.requests))
+			}
+			limit := map[string]string{apiProviderAnthropic: "max_tokens", apiProviderOpenAI: "max_completion_tokens"}[tt.provider]
+			if api.requests[0][limit] != float64(64) {
+				t.Fatalf("first request = %v, want %s 64", api.requests[0], limit)
+			}
+			followUp, _ := json.Marshal(api.requests[1]["messages"])
+			if !strings.Contains(string(followUp), "cut off at the reply's token limit") {
+				t.Fatalf("the truncated call got no error result: %s", followUp)
+			}
+		})
+	}
+}
+
+func TestAPIAgentResolveStaysInRepo(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	a := &apiAgent{root: root, out: io.Discard}
+	for _, path := range []string{"../outside.txt", "/etc/passwd", "a/../../b"} {
+		if _, err := a.resolve(path); err == nil {
+			t.Fatalf("resolve(%q) succeeded", path)
+		}
+	}
+	if got, err := a.resolve("a/../b.txt"); err != nil || got != filepath.Join(root, "b.txt") {
+		t.Fatalf("resolve = %q, %v", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "f.go"), []byte("a := 1\na := 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	text, failed := a.runTool(context.Background(), apiToolCall{Name: "edit_file", Input: `{"path":"f.go","old_text":"a := 1","new_text":"b := 2"}`})
+	if !failed || !strings.Contains(text, "occurs 2 times") {
+		t.Fatalf("edit_file = %q, %v", text, failed)
+	}
+}
+
+func TestAPIAgentCommand(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{Agent: "api", Model: "claude-sonnet-4-5", APIProvider: apiProviderOpenAI, APIBaseURL: "http://localhost:8000/v1", APIMaxTokens: 4096, Temperature: "0"}}
+	cmd, err := r.buildAgentCommand("fix #5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "api-agent --api-provider openai --model claude-sonnet-4-5 --api-base-url http://localhost:8000/v1 --api-max-tokens 4096 --temperature 0"
+	if got := strings.Join(cmd.Args[1:], " "); got != want || cmd.Path != ghirExecutable() {
+		t.Fatalf("command = %s %s, want %s", cmd.Path, got, want)
+	}
+	if data, _ := io.ReadAll(cmd.Stdin); string(data) != "fix #5" {
+		t.Fatalf("stdin = %q", data)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// artifactsEnv tells the verify command where to put its reports.
+const artifactsEnv = "GHIR_ARTIFACTS_DIR"
+
+func validArtifactPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("artifact pattern is empty")
+	}
+	if filepath.IsAbs(pattern) || pattern == ".." || strings.HasPrefix(filepath.ToSlash(pattern), "../") || strings.Contains(filepath.ToSlash(pattern), "/../") {
+		return fmt.Errorf("artifact pattern %q must stay inside the repository", pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("artifact pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// artifactsDir is <run-dir>/<issue>.artifacts, next to the issue's attempt
+// logs.
+func (r *runner) artifactsDir(issue string) string {
+	dir := r.runDir
+	if dir == "" {
+		dir = r.opts.LogDir
+	}
+	return filepath.Join(dir, issue+".artifacts")
+}
+
+// prepareArtifacts empties the issue's artifact directory before a
+// verification, so it only ever holds what the latest one produced, and
+// returns its absolute path for the command's environment.
+func (r *runner) prepareArtifacts(issue string) (string, error) {
+	dir := r.artifactsDir(issue)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("clear artifact dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create artifact dir: %w", err)
+	}
+	return filepath.Abs(dir)
+}
+
+// collectArtifacts copies what --artifact matches in the repository into the
+// artifact directory and lists every file there, the ones the command wrote
+// to $GHIR_ARTIFACTS_DIR included. An empty directory is removed.
+func (r *runner) collectArtifacts(issue string) []string {
+	dir := r.artifactsDir(issue)
+	logDir, _ := filepath.Abs(r.opts.LogDir)
+	for _, pattern := range r.opts.Artifacts {
+		matches, err := filepath.Glob(filepath.Join(r.repoRoot, pattern))
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+			continue
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(r.repoRoot, match)
+			if err != nil || pathWithin(match, logDir) {
+				continue
+			}
+			if err := copyArtifact(match, filepath.Join(dir, rel)); err != nil {
+				r.printf(r.colors.Yellow, "WARNING: could not collect artifact %s for #%s: %v\n", rel, issue, err)
+			}
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		r.printf(r.colors.Yellow, "WARNING: could not list the artifacts of #%s: %v\n", issue, err)
+	}
+	if len(files) == 0 {
+		_ = os.RemoveAll(dir)
+		return nil
+	}
+	r.printf(r.colors.Blue, "Collected %d verification artifact(s) for #%s in %s\n", len(files), issue, dir)
+	return files
+}
+
+func pathWithin(path, dir string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(dir, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyArtifact copies a file, or a directory tree, to dst. Symlinks are
+// skipped.
+func copyArtifact(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case !d.Type().IsRegular():
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = in.Close()
+		}()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// artifactsMarkdown lists the artifacts for a PR description.
+func (r *runner) artifactsMarkdown(artifacts []string) string {
+	if len(artifacts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(r.tr("## Verification artifacts") + "\n\n")
+	for _, path := range artifacts {
+		if pathWithin(path, r.repoRoot) {
+			if rel, err := filepath.Rel(r.repoRoot, path); err == nil {
+				path = rel
+			}
+		}
+		fmt.Fprintf(&b, "- `%s`\n", filepath.ToSlash(path))
+	}
+	return b.String() + "\n"
+}
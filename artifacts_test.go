@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestValidArtifactPattern(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		wantErr string
+	}{
+		{pattern: "reports/*.xml"},
+		{pattern: "coverage"},
+		{pattern: "e2e/screenshots/*.png"},
+		{pattern: "", wantErr: "empty"},
+		{pattern: "/tmp/report.xml", wantErr: "inside the repository"},
+		{pattern: "../other/report.xml", wantErr: "inside the repository"},
+		{pattern: "a/../../b", wantErr: "inside the repository"},
+		{pattern: "reports/[.xml", wantErr: "syntax error"},
+	}
+	for _, tt := range tests {
+		err := validArtifactPattern(tt.pattern)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Fatalf("validArtifactPattern(%q) = %v, want %q", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestVerifyCollectsArtifacts(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, ".git", "info", "exclude"), []byte("reports/\ncoverage/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gh := fakeIssueGH(t, "Add f", "add f")
+	agent := fakeAgent(t, "echo f > f.txt\ngit add f.txt\ngit commit -q -m \"feat: add f (#5)\"")
+	verify := `mkdir -p reports coverage/html && echo '<testsuite/>' > reports/junit.xml && echo '<html/>' > coverage/html/index.html && echo png > "$GHIR_ARTIFACTS_DIR/home.png"`
+	opts := options{
+		Agent:       "claude",
+		ClaudeBin:   agent,
+		GHBin:       gh,
+		SingleIssue: "5",
+		VerifyCmd:   verify,
+		Artifacts:   []string{"reports/*.xml", "coverage", "missing/*.txt"},
+	}
+	r := newTestRunner(t, repo, opts)
+	r.runQueue()
+	if got := r.failures["5"]; got != "" {
+		t.Fatalf("failure = %q", got)
+	}
+
+	st, _ := r.state.get("5")
+	dir := r.artifactsDir("5")
+	var got []string
+	for _, path := range st.Artifacts {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	want := []string{"coverage/html/index.html", "home.png", "reports/junit.xml"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("artifacts = %v, want %v", got, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "reports", "junit.xml")); err != nil || string(data) != "<testsuite/>\n" {
+		t.Fatalf("junit.xml = %q, %v", data, err)
+	}
+
+	links := r.boardArtifacts(st.Artifacts, true)
+	if len(links) != 3 || !strings.HasPrefix(links[0].URL, "/artifacts/") || !strings.HasSuffix(links[0].URL, links[0].Name) {
+		t.Fatalf("board links = %+v", links)
+	}
+}
+
+func TestVerifyWithoutArtifactsLeavesNoDir(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	r := &runner{repoRoot: repo, opts: options{LogDir: t.TempDir(), VerifyCmd: "true", NoColor: true, Quiet: true}}
+	result, passed := r.verifyIssueResult("5", "")
+	if !passed || result.Artifacts != nil {
+		t.Fatalf("passed = %v, artifacts = %v", passed, result.Artifacts)
+	}
+	if _, err := os.Stat(r.artifactsDir("5")); !os.IsNotExist(err) {
+		t.Fatalf("artifact dir left behind: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "strings"
+
+// assignSelf adds the gh user as an assignee of the issue so the team can see
+// the runner owns it. It reports whether the assignment is new, i.e. whether
+// a failure should undo it.
+func (r *runner) assignSelf(edit *issueEdit, details issueDetails) bool {
+	if len(details.Assignees) > 0 {
+		login, err := r.commandOutput(r.opts.GHBin, "api", "user", "--jq", ".login")
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not look up the gh user: %v\n", err)
+		}
+		login = strings.TrimSpace(login)
+		for _, assignee := range details.Assignees {
+			if login != "" && strings.EqualFold(assignee.Login, login) {
+				return false
+			}
+		}
+		if login == "" {
+			// Without the login a failure could unassign someone who
+			// already owned the issue, so the assignment is kept.
+			edit.assign = true
+			return false
+		}
+	}
+	edit.assign = true
+	return true
+}
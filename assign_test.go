@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssignSelf(t *testing.T) {
+	t.Parallel()
+
+	const commit = "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\""
+	tests := []struct {
+		name      string
+		assignees string
+		wipLabel  string
+		agent     string
+		want      issueResult
+		wantCalls []string
+	}{
+		{
+			name:      "success keeps the assignment",
+			assignees: `[]`,
+			agent:     commit,
+			want:      resultSuccess,
+			wantCalls: []string{"issue edit 5 --add-assignee @me"},
+		},
+		{
+			name:      "failure undoes it",
+			assignees: `[{"login":"someone"}]`,
+			agent:     "true",
+			want:      resultFailed,
+			wantCalls: []string{"api user --jq .login", "issue edit 5 --add-assignee @me", "issue edit 5 --remove-assignee @me"},
+		},
+		{
+			name:      "already assigned",
+			assignees: `[{"login":"Runner"}]`,
+			agent:     "true",
+			want:      resultFailed,
+			wantCalls: []string{"api user --jq .login"},
+		},
+		{
+			name:      "labels and assignee change in one edit",
+			assignees: `[]`,
+			wipLabel:  "wip",
+			agent:     "true",
+			want:      resultFailed,
+			wantCalls: []string{"issue edit 5 --add-label wip --add-assignee @me", "issue edit 5 --remove-label wip --remove-assignee @me"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			dir := t.TempDir()
+			calls := filepath.Join(dir, "gh.calls")
+			gh := writeFakeBin(t, "gh", `case "$1 $2" in
+"issue edit"|"api user")
+  echo "$*" >> `+calls+`
+  [ "$1" = api ] && echo runner
+  ;;
+*)
+  echo '{"title":"Add greeting","body":"say hi","state":"OPEN","labels":[],"assignees":`+tt.assignees+`}'
+  ;;
+esac
+exit 0`)
+			agent := fakeAgent(t, tt.agent)
+			opts := options{
+				Agent:      "claude",
+				ClaudeBin:  agent,
+				GHBin:      gh,
+				LogDir:     filepath.Join(dir, "logs"),
+				AssignSelf: true,
+				WIPLabel:   tt.wipLabel,
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+			data, _ := os.ReadFile(calls)
+			if got := strings.TrimSpace(string(data)); got != strings.Join(tt.wantCalls, "\n") {
+				t.Fatalf("gh calls:\n%s\nwant:\n%s", got, strings.Join(tt.wantCalls, "\n"))
+			}
+		})
+	}
+}
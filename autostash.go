@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// autostash parks the uncommitted changes (untracked files included) found
+// before an issue, so --autostash can run the issue on a clean tree. It
+// returns the stash commit, which identifies the entry even if more stashes
+// are pushed on top of it (--rollback-on-failure does).
+func (r *runner) autostash(issue string) (string, error) {
+	message := fmt.Sprintf("ghir autostash before #%s", issue)
+	if _, err := r.gitOutput("stash", "push", "--include-untracked", "-m", message); err != nil {
+		return "", err
+	}
+	commit, err := r.gitOutput("rev-parse", "stash@{0}")
+	if err != nil {
+		return "", err
+	}
+	r.printf(r.colors.Yellow, "Stashed uncommitted changes before #%s\n", issue)
+	return commit, nil
+}
+
+// restoreAutostash puts the stashed changes back after the issue. When they
+// conflict with what the issue changed, the tree is reset to HEAD and the
+// stash entry is kept for the user to apply by hand, so the next issue still
+// starts clean and nothing is lost.
+func (r *runner) restoreAutostash(issue, commit string) {
+	ref, err := r.stashRef(commit)
+	if err != nil {
+		r.printf(r.colors.Red, "ERROR: could not find the changes stashed before #%s (%s): %v\n", issue, shortCommit(commit), err)
+		return
+	}
+	if _, err := r.gitOutput("stash", "apply", ref); err != nil {
+		conflicts, _ := r.gitOutput("diff", "--name-only", "--diff-filter=U")
+		if _, resetErr := r.gitOutput("reset", "--hard", "HEAD"); resetErr != nil {
+			r.printf(r.colors.Red, "ERROR: could not undo the failed restore of the changes stashed before #%s: %v\n", issue, resetErr)
+		}
+		if conflicts != "" {
+			r.printf(r.colors.Red, "ERROR: the changes stashed before #%s conflict with it (%s); they are kept in %s\n", issue, strings.Join(strings.Fields(conflicts), ", "), ref)
+		} else {
+			r.printf(r.colors.Red, "ERROR: could not restore the changes stashed before #%s; they are kept in %s: %v\n", issue, ref, err)
+		}
+		return
+	}
+	if _, err := r.gitOutput("stash", "drop", ref); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: restored the stashed changes but could not drop %s: %v\n", ref, err)
+		return
+	}
+	r.printf(r.colors.Yellow, "Restored the changes stashed before #%s\n", issue)
+}
+
+// stashRef finds the stash@{n} entry of a stash commit.
+func (r *runner) stashRef(commit string) (string, error) {
+	out, err := r.gitOutput("stash", "list", "--format=%H")
+	if err != nil {
+		return "", err
+	}
+	for i, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == commit {
+			return fmt.Sprintf("stash@{%d}", i), nil
+		}
+	}
+	return "", fmt.Errorf("not in the stash list")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAutostash(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		autostash bool
+		agent     string
+		want      issueResult
+		wantNotes string
+		wantStash bool
+	}{
+		{
+			name:      "changes are restored after the issue",
+			autostash: true,
+			agent:     "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"",
+			want:      resultSuccess,
+			wantNotes: "my local edit\n",
+		},
+		{
+			name:      "conflicting changes stay in the stash",
+			autostash: true,
+			agent:     "echo agent > notes.txt\ngit add notes.txt\ngit commit -q -m \"feat: rewrite notes (#5)\"",
+			want:      resultSuccess,
+			wantNotes: "agent\n",
+			wantStash: true,
+		},
+		{
+			name:      "without --autostash a dirty tree is refused",
+			agent:     "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"",
+			want:      resultFailed,
+			wantNotes: "my local edit\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			notes := filepath.Join(repo, "notes.txt")
+			if err := os.WriteFile(notes, []byte("notes\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			runGit(t, repo, "add", "notes.txt")
+			runGit(t, repo, "commit", "-q", "-m", "add notes")
+			if err := os.WriteFile(notes, []byte("my local edit\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repo, "scratch.txt"), []byte("untracked\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			gh := fakeIssueGH(t, "Add greeting", "say hi")
+			agent := fakeAgent(t, tt.agent)
+			opts := options{
+				Agent:     "claude",
+				ClaudeBin: agent,
+				GHBin:     gh,
+				AutoStash: tt.autostash,
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+
+			if data, _ := os.ReadFile(notes); string(data) != tt.wantNotes {
+				t.Fatalf("notes.txt = %q, want %q", data, tt.wantNotes)
+			}
+			if stash := runGit(t, repo, "stash", "list"); (stash != "") != tt.wantStash {
+				t.Fatalf("stash = %q, want an entry: %v", stash, tt.wantStash)
+			}
+			if !tt.wantStash {
+				if _, err := os.Stat(filepath.Join(repo, "scratch.txt")); err != nil {
+					t.Fatalf("untracked file not restored: %v", err)
+				}
+			}
+		})
+	}
+}
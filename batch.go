@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+)
+
+var firstBadCommitPattern = regexp.MustCompile(`(?m)^([0-9a-f]{7,40}) is the first bad commit`)
+
+// batchIssue is the commit range a successful issue added to the batch.
+type batchIssue struct {
+	ID   string
+	From string
+	To   string
+}
+
+type batchCheck struct {
+	name    string
+	command string
+	logName string
+}
+
+func (r *runner) batchChecks() []batchCheck {
+	var checks []batchCheck
+	if r.opts.VerifyFullAtEnd {
+		checks = append(checks, batchCheck{name: "full suite", command: expandVerifyCommand(r.opts.VerifyCmd, ""), logName: "batch.verify.log"})
+	}
+	if r.opts.E2ECmd != "" {
+		checks = append(checks, batchCheck{name: "e2e", command: r.opts.E2ECmd, logName: "batch.e2e.log"})
+	}
+	return checks
+}
+
+// runBatchPhase runs the end-of-batch checks on the combined result of every
+// issue of the run. With --bisect a failing check is bisected between the
+// batch start and HEAD to find the issue whose commits broke it, which is
+// marked needs-review; with --revert-bad its commits are also reverted, and
+// the check passes if it is green again.
+func (r *runner) runBatchPhase(start string, issues []batchIssue) bool {
+	checks := r.batchChecks()
+	if len(checks) == 0 || len(issues) == 0 {
+		return true
+	}
+	fmt.Fprintln(r.stdout())
+	r.printf(r.colors.Blue, "Batch phase: checking the combined result of %d issue(s)\n", len(issues))
+	passed := true
+	for _, check := range checks {
+		if r.runBatchCheck(check) {
+			continue
+		}
+		if !r.opts.Bisect {
+			passed = false
+			continue
+		}
+		bad, ok := r.bisectBatch(check, start, issues)
+		if !ok {
+			passed = false
+			continue
+		}
+		r.flagBatchRegression(bad, check)
+		if r.opts.RevertBad && r.revertBatchIssue(bad) && r.runBatchCheck(check) {
+			r.printf(r.colors.Yellow, "Batch %s is green again after reverting #%s\n", check.name, bad.ID)
+			continue
+		}
+		passed = false
+	}
+	return passed
+}
+
+func (r *runner) runBatchCheck(check batchCheck) (passed bool) {
+	defer func() {
+		r.record(journalEntry{Event: journalVerified, Title: check.name, Passed: boolPtr(passed)})
+	}()
+
+	logPath := filepath.Join(r.opts.LogDir, check.logName)
+	r.printf(r.colors.Yellow, "Running the batch %s: %s\n", check.name, check.command)
+	result, err := r.runCheck(r.repoRoot, check.command, logPath)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: batch %s could not run: %v\n", check.name, err)
+		return false
+	}
+	if !result.Passed {
+		r.printf(r.colors.Red, "FAILED: batch %s exited with code %d\n", check.name, result.ExitCode)
+		for _, line := range compactMultiline(tailLines(result.Output, 20), 20, 4000) {
+			r.printf(r.colors.Red, "  %s\n", line)
+		}
+		r.printf(r.colors.Red, "Check log: %s\n", logPath)
+		return false
+	}
+	r.printf(r.colors.Green, "Batch %s passed\n", check.name)
+	return true
+}
+
+// bisectBatch runs `git bisect run` with the failing check, treating the
+// batch start as good, and names the issue that added the first bad commit.
+func (r *runner) bisectBatch(check batchCheck, start string, issues []batchIssue) (batchIssue, bool) {
+	if dirty, err := r.workingTreeDirty(); err != nil || dirty {
+		r.printf(r.colors.Yellow, "WARNING: not bisecting the batch %s failure: the working tree is not clean\n", check.name)
+		return batchIssue{}, false
+	}
+	r.printf(r.colors.Blue, "Bisecting the batch %s failure between %s and HEAD...\n", check.name, shortSHA(start))
+	if _, err := r.gitOutput("bisect", "start", "HEAD", start); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not start git bisect: %v\n", err)
+		return batchIssue{}, false
+	}
+	defer func() {
+		if _, err := r.gitOutput("bisect", "reset"); err != nil {
+			r.printf(r.colors.Red, "WARNING: git bisect reset failed, run it by hand: %v\n", err)
+		}
+	}()
+
+	logPath := filepath.Join(r.opts.LogDir, "batch.bisect.log")
+	out, err := r.runBisect(check.command, logPath)
+	m := firstBadCommitPattern.FindStringSubmatch(out)
+	if m == nil {
+		if err == nil {
+			err = errors.New("no first bad commit reported")
+		}
+		r.printf(r.colors.Yellow, "WARNING: bisect could not implicate a commit (see %s): %v\n", logPath, err)
+		return batchIssue{}, false
+	}
+	sha := m[1]
+	subject, _ := r.gitOutput("log", "-1", "--pretty=format:%s", sha)
+	bad, ok := r.issueForCommit(sha, issues)
+	r.record(journalEntry{Event: journalBisected, Issue: bad.ID, Title: check.name, Commit: sha, Subject: subject})
+	if !ok {
+		r.printf(r.colors.Red, "First bad commit: %s %s (not made by an issue of this batch)\n", shortSHA(sha), subject)
+		return batchIssue{}, false
+	}
+	r.printf(r.colors.Red, "Implicated: issue #%s, first bad commit %s %s\n", bad.ID, shortSHA(sha), subject)
+	return bad, true
+}
+
+func (r *runner) runBisect(command, logPath string) (string, error) {
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return "", fmt.Errorf("create bisect log: %w", err)
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	var buf bytes.Buffer
+	cmd := exec.Command("git", "bisect", "run", "sh", "-c", command)
+	cmd.Dir = r.repoRoot
+	cmd.Env = r.cacheEnv
+	cmd.Stdout = io.MultiWriter(r.stampLog(logFile), &buf)
+	cmd.Stderr = cmd.Stdout
+	err = cmd.Run()
+	return buf.String(), err
+}
+
+// issueForCommit finds the batch issue whose From..To range contains sha.
+func (r *runner) issueForCommit(sha string, issues []batchIssue) (batchIssue, bool) {
+	for _, issue := range issues {
+		if _, err := r.gitOutput("merge-base", "--is-ancestor", sha, issue.To); err != nil {
+			continue
+		}
+		if _, err := r.gitOutput("merge-base", "--is-ancestor", sha, issue.From); err == nil {
+			continue
+		}
+		return issue, true
+	}
+	return batchIssue{}, false
+}
+
+// flagBatchRegression takes the implicated issue out of the done file and
+// marks it needs-review, as reverify does for regressions.
+func (r *runner) flagBatchRegression(bad batchIssue, check batchCheck) {
+	delete(r.doneSet, bad.ID)
+	if err := r.rewriteDoneFile(fmt.Sprintf("Marked #%s needs-review: it broke the batch %s\n", bad.ID, check.name)); err != nil {
+		r.printf(r.colors.Red, "WARNING: %v\n", err)
+	}
+	if r.state == nil {
+		return
+	}
+	err := r.state.update(bad.ID, func(st *issueState) {
+		st.Status = statusNeedsReview
+		st.Failure = string(failureVerification)
+	})
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not update state for #%s: %v\n", bad.ID, err)
+	}
+}
+
+// revertBatchIssue reverts every commit of the issue, newest first, on top
+// of HEAD. A conflicting revert is aborted and leaves HEAD as it was.
+func (r *runner) revertBatchIssue(bad batchIssue) bool {
+	r.printf(r.colors.Yellow, "Reverting the commits of #%s (%s..%s)\n", bad.ID, shortSHA(bad.From), shortSHA(bad.To))
+	if _, err := r.gitOutput("revert", "--no-edit", bad.From+".."+bad.To); err != nil {
+		_, _ = r.gitOutput("revert", "--abort")
+		r.printf(r.colors.Red, "WARNING: could not revert #%s: %v\n", bad.ID, err)
+		return false
+	}
+	head, _ := r.gitOutput("rev-parse", "HEAD")
+	r.record(journalEntry{Event: journalReverted, Issue: bad.ID, Commit: head})
+	return true
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBatchPhaseBisectsToTheBreakingIssue(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	commitFile(t, repo, ".gitignore", "logs/\n")
+	start := runGit(t, repo, "rev-parse", "HEAD")
+	commitFile(t, repo, "one.txt", "1\n")
+	afterOne := runGit(t, repo, "rev-parse", "HEAD")
+	commitFile(t, repo, "two.txt", "2\n")
+	commitFile(t, repo, "broken", "x\n")
+	commitFile(t, repo, "three.txt", "3\n")
+	afterTwo := runGit(t, repo, "rev-parse", "HEAD")
+
+	opts := options{
+		LogDir:          filepath.Join(repo, "logs"),
+		VerifyCmd:       "true",
+		VerifyFullAtEnd: true,
+		E2ECmd:          "! test -f broken",
+		Bisect:          true,
+	}
+	r := newTestRunner(t, repo, opts)
+	if err := r.openJournal("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := []batchIssue{{ID: "1", From: start, To: afterOne}, {ID: "2", From: afterOne, To: afterTwo}}
+	if r.runBatchPhase(start, issues) {
+		t.Fatal("batch phase passed with a failing e2e command")
+	}
+	r.closeJournal()
+
+	if head := runGit(t, repo, "rev-parse", "HEAD"); head != afterTwo {
+		t.Fatalf("HEAD after bisect = %s, want %s", head, afterTwo)
+	}
+	data, err := os.ReadFile(filepath.Join(r.opts.LogDir, "run-test.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var checks []string
+	var bisected journalEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry journalEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		switch entry.Event {
+		case journalVerified:
+			checks = append(checks, entry.Title+"="+map[bool]string{true: "pass", false: "fail"}[*entry.Passed])
+		case journalBisected:
+			bisected = entry
+		}
+	}
+	if strings.Join(checks, ",") != "full suite=pass,e2e=fail" {
+		t.Fatalf("batch checks = %v", checks)
+	}
+	if bisected.Issue != "2" || bisected.Subject != "update broken" {
+		t.Fatalf("bisected = %+v, want issue 2 at 'update broken'", bisected)
+	}
+}
+
+func TestBatchPhaseOptions(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseArgs([]string{"--bisect"}); err != nil {
+		t.Fatal(err)
+	}
+	opts, _ := parseArgs([]string{"--bisect", "--verify-cmd", "make test"})
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "--bisect requires") {
+		t.Fatalf("err = %v", err)
+	}
+	opts, _ = parseArgs([]string{"--revert-bad", "--e2e-cmd", "make e2e"})
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "--revert-bad requires --bisect") {
+		t.Fatalf("err = %v", err)
+	}
+	opts, _ = parseArgs([]string{"--bisect", "--revert-bad", "--e2e-cmd", "make e2e"})
+	if err := validateOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchPhaseRevertsTheBreakingIssue(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	commitFile(t, repo, ".gitignore", "logs/\n")
+	start := runGit(t, repo, "rev-parse", "HEAD")
+	commitFile(t, repo, "broken", "x\n")
+	afterOne := runGit(t, repo, "rev-parse", "HEAD")
+	commitFile(t, repo, "two.txt", "2\n")
+	afterTwo := runGit(t, repo, "rev-parse", "HEAD")
+
+	opts := options{
+		LogDir:    filepath.Join(repo, "logs"),
+		E2ECmd:    "! test -f broken",
+		Bisect:    true,
+		RevertBad: true,
+	}
+	opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+	if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(opts.DoneFile, []byte("1\n2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRunner(t, repo, opts)
+
+	issues := []batchIssue{{ID: "1", From: start, To: afterOne}, {ID: "2", From: afterOne, To: afterTwo}}
+	if !r.runBatchPhase(start, issues) {
+		t.Fatal("batch phase should be green after reverting the breaking issue")
+	}
+	if _, err := os.Stat(filepath.Join(repo, "broken")); !os.IsNotExist(err) {
+		t.Fatalf("broken file still present: %v", err)
+	}
+	if subject := runGit(t, repo, "log", "-1", "--pretty=%s"); subject != `Revert "update broken"` {
+		t.Fatalf("HEAD subject = %q", subject)
+	}
+	if r.isCompleted("1") || !r.isCompleted("2") {
+		t.Fatalf("done set after revert: 1=%v 2=%v", r.isCompleted("1"), r.isCompleted("2"))
+	}
+	if st, _ := r.state.get("1"); st.Status != statusNeedsReview {
+		t.Fatalf("status of #1 = %q, want %q", st.Status, statusNeedsReview)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultBenchLabel     = "performance"
+	defaultBenchThreshold = 5.0
+)
+
+// benchResult maps benchmark name to unit to value, e.g.
+// BenchmarkParse-8 -> ns/op -> 1234.
+type benchResult map[string]map[string]float64
+
+type benchDelta struct {
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Before    float64 `json:"before"`
+	After     float64 `json:"after"`
+	DeltaPct  float64 `json:"delta_pct"`
+	Regressed bool    `json:"regressed,omitempty"`
+}
+
+// parseBenchOutput reads Go benchmark lines ("BenchmarkX-8  1000  1234 ns/op
+// 56 B/op"). Repeated runs (-count) are averaged.
+func parseBenchOutput(out string) benchResult {
+	sums := make(map[string]map[string]float64)
+	counts := make(map[string]map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+			continue
+		}
+		name := fields[0]
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			unit := fields[i+1]
+			if sums[name] == nil {
+				sums[name] = make(map[string]float64)
+				counts[name] = make(map[string]int)
+			}
+			sums[name][unit] += value
+			counts[name][unit]++
+		}
+	}
+	result := make(benchResult, len(sums))
+	for name, units := range sums {
+		result[name] = make(map[string]float64, len(units))
+		for unit, sum := range units {
+			result[name][unit] = sum / float64(counts[name][unit])
+		}
+	}
+	return result
+}
+
+// higherIsBetter covers throughput units such as MB/s.
+func higherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// compareBench lists every metric present in both runs, worst regression
+// first. A metric regresses when it gets worse by more than thresholdPct.
+func compareBench(before, after benchResult, thresholdPct float64) []benchDelta {
+	var deltas []benchDelta
+	for name, units := range before {
+		for unit, old := range units {
+			current, ok := after[name][unit]
+			if !ok {
+				continue
+			}
+			d := benchDelta{Name: name, Unit: unit, Before: old, After: current}
+			worse := current - old
+			if higherIsBetter(unit) {
+				worse = old - current
+			}
+			if old != 0 {
+				d.DeltaPct = (current - old) / old * 100
+				d.Regressed = worse/old*100 > thresholdPct
+			} else {
+				// Growing from zero (say, 0 allocs/op) has no percentage.
+				d.Regressed = worse > 0
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Regressed != deltas[j].Regressed {
+			return deltas[i].Regressed
+		}
+		if deltas[i].Name != deltas[j].Name {
+			return deltas[i].Name < deltas[j].Name
+		}
+		return deltas[i].Unit < deltas[j].Unit
+	})
+	return deltas
+}
+
+func (r *runner) benchApplies(details issueDetails) bool {
+	if r.opts.BenchCmd == "" {
+		return false
+	}
+	for _, label := range details.Labels {
+		if strings.EqualFold(strings.TrimSpace(label.Name), r.opts.BenchLabel) {
+			return true
+		}
+	}
+	return false
+}
+
+// runBench runs --bench-cmd and appends its output to <log-dir>/<id>.bench.log.
+func (r *runner) runBench(issue, phase string) (benchResult, error) {
+	command := expandVerifyCommand(r.opts.BenchCmd, issue)
+	r.printf(r.colors.Yellow, "Benchmarking issue #%s (%s): %s\n", issue, phase, command)
+
+	logPath := filepath.Join(r.opts.LogDir, issue+".bench.log")
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if phase == "before" {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	logFile, err := os.OpenFile(logPath, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open bench log: %w", err)
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+	logWriter := r.stampLog(logFile)
+	fmt.Fprintf(logWriter, "== %s: %s\n", phase, command)
+
+	var buf bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = r.repoRoot
+	cmd.Env = r.cacheEnv
+	cmd.Stdout = io.MultiWriter(logWriter, &buf)
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("benchmark command exited with code %d (see %s)", exitErr.ExitCode(), logPath)
+		}
+		return nil, fmt.Errorf("start benchmark command: %w", err)
+	}
+	result := parseBenchOutput(buf.String())
+	if len(result) == 0 {
+		return nil, fmt.Errorf("benchmark command printed no benchmark results (see %s)", logPath)
+	}
+	return result, nil
+}
+
+// benchGate re-runs the benchmarks on the new HEAD and fails the issue when a
+// metric got worse than --bench-threshold allows.
+func (r *runner) benchGate(issue string, before benchResult, attempt *issueAttempt) bool {
+	after, err := r.runBench(issue, "after")
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: %v\n", err)
+		return false
+	}
+	deltas := compareBench(before, after, r.opts.BenchThreshold)
+	attempt.bench = deltas
+	regressed := 0
+	for _, d := range deltas {
+		color := r.colors.Green
+		if d.Regressed {
+			color = r.colors.Red
+			regressed++
+		}
+		r.printf(color, "  %-40s %-10s %12.4g -> %-12.4g %+.1f%%\n", d.Name, d.Unit, d.Before, d.After, d.DeltaPct)
+	}
+	if len(deltas) == 0 {
+		r.printf(r.colors.Yellow, "WARNING: no benchmark was reported both before and after the change for #%s\n", issue)
+	}
+	if regressed > 0 {
+		r.printf(r.colors.Red, "FAILED: %d benchmark metric(s) regressed by more than %.1f%% for #%s\n", regressed, r.opts.BenchThreshold, issue)
+		return false
+	}
+	return true
+}
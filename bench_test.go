@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBenchOutput(t *testing.T) {
+	t.Parallel()
+
+	out := strings.Join([]string{
+		"goos: linux",
+		"BenchmarkParse-8   	  100000	      1200 ns/op	     64 B/op	       2 allocs/op",
+		"BenchmarkParse-8   	  100000	      1000 ns/op	     64 B/op	       2 allocs/op",
+		"BenchmarkCopy-8    	    5000	    250000 ns/op	 400.00 MB/s",
+		"BenchmarkBroken-8  	 oops",
+		"PASS",
+	}, "\n")
+	got := parseBenchOutput(out)
+	if len(got) != 2 {
+		t.Fatalf("got %d benchmarks: %v", len(got), got)
+	}
+	if got["BenchmarkParse-8"]["ns/op"] != 1100 || got["BenchmarkParse-8"]["allocs/op"] != 2 {
+		t.Fatalf("parse = %v", got["BenchmarkParse-8"])
+	}
+	if got["BenchmarkCopy-8"]["MB/s"] != 400 {
+		t.Fatalf("copy = %v", got["BenchmarkCopy-8"])
+	}
+}
+
+func TestCompareBench(t *testing.T) {
+	t.Parallel()
+
+	before := benchResult{
+		"BenchmarkA":    {"ns/op": 100, "allocs/op": 0},
+		"BenchmarkB":    {"MB/s": 200, "ns/op": 100},
+		"BenchmarkGone": {"ns/op": 1},
+	}
+	after := benchResult{
+		"BenchmarkA": {"ns/op": 104, "allocs/op": 1},
+		"BenchmarkB": {"MB/s": 180, "ns/op": 50},
+	}
+	deltas := compareBench(before, after, 5)
+	if len(deltas) != 4 {
+		t.Fatalf("got %d deltas: %+v", len(deltas), deltas)
+	}
+	regressed := map[string]bool{}
+	for _, d := range deltas {
+		regressed[d.Name+" "+d.Unit] = d.Regressed
+	}
+	want := map[string]bool{
+		"BenchmarkA ns/op":     false, // +4% is within 5%
+		"BenchmarkA allocs/op": true,  // up from zero
+		"BenchmarkB MB/s":      true,  // throughput dropped 10%
+		"BenchmarkB ns/op":     false,
+	}
+	for key, w := range want {
+		if regressed[key] != w {
+			t.Fatalf("%s regressed = %v, want %v (%+v)", key, regressed[key], w, deltas)
+		}
+	}
+	if !deltas[0].Regressed || !deltas[1].Regressed || deltas[2].Regressed {
+		t.Fatalf("regressions should sort first: %+v", deltas)
+	}
+}
+
+func TestBenchGateRecordsDeltas(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	r := &runner{
+		opts: options{
+			LogDir:         logDir,
+			BenchCmd:       "echo 'BenchmarkX-4 10 130 ns/op'",
+			BenchLabel:     defaultBenchLabel,
+			BenchThreshold: 10,
+			NoColor:        true,
+		},
+		repoRoot: logDir,
+	}
+	if !r.benchApplies(issueDetails{Labels: []issueLabel{{Name: "Performance"}}}) || r.benchApplies(issueDetails{}) {
+		t.Fatal("benchApplies should follow the label")
+	}
+	attempt := &issueAttempt{issue: "5"}
+	if r.benchGate("5", benchResult{"BenchmarkX-4": {"ns/op": 100}}, attempt) {
+		t.Fatal("a 30% slowdown should fail the gate")
+	}
+	if len(attempt.bench) != 1 || attempt.bench[0].DeltaPct != 30 {
+		t.Fatalf("deltas = %+v", attempt.bench)
+	}
+	data, err := os.ReadFile(filepath.Join(logDir, "5.bench.log"))
+	if err != nil || !strings.Contains(string(data), "== after: echo") {
+		t.Fatalf("bench log = %q, %v", data, err)
+	}
+	if !r.benchGate("5", benchResult{"BenchmarkX-4": {"ns/op": 125}}, attempt) {
+		t.Fatal("a 4% slowdown should pass the gate")
+	}
+}
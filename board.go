@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBoardAddr      = "127.0.0.1:8765"
+	boardRefreshSeconds   = 10
+	defaultBoardFileName  = "board.html"
+	boardColumnPending    = "Pending"
+	boardColumnInProgress = "In progress"
+	boardColumnDone       = "Done"
+	boardColumnReview     = "Needs review"
+)
+
+type boardCard struct {
+	Issue     string
+	Title     string
+	Status    string
+	Failure   string
+	Agent     string
+	Model     string
+	Attempts  int
+	Duration  string
+	Tokens    string
+	Cost      string
+	LogURL    string
+	Artifacts []boardLink
+	Changes   string
+	Summary   string
+}
+
+type boardLink struct {
+	Name string
+	URL  string
+}
+
+type boardColumn struct {
+	Name  string
+	Cards []boardCard
+}
+
+type boardPage struct {
+	Generated string
+	Refresh   int
+	Serving   bool
+	Waiting   string // session-limit wait of the latest run
+	// Token is the CSRF token of the action forms (boardactions.go); they
+	// are left out when it is empty.
+	Token   string
+	Columns []boardColumn
+}
+
+func (r *runner) runBoard() error {
+	if !r.opts.Serve {
+		path := filepath.Join(r.opts.LogDir, defaultBoardFileName)
+		page, err := r.buildBoard(false)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := boardTemplate.Execute(&buf, page); err != nil {
+			return fmt.Errorf("render board: %w", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("write board: %w", err)
+		}
+		r.printf(r.colors.Green, "Board written to %s\n", path)
+		return nil
+	}
+
+	actions, err := newBoardActions(r)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+		page, err := r.buildBoard(true)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Token = actions.tokenFor(req)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := boardTemplate.Execute(w, page); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/logs/", func(w http.ResponseWriter, req *http.Request) {
+		r.serveLogFile(w, req, strings.TrimPrefix(req.URL.Path, "/logs/"))
+	})
+	mux.HandleFunc("/follow/", func(w http.ResponseWriter, req *http.Request) {
+		r.followLogFile(w, req, strings.TrimPrefix(req.URL.Path, "/follow/"))
+	})
+	mux.HandleFunc("/artifacts/", func(w http.ResponseWriter, req *http.Request) {
+		r.serveArtifact(w, req, strings.TrimPrefix(req.URL.Path, "/artifacts/"))
+	})
+	mux.HandleFunc("/runs", r.serveHistory)
+	mux.HandleFunc("/api/limit", r.serveLimit)
+	mux.Handle("/actions/", actions)
+
+	r.printf(r.colors.Blue, "Serving board on http://%s (refreshes every %ds, Ctrl-C to stop)\n", r.opts.Addr, boardRefreshSeconds)
+	return http.ListenAndServe(r.opts.Addr, mux)
+}
+
+func (r *runner) serveLogFile(w http.ResponseWriter, req *http.Request, name string) {
+	clean := filepath.Clean("/" + name)
+	path := filepath.Join(r.opts.LogDir, clean)
+	if !strings.HasPrefix(path, filepath.Clean(r.opts.LogDir)+string(filepath.Separator)) || !strings.HasSuffix(path, ".log") {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeFile(w, req, path)
+}
+
+// serveArtifact serves a file collected into an <issue>.artifacts directory
+// of the log dir.
+func (r *runner) serveArtifact(w http.ResponseWriter, req *http.Request, name string) {
+	clean := filepath.Clean("/" + name)
+	path := filepath.Join(r.opts.LogDir, clean)
+	if !strings.HasPrefix(path, filepath.Clean(r.opts.LogDir)+string(filepath.Separator)) || !strings.Contains(filepath.ToSlash(clean), ".artifacts/") {
+		http.NotFound(w, req)
+		return
+	}
+	http.ServeFile(w, req, path)
+}
+
+func (r *runner) buildBoard(serving bool) (boardPage, error) {
+	state, err := loadStateStore(filepath.Join(r.opts.LogDir, defaultStateFileName))
+	if err != nil {
+		return boardPage{}, err
+	}
+	done, err := loadDoneSet(r.doneFile)
+	if err != nil {
+		return boardPage{}, err
+	}
+
+	var order []string
+	seen := make(map[string]struct{})
+	if queue, err := r.loadIssues(); err == nil {
+		for _, entry := range queue {
+			order = append(order, entry.ID)
+			seen[entry.ID] = struct{}{}
+		}
+	}
+	states := state.snapshot()
+	var extra []string
+	for id := range states {
+		if _, ok := seen[id]; !ok {
+			extra = append(extra, id)
+			seen[id] = struct{}{}
+		}
+	}
+	for id := range done {
+		if _, ok := seen[id]; !ok {
+			extra = append(extra, id)
+			seen[id] = struct{}{}
+		}
+	}
+	sortStringsNumeric(extra)
+	order = append(order, extra...)
+
+	columns := []boardColumn{
+		{Name: boardColumnPending},
+		{Name: boardColumnInProgress},
+		{Name: boardColumnDone},
+		{Name: boardColumnReview},
+	}
+	columnIndex := map[string]int{
+		boardColumnPending:    0,
+		boardColumnInProgress: 1,
+		boardColumnDone:       2,
+		boardColumnReview:     3,
+	}
+
+	now := r.now()
+	for _, id := range order {
+		st, hasState := states[id]
+		_, isDone := done[id]
+		_, isSkipped := r.skipSet[id]
+		card := boardCard{Issue: id, Status: statusPending}
+		if hasState {
+			card.Title = st.Title
+			card.Status = st.Status
+			card.Failure = st.Failure
+			card.Agent = st.Agent
+			card.Model = st.Model
+			card.Attempts = st.Attempts
+			card.Duration = formatStateDuration(st, now)
+			if st.Tokens > 0 {
+				card.Tokens = fmt.Sprintf("%d", st.Tokens)
+			}
+			if st.CostUSD > 0 {
+				card.Cost = fmt.Sprintf("$%.2f", st.CostUSD)
+			}
+			card.LogURL = r.boardLogURL(st.LogPath, serving)
+			if serving && st.Status == statusInProgress && card.LogURL != "" {
+				card.LogURL = "/follow/" + strings.TrimPrefix(card.LogURL, "/logs/")
+			}
+			card.Artifacts = r.boardArtifacts(st.Artifacts, serving)
+			if st.Changes != nil && len(st.Changes.Files) > 0 {
+				card.Changes, card.Summary = st.Changes.headline(), st.Changes.Text
+			}
+		}
+		if isDone && card.Status != statusInProgress {
+			card.Status = statusDone
+		} else if isSkipped && card.Status == statusPending {
+			card.Status = statusSkipped
+		}
+		column := boardColumnForStatus(card.Status)
+		columns[columnIndex[column]].Cards = append(columns[columnIndex[column]].Cards, card)
+	}
+
+	page := boardPage{
+		Generated: now.Format("2006-01-02 15:04:05 MST"),
+		Columns:   columns,
+	}
+	if wait, err := currentLimitWait(r.opts.LogDir, now); err == nil && wait.Waiting {
+		page.Waiting = fmt.Sprintf("Waiting for the %s session limit (issue #%s): resuming at %s, %d minutes left", agentDisplayName(wait.Agent), wait.Issue, wait.ResumeAt, (wait.RemainingSec+59)/60)
+	}
+	if serving {
+		page.Refresh = boardRefreshSeconds
+		page.Serving = true
+	}
+	return page, nil
+}
+
+func boardColumnForStatus(status string) string {
+	switch status {
+	case statusInProgress:
+		return boardColumnInProgress
+	case statusDone:
+		return boardColumnDone
+	case statusFailed, statusNeedsReview:
+		return boardColumnReview
+	default:
+		return boardColumnPending
+	}
+}
+
+func formatStateDuration(st issueState, now time.Time) string {
+	total := time.Duration(st.DurationSec * float64(time.Second))
+	if st.Status == statusInProgress && st.StartedAt != "" {
+		if started, err := time.Parse(time.RFC3339, st.StartedAt); err == nil {
+			total += now.Sub(started)
+		}
+	}
+	if total <= 0 {
+		return ""
+	}
+	return total.Round(time.Second).String()
+}
+
+func (r *runner) boardLogURL(logPath string, serving bool) string {
+	if logPath == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(r.opts.LogDir, logPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if serving {
+		return "/logs/" + filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// boardArtifacts links the artifacts of an issue, named by their path in its
+// artifact directory.
+func (r *runner) boardArtifacts(paths []string, serving bool) []boardLink {
+	var links []boardLink
+	for _, path := range paths {
+		url := r.boardLogURL(path, serving)
+		if url == "" {
+			continue
+		}
+		if serving {
+			url = "/artifacts/" + strings.TrimPrefix(url, "/logs/")
+		}
+		name := filepath.ToSlash(path)
+		if i := strings.Index(name, ".artifacts/"); i >= 0 {
+			name = name[i+len(".artifacts/"):]
+		}
+		links = append(links, boardLink{Name: name, URL: url})
+	}
+	return links
+}
+
+var boardTemplate = template.Must(template.New("board").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ghir board</title>
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 1.5rem; background: #f6f8fa; color: #1f2328; }
+h1 { font-size: 1.4rem; margin: 0 0 .25rem; }
+.generated { color: #59636e; font-size: .85rem; margin-bottom: 1rem; }
+.columns { display: grid; grid-template-columns: repeat(4, 1fr); gap: 1rem; }
+.column { background: #eaeef2; border-radius: 8px; padding: .75rem; }
+.column h2 { font-size: 1rem; margin: 0 0 .75rem; }
+.card { background: #fff; border-radius: 6px; padding: .6rem .7rem; margin-bottom: .6rem; box-shadow: 0 1px 2px rgba(0,0,0,.08); }
+.card .title { font-weight: 600; }
+.card .meta { color: #59636e; font-size: .8rem; margin-top: .3rem; }
+.waiting { background: #fff1b3; border-radius: 6px; padding: .5rem .7rem; margin-bottom: 1rem; }
+.badge { display: inline-block; font-size: .7rem; padding: 0 .4rem; border-radius: 1rem; background: #d0d7de; margin-left: .3rem; }
+.badge.failed { background: #ffcecb; }
+.badge.needs-review { background: #fff1b3; }
+.badge.skipped { background: #ddf4ff; }
+form.actions { display: inline; margin: 0; }
+.enqueue { margin-bottom: 1rem; font-size: .9rem; }
+</style>
+</head>
+<body>
+<h1>ghir queue</h1>
+{{if .Serving}}<nav style="margin-bottom: .5rem; font-size: .9rem;">Queue &middot; <a href="/runs">Run history</a></nav>{{end}}
+<div class="generated">Generated {{.Generated}}</div>
+{{if .Waiting}}<div class="waiting" role="status">{{.Waiting}}</div>{{end}}
+{{if .Token}}<form class="enqueue" method="post" action="/actions/enqueue"><input type="hidden" name="token" value="{{.Token}}"><label>Add issue # <input name="issue" size="6" required pattern="[0-9]+"></label> <button type="submit">Enqueue</button></form>{{end}}
+<div class="columns">
+{{range .Columns}}<div class="column">
+<h2>{{.Name}} ({{len .Cards}})</h2>
+{{range .Cards}}<div class="card">
+<div class="title">#{{.Issue}}{{if .Title}} {{.Title}}{{end}}{{if or (eq .Status "failed") (eq .Status "needs-review") (eq .Status "skipped")}}<span class="badge {{.Status}}">{{.Status}}{{if .Failure}}: {{.Failure}}{{end}}</span>{{end}}</div>
+<div class="meta">{{if .Agent}}{{.Agent}}{{if .Model}} / {{.Model}}{{end}}{{end}}{{if .Attempts}} &middot; {{.Attempts}} attempt(s){{end}}{{if .Duration}} &middot; {{.Duration}}{{end}}{{if .Tokens}} &middot; {{.Tokens}} tokens{{end}}{{if .Cost}} &middot; {{.Cost}}{{end}}{{if .LogURL}} &middot; <a href="{{.LogURL}}">{{if eq .Status "in-progress"}}live log{{else}}log{{end}}</a>{{end}}</div>
+{{if .Changes}}<div class="meta"{{if .Summary}} title="{{.Summary}}"{{end}}>{{.Changes}}</div>{{end}}
+{{if .Artifacts}}<div class="meta">Artifacts:{{range $i, $a := .Artifacts}}{{if $i}},{{end}} <a href="{{$a.URL}}">{{$a.Name}}</a>{{end}}</div>{{end}}
+{{if and $.Token (or (eq .Status "failed") (eq .Status "needs-review"))}}<div class="meta"><form class="actions" method="post" action="/actions/approve"><input type="hidden" name="token" value="{{$.Token}}"><input type="hidden" name="issue" value="{{.Issue}}"><button type="submit">Approve</button></form> <form class="actions" method="post" action="/actions/reject"><input type="hidden" name="token" value="{{$.Token}}"><input type="hidden" name="issue" value="{{.Issue}}"><button type="submit">Reject</button></form></div>{{end}}
+</div>
+{{end}}</div>
+{{end}}</div>
+</body>
+</html>
+`))
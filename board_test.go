@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildBoardColumns(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	doneFile := filepath.Join(logDir, defaultDoneFileName)
+	if err := os.WriteFile(doneFile, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := loadStateStore(filepath.Join(logDir, defaultStateFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates := map[string]issueState{
+		"1": {Status: statusDone, Title: "Done one", DurationSec: 90, LogPath: filepath.Join(logDir, "1.log")},
+		"2": {Status: statusInProgress, Title: "Working"},
+		"3": {Status: statusFailed, Title: "Broken"},
+		"5": {Status: statusNeedsReview, Title: "Verify failed", Tokens: 1200},
+	}
+	for id, want := range updates {
+		want := want
+		if err := store.update(id, func(st *issueState) { *st = want; st.Issue = id }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &runner{
+		opts:     options{LogDir: logDir, IssuesCSV: "1,2,3,4,6"},
+		doneFile: doneFile,
+		skipSet:  map[string]struct{}{"6": {}},
+	}
+	page, err := r.buildBoard(true)
+	if err != nil {
+		t.Fatalf("buildBoard: %v", err)
+	}
+	if page.Refresh != boardRefreshSeconds {
+		t.Fatalf("refresh = %d, want %d", page.Refresh, boardRefreshSeconds)
+	}
+
+	got := make(map[string][]string)
+	for _, column := range page.Columns {
+		for _, card := range column.Cards {
+			got[column.Name] = append(got[column.Name], card.Issue+":"+card.Status)
+		}
+	}
+	want := map[string][]string{
+		boardColumnPending:    {"4:pending", "6:skipped"},
+		boardColumnInProgress: {"2:in-progress"},
+		boardColumnDone:       {"1:done"},
+		boardColumnReview:     {"3:failed", "5:needs-review"},
+	}
+	for column, cards := range want {
+		if strings.Join(got[column], ",") != strings.Join(cards, ",") {
+			t.Fatalf("column %q = %v, want %v", column, got[column], cards)
+		}
+	}
+
+	done := page.Columns[2].Cards[0]
+	if done.Duration != "1m30s" || done.LogURL != "/logs/1.log" {
+		t.Fatalf("unexpected done card: %+v", done)
+	}
+
+	var buf strings.Builder
+	if err := boardTemplate.Execute(&buf, page); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(buf.String(), `http-equiv="refresh"`) || !strings.Contains(buf.String(), "Verify failed") {
+		t.Fatalf("rendered board missing content:\n%s", buf.String())
+	}
+}
+
+func TestServeLogFileStaysInLogDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	logDir := filepath.Join(root, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "1.log"), []byte("agent output"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.log"), []byte("nope"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{opts: options{LogDir: logDir}}
+
+	rec := httptest.NewRecorder()
+	r.serveLogFile(rec, httptest.NewRequest("GET", "/logs/1.log", nil), "1.log")
+	if rec.Code != 200 || rec.Body.String() != "agent output" {
+		t.Fatalf("unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, name := range []string{"../secret.log", "state.json"} {
+		rec = httptest.NewRecorder()
+		r.serveLogFile(rec, httptest.NewRequest("GET", "/logs/x", nil), name)
+		if rec.Code != 404 {
+			t.Fatalf("%s: expected 404, got %d", name, rec.Code)
+		}
+	}
+}
+
+func TestFollowLogFileStreamsAppendedOutput(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	path := filepath.Join(logDir, "7.attempt-1.log")
+	if err := os.WriteFile(path, []byte("first\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{opts: options{LogDir: logDir}}
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return
+		}
+		_, _ = f.WriteString("second\n")
+		_ = f.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	r.followLogFile(rec, httptest.NewRequest("GET", "/follow/7.attempt-1.log", nil).WithContext(ctx), "7.attempt-1.log")
+	if rec.Body.String() != "first\nsecond\n" {
+		t.Fatalf("streamed %q, want both writes", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.followLogFile(rec, httptest.NewRequest("GET", "/follow/x", nil), "../state.json")
+	if rec.Code != 404 {
+		t.Fatalf("expected 404 outside the log dir, got %d", rec.Code)
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	journals := map[string]string{
+		"run-20260301T100000Z.jsonl": `{"time":"2026-03-01T10:00:00Z","event":"run_started","title":"nightly","agent":"codex","issues":3}
+{"time":"2026-03-01T10:00:05Z","event":"issue_finished","issue":"1","result":"success"}
+{"time":"2026-03-01T10:42:00Z","event":"run_finished","succeeded":2,"failed":1,"skipped":0}
+`,
+		"run-20260302T100000Z.jsonl": `{"time":"2026-03-02T10:00:00Z","event":"run_started","agent":"claude","model":"sonnet","issues":5}
+`,
+	}
+	for name, content := range journals {
+		if err := os.WriteFile(filepath.Join(logDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, err := runHistory(logDir, boardHistoryLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("runs = %+v, want 2", runs)
+	}
+	if runs[0].Agent != "claude" || runs[0].Finished != "" {
+		t.Fatalf("newest run = %+v, want the unfinished claude run first", runs[0])
+	}
+	want := runSummary{Started: "2026-03-01T10:00:00Z", Finished: "2026-03-01T10:42:00Z", Title: "nightly", Agent: "codex", Issues: 3, Succeeded: 2, Failed: 1, Duration: "42m0s"}
+	if runs[1] != want {
+		t.Fatalf("finished run = %+v, want %+v", runs[1], want)
+	}
+
+	r := &runner{opts: options{LogDir: logDir}}
+	rec := httptest.NewRecorder()
+	r.serveHistory(rec, httptest.NewRequest("GET", "/runs", nil))
+	if body := rec.Body.String(); rec.Code != 200 || !strings.Contains(body, "nightly") || !strings.Contains(body, "not finished") {
+		t.Fatalf("history page %d:\n%s", rec.Code, body)
+	}
+}
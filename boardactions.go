@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	boardActionApprove = "approve"
+	boardActionReject  = "reject"
+	boardActionEnqueue = "enqueue"
+)
+
+// boardActions handles the forms of the served board: approving or
+// rejecting an issue that needs review, and adding an issue to the issue
+// file. They only answer requests from the machine running ghir that carry
+// the token of the page they were posted from.
+type boardActions struct {
+	r     *runner
+	token string
+	// mu serializes actions, which read and rewrite the done file and
+	// state.json.
+	mu sync.Mutex
+}
+
+// boardActionError is an action refused for a reason the user can fix; it
+// carries the HTTP status to answer with.
+type boardActionError struct {
+	status int
+	msg    string
+}
+
+func (e *boardActionError) Error() string { return e.msg }
+
+func newBoardActions(r *runner) (*boardActions, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("create board token: %w", err)
+	}
+	return &boardActions{r: r, token: hex.EncodeToString(buf)}, nil
+}
+
+// tokenFor is the token to render into the action forms, or "" when the
+// viewer may not act and the forms are left out.
+func (a *boardActions) tokenFor(req *http.Request) string {
+	if a == nil || !isLoopbackRequest(req) {
+		return ""
+	}
+	return a.token
+}
+
+func isLoopbackRequest(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sameOrigin rejects posts sent by another site: a browser names the page a
+// form came from in Origin (or at least Referer), and it has to be the board.
+func sameOrigin(req *http.Request) bool {
+	source := req.Header.Get("Origin")
+	if source == "" {
+		source = req.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	return err == nil && u.Host == req.Host
+}
+
+func (a *boardActions) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isLoopbackRequest(req) {
+		http.Error(w, "board actions are only accepted from localhost", http.StatusForbidden)
+		return
+	}
+	if !sameOrigin(req) || subtle.ConstantTimeCompare([]byte(req.PostFormValue("token")), []byte(a.token)) != 1 {
+		http.Error(w, "invalid or missing board token; reload the board", http.StatusForbidden)
+		return
+	}
+
+	issue := strings.TrimPrefix(strings.TrimSpace(req.PostFormValue("issue")), "#")
+	var err error
+	switch action := strings.TrimPrefix(req.URL.Path, "/actions/"); action {
+	case boardActionApprove, boardActionReject:
+		err = a.review(action, issue)
+	case boardActionEnqueue:
+		err = a.enqueue(issue)
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		var refused *boardActionError
+		if errors.As(err, &refused) {
+			status = refused.status
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, req, "/", http.StatusSeeOther)
+}
+
+// review approves an issue that failed or needs review, which marks it done
+// as it stands, or rejects it, which makes it pending so the next run picks
+// it up again (like ghir reset <issue>). A run in progress keeps its own
+// copy of the tracking files, so both wait until it has finished.
+func (a *boardActions) review(action, issue string) error {
+	if !isIssueID(issue) {
+		return &boardActionError{http.StatusBadRequest, fmt.Sprintf("invalid issue %q", issue)}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if runs, err := runHistory(a.r.opts.LogDir, 1); err != nil {
+		return err
+	} else if len(runs) > 0 && runs[0].Finished == "" {
+		return &boardActionError{http.StatusConflict, "a run is in progress; approve or reject once it has finished"}
+	}
+	r := a.r
+	done, err := loadDoneSet(r.doneFile)
+	if err != nil {
+		return err
+	}
+	state, err := loadStateStore(filepath.Join(r.opts.LogDir, defaultStateFileName))
+	if err != nil {
+		return err
+	}
+	r.doneSet, r.state = done, state
+	st, ok := state.get(issue)
+	if _, isDone := done[issue]; isDone || !ok || (st.Status != statusFailed && st.Status != statusNeedsReview) {
+		return &boardActionError{http.StatusConflict, fmt.Sprintf("#%s does not need review", issue)}
+	}
+
+	if action == boardActionReject {
+		if err := r.markPending([]string{issue}); err != nil {
+			return err
+		}
+		r.printf(r.colors.Yellow, "Board: rejected #%s, it is pending again\n", issue)
+		return nil
+	}
+	if err := r.markCompleted(issue, nil); err != nil {
+		return err
+	}
+	err = state.update(issue, func(st *issueState) {
+		st.Status = statusDone
+		st.Failure = ""
+	})
+	if err != nil {
+		return fmt.Errorf("update state of #%s: %w", issue, err)
+	}
+	r.printf(r.colors.Green, "Board: approved #%s\n", issue)
+	return nil
+}
+
+// enqueue appends an issue to the plain issue file, where the next run
+// finds it.
+func (a *boardActions) enqueue(issue string) error {
+	if !issuePattern.MatchString(issue) {
+		return &boardActionError{http.StatusBadRequest, fmt.Sprintf("invalid issue number %q", issue)}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r := a.r
+	path := r.opts.IssuesFile
+	if _, frozen, err := r.loadFrozenQueue(); err != nil {
+		return err
+	} else if frozen {
+		return &boardActionError{http.StatusConflict, "the queue is frozen; run ghir thaw to add issues"}
+	}
+	if path == "" || path == stdinIssuesFile || isStructuredIssueFile(path) {
+		return &boardActionError{http.StatusConflict, "issues can only be added to a plain issue file"}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read issues file: %w", err)
+	}
+	listed, err := parseIssueLines(string(data), path)
+	if err != nil {
+		return err
+	}
+	for _, id := range listed {
+		if id == issue {
+			return nil
+		}
+	}
+	line := issue + "\n"
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		line = "\n" + line
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create issues file directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open issues file: %w", err)
+	}
+	if _, err := f.WriteString(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write issues file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write issues file: %w", err)
+	}
+	r.printf(r.colors.Green, "Board: added #%s to %s\n", issue, path)
+	return nil
+}
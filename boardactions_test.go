@@ -0,0 +1,192 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newBoardActionsForTest(t *testing.T) *boardActions {
+	t.Helper()
+
+	dir := t.TempDir()
+	r := newTestRunner(t, dir, options{IssuesFile: filepath.Join(dir, "issues.txt")})
+	actions, err := newBoardActions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actions
+}
+
+func postBoardAction(a *boardActions, action string, form url.Values, edit func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/actions/"+action, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "127.0.0.1:50000"
+	if edit != nil {
+		edit(req)
+	}
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBoardActionsRefuseForeignRequests(t *testing.T) {
+	t.Parallel()
+
+	a := newBoardActionsForTest(t)
+	valid := url.Values{"token": {a.token}, "issue": {"5"}}
+	tests := []struct {
+		name string
+		form url.Values
+		edit func(*http.Request)
+		want int
+	}{
+		{name: "get", form: valid, edit: func(req *http.Request) { req.Method = http.MethodGet }, want: http.StatusMethodNotAllowed},
+		{name: "remote viewer", form: valid, edit: func(req *http.Request) { req.RemoteAddr = "192.0.2.7:50000" }, want: http.StatusForbidden},
+		{name: "missing token", form: url.Values{"issue": {"5"}}, want: http.StatusForbidden},
+		{name: "wrong token", form: url.Values{"token": {"guess"}, "issue": {"5"}}, want: http.StatusForbidden},
+		{name: "other site", form: valid, edit: func(req *http.Request) { req.Header.Set("Origin", "https://evil.example") }, want: http.StatusForbidden},
+		{name: "unknown action", form: valid, edit: func(req *http.Request) { req.URL.Path = "/actions/delete" }, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := postBoardAction(a, boardActionEnqueue, tt.form, tt.edit); rec.Code != tt.want {
+			t.Fatalf("%s: status = %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body.String())
+		}
+	}
+	if _, err := os.Stat(a.r.opts.IssuesFile); !os.IsNotExist(err) {
+		t.Fatalf("a refused request changed the issue file: %v", err)
+	}
+
+	local := httptest.NewRequest(http.MethodGet, "/", nil)
+	local.RemoteAddr = "[::1]:50000"
+	remote := httptest.NewRequest(http.MethodGet, "/", nil)
+	if a.tokenFor(local) != a.token || a.tokenFor(remote) != "" {
+		t.Fatal("the forms must be offered to localhost only")
+	}
+}
+
+func TestBoardActionsReview(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		action     string
+		status     string
+		journal    string
+		wantCode   int
+		wantStatus string
+		wantDone   bool
+	}{
+		{name: "approve", action: boardActionApprove, status: statusNeedsReview, wantCode: http.StatusSeeOther, wantStatus: statusDone, wantDone: true},
+		{name: "reject", action: boardActionReject, status: statusFailed, wantCode: http.StatusSeeOther, wantStatus: statusPending},
+		{name: "nothing to review", action: boardActionApprove, status: statusPending, wantCode: http.StatusConflict, wantStatus: statusPending},
+		{
+			name:       "run in progress",
+			action:     boardActionApprove,
+			status:     statusNeedsReview,
+			journal:    `{"time":"2026-03-02T10:00:00Z","event":"run_started","agent":"claude","issues":5}` + "\n",
+			wantCode:   http.StatusConflict,
+			wantStatus: statusNeedsReview,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := newBoardActionsForTest(t)
+			r := a.r
+			if err := r.state.update("5", func(st *issueState) { st.Status = tt.status; st.Attempts = 2 }); err != nil {
+				t.Fatal(err)
+			}
+			if tt.journal != "" {
+				if err := os.WriteFile(filepath.Join(r.opts.LogDir, "run-20260302T100000Z.jsonl"), []byte(tt.journal), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rec := postBoardAction(a, tt.action, url.Values{"token": {a.token}, "issue": {"5"}}, nil)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if got := boardStatus(t, r, "5"); got != tt.wantStatus {
+				t.Fatalf("board status = %q, want %q", got, tt.wantStatus)
+			}
+			done, err := loadDoneSet(r.doneFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := done["5"]; ok != tt.wantDone {
+				t.Fatalf("#5 in the done file = %v, want %v", ok, tt.wantDone)
+			}
+		})
+	}
+}
+
+func TestBoardActionsEnqueue(t *testing.T) {
+	t.Parallel()
+
+	a := newBoardActionsForTest(t)
+	path := a.r.opts.IssuesFile
+	if err := os.WriteFile(path, []byte("1\n# later\n2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range []string{"#7", "2", "7"} {
+		if rec := postBoardAction(a, boardActionEnqueue, url.Values{"token": {a.token}, "issue": {issue}}, nil); rec.Code != http.StatusSeeOther {
+			t.Fatalf("enqueue %s: status = %d (%s)", issue, rec.Code, rec.Body.String())
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "1\n# later\n2\n7\n" {
+		t.Fatalf("issue file = %q", data)
+	}
+
+	if rec := postBoardAction(a, boardActionEnqueue, url.Values{"token": {a.token}, "issue": {"x;rm"}}, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid issue: status = %d", rec.Code)
+	}
+	if err := os.WriteFile(a.r.frozenQueuePath(), []byte(`{"entries":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rec := postBoardAction(a, boardActionEnqueue, url.Values{"token": {a.token}, "issue": {"9"}}, nil); rec.Code != http.StatusConflict {
+		t.Fatalf("frozen queue: status = %d", rec.Code)
+	}
+}
+
+func TestBoardRendersActionFormsWithToken(t *testing.T) {
+	t.Parallel()
+
+	a := newBoardActionsForTest(t)
+	for id, status := range map[string]string{"5": statusNeedsReview, "6": statusDone} {
+		status := status
+		if err := a.r.state.update(id, func(st *issueState) { st.Status = status }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page, err := a.r.buildBoard(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var html strings.Builder
+	if err := boardTemplate.Execute(&html, page); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html.String(), "/actions/") {
+		t.Fatal("forms rendered without a token")
+	}
+
+	page.Token = a.token
+	html.Reset()
+	if err := boardTemplate.Execute(&html, page); err != nil {
+		t.Fatal(err)
+	}
+	out := html.String()
+	if strings.Count(out, `action="/actions/approve"`) != 1 || strings.Count(out, `action="/actions/reject"`) != 1 || !strings.Contains(out, `action="/actions/enqueue"`) {
+		t.Fatalf("expected one approve/reject pair and the enqueue form:\n%s", out)
+	}
+	if !strings.Contains(out, `value="`+a.token+`"`) {
+		t.Fatal("forms do not carry the token")
+	}
+}
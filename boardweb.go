@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	boardHistoryLimit  = 50
+	followPollInterval = time.Second
+	// followIdleTimeout ends a live log once nothing was written for this
+	// long, so finished attempts do not hold connections open.
+	followIdleTimeout = 10 * time.Minute
+)
+
+// runSummary is one run of the history page, read from its journal.
+type runSummary struct {
+	Started   string
+	Finished  string
+	Title     string
+	Agent     string
+	Model     string
+	Issues    int
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Duration  string
+}
+
+type historyPage struct {
+	Generated string
+	Runs      []runSummary
+}
+
+// runHistory summarizes the newest run journals (run-<timestamp>.jsonl) in
+// the log dir, newest first.
+func runHistory(logDir string, limit int) ([]runSummary, error) {
+	paths, err := filepath.Glob(filepath.Join(logDir, "run-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	if len(paths) > limit {
+		paths = paths[:limit]
+	}
+	runs := make([]runSummary, 0, len(paths))
+	for _, path := range paths {
+		run, err := summarizeJournal(path)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func summarizeJournal(path string) (runSummary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return runSummary{}, err
+	}
+	defer f.Close()
+
+	var run runSummary
+	var started, finished time.Time
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		switch entry.Event {
+		case journalRunStarted:
+			run.Started, run.Title, run.Agent, run.Model = entry.Time, entry.Title, entry.Agent, entry.Model
+			if entry.Issues != nil {
+				run.Issues = *entry.Issues
+			}
+			started, _ = time.Parse(time.RFC3339, entry.Time)
+		case journalRunFinished:
+			run.Finished = entry.Time
+			finished, _ = time.Parse(time.RFC3339, entry.Time)
+			if entry.Succeeded != nil {
+				run.Succeeded = *entry.Succeeded
+			}
+			if entry.Failed != nil {
+				run.Failed = *entry.Failed
+			}
+			if entry.Skipped != nil {
+				run.Skipped = *entry.Skipped
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return runSummary{}, err
+	}
+	if !started.IsZero() && !finished.IsZero() {
+		run.Duration = finished.Sub(started).Round(time.Second).String()
+	}
+	return run, nil
+}
+
+func (r *runner) serveHistory(w http.ResponseWriter, req *http.Request) {
+	runs, err := runHistory(r.opts.LogDir, boardHistoryLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page := historyPage{Generated: r.now().Format("2006-01-02 15:04:05 MST"), Runs: runs}
+	if err := historyTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// followLogFile streams an agent log as it grows, so an attempt in progress
+// can be watched from the browser.
+func (r *runner) followLogFile(w http.ResponseWriter, req *http.Request, name string) {
+	clean := filepath.Clean("/" + name)
+	path := filepath.Join(r.opts.LogDir, clean)
+	if !strings.HasPrefix(path, filepath.Clean(r.opts.LogDir)+string(filepath.Separator)) || !strings.HasSuffix(path, ".log") {
+		http.NotFound(w, req)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+	idleSince := time.Now()
+	for {
+		n, err := io.Copy(w, f)
+		if err != nil {
+			return
+		}
+		if n > 0 {
+			idleSince = time.Now()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if time.Since(idleSince) > followIdleTimeout {
+			return
+		}
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+var historyTemplate = template.Must(template.New("history").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ghir run history</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 1.5rem; background: #f6f8fa; color: #1f2328; }
+h1 { font-size: 1.4rem; margin: 0 0 .25rem; }
+nav { margin-bottom: 1rem; font-size: .9rem; }
+.generated { color: #59636e; font-size: .85rem; margin-bottom: 1rem; }
+table { border-collapse: collapse; background: #fff; }
+th, td { text-align: left; padding: .35rem .7rem; border-bottom: 1px solid #d0d7de; font-size: .9rem; }
+td.num { text-align: right; }
+.failed { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>ghir run history</h1>
+<nav><a href="/">Queue</a> &middot; Run history</nav>
+<div class="generated">Generated {{.Generated}}</div>
+{{if .Runs}}<table>
+<tr><th>Started</th><th>Run</th><th>Agent</th><th>Issues</th><th>Succeeded</th><th>Failed</th><th>Skipped</th><th>Duration</th></tr>
+{{range .Runs}}<tr>
+<td>{{.Started}}</td>
+<td>{{if .Title}}{{.Title}}{{else}}&mdash;{{end}}</td>
+<td>{{.Agent}}{{if .Model}} / {{.Model}}{{end}}</td>
+<td class="num">{{.Issues}}</td>
+{{if .Finished}}<td class="num">{{.Succeeded}}</td><td class="num{{if .Failed}} failed{{end}}">{{.Failed}}</td><td class="num">{{.Skipped}}</td><td>{{.Duration}}</td>{{else}}<td colspan="4">not finished</td>{{end}}
+</tr>
+{{end}}</table>{{else}}<p>No runs recorded yet.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const buildOutputLines = 40
+
+// buildIssue runs --build-cmd on what the agent left behind, before the
+// runner commits anything for it. A failing build is printed in full view
+// (the last buildOutputLines lines), since compiler errors are the first
+// thing to look at.
+func (r *runner) buildIssue(issue string) bool {
+	if r.opts.BuildCmd == "" {
+		return true
+	}
+	command := expandVerifyCommand(r.opts.BuildCmd, issue)
+	r.printf(r.colors.Yellow, "Building issue #%s: %s\n", issue, command)
+	result, err := r.runCheck(r.repoRoot, command, filepath.Join(r.opts.LogDir, issue+".build.log"))
+	r.record(journalEntry{Event: journalBuilt, Issue: issue, Passed: boolPtr(err == nil && result.Passed), ExitCode: intPtr(result.ExitCode), LogPath: result.LogPath})
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: build could not run for #%s: %v\n", issue, err)
+		return false
+	}
+	if result.Passed {
+		r.printf(r.colors.Green, "Build passed for issue #%s\n", issue)
+		return true
+	}
+	r.rule(r.colors.Red, "=")
+	r.printf(r.colors.Red, "BUILD FAILED for issue #%s (exit %d): %s\n", issue, result.ExitCode, command)
+	r.rule(r.colors.Red, "=")
+	for _, line := range compactMultiline(tailLines(result.Output, buildOutputLines), buildOutputLines, 8000) {
+		r.printf(r.colors.Red, "  %s\n", line)
+	}
+	r.rule(r.colors.Red, "=")
+	r.printf(r.colors.Red, "Check log: %s\n", result.LogPath)
+	return false
+}
+
+// shelveUnbuilt moves uncommitted changes that do not build into a stash
+// entry instead of committing them, so history stays free of broken
+// fallback commits and the work can still be recovered.
+func (r *runner) shelveUnbuilt(issue string) {
+	dirty, err := r.workingTreeDirty()
+	if err != nil || !dirty {
+		return
+	}
+	message := fmt.Sprintf("ghir: #%s did not build", issue)
+	if _, err := r.gitOutput("stash", "push", "--include-untracked", "-m", message); err != nil {
+		r.printf(r.colors.Red, "WARNING: could not stash the uncommitted changes of #%s: %v\n", issue, err)
+		return
+	}
+	r.printf(r.colors.Yellow, "Not committing changes that do not build; they are kept in stash@{0} (%q)\n", message)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildGateKeepsBrokenChangesOutOfHistory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		agent       string
+		buildCmd    string
+		wantFailure failureCategory
+		wantHead    string
+		wantStash   bool
+	}{
+		{
+			name:        "uncommitted changes that do not build are stashed",
+			agent:       "echo 'broken' > main.go",
+			buildCmd:    "echo 'main.go:1:1: syntax error'; exit 2",
+			wantFailure: failureBuild,
+			wantHead:    "init",
+			wantStash:   true,
+		},
+		{
+			name:        "agent commit that does not build fails the issue",
+			agent:       "echo 'broken' > main.go && git add main.go && git commit -q -m 'feat: broken (#5)'",
+			buildCmd:    "exit 2",
+			wantFailure: failureBuild,
+			wantHead:    "feat: broken (#5)",
+		},
+		{
+			name:     "a passing build lets the fallback commit through",
+			agent:    "echo 'ok' > main.go",
+			buildCmd: "test -f main.go",
+			wantHead: "feat: implement #5 - Fix main",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := fakeIssueGH(t, "Fix main", "fix it")
+			agent := fakeAgent(t, tt.agent)
+			opts := options{
+				Agent:       "claude",
+				ClaudeBin:   agent,
+				GHBin:       gh,
+				SingleIssue: "5",
+				BuildCmd:    tt.buildCmd,
+				CoAuthor:    coAuthorNone,
+			}
+			r := newTestRunner(t, repo, opts)
+			r.runQueue()
+			if got := r.failures["5"]; got != tt.wantFailure {
+				t.Fatalf("failure = %q, want %q", got, tt.wantFailure)
+			}
+			if head := strings.TrimSpace(runGit(t, repo, "log", "-1", "--pretty=%s")); head != tt.wantHead {
+				t.Fatalf("HEAD = %q, want %q", head, tt.wantHead)
+			}
+			if status := strings.TrimSpace(runGit(t, repo, "status", "--porcelain")); status != "" {
+				t.Fatalf("working tree not clean: %s", status)
+			}
+			stash := runGit(t, repo, "stash", "list")
+			if got := strings.Contains(stash, "ghir: #5 did not build"); got != tt.wantStash {
+				t.Fatalf("stash list = %q, want stashed=%v", stash, tt.wantStash)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseCacheSpec splits a --cache value of the form VAR=dir.
+func parseCacheSpec(spec string) (string, string, error) {
+	name, dir, ok := strings.Cut(spec, "=")
+	name, dir = strings.TrimSpace(name), strings.TrimSpace(dir)
+	if !ok || !envNamePattern.MatchString(name) || dir == "" {
+		return "", "", fmt.Errorf("--cache must be VAR=dir (got %q)", spec)
+	}
+	return name, dir, nil
+}
+
+// cacheSpecs turns the caches map of config.yaml into sorted VAR=dir specs.
+func cacheSpecs(caches map[string]string) []string {
+	specs := make([]string, 0, len(caches))
+	for name, dir := range caches {
+		specs = append(specs, name+"="+dir)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+// buildCacheEnv is environ with every cache variable pointed at its
+// directory, created if needed. Relative directories are taken from the repo
+// root, so every issue, worktree and child runner shares the same cache. A
+// later spec for the same variable wins. It returns nil without caches, which
+// keeps the inherited environment.
+func buildCacheEnv(specs []string, repoRoot string, environ []string) ([]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	dirs := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		name, dir, err := parseCacheSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		dir = resolvePath(repoRoot, expandHome(dir))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create cache dir for %s: %w", name, err)
+		}
+		dirs[name] = dir
+	}
+	env := make([]string, 0, len(environ)+len(dirs))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := dirs[name]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for _, name := range sortedKeys(dirs) {
+		env = append(env, name+"="+dirs[name])
+	}
+	return env, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// linkSharedDirs symlinks --share-dir directories (node_modules, target/,
+// ...) from the repo into a worktree ghir created, so the worktree does not
+// install or build them from scratch. Directories the worktree already has
+// are left alone.
+func (r *runner) linkSharedDirs(worktree string) {
+	for _, rel := range r.opts.ShareDirs {
+		src := filepath.Join(r.repoRoot, rel)
+		if info, err := os.Stat(src); err != nil || !info.IsDir() {
+			continue
+		}
+		dst := filepath.Join(worktree, rel)
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		err := os.MkdirAll(filepath.Dir(dst), 0o755)
+		if err == nil {
+			err = os.Symlink(src, dst)
+		}
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not share %s with %s: %v\n", rel, worktree, err)
+		}
+	}
+}
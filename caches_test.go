@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCacheSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec     string
+		wantName string
+		wantDir  string
+		wantErr  bool
+	}{
+		{spec: "GOCACHE=/tmp/go-build", wantName: "GOCACHE", wantDir: "/tmp/go-build"},
+		{spec: "npm_config_cache = .cache/npm", wantName: "npm_config_cache", wantDir: ".cache/npm"},
+		{spec: "GOCACHE", wantErr: true},
+		{spec: "GOCACHE=", wantErr: true},
+		{spec: "1X=/tmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.spec, func(t *testing.T) {
+			t.Parallel()
+			name, dir, err := parseCacheSpec(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCacheSpec(%q) succeeded", tt.spec)
+				}
+				return
+			}
+			if err != nil || name != tt.wantName || dir != tt.wantDir {
+				t.Fatalf("parseCacheSpec(%q) = %q, %q, %v", tt.spec, name, dir, err)
+			}
+		})
+	}
+}
+
+func TestBuildCacheEnv(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	env, err := buildCacheEnv([]string{"GOCACHE=.cache/go"}, repo, []string{"PATH=/bin", "GOCACHE=/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PATH=/bin", "GOCACHE=" + filepath.Join(repo, ".cache/go")}
+	if strings.Join(env, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("env = %q, want %q", env, want)
+	}
+	if info, err := os.Stat(filepath.Join(repo, ".cache/go")); err != nil || !info.IsDir() {
+		t.Fatalf("cache dir not created: %v", err)
+	}
+
+	env, err = buildCacheEnv([]string{"GOCACHE=a", "GOCACHE=b"}, repo, nil)
+	if err != nil || len(env) != 1 || env[0] != "GOCACHE="+filepath.Join(repo, "b") {
+		t.Fatalf("later spec should win: %q, %v", env, err)
+	}
+	if env, err := buildCacheEnv(nil, repo, []string{"PATH=/bin"}); env != nil || err != nil {
+		t.Fatalf("no caches: %q, %v", env, err)
+	}
+}
+
+func TestVerifyAndBaselineShareCaches(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	if err := os.MkdirAll(filepath.Join(repo, "node_modules", "left-pad"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	opts := options{
+		VerifyCmd: `test -d node_modules/left-pad && echo "$GOCACHE" > "$GOCACHE/seen"`,
+		Baseline:  "HEAD",
+		Caches:    []string{"GOCACHE=.cache/go"},
+		ShareDirs: []string{"node_modules"},
+	}
+	r := newTestRunner(t, repo, opts)
+	result, err := r.baselineVerify("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed {
+		t.Fatalf("baseline verify failed: %s", result.Output)
+	}
+	seen, err := os.ReadFile(filepath.Join(repo, ".cache/go", "seen"))
+	if err != nil || strings.TrimSpace(string(seen)) != filepath.Join(repo, ".cache/go") {
+		t.Fatalf("GOCACHE seen by the worktree = %q, %v", seen, err)
+	}
+}
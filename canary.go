@@ -0,0 +1,84 @@
+package main
+
+import "fmt"
+
+// canaryFirst makes the first pending issue of the queue the canary.
+const canaryFirst = "first"
+
+func (r *runner) isPending(id string) bool {
+	return !r.isSkipped(id) && (!r.isCompleted(id) || r.opts.Force)
+}
+
+// orderCanary moves the --canary issue to the front of the queue, so a
+// broken template, expired credentials or a misconfigured gate fail on one
+// issue before the rest of the batch burns quota.
+func (r *runner) orderCanary(issues []issueEntry) ([]issueEntry, error) {
+	idx := -1
+	for i, entry := range issues {
+		if r.opts.Canary == canaryFirst && r.isPending(entry.ID) || entry.ID == r.opts.Canary {
+			idx = i
+			break
+		}
+	}
+	switch {
+	case idx < 0 && r.opts.Canary == canaryFirst:
+		return issues, nil
+	case idx < 0:
+		return nil, fmt.Errorf("canary #%s is not in the queue", r.opts.Canary)
+	case !r.isPending(issues[idx].ID):
+		return nil, fmt.Errorf("canary #%s is completed or on the skip list; pick a pending issue (or use --force)", r.opts.Canary)
+	}
+	ordered := append([]issueEntry{issues[idx]}, issues[:idx]...)
+	ordered = append(ordered, issues[idx+1:]...)
+	r.printf(r.colors.Blue, "Canary: #%s runs first; the rest of the batch only starts if it passes\n", issues[idx].ID)
+	return ordered, nil
+}
+
+// canaryVerdict reports the canary's result and whether the batch may go on.
+// A skipped canary (closed, or labelled to skip) proves nothing: with
+// --canary first the next pending issue takes its place, a named canary
+// stops the batch.
+func (r *runner) canaryVerdict(entry issueEntry, result issueResult) (passed, replaced bool) {
+	switch {
+	case result == resultSuccess:
+		r.printf(r.colors.Green, "Canary #%s passed; continuing with the batch\n", entry.ID)
+		return true, false
+	case result == resultSkipped && r.opts.Canary == canaryFirst:
+		r.printf(r.colors.Yellow, "Canary #%s was skipped; the next pending issue is the canary\n", entry.ID)
+		return false, true
+	case result == resultSkipped:
+		r.printf(r.colors.Red, "Canary #%s was skipped, so it proved nothing; not starting the rest of the batch\n", entry.ID)
+	default:
+		r.printf(r.colors.Red, "Canary #%s failed; not starting the rest of the batch\n", entry.ID)
+	}
+	return false, false
+}
+
+// runParallelCanary runs the canary on its own before the workers start and
+// returns the issues left for them, or passed=false when the batch stops.
+func (r *runner) runParallelCanary(issues []issueEntry) (succeeded, failed, skipped int, outcomes []manifestOutcome, rest []issueEntry, passed bool) {
+	for len(issues) > 0 {
+		if !r.isPending(issues[0].ID) {
+			rest = append(rest, issues[0])
+			issues = issues[1:]
+			continue
+		}
+		canary := issues[0]
+		issues = issues[1:]
+		s, f, k, o := r.runParallel([]issueEntry{canary})
+		succeeded, failed, skipped = succeeded+s, failed+f, skipped+k
+		outcomes = append(outcomes, o...)
+		result := resultFailed
+		switch {
+		case s > 0:
+			result = resultSuccess
+		case k > 0:
+			result = resultSkipped
+		}
+		passed, replaced := r.canaryVerdict(canary, result)
+		if !replaced {
+			return succeeded, failed, skipped, outcomes, append(rest, issues...), passed
+		}
+	}
+	return succeeded, failed, skipped, outcomes, rest, true
+}
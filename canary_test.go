@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOrderCanary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		canary  string
+		force   bool
+		want    string
+		wantErr string
+	}{
+		{name: "first pending", canary: canaryFirst, want: "3,1,2,4"},
+		{name: "first with force", canary: canaryFirst, force: true, want: "1,2,3,4"},
+		{name: "named", canary: "4", want: "4,1,2,3"},
+		{name: "not in queue", canary: "9", wantErr: "not in the queue"},
+		{name: "completed", canary: "1", wantErr: "completed or on the skip list"},
+		{name: "skipped", canary: "2", wantErr: "completed or on the skip list"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{
+				opts:    options{Canary: tt.canary, Force: tt.force, NoColor: true, Quiet: true},
+				doneSet: map[string]doneRecord{"1": {ID: "1"}},
+				skipSet: map[string]struct{}{"2": {}},
+			}
+			got, err := r.orderCanary([]issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("orderCanary() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids(got) != tt.want {
+				t.Fatalf("orderCanary() = %s, want %s", ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestCanaryRunsFirstAndGatesTheBatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		agent      string
+		wantCode   int
+		wantIssues string
+	}{
+		{
+			name:       "passing canary lets the batch run",
+			agent:      "[ \"$1\" = --version ] && exit 0\ndate +%s%N >> change.txt\ngit add change.txt\ngit commit -q -m \"fix: change\"",
+			wantIssues: "6,5",
+		},
+		{
+			name:       "failing canary stops the batch",
+			agent:      "[ \"$1\" = --version ] && exit 0\nexit 1",
+			wantCode:   1,
+			wantIssues: "6",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			calls := filepath.Join(t.TempDir(), "gh-calls")
+			gh := writeFakeBin(t, "gh", `[ "$1 $2" = "issue view" ] && echo "$3" >> `+calls+`
+echo '{"title":"Change","body":"change it","state":"OPEN","labels":[]}'`)
+			agent := fakeAgent(t, tt.agent)
+			path := filepath.Join(t.TempDir(), "batch.yaml")
+			if err := os.WriteFile(path, []byte("issues:\n  - 5\n  - 6\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := loadRunManifest(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := options{
+				Agent:     "claude",
+				ClaudeBin: agent,
+				GHBin:     gh,
+				Canary:    "6",
+				manifest:  m,
+			}
+			r := newTestRunner(t, repo, opts)
+			if code := r.runQueue(); code != tt.wantCode {
+				t.Fatalf("runQueue() = %d, want %d", code, tt.wantCode)
+			}
+			data, err := os.ReadFile(calls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, id := range strings.Fields(string(data)) {
+				if len(got) == 0 || got[len(got)-1] != id {
+					got = append(got, id)
+				}
+			}
+			if strings.Join(got, ",") != tt.wantIssues {
+				t.Fatalf("issues viewed = %v, want %s", got, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestCanaryOptions(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseArgs([]string{"--canary"}); err == nil {
+		t.Fatal("--canary without a value should fail")
+	}
+	opts, err := parseArgs([]string{"--canary", "first", "--issue", "3"})
+	if err == nil {
+		err = validateOptions(opts)
+	}
+	if err == nil || !strings.Contains(err.Error(), "--canary cannot be combined with --issue") {
+		t.Fatalf("error = %v, want --canary/--issue conflict", err)
+	}
+}
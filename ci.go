@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sourceJUnit   = "junit"
+	sourceActions = "actions"
+
+	ciMaxOutputLines = 80
+	ciMaxLogLines    = 150
+)
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	SystemOut string         `xml:"system-out"`
+	SystemErr string         `xml:"system-err"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// loadJUnitIssues makes one task per failing or erroring test case across
+// every report matching pattern (a path or a glob).
+func loadJUnitIssues(pattern string) ([]issueEntry, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("--junit: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("--junit: no report matches %s", pattern)
+	}
+	var entries []issueEntry
+	seen := make(map[string]struct{})
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read JUnit report: %w", err)
+		}
+		var root junitSuite
+		if err := xml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		for _, failed := range failingCases(root, "") {
+			e := failed.entry()
+			if _, ok := seen[e.ID]; ok {
+				continue
+			}
+			seen[e.ID] = struct{}{}
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no failing tests in %s", pattern)
+	}
+	return entries, nil
+}
+
+type junitFailure struct {
+	suite   string
+	test    junitCase
+	problem junitProblem
+	kind    string
+}
+
+func failingCases(suite junitSuite, parent string) []junitFailure {
+	name := firstNonEmpty(suite.Name, parent)
+	var out []junitFailure
+	for _, tc := range suite.Cases {
+		switch {
+		case len(tc.Failures) > 0:
+			out = append(out, junitFailure{suite: name, test: tc, problem: tc.Failures[0], kind: "failure"})
+		case len(tc.Errors) > 0:
+			out = append(out, junitFailure{suite: name, test: tc, problem: tc.Errors[0], kind: "error"})
+		}
+	}
+	for _, child := range suite.Suites {
+		out = append(out, failingCases(child, name)...)
+	}
+	return out
+}
+
+func (f junitFailure) testName() string {
+	if f.test.Classname == "" {
+		return f.test.Name
+	}
+	return f.test.Classname + "." + f.test.Name
+}
+
+// entry keys the id on the test and the first line of the failure message, so
+// a test that starts failing differently after a fix becomes a new task.
+func (f junitFailure) entry() issueEntry {
+	message := firstLine(firstNonEmpty(f.problem.Message, f.problem.Text))
+	return issueEntry{
+		ID:     syntheticID(sourceJUnit, f.suite, f.testName(), message),
+		Source: sourceJUnit,
+		Title:  fmt.Sprintf("Failing test %s", f.testName()),
+		Body:   f.body(),
+	}
+}
+
+func (f junitFailure) body() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Test: %s\n", f.testName())
+	if f.suite != "" {
+		fmt.Fprintf(&b, "Suite: %s\n", f.suite)
+	}
+	if f.test.File != "" {
+		location := f.test.File
+		if f.test.Line != "" {
+			location += ":" + f.test.Line
+		}
+		fmt.Fprintf(&b, "File: %s\n", location)
+	}
+	fmt.Fprintf(&b, "Result: %s", f.kind)
+	if f.problem.Type != "" {
+		fmt.Fprintf(&b, " (%s)", f.problem.Type)
+	}
+	b.WriteString("\n")
+	if f.problem.Message != "" {
+		fmt.Fprintf(&b, "Message: %s\n", normalizeWhitespace(f.problem.Message))
+	}
+	if text := strings.TrimSpace(f.problem.Text); text != "" {
+		fmt.Fprintf(&b, "\nOutput:\n\n```\n%s\n```\n", tailLines(text, ciMaxOutputLines))
+	}
+	for _, stream := range []struct{ name, text string }{{"stdout", f.test.SystemOut}, {"stderr", f.test.SystemErr}} {
+		if text := strings.TrimSpace(stream.text); text != "" {
+			fmt.Fprintf(&b, "\nTest %s:\n\n```\n%s\n```\n", stream.name, tailLines(text, ciMaxOutputLines))
+		}
+	}
+	b.WriteString("\nRun the test, find out why it fails and fix the cause. Only change the test if the test itself is wrong; do not skip or delete it.\n")
+	return b.String()
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
+
+type actionsRun struct {
+	DatabaseID   int64  `json:"databaseId"`
+	DisplayTitle string `json:"displayTitle"`
+	WorkflowName string `json:"workflowName"`
+	HeadBranch   string `json:"headBranch"`
+	HeadSha      string `json:"headSha"`
+	URL          string `json:"url"`
+}
+
+type actionsJob struct {
+	DatabaseID int64  `json:"databaseId"`
+	Name       string `json:"name"`
+	Conclusion string `json:"conclusion"`
+	URL        string `json:"url"`
+	Steps      []struct {
+		Name       string `json:"name"`
+		Conclusion string `json:"conclusion"`
+	} `json:"steps"`
+}
+
+// loadActionsIssues turns the failed jobs of the latest failed workflow run
+// into tasks. Ids include the run, so tomorrow's failure of the same job is a
+// new task.
+func (r *runner) loadActionsIssues() ([]issueEntry, error) {
+	args := []string{"run", "list", "--status", "failure", "--limit", "1", "--json", "databaseId,displayTitle,workflowName,headBranch,headSha,url"}
+	if r.opts.Workflow != "" {
+		args = append(args, "--workflow", r.opts.Workflow)
+	}
+	out, err := r.commandOutput(r.opts.GHBin, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list failed workflow runs: %w", err)
+	}
+	var runs []actionsRun
+	if err := json.Unmarshal([]byte(out), &runs); err != nil {
+		return nil, fmt.Errorf("parse gh run list output: %w", err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no failed workflow runs found")
+	}
+	run := runs[0]
+	runID := fmt.Sprint(run.DatabaseID)
+
+	out, err = r.commandOutput(r.opts.GHBin, "run", "view", runID, "--json", "jobs")
+	if err != nil {
+		return nil, fmt.Errorf("list jobs of run %s: %w", runID, err)
+	}
+	var view struct {
+		Jobs []actionsJob `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		return nil, fmt.Errorf("parse gh run view output: %w", err)
+	}
+
+	var entries []issueEntry
+	for _, job := range view.Jobs {
+		if job.Conclusion != "failure" && job.Conclusion != "timed_out" {
+			continue
+		}
+		log, err := r.commandOutput(r.opts.GHBin, "run", "view", "--job", fmt.Sprint(job.DatabaseID), "--log-failed")
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not fetch the log of job %s: %v\n", job.Name, err)
+			log = ""
+		}
+		entries = append(entries, issueEntry{
+			ID:     syntheticID(sourceActions, runID, job.Name),
+			Source: sourceActions,
+			Title:  fmt.Sprintf("CI job %s / %s failed", run.WorkflowName, job.Name),
+			Body:   actionsJobBody(run, job, log),
+		})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("run %s has no failed jobs", runID)
+	}
+	return entries, nil
+}
+
+func actionsJobBody(run actionsRun, job actionsJob, log string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Workflow: %s\nRun: %s\n", run.WorkflowName, firstNonEmpty(run.URL, fmt.Sprint(run.DatabaseID)))
+	if run.DisplayTitle != "" {
+		fmt.Fprintf(&b, "Trigger: %s\n", run.DisplayTitle)
+	}
+	sha := run.HeadSha
+	if len(sha) > 12 {
+		sha = sha[:12]
+	}
+	fmt.Fprintf(&b, "Branch: %s @ %s\n", run.HeadBranch, sha)
+	fmt.Fprintf(&b, "Job: %s (%s)\n", job.Name, firstNonEmpty(job.URL, job.Conclusion))
+	var failedSteps []string
+	for _, step := range job.Steps {
+		if step.Conclusion == "failure" {
+			failedSteps = append(failedSteps, step.Name)
+		}
+	}
+	if len(failedSteps) > 0 {
+		fmt.Fprintf(&b, "Failed steps: %s\n", strings.Join(failedSteps, ", "))
+	}
+	if log = strings.TrimSpace(stripActionsLogPrefix(log)); log != "" {
+		fmt.Fprintf(&b, "\nFailed step log (last %d lines):\n\n```\n%s\n```\n", ciMaxLogLines, tailLines(log, ciMaxLogLines))
+	}
+	b.WriteString("\nReproduce the failure locally, fix the cause and run the same check again.\n")
+	return b.String()
+}
+
+// stripActionsLogPrefix drops the "<job>\t<step>\t" columns gh puts in front
+// of every --log-failed line.
+func stripActionsLogPrefix(log string) string {
+	lines := strings.Split(log, "\n")
+	for i, line := range lines {
+		if parts := strings.SplitN(line, "\t", 3); len(parts) == 3 {
+			lines[i] = parts[2]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api">
+    <testcase classname="api.UserTest" name="testCreate" file="src/api/user_test.py" line="12">
+      <failure message="assert 500 == 201" type="AssertionError">Traceback (most recent call last):
+  File "src/api/user_test.py", line 14, in testCreate
+AssertionError: assert 500 == 201</failure>
+      <system-out>POST /users -> 500</system-out>
+    </testcase>
+    <testcase classname="api.UserTest" name="testList"/>
+    <testcase classname="api.UserTest" name="testSkip"><skipped/></testcase>
+    <testsuite name="api.nested">
+      <testcase classname="api.DBTest" name="testConnect"><error message="connection refused" type="OSError"/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func TestLoadJUnitIssues(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.xml", "b.xml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(testJUnit), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := loadJUnitIssues(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		t.Fatalf("loadJUnitIssues: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (duplicates across reports collapse): %+v", len(entries), entries)
+	}
+	if entries[0].Title != "Failing test api.UserTest.testCreate" || entries[1].Title != "Failing test api.DBTest.testConnect" {
+		t.Fatalf("titles = %q, %q", entries[0].Title, entries[1].Title)
+	}
+	for _, want := range []string{
+		"Suite: api\n",
+		"File: src/api/user_test.py:12",
+		"Result: failure (AssertionError)",
+		"Message: assert 500 == 201",
+		"AssertionError: assert 500 == 201\n```",
+		"Test stdout:\n\n```\nPOST /users -> 500",
+	} {
+		if !strings.Contains(entries[0].Body, want) {
+			t.Fatalf("body missing %q:\n%s", want, entries[0].Body)
+		}
+	}
+	if !strings.Contains(entries[1].Body, "Suite: api.nested") || !strings.Contains(entries[1].Body, "Result: error (OSError)") {
+		t.Fatalf("nested suite body:\n%s", entries[1].Body)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "green.xml"), []byte(`<testsuite name="ok"><testcase name="t"/></testsuite>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadJUnitIssues(filepath.Join(dir, "green.xml")); err == nil || !strings.Contains(err.Error(), "no failing tests") {
+		t.Fatalf("expected no-failures error, got %v", err)
+	}
+}
+
+func TestLoadActionsIssues(t *testing.T) {
+	t.Parallel()
+
+	gh := writeFakeBin(t, "gh", `case "$*" in
+  "run list --status failure --limit 1 --json databaseId,displayTitle,workflowName,headBranch,headSha,url --workflow nightly.yml")
+    echo '[{"databaseId":555,"displayTitle":"Nightly","workflowName":"nightly","headBranch":"main","headSha":"0123456789abcdef","url":"https://github.com/acme/web/actions/runs/555"}]' ;;
+  "run view 555 --json jobs")
+    echo '{"jobs":[{"databaseId":1,"name":"lint","conclusion":"success"},{"databaseId":2,"name":"test (linux)","conclusion":"failure","url":"https://github.com/acme/web/actions/runs/555/job/2","steps":[{"name":"Checkout","conclusion":"success"},{"name":"Run tests","conclusion":"failure"}]}]}' ;;
+  "run view --job 2 --log-failed")
+    printf 'test (linux)\tRun tests\t--- FAIL: TestParse\ntest (linux)\tRun tests\tFAIL\n' ;;
+  *) echo "unexpected: $*" >&2; exit 1 ;;
+esac`)
+	r := &runner{opts: options{GHBin: gh, Workflow: "nightly.yml"}}
+	entries, err := r.loadActionsIssues()
+	if err != nil {
+		t.Fatalf("loadActionsIssues: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	e := entries[0]
+	if e.ID != syntheticID(sourceActions, "555", "test (linux)") || e.Title != "CI job nightly / test (linux) failed" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	for _, want := range []string{
+		"Run: https://github.com/acme/web/actions/runs/555",
+		"Branch: main @ 0123456789ab",
+		"Failed steps: Run tests",
+		"```\n--- FAIL: TestParse\nFAIL\n```",
+	} {
+		if !strings.Contains(e.Body, want) {
+			t.Fatalf("body missing %q:\n%s", want, e.Body)
+		}
+	}
+}
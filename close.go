@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// closeIssue closes the GitHub issue once the runner considers it done, with
+// a comment naming the commit, instead of relying on a "Closes #N" trailer
+// reaching the default branch. Synthetic tasks have no issue to close.
+// Failures only warn.
+func (r *runner) closeIssue(issue string, entry issueEntry, branch string) {
+	if entry.synthetic() {
+		return
+	}
+	commit, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not close #%s: %v\n", issue, err)
+		return
+	}
+	comment := r.closingComment(commit, branch)
+	if _, err := r.ghWrite("issue", "close", issue, "--reason", "completed", "--comment", comment); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not close #%s: %v\n", issue, err)
+		return
+	}
+	r.record(journalEntry{Event: journalIssueClosed, Issue: issue, Commit: commit})
+	r.printf(r.colors.Blue, "Closed #%s\n", issue)
+}
+
+func (r *runner) closingComment(commit, branch string) string {
+	text := fmt.Sprintf(r.tr("Implemented by ghir in %s."), commit)
+	if r.opts.VerifyCmd != "" {
+		text = fmt.Sprintf(r.tr("Implemented by ghir in %s and verified with `%s`."), commit, r.opts.VerifyCmd)
+	}
+	if link := r.commentLink(branch, ""); link != "" {
+		text += "\n\n" + link
+	}
+	return text + "\n"
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCloseOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		verify    string
+		want      issueResult
+		wantClose bool
+	}{
+		{name: "verified", verify: "true", want: resultSuccess, wantClose: true},
+		{name: "verification failed", verify: "false", want: resultFailed},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			dir := t.TempDir()
+			calls := filepath.Join(dir, "gh.calls")
+			gh := writeFakeBin(t, "gh", `if [ "$1 $2" = "issue close" ]; then
+  printf '%s\n' "$*" >> `+calls+`
+  exit 0
+fi
+echo '{"title":"Add greeting","body":"say hi","state":"OPEN","labels":[]}'`)
+			agent := fakeAgent(t, "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"")
+			opts := options{
+				Agent:          "claude",
+				ClaudeBin:      agent,
+				GHBin:          gh,
+				LogDir:         filepath.Join(dir, "logs"),
+				VerifyCmd:      tt.verify,
+				CloseOnSuccess: true,
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+			data, _ := os.ReadFile(calls)
+			got := string(data)
+			if !tt.wantClose {
+				if got != "" {
+					t.Fatalf("issue closed after a failed verification: %s", got)
+				}
+				return
+			}
+			head := runGit(t, repo, "rev-parse", "HEAD")
+			if !strings.HasPrefix(got, "issue close 5 --reason completed --comment Implemented by ghir in "+head+" and verified with `true`.") {
+				t.Fatalf("gh call = %q", got)
+			}
+		})
+	}
+}
+
+func TestCloseOnSuccessOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"--close-on-success", "--create-pr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "--create-pr") {
+		t.Fatalf("validateOptions() error = %v, want --create-pr conflict", err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// coAuthorNone leaves the Co-Authored-By trailer off ghir's commits.
+const coAuthorNone = "none"
+
+var coAuthorPattern = regexp.MustCompile(`^[^<>\n]+ <[^<>\s]+@[^<>\s]+>$`)
+
+// agentEmails are the addresses each agent's vendor uses for commit
+// attribution.
+var agentEmails = map[string]string{
+	"claude":       "noreply@anthropic.com",
+	"codex":        "noreply@openai.com",
+	"gemini":       "noreply@google.com",
+	"cursor-agent": "cursoragent@cursor.com",
+	"aider":        "noreply@aider.chat",
+	"opencode":     "noreply@opencode.ai",
+	"copilot":      "198982749+Copilot@users.noreply.github.com",
+	"qwen":         "noreply@qwen.ai",
+}
+
+// apiProviderEmails credit the api agent's provider.
+var apiProviderEmails = map[string]string{
+	apiProviderAnthropic: agentEmails["claude"],
+	apiProviderOpenAI:    agentEmails["codex"],
+}
+
+func validCoAuthor(value string) error {
+	if value == "" || value == coAuthorNone || coAuthorPattern.MatchString(value) {
+		return nil
+	}
+	return fmt.Errorf(`must be "Name <email>" or %s (got %q)`, coAuthorNone, value)
+}
+
+// coAuthorTrailer is the trailer ghir adds to the commits it makes itself
+// (the fallback commit and partial work at a session limit). It credits the
+// agent that did the work, with the model when one was chosen, unless
+// --co-author names someone else or turns it off. Custom agents are only
+// credited with their co_author, and local models not at all.
+func (r *runner) coAuthorTrailer() string {
+	switch r.opts.CoAuthor {
+	case coAuthorNone:
+		return ""
+	case "":
+	default:
+		return "\n\nCo-Authored-By: " + r.opts.CoAuthor
+	}
+	if agent, ok := r.opts.CustomAgents[r.opts.Agent]; ok {
+		if agent.CoAuthor == "" || agent.CoAuthor == coAuthorNone {
+			return ""
+		}
+		return "\n\nCo-Authored-By: " + agent.CoAuthor
+	}
+	if r.opts.Agent == "local" {
+		return ""
+	}
+	name := agentDisplayName(r.opts.Agent)
+	if r.opts.Model != "" {
+		name += " (" + r.opts.Model + ")"
+	}
+	email, ok := agentEmails[r.opts.Agent]
+	if r.opts.Agent == "api" {
+		email, ok = apiProviderEmails[r.opts.APIProvider]
+	}
+	if !ok {
+		email = agentEmails["claude"]
+	}
+	return fmt.Sprintf("\n\nCo-Authored-By: %s <%s>", name, email)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCoAuthorTrailer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts options
+		want string
+	}{
+		{name: "claude", opts: options{Agent: "claude"}, want: "\n\nCo-Authored-By: Claude <noreply@anthropic.com>"},
+		{name: "codex with model", opts: options{Agent: "codex", Model: "gpt-5.3-codex"}, want: "\n\nCo-Authored-By: Codex (gpt-5.3-codex) <noreply@openai.com>"},
+		{name: "gemini", opts: options{Agent: "gemini"}, want: "\n\nCo-Authored-By: Gemini <noreply@google.com>"},
+		{name: "cursor agent", opts: options{Agent: "cursor-agent", Model: "auto"}, want: "\n\nCo-Authored-By: Cursor Agent (auto) <cursoragent@cursor.com>"},
+		{name: "custom", opts: options{Agent: "codex", CoAuthor: "Build Bot <bot@example.com>"}, want: "\n\nCo-Authored-By: Build Bot <bot@example.com>"},
+		{name: "suppressed", opts: options{Agent: "gemini", CoAuthor: coAuthorNone}, want: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{opts: tt.opts}
+			if got := r.coAuthorTrailer(); got != tt.want {
+				t.Fatalf("coAuthorTrailer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoAuthorOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--co-author", "none"}},
+		{args: []string{"--co-author", "Build Bot <bot@example.com>"}},
+		{args: []string{"--co-author", "bot@example.com"}, wantErr: `--co-author: must be "Name <email>" or none`},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("options %v returned unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("options %v error = %v, want substring %q", tt.args, err, tt.wantErr)
+		}
+	}
+
+	if err := (&repoConfig{CoAuthor: "nobody"}).validate(); err == nil || !strings.HasPrefix(err.Error(), "co_author: ") {
+		t.Fatalf("validate() = %v, want a co_author error", err)
+	}
+}
@@ -0,0 +1,694 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	commandRun    = "run"
+	commandStatus = "status"
+	commandReset  = "reset"
+	commandLogs   = "logs"
+	commandInit   = "init"
+)
+
+type cliCommand struct {
+	name    string
+	usage   string
+	summary string
+	flags   [][]string
+	minArgs int
+	maxArgs int
+	prepare func(opts *options) error
+	run     func(r *runner) int
+	// standalone commands run outside any repository.
+	standalone func(opts options) int
+}
+
+var (
+	globalFlags = []string{"--log-dir", "--done-file", "--config", "--profile", "--lang", "--gh-bin", "--gh-write-interval", "--app-id", "--app-key", "--app-installation", "--no-color", "--plain", "--timezone", "-v", "-vv", "-q", "--quiet", "--timestamps", "-h", "--help"}
+	queueFlags  = []string{"--issues", "--issues-file", "--sarif", "--junit", "--workflow", "--source", "--todo-tags", "--todo-path", "--sentry-project", "--sentry-limit", "--assigned-to-me", "--assignee", "--skip", "--priority-labels"}
+	agentFlags  = []string{"--agent", "--model", "--claude-bin", "--codex-bin", "--gemini-bin", "--cursor-bin", "--aider-bin", "--opencode-bin", "--copilot-bin", "--qwen-bin", "--api-provider", "--api-base-url", "--api-max-tokens", "--ollama-url", "--prompt-template", "--translate", "--translate-model", "--redact", "--seed", "--temperature", "--stream-view", "--wait-buffer-sec", "--on-limit", "--co-author"}
+	verifyFlags = []string{"--build-cmd", "--verify-cmd", "--lint-cmd", "--content-gate", "--debug-pattern", "--formatter", "--format-fix", "--protect-runner-files", "--artifact", "--verify-retries", "--baseline", "--snapshot-failures", "--bench-cmd", "--bench-threshold", "--bench-label", "--verify-scope", "--verify-full-at-end", "--e2e-cmd", "--bisect", "--revert-bad", "--cache", "--share-dir"}
+)
+
+var cliCommands = []cliCommand{
+	{
+		name:    commandRun,
+		usage:   "run [options]",
+		summary: "Process the issue queue (the default when no command is given)",
+		flags:   [][]string{queueFlags, agentFlags, verifyFlags, {"--dry-run", "--issue", "-f", "--manifest", "--notify", "--events", "--force", "--include-closed", "--tui", "--pick", "--parallel", "--sample", "--stratify", "--canary", "--create-pr", "--pr-template", "--pr-draft", "--push", "--push-remote", "--comment-on-issue", "--comment-template", "--diff-summary", "--diff-summary-cmd", "--diff-summary-model", "--assign-self", "--close-on-success", "--rollback-on-failure", "--autostash", "--wip-label", "--done-label"}},
+		run:     (*runner).runQueue,
+	},
+	{
+		name:    commandStatus,
+		usage:   "status [--output text|json] [options]",
+		summary: "Show completion status for the configured issues",
+		flags:   [][]string{queueFlags, {"--output"}},
+		prepare: func(opts *options) error {
+			opts.Status = true
+			return nil
+		},
+		run: (*runner).runQueue,
+	},
+	{
+		name:    commandReset,
+		usage:   "reset [id] [--failed-only] [--needs-review] [--since <date>] [--label <label>] [options]",
+		summary: "Reset all completions, one issue, or the issues matching filters",
+		flags:   [][]string{{"--failed-only", "--needs-review", "--since", "--label"}},
+		maxArgs: 1,
+		prepare: func(opts *options) error {
+			opts.Reset = true
+			if len(opts.Args) == 1 {
+				if !isIssueID(opts.Args[0]) {
+					return fmt.Errorf("reset issue must be numeric: %q", opts.Args[0])
+				}
+				opts.ResetIssue = opts.Args[0]
+			}
+			return nil
+		},
+		run: (*runner).runQueue,
+	},
+	{
+		name:    commandLogs,
+		usage:   "logs <id> [--verify] [--tail <n>] [options]",
+		summary: "Print the agent (or verification) log for an issue",
+		flags:   [][]string{{"--verify", "--tail"}},
+		minArgs: 1,
+		maxArgs: 1,
+		prepare: func(opts *options) error {
+			if !isIssueID(opts.Args[0]) {
+				return fmt.Errorf("logs issue must be numeric: %q", opts.Args[0])
+			}
+			return nil
+		},
+		run: func(r *runner) int {
+			return exitCode(r.showLogs(r.opts.Args[0]))
+		},
+	},
+	{
+		name:    commandRefine,
+		usage:   "refine <id> [--force] [options]",
+		summary: "Edit the prompt of a failed issue in $EDITOR and re-run it right away",
+		flags:   [][]string{queueFlags, agentFlags, verifyFlags, {"--force"}},
+		minArgs: 1,
+		maxArgs: 1,
+		prepare: func(opts *options) error {
+			if !isIssueID(opts.Args[0]) {
+				return fmt.Errorf("refine issue must be numeric: %q", opts.Args[0])
+			}
+			return nil
+		},
+		run: func(r *runner) int {
+			return exitCode(r.refineIssue(r.opts.Args[0]))
+		},
+	},
+	{
+		name:    commandExperiment,
+		usage:   "experiment --templates <a.tmpl,b.tmpl> --verify-cmd <cmd> [options]",
+		summary: "Run several prompt templates on the same issues in throwaway branches and compare pass rates and cost",
+		flags:   [][]string{queueFlags, agentFlags, verifyFlags, {"--templates"}},
+		run: func(r *runner) int {
+			return exitCode(r.runExperiment())
+		},
+	},
+	{
+		name:    commandRepro,
+		usage:   "repro <id> [attempt] [options]",
+		summary: "Re-run a recorded attempt (default: the latest) with the same agent, model, seed, prompt and start commit",
+		flags:   [][]string{verifyFlags, {"--repro"}},
+		minArgs: 1,
+		maxArgs: 2,
+		prepare: func(opts *options) error {
+			if !isIssueID(opts.Args[0]) {
+				return fmt.Errorf("repro issue must be numeric: %q", opts.Args[0])
+			}
+			if len(opts.Args) == 2 {
+				n, err := strconv.Atoi(opts.Args[1])
+				if err != nil || n < 1 {
+					return fmt.Errorf("repro attempt must be a positive number: %q", opts.Args[1])
+				}
+				opts.ReproAttempt = n
+			}
+			return nil
+		},
+		run: func(r *runner) int {
+			return exitCode(r.runRepro(r.opts.Args[0], r.opts.ReproAttempt))
+		},
+	},
+	{
+		name:    commandPlan,
+		usage:   "plan [--emit-manifest [--out <path>]] [options]",
+		summary: "List the pending issues with their agent, model and estimated cost, or propose them as a run manifest",
+		flags:   [][]string{queueFlags, {"--agent", "--model", "--verify-cmd", "--force", "--include-closed", "--emit-manifest", "--out"}},
+		run: func(r *runner) int {
+			return exitCode(r.runPlan())
+		},
+	},
+	{
+		name:    commandFreeze,
+		usage:   "freeze [--force] [options]",
+		summary: "Snapshot the resolved queue so later runs use it unchanged until thaw",
+		flags:   [][]string{queueFlags, {"--force"}},
+		run: func(r *runner) int {
+			return exitCode(r.freezeQueue())
+		},
+	},
+	{
+		name:    commandThaw,
+		usage:   "thaw [options]",
+		summary: "Drop the frozen queue and resolve the queue from its sources again",
+		run: func(r *runner) int {
+			return exitCode(r.thawQueue())
+		},
+	},
+	{
+		name:    commandConfig,
+		usage:   "config <validate [--config <path>] [-f <manifest>]|schema <config|manifest> [--out <path>]>",
+		summary: "Check the config file and run manifests against their JSON Schema, or print the schemas",
+		flags:   [][]string{{"-f", "--manifest", "--out"}},
+		minArgs: 1,
+		maxArgs: 2,
+		prepare: func(opts *options) error {
+			if !isConfigAction(opts.Args[0]) {
+				return fmt.Errorf("unknown config action %q (supported: %s, %s)", opts.Args[0], configActionValidate, configActionSchema)
+			}
+			opts.ConfigAction = opts.Args[0]
+			opts.Args = opts.Args[1:]
+			switch {
+			case opts.ConfigAction == configActionValidate && len(opts.Args) > 0:
+				return fmt.Errorf("config validate takes no arguments (use --config and -f)")
+			case opts.ConfigAction == configActionSchema && len(opts.Args) != 1:
+				return fmt.Errorf("config schema requires one of: %s, %s", schemaKindConfig, schemaKindManifest)
+			case opts.ConfigAction == configActionValidate && opts.flagSet("--out"):
+				return fmt.Errorf("--out is only supported by config schema")
+			}
+			return nil
+		},
+		standalone: runConfigCommand,
+	},
+	{
+		name:    commandInit,
+		usage:   "init [--force] [options]",
+		summary: "Scaffold .ticket-runner/ (issues list, prompt template, config) in this repo",
+		flags:   [][]string{{"--force"}},
+		run: func(r *runner) int {
+			return exitCode(r.initRepo())
+		},
+	},
+	{
+		name:    commandReverify,
+		usage:   "reverify --verify-cmd <cmd> [--reopen] [options]",
+		summary: "Re-run --verify-cmd for completed issues against HEAD and flag regressions",
+		flags:   [][]string{{"--verify-cmd", "--reopen"}},
+		run: func(r *runner) int {
+			regressions, err := r.reverify()
+			if code := exitCode(err); code != 0 {
+				return code
+			}
+			if regressions > 0 {
+				return 1
+			}
+			return 0
+		},
+	},
+	{
+		name:    commandBoard,
+		usage:   "board [--serve] [--addr <host:port>] [options]",
+		summary: "Render the queue as an HTML board (<log-dir>/board.html), or serve it live with --serve",
+		flags:   [][]string{queueFlags, {"--serve", "--addr"}},
+		run: func(r *runner) int {
+			return exitCode(r.runBoard())
+		},
+	},
+	{
+		name:    commandExportMetrics,
+		usage:   "export-metrics [--format csv|parquet] [--out <path>] [options]",
+		summary: "Dump per-issue metrics from <log-dir>/state.json as CSV or Parquet",
+		flags:   [][]string{{"--format", "--out"}},
+		run: func(r *runner) int {
+			return exitCode(r.exportMetrics())
+		},
+	},
+	{
+		name:    commandMergeReport,
+		usage:   "merge-report [--no-sync] [--output text|json] [options]",
+		summary: "Sync the state of ghir's pull requests and report cost per merged change and abandonment rate per agent and model",
+		flags:   [][]string{{"--no-sync", "--output"}},
+		run: func(r *runner) int {
+			return exitCode(r.runMergeReport())
+		},
+	},
+	{
+		name:    commandGC,
+		usage:   "gc [--keep <age>] [--archive <path>] [--dry-run] [options]",
+		summary: "Archive and remove run directories, journals, logs, diagnostics and worktrees older than the retention period",
+		flags:   [][]string{{"--keep", "--archive", "--dry-run"}},
+		run: func(r *runner) int {
+			return exitCode(r.runGC())
+		},
+	},
+	{
+		name:    commandReconcile,
+		usage:   "reconcile [--dry-run] [options]",
+		summary: "Bring the done file and state.json in line with the issues and pull requests on GitHub and report what changed",
+		flags:   [][]string{{"--dry-run"}},
+		run: func(r *runner) int {
+			return exitCode(r.reconcile())
+		},
+	},
+	{
+		name:    commandMigrate,
+		usage:   "migrate [<dir>] [--dry-run] [options]",
+		summary: "Import the .completed file and <issue>.log files of older runners and shell scripts into the done file and state.json",
+		flags:   [][]string{{"--dry-run"}},
+		maxArgs: 1,
+		run: func(r *runner) int {
+			dir := ""
+			if len(r.opts.Args) == 1 {
+				dir = r.opts.Args[0]
+			}
+			return exitCode(r.migrate(dir))
+		},
+	},
+	{
+		name:    commandOrg,
+		usage:   "org run --org <org> --label <label> [--workdir <dir>] [options]",
+		summary: "Run labelled issues across every repo of an organization, each with its own repo config",
+		flags:   [][]string{agentFlags, verifyFlags, {"--org", "--label", "--workdir", "--dry-run", "--force", "--include-closed"}},
+		minArgs: 1,
+		maxArgs: 1,
+		prepare: func(opts *options) error {
+			if opts.Args[0] != orgActionRun {
+				return fmt.Errorf("unknown org action %q (supported: %s)", opts.Args[0], orgActionRun)
+			}
+			if opts.Org == "" || opts.Label == "" {
+				return fmt.Errorf("org run requires --org and --label")
+			}
+			return nil
+		},
+		standalone: runOrg,
+	},
+	{
+		name:    commandAPIAgent,
+		usage:   "api-agent --model <model> [--api-provider <name>] [--api-base-url <url>] [--api-max-tokens <n>] < prompt",
+		summary: "Run the built-in API agent on the prompt from stdin in the current directory (what --agent api runs)",
+		flags:   [][]string{{"--model", "--api-provider", "--api-base-url", "--api-max-tokens", "--temperature"}},
+		prepare: func(opts *options) error {
+			if opts.Model == "" {
+				return fmt.Errorf("api-agent requires --model")
+			}
+			return nil
+		},
+		standalone: runAPIAgent,
+	},
+	{
+		name:    commandLocalAgent,
+		usage:   "local-agent --model <model> [--ollama-url <url>] < prompt",
+		summary: "Run the local Ollama agent on the prompt from stdin in the current directory (what --agent local runs)",
+		flags:   [][]string{{"--model", "--ollama-url", "--seed", "--temperature"}},
+		prepare: func(opts *options) error {
+			if opts.Model == "" {
+				return fmt.Errorf("local-agent requires --model")
+			}
+			return nil
+		},
+		standalone: runLocalAgent,
+	},
+	{
+		name:    commandProfile,
+		usage:   "profile <install <url|owner/repo>|update [name]|list|remove <name>> [--ref <ref>] [--name <name>]",
+		summary: "Install, update, list or remove shared profile packages in .ticket-runner/profiles/",
+		flags:   [][]string{{"--ref", "--name"}},
+		maxArgs: 2,
+		prepare: func(opts *options) error {
+			if len(opts.Args) == 0 || !isProfileAction(opts.Args[0]) {
+				return fmt.Errorf("profile requires an action: install, update, list or remove")
+			}
+			opts.ProfileAction = opts.Args[0]
+			opts.Args = opts.Args[1:]
+			return validateProfileArgs(*opts)
+		},
+		run: func(r *runner) int {
+			return exitCode(r.runProfileCommand())
+		},
+	},
+}
+
+func lookupCommand(name string) (cliCommand, bool) {
+	if name == "" {
+		name = commandRun
+	}
+	for _, cmd := range cliCommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return cliCommand{}, false
+}
+
+func (c cliCommand) allowsFlag(flag string) bool {
+	for _, group := range append([][]string{globalFlags}, c.flags...) {
+		for _, allowed := range group {
+			if allowed == flag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func finishCommandArgs(opts *options) error {
+	if opts.Command == "" {
+		return nil
+	}
+	cmd, _ := lookupCommand(opts.Command)
+	for flag := range opts.explicit {
+		if !cmd.allowsFlag(flag) {
+			return fmt.Errorf("%s is not supported by %s (see ticket-runner %s --help)", flag, cmd.name, cmd.name)
+		}
+	}
+	if opts.Help {
+		return nil
+	}
+	if len(opts.Args) < cmd.minArgs || len(opts.Args) > cmd.maxArgs {
+		return fmt.Errorf("usage: ticket-runner %s", cmd.usage)
+	}
+	if cmd.prepare != nil {
+		return cmd.prepare(opts)
+	}
+	return nil
+}
+
+func printCommandUsage(name string) {
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		printUsage()
+		return
+	}
+	fmt.Printf("Usage:\n  ticket-runner %s\n\n%s\n\nOptions:\n", cmd.usage, cmd.summary)
+	for _, line := range strings.Split(strings.TrimRight(optionsHelp, "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if cmd.allowsFlag(strings.TrimSuffix(fields[0], ",")) {
+			fmt.Println(line)
+		}
+	}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	return 1
+}
+
+func (r *runner) runQueue() int {
+	if r.opts.Reset {
+		return exitCode(r.handleReset())
+	}
+
+	issues, err := r.loadIssues()
+	if err != nil {
+		return exitCode(err)
+	}
+
+	if r.opts.Status {
+		if r.opts.Output == outputJSON {
+			return exitCode(r.printStatusJSON(issues))
+		}
+		r.printStatus(issues)
+		return 0
+	}
+
+	if r.opts.Pick {
+		if issues, err = r.pickIssues(issues); err != nil {
+			return exitCode(err)
+		}
+		if len(issues) == 0 {
+			r.printf(r.colors.Yellow, "No issues selected, nothing to do\n")
+			return 0
+		}
+	}
+
+	if r.opts.Sample > 0 {
+		if issues, err = r.sampleQueue(issues); err != nil {
+			return exitCode(err)
+		}
+		if len(issues) == 0 {
+			r.printf(r.colors.Yellow, "No pending issues to sample, nothing to do\n")
+			return 0
+		}
+	}
+
+	if r.opts.Canary != "" {
+		if issues, err = r.orderCanary(issues); err != nil {
+			return exitCode(err)
+		}
+	}
+	if err := r.checkIssueModels(issues); err != nil {
+		return exitCode(err)
+	}
+
+	if r.opts.TUI {
+		if err := r.startTUI(issues); err != nil {
+			return exitCode(err)
+		}
+		defer r.stopTUI()
+	}
+
+	r.printBanner(issues)
+
+	if !r.opts.DryRun {
+		stamp := r.fileStamp(r.now())
+		if err := r.startRunDir(stamp); err != nil {
+			return exitCode(err)
+		}
+		r.recordEnvironment()
+		r.archiveManifest()
+		if err := r.openJournal(stamp); err != nil {
+			return exitCode(err)
+		}
+		defer r.closeJournal()
+		r.record(journalEntry{Event: journalRunStarted, Title: r.manifestName(), Agent: r.opts.Agent, Model: r.opts.Model, Issues: intPtr(len(issues)), Environment: r.envFingerprint})
+	}
+
+	if r.opts.SnapshotFails && !r.opts.DryRun {
+		if err := r.takeFailureSnapshot(); err != nil {
+			return exitCode(err)
+		}
+	}
+
+	if r.opts.SingleIssue != "" {
+		r.opts.Force = true
+		r.tuiStatus(issues[0].ID, tuiStatusRunning)
+		result := r.processIssue(1, len(issues), issues[0])
+		r.stopTUI()
+		r.record(journalEntry{Event: journalRunFinished, Result: result.String()})
+		if result != resultSuccess && result != resultSkipped {
+			r.printf(r.colors.Red, "Failure: %s\n", r.failures[issues[0].ID])
+			return 1
+		}
+		return 0
+	}
+
+	succeeded, failed, skipped := 0, 0, 0
+	results := manifestResults{StartedAt: r.timestamp(r.now())}
+	batchStart, _ := r.gitOutput("rev-parse", "HEAD")
+	var batch []batchIssue
+	// canaryPending is set until the --canary issue has passed.
+	canaryPending := r.opts.Canary != "" && !r.opts.DryRun
+	canaryStopped := false
+	if r.opts.Parallel > 1 {
+		rest := issues
+		if canaryPending {
+			var passed bool
+			succeeded, failed, skipped, results.Issues, rest, passed = r.runParallelCanary(issues)
+			canaryStopped = !passed
+		}
+		if !canaryStopped && len(rest) > 0 {
+			s, f, k, outcomes := r.runParallel(rest)
+			succeeded, failed, skipped = succeeded+s, failed+f, skipped+k
+			results.Issues = append(results.Issues, outcomes...)
+		}
+	} else {
+		for i, entry := range issues {
+			idx := i + 1
+			r.tuiStatus(entry.ID, tuiStatusRunning)
+			before, _ := r.gitOutput("rev-parse", "HEAD")
+			isCanary := canaryPending && r.isPending(entry.ID)
+			result := r.processIssue(idx, len(issues), entry)
+			for result == resultRetry {
+				r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", entry.ID)
+				r.tuiStatus(entry.ID, tuiStatusRunning)
+				result = r.processIssue(idx, len(issues), entry)
+			}
+			results.Issues = append(results.Issues, manifestOutcome{Issue: entry.ID, Result: result.String()})
+			if isCanary {
+				passed, replaced := r.canaryVerdict(entry, result)
+				canaryPending = !passed
+				if !passed && !replaced && result == resultSkipped {
+					r.tuiStatus(entry.ID, tuiStatusSkipped)
+					skipped++
+					canaryStopped = true
+					break
+				}
+			}
+			if result == resultSuccess {
+				r.tuiStatus(entry.ID, tuiStatusDone)
+				succeeded++
+				if after, err := r.gitOutput("rev-parse", "HEAD"); err == nil && after != before {
+					batch = append(batch, batchIssue{ID: entry.ID, From: before, To: after})
+				}
+				continue
+			}
+			if result == resultSkipped {
+				r.tuiStatus(entry.ID, tuiStatusSkipped)
+				skipped++
+				continue
+			}
+			r.tuiStatus(entry.ID, tuiStatusFailed)
+			failed++
+			r.printf(r.colors.Red, "Stopping due to failure on issue #%s\n", entry.ID)
+			break
+		}
+	}
+
+	batchFailed := false
+	if !r.opts.DryRun && !r.runBatchPhase(batchStart, batch) {
+		batchFailed = true
+	}
+
+	r.stopTUI()
+	r.record(journalEntry{Event: journalRunFinished, Succeeded: intPtr(succeeded), Failed: intPtr(failed), Skipped: intPtr(skipped)})
+	if !r.opts.DryRun {
+		results.Succeeded, results.Failed, results.Skipped = succeeded, failed, skipped
+		r.writeManifestResults(results)
+		r.notify(notification{Event: journalRunFinished, Manifest: r.manifestName(), Succeeded: succeeded, Failed: failed, Skipped: skipped, RunDir: r.runDir, Changes: r.runChanges(results.Issues)})
+	}
+	fmt.Println()
+	r.rule(r.colors.Blue, "=")
+	r.printf(r.colors.Green, "Succeeded: %d\n", succeeded)
+	r.printf(r.colors.Red, "Failed: %d\n", failed)
+	r.printFailureSummary()
+	r.printFallbackSummary()
+	r.printAgentSummary()
+	if r.opts.DryRun {
+		r.printEstimate()
+	}
+	if skipped > 0 {
+		r.printf(r.colors.Yellow, "Skipped: %d\n", skipped)
+	}
+	if batchFailed {
+		r.printf(r.colors.Red, "Batch phase: FAILED\n")
+	}
+	if canaryStopped {
+		r.printf(r.colors.Red, "Canary: FAILED (the rest of the batch was not started)\n")
+	}
+	r.rule(r.colors.Blue, "=")
+
+	if failed > 0 || batchFailed || canaryStopped {
+		return 1
+	}
+	return 0
+}
+
+func (r *runner) showLogs(issue string) error {
+	path := filepath.Join(r.opts.LogDir, issue+".log")
+	if r.opts.LogsVerify {
+		path = filepath.Join(r.opts.LogDir, issue+".verify.log")
+	} else if st, ok := r.state.get(issue); ok && st.LogPath != "" {
+		path = st.LogPath
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no log for issue #%s (%s)", issue, path)
+		}
+		return err
+	}
+	output := string(data)
+	if r.opts.LogsTail > 0 {
+		output = tailLines(output, r.opts.LogsTail) + "\n"
+	}
+	fmt.Print(output)
+	return nil
+}
+
+const (
+	initIssuesFile = "# One issue id per line, in processing order.\n# 1721\n"
+	initConfigFile = "# yaml-language-server: $schema=" + schemaBaseURL + `config.schema.json
+# Shared ghir defaults. Command-line flags override these.
+# agent: claude
+# model: sonnet
+# verify_cmd: go test ./...
+# profiles:
+#   fast:
+#     agent: codex
+`
+)
+
+func (r *runner) initRepo() error {
+	files := []struct {
+		path    string
+		content string
+	}{
+		{path: defaultIssueFilePath, content: initIssuesFile},
+		{path: defaultPromptTemplate, content: defaultPromptBody},
+		{path: defaultConfigPath, content: initConfigFile},
+	}
+	for _, file := range files {
+		path := filepath.Join(r.repoRoot, file.path)
+		if _, err := os.Stat(path); err == nil && !r.opts.Force {
+			r.printf(r.colors.Yellow, "Exists, leaving as is: %s\n", file.path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(file.content), 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", file.path, err)
+		}
+		r.printf(r.colors.Green, "Created %s\n", file.path)
+	}
+
+	added, err := ensureGitignoreEntry(r.repoRoot, defaultLogDirName+"/")
+	if err != nil {
+		return err
+	}
+	if added {
+		r.printf(r.colors.Green, "Added %s/ to .gitignore\n", defaultLogDirName)
+	}
+	return nil
+}
+
+func ensureGitignoreEntry(repoRoot, entry string) (bool, error) {
+	path := filepath.Join(repoRoot, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read .gitignore: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == entry || line == strings.TrimSuffix(entry, "/") || line == "/"+entry {
+			return false, nil
+		}
+	}
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += entry + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return false, fmt.Errorf("write .gitignore: %w", err)
+	}
+	return true, nil
+}
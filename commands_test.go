@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsSubcommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+		check   func(t *testing.T, opts options)
+	}{
+		{
+			name: "flat flags still work",
+			args: []string{"--status", "--issues", "1,2"},
+			check: func(t *testing.T, opts options) {
+				if opts.Command != "" || !opts.Status || opts.IssuesCSV != "1,2" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "run accepts run flags",
+			args: []string{"run", "--dry-run", "--agent", "codex"},
+			check: func(t *testing.T, opts options) {
+				if opts.Command != commandRun || !opts.DryRun || opts.Agent != "codex" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "status",
+			args: []string{"status", "--issues-file", "-"},
+			check: func(t *testing.T, opts options) {
+				if !opts.Status || opts.IssuesFile != "-" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "reset one issue",
+			args: []string{"reset", "12"},
+			check: func(t *testing.T, opts options) {
+				if !opts.Reset || opts.ResetIssue != "12" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "reset everything",
+			args: []string{"reset"},
+			check: func(t *testing.T, opts options) {
+				if !opts.Reset || opts.ResetIssue != "" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "logs with tail",
+			args: []string{"logs", "7", "--tail", "5", "--verify"},
+			check: func(t *testing.T, opts options) {
+				if opts.LogsTail != 5 || !opts.LogsVerify || len(opts.Args) != 1 || opts.Args[0] != "7" {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "help for a command skips argument checks",
+			args: []string{"logs", "--help"},
+			check: func(t *testing.T, opts options) {
+				if !opts.Help || opts.Command != commandLogs {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{
+			name: "status as json",
+			args: []string{"status", "--output", "json"},
+			check: func(t *testing.T, opts options) {
+				if !opts.Status || opts.Output != outputJSON {
+					t.Fatalf("unexpected options: %+v", opts)
+				}
+			},
+		},
+		{name: "unknown output format", args: []string{"status", "--output", "xml"}, wantErr: "--output must be one of"},
+		{name: "output is a status flag", args: []string{"run", "--output", "json"}, wantErr: "--output is not supported by run"},
+		{name: "unknown command", args: []string{"frobnicate"}, wantErr: "unknown command"},
+		{name: "flag from another mode", args: []string{"status", "--agent", "codex"}, wantErr: "--agent is not supported by status"},
+		{name: "logs needs an issue", args: []string{"logs"}, wantErr: "usage: ticket-runner logs"},
+		{name: "logs issue must be numeric", args: []string{"logs", "abc"}, wantErr: "must be numeric"},
+		{name: "reset takes one issue", args: []string{"reset", "1", "2"}, wantErr: "usage: ticket-runner reset"},
+		{name: "tail must be positive", args: []string{"logs", "1", "--tail", "0"}, wantErr: "--tail"},
+		{name: "refine needs an issue", args: []string{"refine"}, wantErr: "usage: ticket-runner refine"},
+		{name: "refine issue must be numeric", args: []string{"refine", "abc"}, wantErr: "must be numeric"},
+		{name: "templates is an experiment flag", args: []string{"run", "--templates", "a,b"}, wantErr: "--templates is not supported by run"},
+		{name: "init rejects positional args", args: []string{"init", "extra"}, wantErr: "usage: ticket-runner init"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := parseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			tt.check(t, opts)
+		})
+	}
+}
+
+func TestInitRepoScaffoldsFiles(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("bin/"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(repo, defaultIssueFilePath)
+	if err := os.MkdirAll(filepath.Dir(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &runner{opts: options{NoColor: true}, repoRoot: repo}
+	if err := r.initRepo(); err != nil {
+		t.Fatalf("initRepo: %v", err)
+	}
+	if err := r.initRepo(); err != nil {
+		t.Fatalf("second initRepo: %v", err)
+	}
+
+	if data, _ := os.ReadFile(existing); string(data) != "42\n" {
+		t.Fatalf("existing issues file overwritten: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, defaultPromptTemplate)); string(data) != defaultPromptBody {
+		t.Fatal("prompt template not scaffolded")
+	}
+	data, err := os.ReadFile(filepath.Join(repo, defaultConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg repoConfig
+	if err := decodeRepoConfig(data, &cfg); err != nil {
+		t.Fatalf("scaffolded config does not parse: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(repo, ".gitignore")); string(data) != "bin/\n.ticket-runs/\n" {
+		t.Fatalf(".gitignore = %q", data)
+	}
+
+	r.opts.Force = true
+	if err := r.initRepo(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != initIssuesFile {
+		t.Fatalf("--force did not overwrite issues file: %q", data)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultCommentTemplate = ".ticket-runner/comment.tmpl"
+	commentMaxStatLines    = 20
+)
+
+// defaultCommentBody is translated as a whole, placeholders included.
+const defaultCommentBody = "Implemented by ghir in {{COMMIT}}.\n\n{{SUMMARY}}\n\n{{LINK}}\n"
+
+// commentLink points reviewers at the PR, or at the branch the commits are
+// on when no PR was opened.
+func (r *runner) commentLink(branch, prURL string) string {
+	switch {
+	case prURL != "":
+		return fmt.Sprintf(r.tr("Pull request: %s"), prURL)
+	case branch != "":
+		return fmt.Sprintf(r.tr("Branch: `%s`"), branch)
+	}
+	return ""
+}
+
+// capDiffStat keeps the first lines of `git diff --stat` and its summary.
+func capDiffStat(stat string, max int) string {
+	lines := strings.Split(strings.TrimRight(stat, "\n"), "\n")
+	if len(lines) <= max {
+		return strings.Join(lines, "\n")
+	}
+	kept := append([]string(nil), lines[:max-1]...)
+	kept = append(kept, fmt.Sprintf(" ... %d more file(s)", len(lines)-max), lines[len(lines)-1])
+	return strings.Join(kept, "\n")
+}
+
+func (r *runner) renderComment(issue string, details issueDetails, commit, summary, stat, branch, prURL string) (string, error) {
+	body := r.tr(defaultCommentBody)
+	if r.opts.CommentTemplate != "" {
+		data, err := os.ReadFile(r.opts.CommentTemplate)
+		if err != nil {
+			return "", fmt.Errorf("read comment template: %w", err)
+		}
+		body = string(data)
+	}
+	replacer := strings.NewReplacer(
+		"{{ISSUE_NUMBER}}", issue,
+		"{{ISSUE_TITLE}}", details.Title,
+		"{{COMMIT}}", commit,
+		"{{SUMMARY}}", summary,
+		"{{DIFF_STAT}}", stat,
+		"{{BRANCH}}", branch,
+		"{{PR_URL}}", prURL,
+		"{{LINK}}", r.commentLink(branch, prURL),
+	)
+	return strings.TrimSpace(replacer.Replace(body)) + "\n", nil
+}
+
+// commentOnIssue posts a summary of the change on the issue (or on the
+// tracking issue of a synthetic task). Failures only warn.
+func (r *runner) commentOnIssue(issue string, entry issueEntry, details issueDetails, startHead, branch, prURL, tracking string, changes *diffSummary) {
+	target := issue
+	if entry.synthetic() {
+		if tracking == "" {
+			return
+		}
+		target = tracking
+	}
+	commit, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not comment on #%s: %v\n", target, err)
+		return
+	}
+	stat, err := r.gitOutput("diff", "--stat", startHead+"..HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not comment on #%s: %v\n", target, err)
+		return
+	}
+	stat = capDiffStat(stat, commentMaxStatLines)
+	summary := changes.markdown(r.tr)
+	if summary == "" {
+		summary = "```\n" + stat + "\n```"
+	}
+	body, err := r.renderComment(issue, details, commit, summary, stat, branch, prURL)
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+		return
+	}
+	if err := r.postStatus(target, body); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not comment on #%s: %v\n", target, err)
+		return
+	}
+	r.printf(r.colors.Blue, "Commented on #%s\n", target)
+}
+
+// postProgress updates the status comment of an issue with an event of a
+// multi-attempt run (a pause or a failed attempt). Failures only warn.
+func (r *runner) postProgress(issue string, entry issueEntry, status string) {
+	if !r.opts.CommentOnIssue || entry.synthetic() {
+		return
+	}
+	if r.state != nil {
+		if st, ok := r.state.get(issue); ok && st.Attempts > 1 {
+			status = fmt.Sprintf("%s (attempt %d)", status, st.Attempts)
+		}
+	}
+	if err := r.postStatus(issue, status); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not update the status comment on #%s: %v\n", issue, err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapDiffStat(t *testing.T) {
+	t.Parallel()
+
+	stat := " a.go | 2 +-\n b.go | 1 +\n c.go | 4 ++--\n 3 files changed, 4 insertions(+), 3 deletions(-)\n"
+	if got := capDiffStat(stat, 5); got != strings.TrimRight(stat, "\n") {
+		t.Fatalf("capDiffStat() = %q", got)
+	}
+	want := " a.go | 2 +-\n ... 2 more file(s)\n 3 files changed, 4 insertions(+), 3 deletions(-)"
+	if got := capDiffStat(stat, 2); got != want {
+		t.Fatalf("capDiffStat() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderComment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		branch   string
+		prURL    string
+		want     string
+	}{
+		{name: "pr link", prURL: "https://github.com/o/r/pull/3", want: "Implemented by ghir in abc123.\n\n1 file(s) changed, +1 -0:\n- `a.go` (+1 -0)\n\nPull request: https://github.com/o/r/pull/3\n"},
+		{name: "branch link", branch: "ghir/issue-5", want: "Implemented by ghir in abc123.\n\n1 file(s) changed, +1 -0:\n- `a.go` (+1 -0)\n\nBranch: `ghir/issue-5`\n"},
+		{name: "custom", template: "Done: {{ISSUE_TITLE}} ({{COMMIT}}) {{PR_URL}}\n", want: "Done: Add greeting (abc123)\n"},
+		{name: "diff stat", template: "```\n{{DIFF_STAT}}\n```\n", want: "```\n a.go | 1 +\n```\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runner{}
+			if tt.template != "" {
+				r.opts.CommentTemplate = filepath.Join(t.TempDir(), "comment.tmpl")
+				if err := os.WriteFile(r.opts.CommentTemplate, []byte(tt.template), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			summary := parseNumstat("1\t0\ta.go\n").markdown((&runner{}).tr)
+			got, err := r.renderComment("5", issueDetails{Title: "Add greeting"}, "abc123", summary, " a.go | 1 +", tt.branch, tt.prURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("renderComment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentOnIssueAfterSuccess(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	calls := filepath.Join(t.TempDir(), "gh.calls")
+	gh := writeFakeBin(t, "gh", `if [ "$1" = issue ] && [ "$2" = comment ]; then
+  printf '%s\n' "$@" > `+calls+`
+  exit 0
+fi
+echo '{"title":"Add greeting","body":"say hi","state":"OPEN","labels":[]}'`)
+	agent := fakeAgent(t, `echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+
+	opts := options{
+		Agent:          "claude",
+		ClaudeBin:      agent,
+		GHBin:          gh,
+		CommentOnIssue: true,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5", Branch: "agent/5"}); result != resultSuccess {
+		t.Fatalf("processIssue() = %v", result)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("gh issue comment was not called: %v", err)
+	}
+	head := runGit(t, repo, "rev-parse", "agent/5")
+	for _, want := range []string{"comment\n5\n--body\n", "Implemented by ghir in " + head, "- `greeting.txt` (+1 -0)", "1 file(s) changed, +1 -0", "Branch: `agent/5`"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("gh args missing %q:\n%s", want, data)
+		}
+	}
+	if st, _ := r.state.get("5"); st.Changes == nil || st.Changes.headline() != "1 file(s) changed, +1 -0" {
+		t.Fatalf("state changes = %+v", st.Changes)
+	}
+}
@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigPath = ".ticket-runner/config.yaml"
+
+type repoConfig struct {
+	Agent             string            `yaml:"agent"`
+	Model             string            `yaml:"model"`
+	ClaudeBin         string            `yaml:"claude_bin"`
+	CodexBin          string            `yaml:"codex_bin"`
+	GeminiBin         string            `yaml:"gemini_bin"`
+	CursorBin         string            `yaml:"cursor_bin"`
+	AiderBin          string            `yaml:"aider_bin"`
+	OpenCodeBin       string            `yaml:"opencode_bin"`
+	CopilotBin        string            `yaml:"copilot_bin"`
+	QwenBin           string            `yaml:"qwen_bin"`
+	APIProvider       string            `yaml:"api_provider"`
+	APIBaseURL        string            `yaml:"api_base_url"`
+	APIMaxTokens      *int              `yaml:"api_max_tokens"`
+	OllamaURL         string            `yaml:"ollama_url"`
+	Translate         *bool             `yaml:"translate"`
+	TranslateModel    string            `yaml:"translate_model"`
+	GHBin             string            `yaml:"gh_bin"`
+	LogDir            string            `yaml:"log_dir"`
+	DoneFile          string            `yaml:"done_file"`
+	IssuesFile        string            `yaml:"issues_file"`
+	SkipFile          string            `yaml:"skip_file"`
+	PromptTemplate    string            `yaml:"prompt_template"`
+	StreamView        string            `yaml:"stream_view"`
+	CoAuthor          string            `yaml:"co_author"`
+	WaitBufferSec     *int              `yaml:"wait_buffer_sec"`
+	OnLimit           string            `yaml:"on_limit"`
+	VerifyRetries     *int              `yaml:"verify_retries"`
+	GHWriteInterval   *int              `yaml:"gh_write_interval"`
+	Parallel          *int              `yaml:"parallel"`
+	VerifyCmd         string            `yaml:"verify_cmd"`
+	LintCmd           string            `yaml:"lint_cmd"`
+	ContentGate       string            `yaml:"content_gate"`
+	DebugPatterns     []string          `yaml:"debug_patterns"`
+	Artifacts         []string          `yaml:"artifacts"`
+	Formatters        []string          `yaml:"formatters"`
+	FormatFix         string            `yaml:"format_fix"`
+	ProtectFiles      string            `yaml:"protect_runner_files"`
+	BuildCmd          string            `yaml:"build_cmd"`
+	Baseline          string            `yaml:"baseline"`
+	IncludeClosed     *bool             `yaml:"include_closed"`
+	PriorityLabels    *bool             `yaml:"priority_labels"`
+	NoColor           *bool             `yaml:"no_color"`
+	Plain             *bool             `yaml:"plain"`
+	Timestamps        *bool             `yaml:"timestamps"`
+	Timezone          string            `yaml:"timezone"`
+	AppID             string            `yaml:"app_id"`
+	AppKeyFile        string            `yaml:"app_key_file"`
+	AppInstallation   string            `yaml:"app_installation"`
+	Lang              string            `yaml:"lang"`
+	TodoTags          string            `yaml:"todo_tags"`
+	TodoPaths         string            `yaml:"todo_paths"`
+	SentryProject     string            `yaml:"sentry_project"`
+	BenchCmd          string            `yaml:"bench_cmd"`
+	BenchThreshold    *float64          `yaml:"bench_threshold"`
+	BenchLabel        string            `yaml:"bench_label"`
+	Redact            []string          `yaml:"redact"`
+	EnvTools          []string          `yaml:"env_tools"`
+	CreatePR          *bool             `yaml:"create_pr"`
+	PRDraft           *bool             `yaml:"pr_draft"`
+	Push              *bool             `yaml:"push"`
+	PushRemote        string            `yaml:"push_remote"`
+	CommentOnIssue    *bool             `yaml:"comment_on_issue"`
+	CommentTemplate   string            `yaml:"comment_template"`
+	DiffSummary       string            `yaml:"diff_summary"`
+	DiffSummaryCmd    string            `yaml:"diff_summary_cmd"`
+	SummaryModel      string            `yaml:"diff_summary_model"`
+	WIPLabel          string            `yaml:"wip_label"`
+	AssignSelf        *bool             `yaml:"assign_self"`
+	CloseOnSuccess    *bool             `yaml:"close_on_success"`
+	DoneLabel         string            `yaml:"done_label"`
+	VerifyScope       string            `yaml:"verify_scope"`
+	VerifyFullAtEnd   *bool             `yaml:"verify_full_at_end"`
+	E2ECmd            string            `yaml:"e2e_cmd"`
+	Bisect            *bool             `yaml:"bisect"`
+	RevertBad         *bool             `yaml:"revert_bad"`
+	RollbackOnFailure *bool             `yaml:"rollback_on_failure"`
+	AutoStash         *bool             `yaml:"autostash"`
+	PRTemplate        string            `yaml:"pr_template"`
+	Caches            map[string]string `yaml:"caches"`
+	ShareDirs         []string          `yaml:"share_dirs"`
+	Notify            []string          `yaml:"notify"`
+	Events            []string          `yaml:"events"`
+
+	Agents   map[string]customAgent `yaml:"agents"`
+	Profiles map[string]repoConfig  `yaml:"profiles"`
+}
+
+func loadRepoConfig(path string) (repoConfig, bool, error) {
+	var cfg repoConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, false, nil
+		}
+		return cfg, false, fmt.Errorf("read config: %w", err)
+	}
+	if err := decodeRepoConfig(data, &cfg); err != nil {
+		return cfg, false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, false, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, true, nil
+}
+
+func decodeRepoConfig(data []byte, cfg *repoConfig) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func (c *repoConfig) validate() error {
+	if err := validateCustomAgents(c.Agents); err != nil {
+		return err
+	}
+	registerCustomAgents(c.Agents)
+	c.Agent = strings.ToLower(strings.TrimSpace(c.Agent))
+	if c.Agent != "" {
+		if _, _, err := parseAgentChain(c.Agent); err != nil {
+			return fmt.Errorf("agent %w", err)
+		}
+	}
+	if c.APIProvider != "" && c.APIProvider != apiProviderAnthropic && c.APIProvider != apiProviderOpenAI {
+		return fmt.Errorf("api_provider must be one of: %s (got %q)", strings.Join(apiProviders, ", "), c.APIProvider)
+	}
+	if c.OnLimit != "" && c.OnLimit != onLimitWait && c.OnLimit != onLimitSwitch {
+		return fmt.Errorf("on_limit must be one of: %s, %s (got %q)", onLimitWait, onLimitSwitch, c.OnLimit)
+	}
+	if c.VerifyScope != "" && c.VerifyScope != verifyScopeFull && c.VerifyScope != verifyScopeChanged {
+		return fmt.Errorf("verify_scope must be one of: %s, %s (got %q)", verifyScopeFull, verifyScopeChanged, c.VerifyScope)
+	}
+	if err := validCoAuthor(c.CoAuthor); err != nil {
+		return fmt.Errorf("co_author: %w", err)
+	}
+	if c.ContentGate != "" && c.ContentGate != contentGateOff && c.ContentGate != contentGateWarn && c.ContentGate != contentGateFail {
+		return fmt.Errorf("content_gate must be one of: %s, %s, %s (got %q)", contentGateOff, contentGateWarn, contentGateFail, c.ContentGate)
+	}
+	if _, err := compileDebugPatterns(c.DebugPatterns); err != nil {
+		return fmt.Errorf("debug_patterns: %w", err)
+	}
+	for _, spec := range c.Formatters {
+		if _, err := parseFormatter(spec); err != nil {
+			return fmt.Errorf("formatters: %w", err)
+		}
+	}
+	if c.DiffSummary != "" && c.DiffSummary != diffSummaryStats && c.DiffSummary != diffSummaryModel && c.DiffSummary != diffSummaryCommand {
+		return fmt.Errorf("diff_summary must be one of: %s, %s, %s (got %q)", diffSummaryStats, diffSummaryModel, diffSummaryCommand, c.DiffSummary)
+	}
+	if c.FormatFix != "" && c.FormatFix != formatFixCommit && c.FormatFix != formatFixAmend {
+		return fmt.Errorf("format_fix must be one of: %s, %s (got %q)", formatFixCommit, formatFixAmend, c.FormatFix)
+	}
+	if c.ProtectFiles != "" && c.ProtectFiles != protectRevert && c.ProtectFiles != protectFail && c.ProtectFiles != protectOff {
+		return fmt.Errorf("protect_runner_files must be one of: %s, %s, %s (got %q)", protectRevert, protectFail, protectOff, c.ProtectFiles)
+	}
+	for _, pattern := range c.Artifacts {
+		if err := validArtifactPattern(pattern); err != nil {
+			return fmt.Errorf("artifacts: %w", err)
+		}
+	}
+	if c.StreamView != "" && c.StreamView != streamViewPretty && c.StreamView != streamViewRaw {
+		return fmt.Errorf("stream_view must be one of: %s, %s (got %q)", streamViewPretty, streamViewRaw, c.StreamView)
+	}
+	c.Lang = normalizeLanguage(c.Lang)
+	if c.Lang != "" && !isSupportedLanguage(c.Lang) {
+		return fmt.Errorf("lang must be one of: %s (got %q)", strings.Join(supportedLanguages(), ", "), c.Lang)
+	}
+	if _, err := loadTimezone(c.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+	if c.Parallel != nil && *c.Parallel < 1 {
+		return fmt.Errorf("parallel must be >= 1")
+	}
+	if c.VerifyRetries != nil && *c.VerifyRetries < 0 {
+		return fmt.Errorf("verify_retries must be >= 0")
+	}
+	if c.APIMaxTokens != nil && *c.APIMaxTokens <= 0 {
+		return fmt.Errorf("api_max_tokens must be >= 1")
+	}
+	if c.WaitBufferSec != nil && *c.WaitBufferSec < 0 {
+		return fmt.Errorf("wait_buffer_sec must be >= 0")
+	}
+	if c.GHWriteInterval != nil && *c.GHWriteInterval < 0 {
+		return fmt.Errorf("gh_write_interval must be >= 0")
+	}
+	if c.BenchThreshold != nil && *c.BenchThreshold < 0 {
+		return fmt.Errorf("bench_threshold must be >= 0")
+	}
+	if _, err := compileRedactPatterns(c.Redact); err != nil {
+		return err
+	}
+	for _, target := range c.Events {
+		if err := validateEventSink(target); err != nil {
+			return fmt.Errorf("events: %w", err)
+		}
+	}
+	for _, target := range c.Notify {
+		if err := validateNotifyTarget(target); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
+	}
+	for name, dir := range c.Caches {
+		if _, _, err := parseCacheSpec(name + "=" + dir); err != nil {
+			return fmt.Errorf("caches: %w", err)
+		}
+	}
+	for name, profile := range c.Profiles {
+		if len(profile.Profiles) > 0 {
+			return fmt.Errorf("profile %q: profiles cannot be nested", name)
+		}
+		if err := profile.validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		c.Profiles[name] = profile
+	}
+	return nil
+}
+
+func (c repoConfig) withProfile(name string) (repoConfig, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		var names []string
+		for known := range c.Profiles {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return c, fmt.Errorf("unknown profile %q (no profiles defined)", name)
+		}
+		return c, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return c.overlay(profile), nil
+}
+
+// overlay returns c with every setting the profile (or run manifest) sets
+// replacing its own.
+func (c repoConfig) overlay(profile repoConfig) repoConfig {
+	merged := c
+	merged.Profiles = nil
+	overrideString := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	if profile.Agent != "" && profile.Agent != c.Agent && profile.Model == "" {
+		merged.Model = ""
+	}
+	overrideString(&merged.Agent, profile.Agent)
+	overrideString(&merged.Model, profile.Model)
+	overrideString(&merged.ClaudeBin, profile.ClaudeBin)
+	overrideString(&merged.CodexBin, profile.CodexBin)
+	overrideString(&merged.GeminiBin, profile.GeminiBin)
+	overrideString(&merged.CursorBin, profile.CursorBin)
+	overrideString(&merged.AiderBin, profile.AiderBin)
+	overrideString(&merged.OpenCodeBin, profile.OpenCodeBin)
+	overrideString(&merged.CopilotBin, profile.CopilotBin)
+	overrideString(&merged.QwenBin, profile.QwenBin)
+	overrideString(&merged.APIProvider, profile.APIProvider)
+	overrideString(&merged.APIBaseURL, profile.APIBaseURL)
+	overrideString(&merged.OllamaURL, profile.OllamaURL)
+	overrideString(&merged.TranslateModel, profile.TranslateModel)
+	overrideString(&merged.GHBin, profile.GHBin)
+	overrideString(&merged.LogDir, profile.LogDir)
+	overrideString(&merged.DoneFile, profile.DoneFile)
+	overrideString(&merged.IssuesFile, profile.IssuesFile)
+	overrideString(&merged.SkipFile, profile.SkipFile)
+	overrideString(&merged.PromptTemplate, profile.PromptTemplate)
+	overrideString(&merged.StreamView, profile.StreamView)
+	overrideString(&merged.VerifyCmd, profile.VerifyCmd)
+	overrideString(&merged.LintCmd, profile.LintCmd)
+	overrideString(&merged.ContentGate, profile.ContentGate)
+	overrideString(&merged.FormatFix, profile.FormatFix)
+	overrideString(&merged.ProtectFiles, profile.ProtectFiles)
+	overrideString(&merged.BuildCmd, profile.BuildCmd)
+	overrideString(&merged.Baseline, profile.Baseline)
+	overrideString(&merged.Lang, profile.Lang)
+	overrideString(&merged.Timezone, profile.Timezone)
+	overrideString(&merged.AppID, profile.AppID)
+	overrideString(&merged.AppKeyFile, profile.AppKeyFile)
+	overrideString(&merged.AppInstallation, profile.AppInstallation)
+	overrideString(&merged.TodoTags, profile.TodoTags)
+	overrideString(&merged.TodoPaths, profile.TodoPaths)
+	overrideString(&merged.SentryProject, profile.SentryProject)
+	overrideString(&merged.BenchCmd, profile.BenchCmd)
+	overrideString(&merged.BenchLabel, profile.BenchLabel)
+	overrideString(&merged.PRTemplate, profile.PRTemplate)
+	overrideString(&merged.CoAuthor, profile.CoAuthor)
+	overrideString(&merged.VerifyScope, profile.VerifyScope)
+	overrideString(&merged.OnLimit, profile.OnLimit)
+	overrideString(&merged.E2ECmd, profile.E2ECmd)
+	overrideString(&merged.PushRemote, profile.PushRemote)
+	overrideString(&merged.CommentTemplate, profile.CommentTemplate)
+	overrideString(&merged.DiffSummary, profile.DiffSummary)
+	overrideString(&merged.DiffSummaryCmd, profile.DiffSummaryCmd)
+	overrideString(&merged.SummaryModel, profile.SummaryModel)
+	overrideString(&merged.WIPLabel, profile.WIPLabel)
+	overrideString(&merged.DoneLabel, profile.DoneLabel)
+	if profile.BenchThreshold != nil {
+		merged.BenchThreshold = profile.BenchThreshold
+	}
+	if len(profile.EnvTools) > 0 {
+		merged.EnvTools = profile.EnvTools
+	}
+	if len(profile.Caches) > 0 {
+		merged.Caches = make(map[string]string, len(c.Caches)+len(profile.Caches))
+		for name, dir := range c.Caches {
+			merged.Caches[name] = dir
+		}
+		for name, dir := range profile.Caches {
+			merged.Caches[name] = dir
+		}
+	}
+	if len(profile.Agents) > 0 {
+		merged.Agents = make(map[string]customAgent, len(c.Agents)+len(profile.Agents))
+		for name, agent := range c.Agents {
+			merged.Agents[name] = agent
+		}
+		for name, agent := range profile.Agents {
+			merged.Agents[name] = agent
+		}
+	}
+	if len(profile.DebugPatterns) > 0 {
+		merged.DebugPatterns = profile.DebugPatterns
+	}
+	if len(profile.Artifacts) > 0 {
+		merged.Artifacts = profile.Artifacts
+	}
+	if len(profile.Formatters) > 0 {
+		merged.Formatters = profile.Formatters
+	}
+	if len(profile.ShareDirs) > 0 {
+		merged.ShareDirs = profile.ShareDirs
+	}
+	// Redaction only ever adds patterns.
+	merged.Redact = append(append([]string(nil), c.Redact...), profile.Redact...)
+	if profile.VerifyRetries != nil {
+		merged.VerifyRetries = profile.VerifyRetries
+	}
+	if profile.APIMaxTokens != nil {
+		merged.APIMaxTokens = profile.APIMaxTokens
+	}
+	if profile.WaitBufferSec != nil {
+		merged.WaitBufferSec = profile.WaitBufferSec
+	}
+	if profile.GHWriteInterval != nil {
+		merged.GHWriteInterval = profile.GHWriteInterval
+	}
+	if profile.Parallel != nil {
+		merged.Parallel = profile.Parallel
+	}
+	if profile.Translate != nil {
+		merged.Translate = profile.Translate
+	}
+	if profile.IncludeClosed != nil {
+		merged.IncludeClosed = profile.IncludeClosed
+	}
+	if profile.PriorityLabels != nil {
+		merged.PriorityLabels = profile.PriorityLabels
+	}
+	if profile.NoColor != nil {
+		merged.NoColor = profile.NoColor
+	}
+	if profile.Plain != nil {
+		merged.Plain = profile.Plain
+	}
+	if profile.Timestamps != nil {
+		merged.Timestamps = profile.Timestamps
+	}
+	if profile.CreatePR != nil {
+		merged.CreatePR = profile.CreatePR
+	}
+	if profile.PRDraft != nil {
+		merged.PRDraft = profile.PRDraft
+	}
+	if profile.Push != nil {
+		merged.Push = profile.Push
+	}
+	if profile.AssignSelf != nil {
+		merged.AssignSelf = profile.AssignSelf
+	}
+	if profile.CloseOnSuccess != nil {
+		merged.CloseOnSuccess = profile.CloseOnSuccess
+	}
+	if profile.CommentOnIssue != nil {
+		merged.CommentOnIssue = profile.CommentOnIssue
+	}
+	if profile.VerifyFullAtEnd != nil {
+		merged.VerifyFullAtEnd = profile.VerifyFullAtEnd
+	}
+	if profile.Bisect != nil {
+		merged.Bisect = profile.Bisect
+	}
+	if profile.RevertBad != nil {
+		merged.RevertBad = profile.RevertBad
+	}
+	if profile.RollbackOnFailure != nil {
+		merged.RollbackOnFailure = profile.RollbackOnFailure
+	}
+	if profile.AutoStash != nil {
+		merged.AutoStash = profile.AutoStash
+	}
+	if len(profile.Events) > 0 {
+		merged.Events = profile.Events
+	}
+	if len(profile.Notify) > 0 {
+		merged.Notify = profile.Notify
+	}
+	return merged
+}
+
+func (o options) flagSet(names ...string) bool {
+	for _, name := range names {
+		if _, ok := o.explicit[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c repoConfig) applyTo(opts *options) {
+	setString := func(dst *string, value string, flags ...string) {
+		if value != "" && !opts.flagSet(flags...) {
+			*dst = value
+		}
+	}
+	setBool := func(dst *bool, value *bool, flags ...string) {
+		if value != nil && !opts.flagSet(flags...) {
+			*dst = *value
+		}
+	}
+
+	if c.Model != "" && !opts.flagSet("--model") && (!opts.flagSet("--agent") || strings.SplitN(c.Agent, ",", 2)[0] == opts.Agent) {
+		opts.Model = c.Model
+	}
+	if c.Agent != "" && !opts.flagSet("--agent") {
+		opts.Agent, opts.FallbackAgents, _ = parseAgentChain(c.Agent)
+	}
+	setString(&opts.ClaudeBin, c.ClaudeBin, "--claude-bin")
+	setString(&opts.CodexBin, c.CodexBin, "--codex-bin")
+	setString(&opts.GeminiBin, c.GeminiBin, "--gemini-bin")
+	setString(&opts.CursorBin, c.CursorBin, "--cursor-bin")
+	setString(&opts.AiderBin, c.AiderBin, "--aider-bin")
+	setString(&opts.OpenCodeBin, c.OpenCodeBin, "--opencode-bin")
+	setString(&opts.CopilotBin, c.CopilotBin, "--copilot-bin")
+	setString(&opts.QwenBin, c.QwenBin, "--qwen-bin")
+	setString(&opts.APIProvider, c.APIProvider, "--api-provider")
+	setString(&opts.APIBaseURL, c.APIBaseURL, "--api-base-url")
+	setString(&opts.OllamaURL, c.OllamaURL, "--ollama-url")
+	if len(c.Agents) > 0 {
+		opts.CustomAgents = c.Agents
+	}
+	setString(&opts.TranslateModel, c.TranslateModel, "--translate-model")
+	setString(&opts.GHBin, c.GHBin, "--gh-bin")
+	setString(&opts.LogDir, c.LogDir, "--log-dir")
+	setString(&opts.DoneFile, c.DoneFile, "--done-file")
+	setString(&opts.IssuesFile, c.IssuesFile, "--issues-file")
+	setString(&opts.SkipFile, c.SkipFile)
+	setString(&opts.PromptTemplate, c.PromptTemplate, "--prompt-template")
+	setString(&opts.StreamView, c.StreamView, "--stream-view")
+	setString(&opts.CoAuthor, c.CoAuthor, "--co-author")
+	setString(&opts.VerifyCmd, c.VerifyCmd, "--verify-cmd")
+	setString(&opts.LintCmd, c.LintCmd, "--lint-cmd")
+	setString(&opts.ContentGate, c.ContentGate, "--content-gate")
+	setString(&opts.FormatFix, c.FormatFix, "--format-fix")
+	setString(&opts.ProtectFiles, c.ProtectFiles, "--protect-runner-files")
+	setString(&opts.BuildCmd, c.BuildCmd, "--build-cmd")
+	setString(&opts.Baseline, c.Baseline, "--baseline")
+	setString(&opts.Lang, c.Lang, "--lang")
+	setString(&opts.Timezone, c.Timezone, "--timezone")
+	setString(&opts.AppID, c.AppID, "--app-id")
+	setString(&opts.AppKeyFile, c.AppKeyFile, "--app-key")
+	setString(&opts.AppInstallation, c.AppInstallation, "--app-installation")
+	setString(&opts.TodoTags, c.TodoTags, "--todo-tags")
+	setString(&opts.TodoPaths, c.TodoPaths, "--todo-path")
+	setString(&opts.SentryProject, c.SentryProject, "--sentry-project")
+	setString(&opts.BenchCmd, c.BenchCmd, "--bench-cmd")
+	setString(&opts.BenchLabel, c.BenchLabel, "--bench-label")
+	setString(&opts.PRTemplate, c.PRTemplate, "--pr-template")
+	setString(&opts.VerifyScope, c.VerifyScope, "--verify-scope")
+	setString(&opts.OnLimit, c.OnLimit, "--on-limit")
+	setString(&opts.E2ECmd, c.E2ECmd, "--e2e-cmd")
+	setString(&opts.PushRemote, c.PushRemote, "--push-remote")
+	setString(&opts.CommentTemplate, c.CommentTemplate, "--comment-template")
+	setString(&opts.DiffSummary, c.DiffSummary, "--diff-summary")
+	setString(&opts.DiffSummaryCmd, c.DiffSummaryCmd, "--diff-summary-cmd")
+	setString(&opts.SummaryModel, c.SummaryModel, "--diff-summary-model")
+	setString(&opts.WIPLabel, c.WIPLabel, "--wip-label")
+	setString(&opts.DoneLabel, c.DoneLabel, "--done-label")
+	if c.BenchThreshold != nil && !opts.flagSet("--bench-threshold") {
+		opts.BenchThreshold = *c.BenchThreshold
+	}
+	if len(c.EnvTools) > 0 {
+		opts.EnvTools = c.EnvTools
+	}
+	if len(c.Caches) > 0 {
+		// Flags come last so they win for the same variable.
+		opts.Caches = append(cacheSpecs(c.Caches), opts.Caches...)
+	}
+	if len(c.Events) > 0 && !opts.flagSet("--events") {
+		opts.Events = c.Events
+	}
+	if len(c.Notify) > 0 && !opts.flagSet("--notify") {
+		opts.Notify = c.Notify
+	}
+	if len(c.DebugPatterns) > 0 && !opts.flagSet("--debug-pattern") {
+		opts.DebugPatterns = c.DebugPatterns
+	}
+	if len(c.Artifacts) > 0 && !opts.flagSet("--artifact") {
+		opts.Artifacts = c.Artifacts
+	}
+	if len(c.Formatters) > 0 && !opts.flagSet("--formatter") {
+		opts.Formatters = c.Formatters
+	}
+	if len(c.ShareDirs) > 0 && !opts.flagSet("--share-dir") {
+		opts.ShareDirs = c.ShareDirs
+	}
+	if len(c.Redact) > 0 {
+		opts.RedactPatterns = append(append([]string(nil), c.Redact...), opts.RedactPatterns...)
+	}
+	if c.Parallel != nil && !opts.flagSet("--parallel") {
+		opts.Parallel = *c.Parallel
+	}
+	if c.VerifyRetries != nil && !opts.flagSet("--verify-retries") {
+		opts.VerifyRetries = *c.VerifyRetries
+	}
+	if c.APIMaxTokens != nil && !opts.flagSet("--api-max-tokens") {
+		opts.APIMaxTokens = *c.APIMaxTokens
+	}
+	if c.WaitBufferSec != nil && !opts.flagSet("--wait-buffer-sec") {
+		opts.WaitBufferSec = *c.WaitBufferSec
+	}
+	if c.GHWriteInterval != nil && !opts.flagSet("--gh-write-interval") {
+		opts.GHWriteInterval = *c.GHWriteInterval
+	}
+	setBool(&opts.Translate, c.Translate, "--translate")
+	setBool(&opts.IncludeClosed, c.IncludeClosed, "--include-closed")
+	setBool(&opts.PriorityLabels, c.PriorityLabels, "--priority-labels")
+	setBool(&opts.NoColor, c.NoColor, "--no-color")
+	setBool(&opts.Plain, c.Plain, "--plain")
+	setBool(&opts.Timestamps, c.Timestamps, "--timestamps")
+	setBool(&opts.CreatePR, c.CreatePR, "--create-pr")
+	setBool(&opts.PRDraft, c.PRDraft, "--pr-draft")
+	setBool(&opts.Push, c.Push, "--push")
+	setBool(&opts.AssignSelf, c.AssignSelf, "--assign-self")
+	setBool(&opts.CloseOnSuccess, c.CloseOnSuccess, "--close-on-success")
+	setBool(&opts.CommentOnIssue, c.CommentOnIssue, "--comment-on-issue")
+	setBool(&opts.VerifyFullAtEnd, c.VerifyFullAtEnd, "--verify-full-at-end")
+	setBool(&opts.Bisect, c.Bisect, "--bisect")
+	setBool(&opts.RevertBad, c.RevertBad, "--revert-bad")
+	setBool(&opts.RollbackOnFailure, c.RollbackOnFailure, "--rollback-on-failure")
+	setBool(&opts.AutoStash, c.AutoStash, "--autostash")
+}
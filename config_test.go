@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyRepoDefaultsMergesConfig(t *testing.T) {
+	t.Parallel()
+
+	config := `agent: codex
+model: gpt-5.3-codex
+codex_bin: /opt/codex
+log_dir: .runs
+wait_buffer_sec: 30
+verify_cmd: go test ./...
+include_closed: true
+`
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantAgent string
+		wantModel string
+		wantWait  int
+		wantLog   string
+	}{
+		{
+			name:      "config only",
+			wantAgent: "codex",
+			wantModel: "gpt-5.3-codex",
+			wantWait:  30,
+			wantLog:   ".runs",
+		},
+		{
+			name:      "flags override config",
+			args:      []string{"--model", "o3", "--wait-buffer-sec", "5", "--log-dir", "logs"},
+			wantAgent: "codex",
+			wantModel: "o3",
+			wantWait:  5,
+			wantLog:   "logs",
+		},
+		{
+			name:      "switching agent drops the config model",
+			args:      []string{"--agent", "claude"},
+			wantAgent: "claude",
+			wantModel: "",
+			wantWait:  30,
+			wantLog:   ".runs",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := t.TempDir()
+			writeRepoConfig(t, repo, config)
+			opts, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			if err := applyRepoDefaults(&opts, repo); err != nil {
+				t.Fatalf("applyRepoDefaults: %v", err)
+			}
+			if opts.Agent != tt.wantAgent || opts.Model != tt.wantModel || opts.WaitBufferSec != tt.wantWait {
+				t.Fatalf("unexpected options: agent=%q model=%q wait=%d", opts.Agent, opts.Model, opts.WaitBufferSec)
+			}
+			if opts.LogDir != filepath.Join(repo, tt.wantLog) {
+				t.Fatalf("log dir = %q", opts.LogDir)
+			}
+			if opts.CodexBin != "/opt/codex" || opts.VerifyCmd != "go test ./..." || !opts.IncludeClosed {
+				t.Fatalf("config values not applied: %+v", opts)
+			}
+		})
+	}
+}
+
+func TestLoadRepoConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "unknown key", config: "agnet: codex\n", wantErr: "field agnet not found"},
+		{name: "bad agent", config: "agent: nope\n", wantErr: `agent must be one of`},
+		{name: "bad stream view", config: "stream_view: fancy\n", wantErr: "stream_view must be one of"},
+		{name: "bad redact pattern", config: "redact:\n  - \"internal-[\"\n", wantErr: "redact pattern"},
+		{name: "bad cache variable", config: "caches:\n  GO-CACHE: /tmp/go\n", wantErr: "--cache must be VAR=dir"},
+	}
+
+	for _, tt := range tests {
+		repo := t.TempDir()
+		writeRepoConfig(t, repo, tt.config)
+		_, _, err := loadRepoConfig(filepath.Join(repo, defaultConfigPath))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("%s: error = %v, want substring %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, found, err := loadRepoConfig(filepath.Join(t.TempDir(), defaultConfigPath)); found || err != nil {
+		t.Fatalf("missing config: found=%v err=%v", found, err)
+	}
+}
+
+func writeRepoConfig(t *testing.T, repo, content string) {
+	t.Helper()
+
+	path := filepath.Join(repo, defaultConfigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyRepoDefaultsWithProfile(t *testing.T) {
+	t.Parallel()
+
+	config := `agent: claude
+model: sonnet
+verify_cmd: go test ./...
+profiles:
+  fast:
+    agent: codex
+  thorough:
+    model: opus
+    prompt_template: .ticket-runner/thorough.tmpl
+    verify_cmd: make ci
+`
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantAgent string
+		wantModel string
+		wantCmd   string
+		wantErr   string
+	}{
+		{name: "no profile", wantAgent: "claude", wantModel: "sonnet", wantCmd: "go test ./..."},
+		{name: "profile switches agent and drops base model", args: []string{"--profile", "fast"}, wantAgent: "codex", wantCmd: "go test ./..."},
+		{name: "profile overrides model and verify", args: []string{"--profile", "thorough"}, wantAgent: "claude", wantModel: "opus", wantCmd: "make ci"},
+		{name: "flags beat profile", args: []string{"--profile", "thorough", "--model", "haiku"}, wantAgent: "claude", wantModel: "haiku", wantCmd: "make ci"},
+		{name: "unknown profile", args: []string{"--profile", "nope"}, wantErr: `unknown profile "nope" (available: fast, thorough)`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := t.TempDir()
+			writeRepoConfig(t, repo, config)
+			opts, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			err = applyRepoDefaults(&opts, repo)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyRepoDefaults: %v", err)
+			}
+			if opts.Agent != tt.wantAgent || opts.Model != tt.wantModel || opts.VerifyCmd != tt.wantCmd {
+				t.Fatalf("unexpected options: agent=%q model=%q verify=%q", opts.Agent, opts.Model, opts.VerifyCmd)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentGateOff  = "off"
+	contentGateWarn = "warn"
+	contentGateFail = "fail"
+
+	contentConflictMarker = "conflict-marker"
+	contentDebug          = "debug"
+	contentDisabledTest   = "disabled-test"
+
+	maxContentFindingsShown = 20
+)
+
+// defaultDebugPatterns are leftover debugging statements in common
+// languages. --debug-pattern replaces them.
+var defaultDebugPatterns = []string{
+	`\bconsole\.log\(`,
+	`\bdebugger;`,
+	`\bpdb\.set_trace\(\)`,
+	`\bbreakpoint\(\)`,
+	`\bbinding\.pry\b`,
+	`\bdbg!\(`,
+	`\bvar_dump\(`,
+}
+
+var (
+	conflictMarkerPattern = regexp.MustCompile(`^(<<<<<<<|\|\|\|\|\|\|\||>>>>>>>)( |$)`)
+	disabledTestPattern   = regexp.MustCompile(`\bt\.Skip(Now|f)?\(|\b(it|describe|test|context)\.(only|skip)\(|\bx(it|describe)\(|@pytest\.mark\.skip\b|@(Disabled|Ignore)\b`)
+	hunkHeaderPattern     = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+)
+
+// proseExtensions are only checked for conflict markers: documentation
+// mentions console.log or t.Skip on purpose.
+var proseExtensions = map[string]bool{".md": true, ".markdown": true, ".rst": true, ".txt": true, ".adoc": true}
+
+// contentFinding is a line a change adds that is most likely a mistake.
+type contentFinding struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+func compileDebugPatterns(values []string) ([]*regexp.Regexp, error) {
+	if len(values) == 0 {
+		values = defaultDebugPatterns
+	}
+	patterns := make([]*regexp.Regexp, 0, len(values))
+	for _, value := range values {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("debug pattern %q: %w", value, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// scanDiffContent looks at the lines a unified diff adds for conflict
+// markers, debug statements and disabled tests.
+func scanDiffContent(diff string, debug []*regexp.Regexp) []contentFinding {
+	var findings []contentFinding
+	file, line, inHunk := "", 0, false
+	for _, raw := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(raw, "diff --git "):
+			file, inHunk = "", false
+			continue
+		case !inHunk && strings.HasPrefix(raw, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(raw, "+++ "), "b/")
+			continue
+		case strings.HasPrefix(raw, "@@"):
+			if m := hunkHeaderPattern.FindStringSubmatch(raw); m != nil {
+				line, _ = strconv.Atoi(m[1])
+				inHunk = true
+			}
+			continue
+		}
+		if !inHunk || file == "" || file == "/dev/null" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(raw, "+"):
+		case strings.HasPrefix(raw, " "):
+			line++
+			continue
+		default:
+			continue
+		}
+		text := strings.TrimPrefix(raw, "+")
+		finding := contentFinding{File: file, Line: line, Text: strings.TrimSpace(text)}
+		line++
+		if conflictMarkerPattern.MatchString(text) {
+			finding.Kind = contentConflictMarker
+		} else if proseExtensions[strings.ToLower(filepath.Ext(file))] {
+			continue
+		} else if disabledTestPattern.MatchString(text) {
+			finding.Kind = contentDisabledTest
+		} else {
+			for _, pattern := range debug {
+				if pattern.MatchString(text) {
+					finding.Kind = contentDebug
+					break
+				}
+			}
+		}
+		if finding.Kind != "" {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// contentGate scans what the issue's commits add. With --content-gate warn
+// (the default) findings are reported and kept in state.json; with fail
+// they fail the issue for review.
+func (r *runner) contentGate(issue, startHead string, attempt *issueAttempt) bool {
+	if r.opts.ContentGate == contentGateOff {
+		return true
+	}
+	debug, err := compileDebugPatterns(r.opts.DebugPatterns)
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+		return true
+	}
+	diff, err := r.gitOutput("diff", "-U0", "--no-color", "--no-ext-diff", startHead+"..HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not diff #%s to check its content: %v\n", issue, err)
+		return true
+	}
+	findings := scanDiffContent(diff, debug)
+	attempt.findings = findings
+	if len(findings) == 0 {
+		return true
+	}
+	color, prefix := r.colors.Yellow, "WARNING"
+	if r.opts.ContentGate == contentGateFail {
+		color, prefix = r.colors.Red, "FAILED"
+	}
+	r.printf(color, "%s: the change for #%s adds %d suspicious line(s):\n", prefix, issue, len(findings))
+	for i, f := range findings {
+		if i == maxContentFindingsShown {
+			r.printf(color, "  ... and %d more\n", len(findings)-i)
+			break
+		}
+		r.printf(color, "  %s:%d: %s: %s\n", f.File, f.Line, f.Kind, truncateForConsole(f.Text, 120))
+	}
+	return r.opts.ContentGate != contentGateFail
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestScanDiffContent(t *testing.T) {
+	t.Parallel()
+
+	diff := `diff --git a/app.js b/app.js
+index 1111111..2222222 100644
+--- a/app.js
++++ b/app.js
+@@ -3,0 +4,2 @@ function main() {
++  console.log("here");
++  return 1;
+@@ -10 +12 @@ function other() {
+-  old();
++  debugger;
+diff --git a/store_test.go b/store_test.go
+--- a/store_test.go
++++ b/store_test.go
+@@ -20,0 +21,6 @@ func TestStore(t *testing.T) {
++	t.Skip("flaky")
++<<<<<<< HEAD
++	want := 1
++=======
++	want := 2
++>>>>>>> feature
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,0 +2 @@
++Remove console.log( calls and t.Skip( before committing.
+diff --git a/gone.js b/gone.js
+deleted file mode 100644
+--- a/gone.js
++++ /dev/null
+@@ -1 +0,0 @@
+-console.log("bye")
+`
+	debug, err := compileDebugPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := scanDiffContent(diff, debug)
+	want := []contentFinding{
+		{File: "app.js", Line: 4, Kind: contentDebug, Text: `console.log("here");`},
+		{File: "app.js", Line: 12, Kind: contentDebug, Text: "debugger;"},
+		{File: "store_test.go", Line: 21, Kind: contentDisabledTest, Text: `t.Skip("flaky")`},
+		{File: "store_test.go", Line: 22, Kind: contentConflictMarker, Text: "<<<<<<< HEAD"},
+		{File: "store_test.go", Line: 26, Kind: contentConflictMarker, Text: ">>>>>>> feature"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("finding %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	custom, err := compileDebugPatterns([]string{`\blog\.Printf\("DEBUG`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scanDiffContent(diff, custom); len(got) != 3 {
+		t.Fatalf("with custom patterns, findings = %+v", got)
+	}
+}
+
+func TestContentGate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		mode        string
+		wantFailure failureCategory
+		wantFound   int
+	}{
+		{name: "warn", mode: contentGateWarn, wantFound: 1},
+		{name: "fail", mode: contentGateFail, wantFailure: failureGate, wantFound: 1},
+		{name: "off", mode: contentGateOff},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := fakeIssueGH(t, "Add f", "add f")
+			agent := fakeAgent(t, "printf 'a\\n<<<<<<< HEAD\\nb\\n' > f.txt\ngit add f.txt\ngit commit -q -m \"feat: add f (#5)\"")
+			opts := options{
+				Agent:       "claude",
+				ClaudeBin:   agent,
+				GHBin:       gh,
+				SingleIssue: "5",
+				ContentGate: tt.mode,
+			}
+			r := newTestRunner(t, repo, opts)
+			r.runQueue()
+			if got := r.failures["5"]; got != tt.wantFailure {
+				t.Fatalf("failure = %q, want %q", got, tt.wantFailure)
+			}
+			st, _ := r.state.get("5")
+			if len(st.Findings) != tt.wantFound {
+				t.Fatalf("findings = %+v, want %d", st.Findings, tt.wantFound)
+			}
+			if tt.wantFound > 0 && (st.Findings[0].File != "f.txt" || st.Findings[0].Line != 2 || st.Findings[0].Kind != contentConflictMarker) {
+				t.Fatalf("finding = %+v", st.Findings[0])
+			}
+			wantStatus := statusDone
+			if tt.wantFailure != "" {
+				wantStatus = statusNeedsReview
+			}
+			if st.Status != wantStatus {
+				t.Fatalf("status = %q, want %q", st.Status, wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// copilotLimitPattern is the Copilot CLI running out of premium
+	// requests or being rate limited.
+	copilotLimitPattern = regexp.MustCompile(`(?i)(quota[_ ]exceeded|exceeded your (monthly )?(premium request|copilot)[a-z ]*(quota|allowance|limit)|out of premium requests|premium request (quota|allowance|limit)|rate[_ ]limit(ed|_exceeded| exceeded)|too many requests)`)
+	// copilotPremiumPattern is the monthly premium request allowance, which
+	// resets on the first of the month (UTC).
+	copilotPremiumPattern = regexp.MustCompile(`(?i)premium request`)
+	copilotRetryPattern   = regexp.MustCompile(`(?i)(?:try again|retry) in (\d+)\s*(s|sec|seconds?|m|min|minutes?|h|hours?)\b`)
+)
+
+// copilotArgs runs the Copilot CLI once on the prompt. --allow-all-tools
+// lets it edit files and run commands without asking; it does not commit.
+func (r *runner) copilotArgs(prompt string, sampling []string) []string {
+	args := []string{"--allow-all-tools"}
+	if r.opts.Model != "" {
+		args = append(args, "--model", r.opts.Model)
+	}
+	args = append(args, sampling...)
+	return append(args, "-p", prompt)
+}
+
+// waitDurationCopilot waits for "try again in 5 minutes" when the message
+// gives it, until the first of next month for the premium request
+// allowance, and for the fallback period otherwise.
+func waitDurationCopilot(logOutput string, now time.Time, bufferSec int) (int, time.Time) {
+	if match := copilotRetryPattern.FindStringSubmatch(logOutput); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err == nil && n > 0 {
+			unit := time.Second
+			switch strings.ToLower(match[2])[0] {
+			case 'm':
+				unit = time.Minute
+			case 'h':
+				unit = time.Hour
+			}
+			wait := int((time.Duration(n) * unit).Seconds()) + bufferSec
+			return wait, now.Add(time.Duration(wait) * time.Second)
+		}
+	}
+	if copilotPremiumPattern.MatchString(logOutput) {
+		utc := now.UTC()
+		reset := time.Date(utc.Year(), utc.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		withBuffer := reset.Add(time.Duration(bufferSec) * time.Second)
+		return int(withBuffer.Sub(now).Seconds()), withBuffer
+	}
+	wait := defaultFallbackWaitSec
+	return wait, now.Add(time.Duration(wait) * time.Second)
+}
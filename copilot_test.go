@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopilotCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "default model", want: "--allow-all-tools -p fix #5"},
+		{name: "model", model: "gpt-5", want: "--allow-all-tools --model gpt-5 -p fix #5"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{opts: options{Agent: "copilot", CopilotBin: "/opt/copilot", Model: tt.model}}
+			cmd, err := r.buildAgentCommand("fix #5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(cmd.Args[1:], " "); cmd.Path != "/opt/copilot" || got != tt.want {
+				t.Fatalf("command = %s %s, want %s", cmd.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitDurationCopilot(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		log  string
+		want time.Time
+	}{
+		{name: "retry after", log: "Error: rate limit exceeded, try again in 5 minutes", want: now.Add(5*time.Minute + 60*time.Second)},
+		{name: "premium requests", log: "Error: You have exceeded your monthly premium request allowance.", want: time.Date(2026, 4, 1, 0, 1, 0, 0, time.UTC)},
+		{name: "no hint", log: "Error: 429 Too Many Requests", want: now.Add(defaultFallbackWaitSec * time.Second)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wait, reset := waitDurationCopilot(tt.log, now, 60)
+			if !reset.Equal(tt.want) || wait != int(tt.want.Sub(now).Seconds()) {
+				t.Fatalf("waitDurationCopilot = %d, %s; want %s", wait, reset, tt.want)
+			}
+		})
+	}
+}
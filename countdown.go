@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// countdownNotifySeconds is how often a session-limit wait is pushed to the
+// --notify targets and --events sinks; the terminal countdown is more
+// frequent.
+const countdownNotifySeconds = 1800
+
+// announceWait reports the time left until a session limit resets to the
+// run journal, and so to the --events sinks, and to the --notify targets.
+func (r *runner) announceWait(issue string, resetTime time.Time, remaining int) {
+	resumeAt := r.timestamp(resetTime)
+	r.record(journalEntry{Event: journalLimitWaiting, Issue: issue, Agent: r.opts.Agent, WaitSec: remaining, ResumeAt: resumeAt})
+	r.notify(notification{Event: journalLimitWaiting, Issue: issue, Agent: r.opts.Agent, ResumeAt: resumeAt, RemainingSec: remaining, RunDir: r.runDir})
+}
+
+// announceResume reports the end of a session-limit wait.
+func (r *runner) announceResume(issue string) {
+	r.record(journalEntry{Event: journalLimitResumed, Issue: issue, Agent: r.opts.Agent})
+	r.notify(notification{Event: journalLimitResumed, Issue: issue, Agent: r.opts.Agent, RunDir: r.runDir})
+}
+
+// limitWait is the session-limit wait of the latest run, as served on
+// /api/limit.
+type limitWait struct {
+	Waiting      bool   `json:"waiting"`
+	Agent        string `json:"agent,omitempty"`
+	Issue        string `json:"issue,omitempty"`
+	ResumeAt     string `json:"resume_at,omitempty"`
+	RemainingSec int    `json:"remaining_sec,omitempty"`
+}
+
+// currentLimitWait reads the newest run journal: the run is waiting when its
+// last limit event is limit_detected or limit_waiting and the reset time has
+// not passed, which also covers a run that was killed mid-wait.
+func currentLimitWait(logDir string, now time.Time) (limitWait, error) {
+	paths, err := filepath.Glob(filepath.Join(logDir, "run-*.jsonl"))
+	if err != nil || len(paths) == 0 {
+		return limitWait{}, err
+	}
+	sort.Strings(paths)
+	f, err := os.Open(paths[len(paths)-1])
+	if err != nil {
+		return limitWait{}, err
+	}
+	defer f.Close()
+
+	var wait limitWait
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		switch entry.Event {
+		case journalLimitDetected, journalLimitWaiting:
+			wait = limitWait{Waiting: true, Agent: entry.Agent, Issue: entry.Issue, ResumeAt: entry.ResumeAt}
+		case journalLimitResumed, journalRunFinished:
+			wait = limitWait{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return limitWait{}, err
+	}
+	if !wait.Waiting {
+		return wait, nil
+	}
+	resumeAt, err := time.Parse(time.RFC3339, wait.ResumeAt)
+	if err != nil || !resumeAt.After(now) {
+		return limitWait{}, nil
+	}
+	wait.RemainingSec = int(resumeAt.Sub(now).Seconds())
+	return wait, nil
+}
+
+func (r *runner) serveLimit(w http.ResponseWriter, req *http.Request) {
+	wait, err := currentLimitWait(r.opts.LogDir, r.now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(wait)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForSessionResetNotifies(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var got []notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var n notification
+		if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		got = append(got, n)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	repo := initTestRepo(t)
+	opts := options{Agent: "claude", Notify: []string{srv.URL}, LogDir: filepath.Join(repo, defaultLogDirName)}
+	r := newTestRunner(t, repo, opts)
+	r.waitForSessionReset("5", 1, time.Now().Add(time.Second))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 2 {
+		t.Fatalf("notifications = %+v, want waiting and resumed", got)
+	}
+	if w := got[0]; w.Event != journalLimitWaiting || w.Issue != "5" || w.Agent != "claude" || w.RemainingSec != 1 || w.ResumeAt == "" {
+		t.Fatalf("waiting notification = %+v", w)
+	}
+	if res := got[1]; res.Event != journalLimitResumed || res.Issue != "5" {
+		t.Fatalf("resumed notification = %+v", res)
+	}
+}
+
+func TestCurrentLimitWait(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 4, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		events []string
+		want   limitWait
+	}{
+		{
+			name:   "waiting",
+			events: []string{`{"event":"run_started"}`, `{"event":"limit_detected","issue":"5","agent":"claude","resume_at":"2026-03-04T03:00:00Z"}`, `{"event":"limit_waiting","issue":"5","agent":"claude","resume_at":"2026-03-04T03:00:00Z"}`},
+			want:   limitWait{Waiting: true, Agent: "claude", Issue: "5", ResumeAt: "2026-03-04T03:00:00Z", RemainingSec: 3600},
+		},
+		{
+			name:   "resumed",
+			events: []string{`{"event":"limit_waiting","issue":"5","agent":"claude","resume_at":"2026-03-04T03:00:00Z"}`, `{"event":"limit_resumed","issue":"5"}`},
+		},
+		{
+			name:   "reset time passed",
+			events: []string{`{"event":"limit_waiting","issue":"5","agent":"claude","resume_at":"2026-03-04T01:00:00Z"}`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "run-20260101T000000Z.jsonl"), []byte(`{"event":"limit_waiting","issue":"1","agent":"codex","resume_at":"2026-03-04T05:00:00Z"}`+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "run-20260304T010000Z.jsonl"), []byte(strings.Join(tt.events, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := currentLimitWait(dir, now)
+			if err != nil || got != tt.want {
+				t.Fatalf("currentLimitWait = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
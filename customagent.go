@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	promptPlaceholder = "{{PROMPT}}"
+	modelPlaceholder  = "{{MODEL}}"
+
+	promptModeArg   = "arg"
+	promptModeStdin = "stdin"
+)
+
+var customAgentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// customAgent is a coding agent declared under agents: in the config, for
+// tools ghir has no built-in support for.
+type customAgent struct {
+	Cmd           []string `yaml:"cmd"`
+	Prompt        string   `yaml:"prompt"`
+	LimitPatterns []string `yaml:"limit_patterns"`
+	CoAuthor      string   `yaml:"co_author"`
+}
+
+func (a customAgent) validate() error {
+	if len(a.Cmd) == 0 || strings.TrimSpace(a.Cmd[0]) == "" {
+		return fmt.Errorf("cmd must name the program to run")
+	}
+	hasPrompt := false
+	for _, arg := range a.Cmd {
+		if strings.Contains(arg, promptPlaceholder) {
+			hasPrompt = true
+		}
+	}
+	switch a.Prompt {
+	case "", promptModeArg:
+		if !hasPrompt {
+			return fmt.Errorf("cmd must contain %s to pass the prompt as an argument (or set prompt: %s)", promptPlaceholder, promptModeStdin)
+		}
+	case promptModeStdin:
+		if hasPrompt {
+			return fmt.Errorf("cmd cannot contain %s when the prompt goes to stdin", promptPlaceholder)
+		}
+	default:
+		return fmt.Errorf("prompt must be one of: %s, %s (got %q)", promptModeArg, promptModeStdin, a.Prompt)
+	}
+	for _, pattern := range a.LimitPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("limit_patterns: %w", err)
+		}
+	}
+	if err := validCoAuthor(a.CoAuthor); err != nil {
+		return fmt.Errorf("co_author: %w", err)
+	}
+	return nil
+}
+
+func validateCustomAgents(agents map[string]customAgent) error {
+	names := make([]string, 0, len(agents))
+	for name := range agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !customAgentNamePattern.MatchString(name) {
+			return fmt.Errorf("agents: %q must be lower case letters, digits, '-' and '_'", name)
+		}
+		for _, builtin := range supportedAgents {
+			if name == builtin {
+				return fmt.Errorf("agents: %q is a built-in agent", name)
+			}
+		}
+		if err := agents[name].validate(); err != nil {
+			return fmt.Errorf("agents: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Agents declared in the config are known before the flags are read, so
+// --agent and issue files can name them.
+var (
+	customAgentsMu     sync.RWMutex
+	customAgentsByName = make(map[string]bool)
+)
+
+func registerCustomAgents(agents map[string]customAgent) {
+	customAgentsMu.Lock()
+	defer customAgentsMu.Unlock()
+	for name := range agents {
+		customAgentsByName[name] = true
+	}
+}
+
+func isCustomAgent(agent string) bool {
+	customAgentsMu.RLock()
+	defer customAgentsMu.RUnlock()
+	return customAgentsByName[agent]
+}
+
+// knownAgents lists the built-in agents followed by the custom ones.
+func knownAgents() []string {
+	customAgentsMu.RLock()
+	custom := make([]string, 0, len(customAgentsByName))
+	for name := range customAgentsByName {
+		custom = append(custom, name)
+	}
+	customAgentsMu.RUnlock()
+	sort.Strings(custom)
+	return append(append([]string(nil), supportedAgents...), custom...)
+}
+
+// preloadCustomAgents registers the agents of the repo config (or the
+// --config file) in args ahead of parseArgs. Problems are left for the
+// normal config loading to report.
+func preloadCustomAgents(args []string) {
+	configPath := ""
+	for i, arg := range args {
+		if arg == "--config" && i+1 < len(args) {
+			configPath = args[i+1]
+		}
+	}
+	repoRoot, err := findRepoRoot()
+	if err != nil {
+		return
+	}
+	if configPath == "" {
+		configPath = filepath.Join(repoRoot, defaultConfigPath)
+	} else {
+		configPath = resolvePath(repoRoot, configPath)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return
+	}
+	_, _, _ = loadRepoConfig(configPath)
+}
+
+// customAgentCommand fills the prompt and model into the agent's cmd. An
+// argument with {{MODEL}} is left out when no model is chosen, so the flag
+// and its value belong in one argument ("--model={{MODEL}}").
+func (r *runner) customAgentCommand(agent customAgent, prompt string) *exec.Cmd {
+	args := make([]string, 0, len(agent.Cmd))
+	for _, arg := range agent.Cmd[1:] {
+		if strings.Contains(arg, modelPlaceholder) {
+			if r.opts.Model == "" {
+				continue
+			}
+			arg = strings.ReplaceAll(arg, modelPlaceholder, r.opts.Model)
+		}
+		args = append(args, strings.ReplaceAll(arg, promptPlaceholder, prompt))
+	}
+	cmd := exec.Command(agent.Cmd[0], args...)
+	if agent.Prompt == promptModeStdin {
+		cmd.Stdin = strings.NewReader(prompt)
+	}
+	return cmd
+}
+
+// sessionLimitHit reports whether the agent stopped at its session limit.
+// A custom agent is at its limit when it failed with output matching one
+// of its limit_patterns; without patterns it never is.
+func (r *runner) sessionLimitHit(logOutput string, exitCode int) bool {
+	agent, ok := r.opts.CustomAgents[r.opts.Agent]
+	if !ok {
+		return detectSessionLimit(logOutput, r.opts.Agent, exitCode)
+	}
+	if exitCode == 0 {
+		return false
+	}
+	for _, pattern := range agent.LimitPatterns {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(logOutput) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCustomAgentValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		agent   customAgent
+		wantErr string
+	}{
+		{name: "prompt argument", agent: customAgent{Cmd: []string{"mytool", "run", "--prompt", "{{PROMPT}}"}}},
+		{name: "stdin", agent: customAgent{Cmd: []string{"mytool"}, Prompt: "stdin", LimitPatterns: []string{`(?i)quota`}}},
+		{name: "no command", wantErr: "cmd must name the program"},
+		{name: "no placeholder", agent: customAgent{Cmd: []string{"mytool", "run"}}, wantErr: "cmd must contain {{PROMPT}}"},
+		{name: "placeholder with stdin", agent: customAgent{Cmd: []string{"mytool", "{{PROMPT}}"}, Prompt: "stdin"}, wantErr: "cannot contain {{PROMPT}}"},
+		{name: "unknown prompt mode", agent: customAgent{Cmd: []string{"mytool", "{{PROMPT}}"}, Prompt: "file"}, wantErr: "prompt must be one of"},
+		{name: "bad limit pattern", agent: customAgent{Cmd: []string{"mytool", "{{PROMPT}}"}, LimitPatterns: []string{"quota["}}, wantErr: "limit_patterns"},
+		{name: "bad co-author", agent: customAgent{Cmd: []string{"mytool", "{{PROMPT}}"}, CoAuthor: "MyTool"}, wantErr: "co_author"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.agent.validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomAgentCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		agent     customAgent
+		model     string
+		wantArgs  string
+		wantStdin string
+	}{
+		{
+			name:     "prompt argument",
+			agent:    customAgent{Cmd: []string{"mytool", "run", "--model={{MODEL}}", "--prompt", "{{PROMPT}}"}},
+			wantArgs: "run --prompt fix #5",
+		},
+		{
+			name:     "model",
+			agent:    customAgent{Cmd: []string{"mytool", "run", "--model={{MODEL}}", "--prompt", "{{PROMPT}}"}},
+			model:    "large",
+			wantArgs: "run --model=large --prompt fix #5",
+		},
+		{
+			name:      "stdin",
+			agent:     customAgent{Cmd: []string{"mytool", "--yes"}, Prompt: "stdin"},
+			wantArgs:  "--yes",
+			wantStdin: "fix #5",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{opts: options{Agent: "mytool", Model: tt.model, CustomAgents: map[string]customAgent{"mytool": tt.agent}}}
+			cmd, err := r.buildAgentCommand("fix #5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(cmd.Args[1:], " "); cmd.Args[0] != "mytool" || got != tt.wantArgs {
+				t.Fatalf("command = %s %s, want %s", cmd.Args[0], got, tt.wantArgs)
+			}
+			stdin := ""
+			if cmd.Stdin != nil {
+				data, _ := io.ReadAll(cmd.Stdin)
+				stdin = string(data)
+			}
+			if stdin != tt.wantStdin {
+				t.Fatalf("stdin = %q, want %q", stdin, tt.wantStdin)
+			}
+			if r.agentBin() != "mytool" || agentDisplayName("mytool") != "mytool" {
+				t.Fatalf("agentBin = %q, display name = %q", r.agentBin(), agentDisplayName("mytool"))
+			}
+		})
+	}
+}
+
+func TestSessionLimitHitCustomAgent(t *testing.T) {
+	t.Parallel()
+
+	agents := map[string]customAgent{
+		"mytool": {Cmd: []string{"mytool", "{{PROMPT}}"}, LimitPatterns: []string{`(?i)credits exhausted`}},
+		"plain":  {Cmd: []string{"plain", "{{PROMPT}}"}},
+	}
+	tests := []struct {
+		agent    string
+		output   string
+		exitCode int
+		want     bool
+	}{
+		{agent: "mytool", output: "Error: Credits exhausted until 5pm", exitCode: 1, want: true},
+		{agent: "mytool", output: "Error: Credits exhausted until 5pm", exitCode: 0},
+		{agent: "mytool", output: "Error: build failed", exitCode: 1},
+		{agent: "plain", output: "You've hit your limit · resets 5pm", exitCode: 1},
+		{agent: "claude", output: "You've hit your limit · resets 5pm", exitCode: 1, want: true},
+	}
+	for _, tt := range tests {
+		r := &runner{opts: options{Agent: tt.agent, CustomAgents: agents}}
+		if got := r.sessionLimitHit(tt.output, tt.exitCode); got != tt.want {
+			t.Fatalf("%s: sessionLimitHit(%q, %d) = %v, want %v", tt.agent, tt.output, tt.exitCode, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRepoDefaultsCustomAgent(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeRepoConfig(t, repo, `agent: acme,claude
+agents:
+  acme:
+    cmd: ["acme", "run", "--prompt", "{{PROMPT}}"]
+    limit_patterns: ["out of credits"]
+profiles:
+  piped:
+    agent: acme-pipe
+    agents:
+      acme-pipe:
+        cmd: ["acme", "--stdin"]
+        prompt: stdin
+`)
+	cfg, _, err := loadRepoConfig(filepath.Join(repo, defaultConfigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Agents) != 1 || len(cfg.Profiles["piped"].Agents) != 1 {
+		t.Fatalf("agents = %+v, profiles = %+v", cfg.Agents, cfg.Profiles)
+	}
+
+	opts, err := parseArgs([]string{"--agent", "acme"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if err := applyRepoDefaults(&opts, repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Agent != "acme" || opts.CustomAgents["acme"].Cmd[0] != "acme" {
+		t.Fatalf("agent = %q, custom agents = %+v", opts.Agent, opts.CustomAgents)
+	}
+
+	opts, err = parseArgs([]string{"--profile", "piped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRepoDefaults(&opts, repo); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Agent != "acme-pipe" || len(opts.CustomAgents) != 2 {
+		t.Fatalf("agent = %q, custom agents = %+v", opts.Agent, opts.CustomAgents)
+	}
+
+	// acme-pipe is only defined in the profile.
+	opts, err = parseArgs([]string{"--agent", "acme-pipe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRepoDefaults(&opts, repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "not defined") {
+		t.Fatalf("validateOptions() = %v", err)
+	}
+
+	writeRepoConfig(t, repo, "agents:\n  codex:\n    cmd: [mycodex, \"{{PROMPT}}\"]\n")
+	if _, _, err := loadRepoConfig(filepath.Join(repo, defaultConfigPath)); err == nil || !strings.Contains(err.Error(), "built-in agent") {
+		t.Fatalf("loadRepoConfig() = %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	diagnosticsDirName  = "diagnostics"
+	diagnosticsLogLines = 200
+	redactedPlaceholder = "[REDACTED]"
+)
+
+var version = "dev"
+
+var diagnosticsEnvVars = []string{
+	"GH_TOKEN",
+	"GITHUB_TOKEN",
+	"GH_HOST",
+	"ANTHROPIC_API_KEY",
+	"OPENAI_API_KEY",
+	"GEMINI_API_KEY",
+	"GOOGLE_API_KEY",
+	"CURSOR_API_KEY",
+	"NO_COLOR",
+	"SHELL",
+	"TERM",
+}
+
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{20,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}\b`),
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}\b`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._\-]{16,}`),
+}
+
+// secretURLPattern matches http(s) URLs; webhook URLs often carry their token in
+// the path or query.
+var secretURLPattern = regexp.MustCompile(`\bhttps?://[^/\s"'?#]+[^\s"']*`)
+
+// diagnosticsHiddenOptions are left out of the bundle whole: the redact
+// patterns describe the secrets themselves.
+var diagnosticsHiddenOptions = map[string]bool{"RedactPatterns": true}
+
+var secretAssignmentPattern = regexp.MustCompile(`(?i)\b([A-Za-z0-9_]*(?:token|secret|password|passwd|api[_-]?key)[A-Za-z0-9_]*)(\s*[=:]\s*)(\\"[^"\\]*\\"|"[^"]*"|'[^']*'|[^\s"'\\,;}]+)`)
+
+type diagnosticReport struct {
+	GeneratedAt string            `json:"generated_at"`
+	Issue       string            `json:"issue"`
+	Failure     string            `json:"failure"`
+	Error       string            `json:"error,omitempty"`
+	Runner      map[string]string `json:"runner"`
+	Options     map[string]any    `json:"options"`
+	Environment map[string]string `json:"environment"`
+}
+
+func runnerVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != "" {
+			revision := setting.Value
+			if len(revision) > 12 {
+				revision = revision[:12]
+			}
+			return version + "+" + revision
+		}
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+func redactSecrets(value string) string {
+	for _, pattern := range secretPatterns {
+		value = pattern.ReplaceAllString(value, redactedPlaceholder)
+	}
+	return secretAssignmentPattern.ReplaceAllString(value, "${1}${2}"+redactedPlaceholder)
+}
+
+// redactURLs keeps the scheme and host of every http(s) URL in value and
+// hides the rest.
+func redactURLs(value string) string {
+	return secretURLPattern.ReplaceAllStringFunc(value, func(raw string) string {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return redactedPlaceholder
+		}
+		origin := u.Scheme + "://" + u.Host
+		if raw == origin || raw == origin+"/" {
+			return raw
+		}
+		return origin + "/" + redactedPlaceholder
+	})
+}
+
+func redactHome(value string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" || home == "/" {
+		return value
+	}
+	return strings.ReplaceAll(value, home, "~")
+}
+
+func (r *runner) writeDiagnostics(issue string, category failureCategory, cause error, attempt *issueAttempt) {
+	path, err := r.buildDiagnostics(issue, category, cause, attempt, time.Now())
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not write diagnostic bundle: %v\n", err)
+		return
+	}
+	r.printf(r.colors.Yellow, "Diagnostic bundle: %s (attach it if you report this as a ghir bug)\n", path)
+}
+
+func (r *runner) buildDiagnostics(issue string, category failureCategory, cause error, attempt *issueAttempt, now time.Time) (string, error) {
+	dir := filepath.Join(r.opts.LogDir, diagnosticsDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	report := diagnosticReport{
+		GeneratedAt: r.timestamp(now),
+		Issue:       issue,
+		Failure:     string(category),
+		Runner: map[string]string{
+			"version": runnerVersion(),
+			"go":      runtime.Version(),
+			"os":      runtime.GOOS,
+			"arch":    runtime.GOARCH,
+		},
+		Options:     r.diagnosticOptions(),
+		Environment: r.diagnosticEnvironment(),
+	}
+	if cause != nil {
+		report.Error = redactHome(redactSecrets(cause.Error()))
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	excerpt := ""
+	if attempt != nil {
+		excerpt = attempt.logOutput
+		if excerpt == "" && attempt.logPath != "" {
+			if data, err := os.ReadFile(attempt.logPath); err == nil {
+				excerpt = string(data)
+			}
+		}
+	}
+	excerpt = redactHome(redactSecrets(tailLines(excerpt, diagnosticsLogLines)))
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-issue-%s.zip", r.fileStamp(now), issue))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(f)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{name: "report.json", data: append(reportJSON, '\n')},
+		{name: "log-excerpt.txt", data: []byte(excerpt + "\n")},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err == nil {
+			_, err = w.Write(file.data)
+		}
+		if err != nil {
+			_ = zw.Close()
+			_ = f.Close()
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (r *runner) diagnosticOptions() map[string]any {
+	data, err := json.Marshal(r.opts)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	for key, value := range fields {
+		if diagnosticsHiddenOptions[key] && value != nil {
+			fields[key] = redactedPlaceholder
+			continue
+		}
+		fields[key] = redactOption(value)
+	}
+	return fields
+}
+
+// redactOption redacts the strings of a decoded option, however deep in
+// lists and maps they sit.
+func redactOption(value any) any {
+	switch v := value.(type) {
+	case string:
+		return redactHome(redactSecrets(redactURLs(v)))
+	case []any:
+		for i := range v {
+			v[i] = redactOption(v[i])
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = redactOption(v[key])
+		}
+	}
+	return value
+}
+
+func (r *runner) diagnosticEnvironment() map[string]string {
+	env := make(map[string]string)
+	if out, err := r.commandOutput("git", "--version"); err == nil {
+		env["git"] = out
+	}
+	for name, bin := range map[string]string{"agent_bin": r.agentBin(), "gh_bin": r.opts.GHBin} {
+		if resolved, err := exec.LookPath(bin); err == nil {
+			env[name] = redactHome(resolved)
+		} else {
+			env[name] = "not found: " + redactHome(bin)
+		}
+	}
+	for _, name := range diagnosticsEnvVars {
+		value, ok := os.LookupEnv(name)
+		switch {
+		case !ok:
+			env["env."+name] = "unset"
+		case name == "SHELL" || name == "TERM":
+			env["env."+name] = value
+		default:
+			env["env."+name] = "set"
+		}
+	}
+	return env
+}
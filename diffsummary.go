@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	diffSummaryStats   = "stats"
+	diffSummaryModel   = "model"
+	diffSummaryCommand = "command"
+
+	defaultDiffSummaryModel = "haiku"
+	diffSummaryMaxFiles     = 20
+	diffSummaryMaxDiffBytes = 100000
+)
+
+const diffSummaryPrompt = `Summarize the following git diff for a reviewer in two to four sentences of plain prose: what changed and why it matters. Do not list every file, do not use headings, and output only the summary.
+
+`
+
+// fileChange is one file of a change, from git diff --numstat. Binary files
+// have no line counts.
+type fileChange struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary,omitempty"`
+}
+
+// diffSummary describes what an issue's commits changed: per-file stats and,
+// from the model and command summarizers, a short description.
+type diffSummary struct {
+	Files   []fileChange `json:"files"`
+	Added   int          `json:"added"`
+	Deleted int          `json:"deleted"`
+	Text    string       `json:"text,omitempty"`
+}
+
+// diffSummarizer describes the change between two revisions. The PR body,
+// the issue comment, state.json and the notifications all use the runner's
+// summarizer (see diffSummarizer), so a team that wants its own summary
+// plugs in one implementation.
+type diffSummarizer interface {
+	summarize(base, head string) (*diffSummary, error)
+}
+
+// headline is "3 file(s) changed, +20 -5".
+// diffHeadlineFormat is the headline of a change: files, lines added and
+// lines deleted.
+const diffHeadlineFormat = "%d file(s) changed, +%d -%d"
+
+func (s *diffSummary) headline() string {
+	return fmt.Sprintf(diffHeadlineFormat, len(s.Files), s.Added, s.Deleted)
+}
+
+// markdown is the description, if any, and the changed files, largest
+// change first, with its English text passed through tr.
+func (s *diffSummary) markdown(tr func(string) string) string {
+	if s == nil || len(s.Files) == 0 && s.Text == "" {
+		return ""
+	}
+	var b strings.Builder
+	if s.Text != "" {
+		b.WriteString(s.Text + "\n\n")
+	}
+	fmt.Fprintf(&b, tr(diffHeadlineFormat)+":\n", len(s.Files), s.Added, s.Deleted)
+	for i, f := range s.Files {
+		if i == diffSummaryMaxFiles {
+			fmt.Fprintf(&b, tr("- ... %d more file(s)\n"), len(s.Files)-i)
+			break
+		}
+		if f.Binary {
+			fmt.Fprintf(&b, tr("- `%s` (binary)\n"), f.Path)
+			continue
+		}
+		fmt.Fprintf(&b, "- `%s` (+%d -%d)\n", f.Path, f.Added, f.Deleted)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// statsSummarizer is the built-in summarizer: per-file line counts, no
+// description.
+type statsSummarizer struct {
+	r *runner
+}
+
+func (s statsSummarizer) summarize(base, head string) (*diffSummary, error) {
+	out, err := s.r.gitOutput("diff", "--numstat", "-M", base+".."+head)
+	if err != nil {
+		return nil, err
+	}
+	return parseNumstat(out), nil
+}
+
+// parseNumstat reads `git diff --numstat` output, ordering the files by the
+// size of their change.
+func parseNumstat(out string) *diffSummary {
+	summary := &diffSummary{Files: []fileChange{}}
+	for _, line := range nonEmptyLines(out) {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		f := fileChange{Path: fields[2]}
+		added, errA := strconv.Atoi(fields[0])
+		deleted, errD := strconv.Atoi(fields[1])
+		if errA != nil || errD != nil {
+			f.Binary = true
+		} else {
+			f.Added, f.Deleted = added, deleted
+		}
+		summary.Files = append(summary.Files, f)
+		summary.Added += f.Added
+		summary.Deleted += f.Deleted
+	}
+	sort.SliceStable(summary.Files, func(i, j int) bool {
+		return summary.Files[i].Added+summary.Files[i].Deleted > summary.Files[j].Added+summary.Files[j].Deleted
+	})
+	return summary
+}
+
+// modelSummarizer adds a description written by the claude CLI with
+// --diff-summary-model, like --translate. Without
+// --dangerously-skip-permissions it cannot edit the repository.
+type modelSummarizer struct {
+	stats statsSummarizer
+}
+
+func (s modelSummarizer) summarize(base, head string) (*diffSummary, error) {
+	summary, err := s.stats.summarize(base, head)
+	if err != nil {
+		return nil, err
+	}
+	r := s.stats.r
+	diff, err := cappedDiff(r, base, head)
+	if err != nil {
+		return summary, err
+	}
+	args := []string{"--print", "--output-format", "text", "--model", r.opts.SummaryModel}
+	text, err := runSummaryCommand(r, r.opts.ClaudeBin, args, diffSummaryPrompt+diff, nil)
+	summary.Text = text
+	return summary, err
+}
+
+// commandSummarizer hands the diff to --diff-summary-cmd on stdin and takes
+// its output as the description. GHIR_DIFF_BASE and GHIR_DIFF_HEAD let the
+// command look at the change itself.
+type commandSummarizer struct {
+	stats statsSummarizer
+	cmd   string
+}
+
+func (s commandSummarizer) summarize(base, head string) (*diffSummary, error) {
+	summary, err := s.stats.summarize(base, head)
+	if err != nil {
+		return nil, err
+	}
+	r := s.stats.r
+	diff, err := cappedDiff(r, base, head)
+	if err != nil {
+		return summary, err
+	}
+	env := []string{"GHIR_DIFF_BASE=" + base, "GHIR_DIFF_HEAD=" + head}
+	text, err := runSummaryCommand(r, "sh", []string{"-c", s.cmd}, diff, env)
+	summary.Text = text
+	return summary, err
+}
+
+func cappedDiff(r *runner, base, head string) (string, error) {
+	diff, err := r.gitOutput("diff", "-M", base+".."+head)
+	if err != nil {
+		return "", err
+	}
+	if len(diff) > diffSummaryMaxDiffBytes {
+		diff = diff[:diffSummaryMaxDiffBytes] + "\n... (diff truncated)\n"
+	}
+	return diff, nil
+}
+
+func runSummaryCommand(r *runner, name string, args []string, stdin string, env []string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = r.repoRoot
+	cmd.Stdin = strings.NewReader(stdin)
+	if len(env) > 0 {
+		base := r.cacheEnv
+		if base == nil {
+			base = os.Environ()
+		}
+		cmd.Env = append(append([]string(nil), base...), env...)
+	} else {
+		cmd.Env = r.cacheEnv
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	started := r.now()
+	err := cmd.Run()
+	r.debugCommand(name, args, started, err)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	text := strings.TrimSpace(stdout.String())
+	if text == "" {
+		return "", errors.New("empty summary")
+	}
+	return text, nil
+}
+
+// diffSummarizer is the summarizer --diff-summary selects.
+func (r *runner) diffSummarizer() diffSummarizer {
+	stats := statsSummarizer{r: r}
+	switch r.opts.DiffSummary {
+	case diffSummaryModel:
+		return modelSummarizer{stats: stats}
+	case diffSummaryCommand:
+		return commandSummarizer{stats: stats, cmd: r.opts.DiffSummaryCmd}
+	}
+	return stats
+}
+
+// summarizeChanges describes what the issue's commits since base changed.
+// A summarizer that fails to describe the change leaves the stats; nothing
+// here fails the issue.
+func (r *runner) summarizeChanges(issue, base string) *diffSummary {
+	summary, err := r.diffSummarizer().summarize(base, "HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not summarize the change of #%s: %v\n", issue, err)
+	}
+	if summary != nil && len(summary.Files) > 0 {
+		r.printf(r.colors.Blue, "Changes: %s\n", summary.headline())
+	}
+	return summary
+}
+
+// prSummary is {{SUMMARY}} in the PR body: the summary under a heading, or
+// nothing.
+func (r *runner) prSummary(summary *diffSummary) string {
+	text := summary.markdown(r.tr)
+	if text == "" {
+		return ""
+	}
+	return r.tr("## Summary") + "\n\n" + text + "\n\n"
+}
+
+// runChanges collects the change summaries of the issues a run finished,
+// for the run_finished notification.
+func (r *runner) runChanges(outcomes []manifestOutcome) map[string]*diffSummary {
+	if r.state == nil {
+		return nil
+	}
+	changes := make(map[string]*diffSummary)
+	for _, outcome := range outcomes {
+		if outcome.Result != resultSuccess.String() {
+			continue
+		}
+		if st, ok := r.state.get(outcome.Issue); ok && st.Changes != nil {
+			changes[outcome.Issue] = st.Changes
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseNumstat(t *testing.T) {
+	t.Parallel()
+
+	summary := parseNumstat("2\t1\tREADME.md\n40\t3\tgreet.go\n-\t-\tlogo.png\n0\t7\told.go\n")
+	if summary.Added != 42 || summary.Deleted != 11 || len(summary.Files) != 4 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	want := "3 more words.\n\n4 file(s) changed, +42 -11:\n- `greet.go` (+40 -3)\n- `old.go` (+0 -7)\n- `README.md` (+2 -1)\n- `logo.png` (binary)"
+	summary.Text = "3 more words."
+	if got := summary.markdown((&runner{}).tr); got != want {
+		t.Fatalf("markdown() = %q, want %q", got, want)
+	}
+	if got := (*diffSummary)(nil).markdown((&runner{}).tr); got != "" {
+		t.Fatalf("nil markdown() = %q", got)
+	}
+	if got := (&runner{}).prSummary(parseNumstat("")); got != "" {
+		t.Fatalf("prSummary() of no change = %q", got)
+	}
+}
+
+func TestDiffSummarizers(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	base := runGit(t, repo, "rev-parse", "HEAD")
+	if err := os.WriteFile(filepath.Join(repo, "greet.go"), []byte("package main\n\nfunc greet() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "feat: greet")
+
+	claude := writeFakeBin(t, "claude", `cat > "$0.stdin"; echo "  Adds greet.  "`)
+	tests := []struct {
+		name     string
+		opts     options
+		wantText string
+		wantErr  string
+	}{
+		{name: "stats", opts: options{DiffSummary: diffSummaryStats}},
+		{name: "model", opts: options{DiffSummary: diffSummaryModel, SummaryModel: "haiku", ClaudeBin: claude}, wantText: "Adds greet."},
+		{name: "command", opts: options{DiffSummary: diffSummaryCommand, DiffSummaryCmd: `grep -c '^+func' | sed "s/^/Added func(s): /"; git log --format=%s "$GHIR_DIFF_BASE..$GHIR_DIFF_HEAD"`}, wantText: "Added func(s): 1\nfeat: greet"},
+		{name: "failing command", opts: options{DiffSummary: diffSummaryCommand, DiffSummaryCmd: "echo nope >&2; exit 3"}, wantErr: "nope"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{repoRoot: repo, opts: tt.opts}
+			summary, err := r.diffSummarizer().summarize(base, "HEAD")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			// The stats are there even when the description failed.
+			if summary == nil || summary.headline() != "1 file(s) changed, +3 -0" || summary.Files[0].Path != "greet.go" {
+				t.Fatalf("summary = %+v", summary)
+			}
+			if summary.Text != tt.wantText {
+				t.Fatalf("text = %q, want %q", summary.Text, tt.wantText)
+			}
+			if tt.opts.ClaudeBin == "" {
+				return
+			}
+			if data, err := os.ReadFile(claude + ".stdin"); err != nil || !strings.Contains(string(data), "+func greet() {}") {
+				t.Fatalf("model prompt = %q, %v; want the diff", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	environmentFileName = "environment.json"
+	envToolTimeout      = 5 * time.Second
+)
+
+// envTool is a version probe. The first non-empty output line is recorded.
+type envTool struct {
+	name string
+	args []string
+}
+
+var defaultEnvTools = []envTool{
+	{name: "git", args: []string{"git", "--version"}},
+	{name: "go", args: []string{"go", "version"}},
+	{name: "node", args: []string{"node", "--version"}},
+	{name: "npm", args: []string{"npm", "--version"}},
+	{name: "python3", args: []string{"python3", "--version"}},
+	{name: "rustc", args: []string{"rustc", "--version"}},
+	{name: "cargo", args: []string{"cargo", "--version"}},
+	{name: "java", args: []string{"java", "-version"}},
+	{name: "make", args: []string{"make", "--version"}},
+	{name: "docker", args: []string{"docker", "--version"}},
+}
+
+// envSnapshot is written to <run-dir>/environment.json for every run, and
+// the latest one to <log-dir>/environment.json to detect drift between runs.
+type envSnapshot struct {
+	CapturedAt  string            `json:"captured_at"`
+	Fingerprint string            `json:"fingerprint"`
+	Runner      string            `json:"runner_version"`
+	RunnerGo    string            `json:"runner_go"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	Kernel      string            `json:"kernel,omitempty"`
+	Distro      string            `json:"distro,omitempty"`
+	Hostname    string            `json:"hostname,omitempty"`
+	CPUs        int               `json:"cpus"`
+	Tools       map[string]string `json:"tools"`
+}
+
+// envTools adds the agent CLI, gh and any env_tools from the config to the
+// default probes.
+func (r *runner) envTools() []envTool {
+	tools := append([]envTool(nil), defaultEnvTools...)
+	tools = append(tools,
+		envTool{name: "agent:" + r.opts.Agent, args: []string{r.agentBin(), "--version"}},
+		envTool{name: "gh", args: []string{r.opts.GHBin, "--version"}},
+	)
+	for _, command := range r.opts.EnvTools {
+		if fields := strings.Fields(command); len(fields) > 0 {
+			tools = append(tools, envTool{name: fields[0], args: []string{"sh", "-c", command}})
+		}
+	}
+	return tools
+}
+
+func (r *runner) captureEnvironment() envSnapshot {
+	snap := envSnapshot{
+		CapturedAt: r.timestamp(r.now()),
+		Runner:     runnerVersion(),
+		RunnerGo:   runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		Tools:      probeTools(r.repoRoot, r.envTools()),
+	}
+	if out, err := exec.Command("uname", "-sr").Output(); err == nil {
+		snap.Kernel = strings.TrimSpace(string(out))
+	}
+	snap.Distro = osReleaseName("/etc/os-release")
+	if host, err := os.Hostname(); err == nil {
+		snap.Hostname = host
+	}
+	snap.Fingerprint = envFingerprint(snap)
+	return snap
+}
+
+// probeTools records the version line of every tool found on PATH; missing
+// tools are left out.
+func probeTools(dir string, tools []envTool) map[string]string {
+	versions := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool.args[0]); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), envToolTimeout)
+		cmd := exec.CommandContext(ctx, tool.args[0], tool.args[1:]...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		cancel()
+		if err != nil && len(out) == 0 {
+			versions[tool.name] = "error: " + err.Error()
+			continue
+		}
+		versions[tool.name] = firstLine(string(out))
+	}
+	return versions
+}
+
+func osReleaseName(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
+
+// envFingerprint hashes what should make results comparable: platform and
+// tool versions, not the host name or capture time.
+func envFingerprint(snap envSnapshot) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s\n", snap.OS, snap.Arch, snap.Kernel, snap.Distro, snap.Runner)
+	names := make([]string, 0, len(snap.Tools))
+	for name := range snap.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\n", name, snap.Tools[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// diffEnvironments describes what changed between two snapshots.
+func diffEnvironments(old, current envSnapshot) []string {
+	var changes []string
+	fields := []struct{ name, before, after string }{
+		{"runner", old.Runner, current.Runner},
+		{"os", old.OS + "/" + old.Arch, current.OS + "/" + current.Arch},
+		{"kernel", old.Kernel, current.Kernel},
+		{"distro", old.Distro, current.Distro},
+		{"host", old.Hostname, current.Hostname},
+	}
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", f.name, orNone(f.before), orNone(f.after)))
+		}
+	}
+	names := make(map[string]struct{})
+	for name := range old.Tools {
+		names[name] = struct{}{}
+	}
+	for name := range current.Tools {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if before, after := old.Tools[name], current.Tools[name]; before != after {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, orNone(before), orNone(after)))
+		}
+	}
+	return changes
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// recordEnvironment snapshots the environment into the run dir, reports drift
+// against the previous run and remembers the fingerprint for state.json.
+func (r *runner) recordEnvironment() {
+	snap := r.captureEnvironment()
+	r.envFingerprint = snap.Fingerprint
+	latest := filepath.Join(r.opts.LogDir, environmentFileName)
+
+	var previous envSnapshot
+	if data, err := os.ReadFile(latest); err == nil {
+		if json.Unmarshal(data, &previous) == nil && previous.Fingerprint != "" && previous.Fingerprint != snap.Fingerprint {
+			r.printf(r.colors.Yellow, "Environment changed since the last run (%s):\n", previous.CapturedAt)
+			for _, change := range diffEnvironments(previous, snap) {
+				r.printf(r.colors.Yellow, "  %s\n", change)
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		r.printf(r.colors.Yellow, "WARNING: could not read %s: %v\n", latest, err)
+	}
+	r.debugf(verbosityVerbose, "Environment %s: %d tool(s) found\n", snap.Fingerprint, len(snap.Tools))
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+	paths := []string{latest}
+	if r.runDir != "" {
+		paths = append(paths, filepath.Join(r.runDir, environmentFileName))
+	}
+	for _, path := range paths {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not write environment snapshot: %v\n", err)
+		}
+	}
+}
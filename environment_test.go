@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProbeTools(t *testing.T) {
+	t.Parallel()
+
+	tool := writeFakeBin(t, "tool", `echo
+echo "tool 1.2.3 (build abc)"
+echo "extra line"`)
+	broken := writeFakeBin(t, "broken", `exit 3`)
+	got := probeTools(t.TempDir(), []envTool{
+		{name: "tool", args: []string{tool, "--version"}},
+		{name: "broken", args: []string{broken}},
+		{name: "missing", args: []string{filepath.Join(t.TempDir(), "nope")}},
+	})
+	if got["tool"] != "tool 1.2.3 (build abc)" {
+		t.Fatalf("tool = %q", got["tool"])
+	}
+	if !strings.HasPrefix(got["broken"], "error: ") {
+		t.Fatalf("broken = %q", got["broken"])
+	}
+	if _, ok := got["missing"]; ok || len(got) != 2 {
+		t.Fatalf("tools = %v", got)
+	}
+}
+
+func TestDiffEnvironments(t *testing.T) {
+	t.Parallel()
+
+	old := envSnapshot{OS: "linux", Arch: "amd64", Hostname: "a", Tools: map[string]string{"go": "go1.22.1", "node": "v20.1.0"}}
+	current := envSnapshot{OS: "linux", Arch: "amd64", Hostname: "b", Tools: map[string]string{"go": "go1.22.3", "cargo": "cargo 1.78.0"}}
+	want := []string{
+		"host: a -> b",
+		"cargo: (none) -> cargo 1.78.0",
+		"go: go1.22.1 -> go1.22.3",
+		"node: v20.1.0 -> (none)",
+	}
+	if got := diffEnvironments(old, current); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("diff =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	sameTools := current
+	sameTools.Hostname = "c"
+	if envFingerprint(current) != envFingerprint(sameTools) {
+		t.Fatal("fingerprint depends on the host name")
+	}
+	if envFingerprint(old) == envFingerprint(current) {
+		t.Fatal("fingerprint ignores tool versions")
+	}
+}
+
+func TestRecordEnvironmentWritesSnapshotAndReportsDrift(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	previous := envSnapshot{CapturedAt: "2026-01-01T00:00:00Z", Fingerprint: "000000000000", OS: "plan9", Tools: map[string]string{}}
+	data, _ := json.Marshal(previous)
+	if err := os.WriteFile(filepath.Join(logDir, environmentFileName), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	r := &runner{opts: options{LogDir: logDir, Agent: "claude", ClaudeBin: "claude", GHBin: "gh"}, repoRoot: logDir}
+	r.stampedOut = newTimestampWriter(&out, func() string { return "" })
+	if err := r.startRunDir("20260102T030405Z"); err != nil {
+		t.Fatal(err)
+	}
+	r.recordEnvironment()
+
+	if len(r.envFingerprint) != 12 {
+		t.Fatalf("fingerprint = %q", r.envFingerprint)
+	}
+	if !strings.Contains(out.String(), "Environment changed since the last run") || !strings.Contains(out.String(), "os: plan9/ -> ") {
+		t.Fatalf("no drift report:\n%s", out.String())
+	}
+	for _, path := range []string{filepath.Join(logDir, environmentFileName), filepath.Join(r.runDir, environmentFileName)} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var snap envSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil || snap.Fingerprint != r.envFingerprint {
+			t.Fatalf("%s: %+v, %v", path, snap, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// bytesPerToken is the usual rule of thumb for English prose and code.
+const bytesPerToken = 4
+
+// agentContextFiles are the repo instruction files each agent reads on
+// start, on top of the prompt.
+var agentContextFiles = map[string][]string{
+	"claude":       {"CLAUDE.md"},
+	"codex":        {"AGENTS.md"},
+	"gemini":       {"GEMINI.md"},
+	"cursor-agent": {"AGENTS.md", ".cursorrules"},
+	"opencode":     {"AGENTS.md"},
+	"copilot":      {".github/copilot-instructions.md", "AGENTS.md"},
+	"qwen":         {"QWEN.md"},
+	"api":          contextFilesForAPI,
+	"local":        contextFilesForLocal,
+}
+
+// promptEstimate sums the dry-run prompts of one agent and model.
+type promptEstimate struct {
+	Agent        string
+	Model        string
+	Issues       int
+	PromptBytes  int
+	ContextBytes int
+}
+
+func (e promptEstimate) tokens() int {
+	return (e.PromptBytes + e.ContextBytes) / bytesPerToken
+}
+
+// estimatePrompt builds the prompt the agent would get for the issue and adds
+// it, with the agent's context files, to the dry-run estimate.
+func (r *runner) estimatePrompt(entry issueEntry, details issueDetails) {
+	prompt, err := r.buildPrompt(entry.ID, details)
+	if err != nil {
+		r.printf(r.colors.Yellow, "[DRY RUN] Cannot build the prompt for #%s: %v\n", entry.ID, err)
+		return
+	}
+	prompt = appendInstructions(prompt, entry.Instructions)
+	prompt = appendAcceptanceCriteria(prompt, parseAcceptanceCriteria(details.Body))
+	if r.promptOverride != "" {
+		prompt = r.promptOverride
+	}
+	contextBytes := 0
+	for _, name := range agentContextFiles[r.opts.Agent] {
+		if info, err := os.Stat(filepath.Join(r.repoRoot, name)); err == nil && !info.IsDir() {
+			contextBytes += int(info.Size())
+		}
+	}
+	r.printf("", "[DRY RUN] Prompt: %d bytes, context files: %d bytes (~%d tokens)\n", len(prompt), contextBytes, (len(prompt)+contextBytes)/bytesPerToken)
+
+	key := agentModelLabel(r.opts.Agent, r.opts.Model)
+	r.locked(func() {
+		if r.estimates == nil {
+			r.estimates = make(map[string]*promptEstimate)
+		}
+		e := r.estimates[key]
+		if e == nil {
+			e = &promptEstimate{Agent: r.opts.Agent, Model: r.opts.Model}
+			r.estimates[key] = e
+		}
+		e.Issues++
+		e.PromptBytes += len(prompt)
+		e.ContextBytes += contextBytes
+	})
+}
+
+// printEstimate prints the dry-run totals per agent and model. Prompt tokens
+// are counted from the prompts; the projected cost is the mean cost of issues
+// done earlier (see plan), since most of a session's tokens are the agent's
+// own reading and output.
+func (r *runner) printEstimate() {
+	if len(r.estimates) == 0 {
+		return
+	}
+	var history costHistory
+	if r.state != nil {
+		history = newCostHistory(r.state.snapshot())
+	}
+	keys := make([]string, 0, len(r.estimates))
+	for key := range r.estimates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	r.printf(r.colors.Blue, "Estimate for the queue (~%d bytes per token):\n", bytesPerToken)
+	var issues, tokens int
+	total := 0.0
+	for _, key := range keys {
+		e := r.estimates[key]
+		mean, basis := history.estimate(e.Agent, e.Model)
+		cost := mean * float64(e.Issues)
+		r.printf("", "  %-22s %3d issue(s)  ~%d prompt tokens  %s (%s)\n", truncateForConsole(key, 22), e.Issues, e.tokens(), formatCost(cost), basis)
+		issues += e.Issues
+		tokens += e.tokens()
+		total += cost
+	}
+	r.printf(r.colors.Blue, "Total: %d issue(s), ~%d prompt tokens, projected cost %s\n", issues, tokens, formatCost(total))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDryRunEstimatesPromptsPerAgent(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "CLAUDE.md"), []byte("Run go test before committing.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	template := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(template, []byte("Fix #{{ISSUE_NUMBER}}: {{ISSUE_TITLE}}\n{{ISSUE_BODY}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gh := writeFakeBin(t, "gh", `echo '{"title":"Issue '"$3"'","body":"body","state":"OPEN","labels":[]}'`)
+	opts := options{
+		Agent:          "claude",
+		Model:          "opus",
+		GHBin:          gh,
+		DryRun:         true,
+		PromptTemplate: template,
+	}
+	r := newTestRunner(t, repo, opts)
+	for _, entry := range []issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3", Agent: "codex"}} {
+		if result := r.processIssue(1, 3, entry); result != resultSuccess {
+			t.Fatalf("dry run of #%s = %v", entry.ID, result)
+		}
+	}
+
+	claude := r.estimates["claude/opus"]
+	prompt := len("Fix #1: Issue 1\nbody\n")
+	if claude == nil || claude.Issues != 2 || claude.PromptBytes != 2*prompt || claude.ContextBytes != 2*len("Run go test before committing.\n") {
+		t.Fatalf("claude estimate = %+v", claude)
+	}
+	codex := r.estimates["codex"]
+	if codex == nil || codex.Issues != 1 || codex.ContextBytes != 0 {
+		t.Fatalf("codex estimate = %+v", codex)
+	}
+	if got, want := claude.tokens(), (claude.PromptBytes+claude.ContextBytes)/bytesPerToken; got != want {
+		t.Fatalf("tokens = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	eventSinkStdout    = "stdout"
+	eventSinkFile      = "file:"
+	eventQueueSize     = 256
+	eventSinkTimeout   = 10 * time.Second
+	defaultNATSPort    = "4222"
+	defaultNATSSubject = "ghir.events"
+)
+
+// eventSink receives every run event (the entries of the run journal) as one
+// JSON object.
+type eventSink interface {
+	publish(event []byte) error
+	close() error
+}
+
+// eventBus fans run events out to the --events sinks. Each sink has its own
+// queue and goroutine, so a slow webhook never holds up the run; events
+// reach every sink in order.
+type eventBus struct {
+	sinks []*queuedSink
+}
+
+type queuedSink struct {
+	target string
+	sink   eventSink
+	queue  chan []byte
+	done   chan struct{}
+	warn   func(format string, args ...any)
+}
+
+func validateEventSink(target string) error {
+	switch {
+	case target == eventSinkStdout:
+		return nil
+	case strings.HasPrefix(target, eventSinkFile):
+		if strings.TrimPrefix(target, eventSinkFile) == "" {
+			return fmt.Errorf("event sink %q needs a path", target)
+		}
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("event sink must be stdout, file:<path>, an http(s) URL or nats://host[:port]/subject (got %q)", target)
+	}
+	switch u.Scheme {
+	case "http", "https", "nats":
+		return nil
+	}
+	return fmt.Errorf("unsupported event sink scheme %q (supported: http, https, nats)", u.Scheme)
+}
+
+// openEvents connects the configured sinks. A sink that cannot be opened only
+// warns; the run goes on without it.
+func (r *runner) openEvents() {
+	if len(r.opts.Events) == 0 {
+		return
+	}
+	bus := &eventBus{}
+	for _, target := range r.opts.Events {
+		sink, err := openEventSink(target)
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not open event sink %s: %s\n", redactURLs(target), redactURLs(err.Error()))
+			continue
+		}
+		q := &queuedSink{target: target, sink: sink, queue: make(chan []byte, eventQueueSize), done: make(chan struct{}), warn: func(format string, args ...any) {
+			r.printf(r.colors.Yellow, format, args...)
+		}}
+		go q.run()
+		bus.sinks = append(bus.sinks, q)
+	}
+	r.events = bus
+}
+
+func openEventSink(target string) (eventSink, error) {
+	switch {
+	case target == eventSinkStdout:
+		return writerSink{w: os.Stdout}, nil
+	case strings.HasPrefix(target, eventSinkFile):
+		f, err := os.OpenFile(strings.TrimPrefix(target, eventSinkFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return writerSink{w: f, closer: f.Close}, nil
+	case strings.HasPrefix(target, "nats://"):
+		return dialNATS(target)
+	default:
+		return webhookSink{url: target, client: &http.Client{Timeout: eventSinkTimeout}}, nil
+	}
+}
+
+func (b *eventBus) publish(event []byte) {
+	if b == nil {
+		return
+	}
+	for _, q := range b.sinks {
+		q.queue <- event
+	}
+}
+
+// close delivers what is queued and closes the sinks.
+func (b *eventBus) close() {
+	if b == nil {
+		return
+	}
+	for _, q := range b.sinks {
+		close(q.queue)
+		<-q.done
+	}
+}
+
+// run delivers the queued events. Delivery errors are reported once per
+// sink, not per event.
+func (q *queuedSink) run() {
+	defer close(q.done)
+	warned := false
+	for event := range q.queue {
+		if err := q.sink.publish(event); err != nil && !warned {
+			q.warn("WARNING: event sink %s failed: %s (further errors are not reported)\n", redactURLs(q.target), redactURLs(err.Error()))
+			warned = true
+		}
+	}
+	if err := q.sink.close(); err != nil && !warned {
+		q.warn("WARNING: event sink %s failed: %s\n", redactURLs(q.target), redactURLs(err.Error()))
+	}
+}
+
+// writerSink writes JSON lines (stdout, file:<path>).
+type writerSink struct {
+	w      interface{ Write([]byte) (int, error) }
+	closer func() error
+}
+
+func (s writerSink) publish(event []byte) error {
+	_, err := s.w.Write(append(append([]byte(nil), event...), '\n'))
+	return err
+}
+
+func (s writerSink) close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer()
+}
+
+// webhookSink POSTs every event as its own request.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s webhookSink) publish(event []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(event))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+func (s webhookSink) close() error { return nil }
+
+// natsSink publishes to a NATS subject over the plain text protocol
+// (INFO/CONNECT/PUB), which is all a publisher needs; JetStream streams
+// bound to the subject store the events.
+type natsSink struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	subject string
+}
+
+func dialNATS(target string) (*natsSink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultNATSPort)
+	}
+	subject := strings.Trim(u.Path, "/")
+	if subject == "" {
+		subject = defaultNATSSubject
+	}
+	conn, err := net.DialTimeout("tcp", host, eventSinkTimeout)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(eventSinkTimeout))
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		_ = conn.Close()
+		return nil, fmt.Errorf("not a NATS server (greeting %q): %v", strings.TrimSpace(info), err)
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	connect := map[string]any{"verbose": false, "pedantic": false, "name": "ghir", "lang": "go"}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			connect["user"], connect["pass"] = u.User.Username(), password
+		} else {
+			connect["auth_token"] = u.User.Username()
+		}
+	}
+	payload, _ := json.Marshal(connect)
+	sink := &natsSink{conn: conn, subject: subject}
+	if err := sink.write("CONNECT " + string(payload) + "\r\n"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	// Answer the server's keep-alive pings; everything else is ignored.
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "PING") {
+				_ = sink.write("PONG\r\n")
+			}
+		}
+	}()
+	return sink, nil
+}
+
+func (s *natsSink) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(eventSinkTimeout))
+	_, err := s.conn.Write([]byte(data))
+	return err
+}
+
+func (s *natsSink) publish(event []byte) error {
+	return s.write(fmt.Sprintf("PUB %s %d\r\n%s\r\n", s.subject, len(event), event))
+}
+
+func (s *natsSink) close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidateEventSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		target string
+		ok     bool
+	}{
+		{"stdout", true},
+		{"file:/tmp/events.jsonl", true},
+		{"file:", false},
+		{"https://hooks.example.com/ghir", true},
+		{"nats://localhost:4222/ghir.events", true},
+		{"nats://localhost", true},
+		{"kafka://broker:9092/topic", false},
+		{"events.jsonl", false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.target, func(t *testing.T) {
+			t.Parallel()
+			if err := validateEventSink(tt.target); (err == nil) != tt.ok {
+				t.Fatalf("validateEventSink(%q) = %v, want ok=%v", tt.target, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEventsReachFileAndWebhookSinks(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var posted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		mu.Lock()
+		posted = append(posted, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	logDir := t.TempDir()
+	file := filepath.Join(logDir, "events.jsonl")
+	r := &runner{opts: options{LogDir: logDir, Events: []string{"file:" + file, server.URL}, NoColor: true, Quiet: true}}
+	if err := r.openJournal("20260102T030405Z"); err != nil {
+		t.Fatal(err)
+	}
+	r.record(journalEntry{Event: journalIssueFetched, Issue: "5"})
+	r.record(journalEntry{Event: journalIssueFinished, Issue: "5", Result: "success"})
+	r.closeJournal()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e journalEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad event line %q: %v", line, err)
+		}
+		events = append(events, e.Event)
+	}
+	want := []string{journalIssueFetched, journalIssueFinished}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Fatalf("file events = %v, want %v", events, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != len(want) || !strings.Contains(posted[1], `"result":"success"`) {
+		t.Fatalf("webhook got %q", posted)
+	}
+}
+
+func TestNATSSinkPublishesToSubject(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		reader := bufio.NewReader(conn)
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				received <- lines
+				return
+			}
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+	}()
+
+	sink, err := openEventSink("nats://" + ln.Addr().String() + "/ci.ghir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.publish([]byte(`{"event":"run_started"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := <-received
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "CONNECT ") {
+		t.Fatalf("server got %q", lines)
+	}
+	if lines[1] != "PUB ci.ghir 23" || lines[2] != `{"event":"run_started"}` {
+		t.Fatalf("publish = %q", lines[1:])
+	}
+}
+
+func TestUnreachableEventSinkOnlyWarns(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{LogDir: t.TempDir(), Events: []string{"nats://127.0.0.1:1/x"}, NoColor: true, Quiet: true}}
+	if err := r.openJournal("20260102T030405Z"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events.sinks) != 0 {
+		t.Fatalf("sinks = %d, want 0", len(r.events.sinks))
+	}
+	r.record(journalEntry{Event: journalIssueFetched, Issue: "5"})
+	r.closeJournal()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	commandExperiment     = "experiment"
+	experimentsDirName    = "experiments"
+	experimentBranchRoot  = "ghir-experiment"
+	experimentMinTemplate = 2
+)
+
+type experimentRun struct {
+	Issue       string  `json:"issue"`
+	Result      string  `json:"result"`
+	Failure     string  `json:"failure,omitempty"`
+	Branch      string  `json:"branch"`
+	Commit      string  `json:"commit,omitempty"`
+	Tokens      int     `json:"tokens,omitempty"`
+	CostUSD     float64 `json:"cost_usd,omitempty"`
+	DurationSec float64 `json:"duration_sec,omitempty"`
+}
+
+type templateResult struct {
+	Name        string          `json:"name"`
+	Path        string          `json:"path"`
+	Passed      int             `json:"passed"`
+	Total       int             `json:"total"`
+	PassRate    float64         `json:"pass_rate"`
+	Tokens      int             `json:"tokens"`
+	CostUSD     float64         `json:"cost_usd"`
+	DurationSec float64         `json:"duration_sec"`
+	Runs        []experimentRun `json:"runs"`
+}
+
+func (t *templateResult) add(run experimentRun) {
+	t.Runs = append(t.Runs, run)
+	t.Total++
+	if run.Result == resultSuccess.String() {
+		t.Passed++
+	}
+	t.PassRate = float64(t.Passed) / float64(t.Total)
+	t.Tokens += run.Tokens
+	t.CostUSD += run.CostUSD
+	t.DurationSec += run.DurationSec
+}
+
+func parseTemplateList(value string) ([]string, error) {
+	var paths []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			paths = append(paths, part)
+		}
+	}
+	if len(paths) < experimentMinTemplate {
+		return nil, fmt.Errorf("--templates needs at least %d comma-separated templates", experimentMinTemplate)
+	}
+	return paths, nil
+}
+
+// templateName is the file name without extension; it names the branches and
+// the per-template log dir, so it must be unique within an experiment.
+func templateName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// rankTemplates orders templates best first: higher verified pass rate, then
+// lower cost (dollars when known, tokens otherwise), then less agent time.
+func rankTemplates(results []templateResult) []templateResult {
+	ranked := append([]templateResult(nil), results...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.PassRate != b.PassRate {
+			return a.PassRate > b.PassRate
+		}
+		if a.CostUSD != b.CostUSD {
+			return a.CostUSD < b.CostUSD
+		}
+		if a.Tokens != b.Tokens {
+			return a.Tokens < b.Tokens
+		}
+		return a.DurationSec < b.DurationSec
+	})
+	return ranked
+}
+
+// runExperiment runs every template against every issue of the queue. Each
+// run starts from the current HEAD on its own branch
+// (ghir-experiment/<stamp>/<template>/<issue>) and keeps its done file and
+// state under <log-dir>/experiments/<stamp>/<template>/, so the real queue is
+// left untouched.
+func (r *runner) runExperiment() error {
+	paths, err := parseTemplateList(r.opts.Templates)
+	if err != nil {
+		return err
+	}
+	results := make([]templateResult, 0, len(paths))
+	seen := make(map[string]string)
+	for _, path := range paths {
+		path = resolvePath(r.repoRoot, path)
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("prompt template: %w", err)
+		}
+		name := templateName(path)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("templates %s and %s share the name %q; rename one", other, path, name)
+		}
+		seen[name] = path
+		results = append(results, templateResult{Name: name, Path: path})
+	}
+	issues, err := r.loadIssues()
+	if err != nil {
+		return err
+	}
+	if dirty, err := r.workingTreeDirty(); err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("uncommitted changes detected; commit or stash before running an experiment")
+	}
+
+	stamp := r.fileStamp(r.now())
+	dir := filepath.Join(r.opts.LogDir, experimentsDirName, stamp)
+	runners := make([]*runner, len(results))
+	for i, res := range results {
+		opts := r.opts
+		opts.LogDir = filepath.Join(dir, res.Name)
+		opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+		opts.PromptTemplate = res.Path
+		opts.Force = true
+		if runners[i], err = newRunner(opts, r.repoRoot); err != nil {
+			return err
+		}
+	}
+
+	r.printf(r.colors.Blue, "Experiment %s: %d template(s) x %d issue(s)\n", stamp, len(results), len(issues))
+	// Issue-major order, so every template sees the same time of day and the
+	// same session-limit conditions for a given issue.
+	for i, entry := range issues {
+		for t := range results {
+			run := runners[t].experimentIssue(i+1, len(issues), entry, results[t], stamp)
+			results[t].add(run)
+		}
+	}
+
+	reportPath := filepath.Join(dir, "report.json")
+	ranked := rankTemplates(results)
+	data, err := json.MarshalIndent(map[string]any{"experiment": stamp, "templates": ranked}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode experiment report: %w", err)
+	}
+	if err := os.WriteFile(reportPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write experiment report: %w", err)
+	}
+	r.printExperimentReport(ranked)
+	r.printf("", "Report: %s\n", reportPath)
+	return nil
+}
+
+func (r *runner) experimentIssue(idx, total int, entry issueEntry, tmpl templateResult, stamp string) experimentRun {
+	entry.Branch = strings.Join([]string{experimentBranchRoot, stamp, tmpl.Name, entry.ID}, "/")
+	entry.PromptTemplate = tmpl.Path
+	r.printf(r.colors.Blue, "Template %s, branch %s\n", tmpl.Name, entry.Branch)
+	result := r.processIssue(idx, total, entry)
+	for result == resultRetry {
+		r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", entry.ID)
+		result = r.processIssue(idx, total, entry)
+	}
+	// A crashed agent can leave edits behind; park them so the next run
+	// starts from a clean tree.
+	if dirty, err := r.workingTreeDirty(); err == nil && dirty {
+		message := fmt.Sprintf("ghir experiment %s: leftovers of %s on #%s", stamp, tmpl.Name, entry.ID)
+		if _, err := r.gitOutput("stash", "push", "--include-untracked", "-m", message); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not stash leftover changes: %v\n", err)
+		} else {
+			r.printf(r.colors.Yellow, "Stashed leftover changes: %s\n", message)
+		}
+	}
+	run := experimentRun{Issue: entry.ID, Result: result.String(), Branch: entry.Branch}
+	if st, ok := r.state.get(entry.ID); ok {
+		run.Failure = st.Failure
+		run.Commit = st.Commit
+		run.Tokens = st.Tokens
+		run.CostUSD = st.CostUSD
+		run.DurationSec = st.DurationSec
+	}
+	return run
+}
+
+func (r *runner) printExperimentReport(ranked []templateResult) {
+	fmt.Fprintln(r.stdout())
+	r.rule(r.colors.Blue, "=")
+	r.printf(r.colors.Blue, "%-24s %9s %7s %10s %9s %10s\n", "Template", "Passed", "Rate", "Tokens", "Cost", "Agent time")
+	for _, res := range ranked {
+		r.printf("", "%-24s %9s %6.0f%% %10d %9s %10s\n",
+			res.Name,
+			fmt.Sprintf("%d/%d", res.Passed, res.Total),
+			res.PassRate*100,
+			res.Tokens,
+			fmt.Sprintf("$%.2f", res.CostUSD),
+			(time.Duration(res.DurationSec) * time.Second).String(),
+		)
+	}
+	r.rule(r.colors.Blue, "=")
+	if len(ranked) > 1 && ranked[0].PassRate == ranked[1].PassRate && ranked[0].Tokens == ranked[1].Tokens && ranked[0].CostUSD == ranked[1].CostUSD {
+		r.printf(r.colors.Yellow, "No clear winner between %s and %s; try a larger sample\n", ranked[0].Name, ranked[1].Name)
+		return
+	}
+	r.printf(r.colors.Green, "Best template: %s (%s)\n", ranked[0].Name, ranked[0].Path)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRankTemplates(t *testing.T) {
+	t.Parallel()
+
+	ranked := rankTemplates([]templateResult{
+		{Name: "slow", PassRate: 0.5, Tokens: 100, DurationSec: 90},
+		{Name: "weak", PassRate: 0.25, Tokens: 10},
+		{Name: "fast", PassRate: 0.5, Tokens: 100, DurationSec: 30},
+		{Name: "cheap", PassRate: 0.5, Tokens: 50, DurationSec: 120},
+	})
+	var names []string
+	for _, res := range ranked {
+		names = append(names, res.Name)
+	}
+	if got := strings.Join(names, ","); got != "cheap,fast,slow,weak" {
+		t.Fatalf("ranking = %s", got)
+	}
+}
+
+func TestExperimentOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"experiment", "--verify-cmd", "true"}, wantErr: "experiment requires --templates"},
+		{args: []string{"experiment", "--templates", "a.tmpl,b.tmpl"}, wantErr: "experiment requires --templates and --verify-cmd"},
+		{args: []string{"experiment", "--templates", "a.tmpl, ", "--verify-cmd", "true"}, wantErr: "at least 2"},
+		{args: []string{"experiment", "--templates", "a.tmpl,b.tmpl", "--verify-cmd", "true"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Parallel()
+			opts, err := parseArgs(tt.args)
+			if err == nil {
+				err = validateOptions(opts)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunExperimentComparesTemplatesOnBranches(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, `prompt=$(cat)
+case "$prompt" in
+*MAGIC*)
+	echo hi > greeting.txt
+	git add greeting.txt
+	git commit -q -m "feat: add greeting"
+	;;
+esac
+echo done`)
+	templates := t.TempDir()
+	plain := filepath.Join(templates, "plain.tmpl")
+	magic := filepath.Join(templates, "magic.tmpl")
+	if err := os.WriteFile(plain, []byte("Fix #{{ISSUE_NUMBER}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(magic, []byte("MAGIC fix #{{ISSUE_NUMBER}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		IssuesCSV: "5,6",
+		Templates: plain + "," + magic,
+		LogDir:    filepath.Join(t.TempDir(), "logs"),
+		VerifyCmd: "test -f greeting.txt",
+	}
+	r := newTestRunner(t, repo, opts)
+	if err := r.runExperiment(); err != nil {
+		t.Fatalf("runExperiment: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(repo, "greeting.txt")); !os.IsNotExist(err) {
+		t.Fatalf("experiment changed the original branch: %v", err)
+	}
+	if r.isCompleted("5") {
+		t.Fatal("experiment marked the real queue done")
+	}
+	branches := runGit(t, repo, "branch", "--list", experimentBranchRoot+"/*")
+	if strings.Count(branches, experimentBranchRoot+"/") != 4 {
+		t.Fatalf("branches = %q", branches)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(r.opts.LogDir, experimentsDirName, "*", "report.json"))
+	if len(reports) != 1 {
+		t.Fatalf("reports = %v", reports)
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Templates []templateResult `json:"templates"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Templates) != 2 || report.Templates[0].Name != "magic" || report.Templates[0].Passed != 2 || report.Templates[1].Passed != 0 {
+		t.Fatalf("report = %+v", report.Templates)
+	}
+	if run := report.Templates[1].Runs[0]; run.Failure != string(failureNoChanges) || !strings.HasSuffix(run.Branch, "/plain/5") {
+		t.Fatalf("plain run = %+v", run)
+	}
+}
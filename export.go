@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	exportFormatCSV     = "csv"
+	exportFormatParquet = "parquet"
+)
+
+var metricsColumns = []string{
+	"issue",
+	"title",
+	"status",
+	"agent",
+	"model",
+	"attempts",
+	"started_at",
+	"finished_at",
+	"duration_sec",
+	"tokens",
+	"cost_usd",
+	"commit",
+	"log_path",
+	"failure",
+	"environment",
+}
+
+func (r *runner) metricsRows() [][]string {
+	states := map[string]issueState{}
+	if r.state != nil {
+		states = r.state.snapshot()
+	}
+	ids := make([]string, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	for id := range r.doneSet {
+		if _, ok := states[id]; !ok {
+			ids = append(ids, id)
+			states[id] = issueState{Issue: id, Status: statusDone}
+		}
+	}
+	sortStringsNumeric(ids)
+
+	rows := make([][]string, 0, len(ids))
+	for _, id := range ids {
+		st := states[id]
+		status := st.Status
+		if r.isCompleted(id) && status != statusInProgress {
+			status = statusDone
+		}
+		rows = append(rows, []string{
+			id,
+			st.Title,
+			status,
+			st.Agent,
+			st.Model,
+			strconv.Itoa(st.Attempts),
+			st.StartedAt,
+			st.FinishedAt,
+			strconv.FormatFloat(st.DurationSec, 'f', -1, 64),
+			strconv.Itoa(st.Tokens),
+			strconv.FormatFloat(st.CostUSD, 'f', -1, 64),
+			st.Commit,
+			st.LogPath,
+			st.Failure,
+			st.Environment,
+		})
+	}
+	return rows
+}
+
+func writeMetricsCSV(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(metricsColumns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *runner) exportMetrics() error {
+	rows := r.metricsRows()
+
+	switch r.opts.ExportFormat {
+	case exportFormatCSV:
+		if r.opts.Out == "" || r.opts.Out == "-" {
+			return writeMetricsCSV(os.Stdout, rows)
+		}
+		f, err := os.Create(r.opts.Out)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", r.opts.Out, err)
+		}
+		if err := writeMetricsCSV(f, rows); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("write %s: %w", r.opts.Out, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		r.printf(r.colors.Green, "Exported %d issue(s) to %s\n", len(rows), r.opts.Out)
+		return nil
+	case exportFormatParquet:
+		return r.exportMetricsParquet(rows)
+	default:
+		return fmt.Errorf("unsupported export format: %s", r.opts.ExportFormat)
+	}
+}
+
+func (r *runner) exportMetricsParquet(rows [][]string) error {
+	if r.opts.Out == "" || r.opts.Out == "-" {
+		return fmt.Errorf("--format parquet requires --out <file>")
+	}
+	duckdb, err := exec.LookPath("duckdb")
+	if err != nil {
+		return fmt.Errorf("parquet export converts through the duckdb CLI, which was not found in PATH; use --format csv instead")
+	}
+
+	tmp, err := os.CreateTemp("", "ghir-metrics-*.csv")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if err := writeMetricsCSV(tmp, rows); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	out, err := filepath.Abs(r.opts.Out)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(
+		"COPY (SELECT * FROM read_csv(%s, header=true, all_varchar=false)) TO %s (FORMAT parquet)",
+		sqlQuote(tmp.Name()), sqlQuote(out),
+	)
+	if _, err := r.commandOutput(duckdb, "-c", query); err != nil {
+		return fmt.Errorf("convert metrics to parquet: %w", err)
+	}
+	r.printf(r.colors.Green, "Exported %d issue(s) to %s\n", len(rows), out)
+	return nil
+}
+
+func sqlQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
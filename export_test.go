@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportMetricsCSV(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	opts := options{
+		LogDir:       dir,
+		DoneFile:     filepath.Join(dir, defaultDoneFileName),
+		ExportFormat: exportFormatCSV,
+		Out:          filepath.Join(dir, "metrics.csv"),
+	}
+	if err := os.WriteFile(opts.DoneFile, []byte("3\n12\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRunner(t, dir, opts)
+	for _, st := range []issueState{
+		{Issue: "12", Title: "Fix, with comma", Status: statusDone, Agent: "codex", Attempts: 2, DurationSec: 90, Tokens: 1500},
+		{Issue: "7", Status: statusNeedsReview, Agent: "claude", Attempts: 1, Failure: string(failureVerification), Environment: "0123456789ab"},
+	} {
+		st := st
+		if err := r.state.update(st.Issue, func(s *issueState) { *s = st }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.exportMetrics(); err != nil {
+		t.Fatalf("exportMetrics: %v", err)
+	}
+	data, err := os.ReadFile(opts.Out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		strings.Join(metricsColumns, ","),
+		"3,,done,,,0,,,0,0,0,,,,",
+		"7,,needs-review,claude,,1,,,0,0,0,,,verification,0123456789ab",
+		`12,"Fix, with comma",done,codex,,2,,,90,1500,0,,,,`,
+	}, "\n") + "\n"
+	if string(data) != want {
+		t.Fatalf("csv mismatch:\ngot\n%s\nwant\n%s", data, want)
+	}
+}
+
+// TestExportMetricsParquetNeedsDuckDB changes PATH, so it does not run in
+// parallel.
+func TestExportMetricsParquetNeedsDuckDB(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "metrics.parquet")
+	r := newTestRunner(t, dir, options{LogDir: dir, ExportFormat: exportFormatParquet, Out: out})
+	err := r.exportMetrics()
+	if err == nil || !strings.Contains(err.Error(), "duckdb CLI, which was not found in PATH") {
+		t.Fatalf("exportMetrics() err = %v, want duckdb not found", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("%s written without duckdb: %v", out, err)
+	}
+
+	r.opts.Out = ""
+	if err := r.exportMetrics(); err == nil || !strings.Contains(err.Error(), "requires --out") {
+		t.Fatalf("exportMetrics() without --out err = %v", err)
+	}
+}
+
+func TestWriteMetricsCSVHeaderOnly(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := writeMetricsCSV(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != strings.Join(metricsColumns, ",")+"\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
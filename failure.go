@@ -0,0 +1,84 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+)
+
+type failureCategory string
+
+const (
+	failureFetch        failureCategory = "fetch"
+	failureAgentCrash   failureCategory = "agent-crash"
+	failureLimit        failureCategory = "limit"
+	failureTimeout      failureCategory = "timeout"
+	failureBuild        failureCategory = "build"
+	failureVerification failureCategory = "verification"
+	failureLint         failureCategory = "lint"
+	failureGate         failureCategory = "gate"
+	failureNoChanges    failureCategory = "no-changes"
+	failureGit          failureCategory = "git"
+	failureUnclassified failureCategory = "unclassified"
+
+	timeoutExitCode = 124
+)
+
+var agentQuotaPattern = regexp.MustCompile(`(?i)(quota|resource[ _]exhausted|usage limit|rate limit|too many requests)`)
+
+// apiQuotaPattern is an exhausted API quota or balance of the agents that
+// run on an API key (aider, opencode, api); unlike a rate limit it does not reset
+// on its own.
+var apiQuotaPattern = regexp.MustCompile(`(?i)(insufficient_quota|exceeded your current quota|credit balance is too low)`)
+
+func classifyAgentExit(logOutput, agent string, exitCode int) failureCategory {
+	if exitCode == timeoutExitCode {
+		return failureTimeout
+	}
+	if agent == "cursor-agent" && agentQuotaPattern.MatchString(logOutput) {
+		return failureLimit
+	}
+	if (agent == "aider" || agent == "opencode" || agent == "api") && apiQuotaPattern.MatchString(logOutput) {
+		return failureLimit
+	}
+	return failureAgentCrash
+}
+
+func (r *runner) recordFailure(issue string, category failureCategory) {
+	if category == "" {
+		category = failureUnclassified
+	}
+	r.locked(func() {
+		if r.failures != nil {
+			r.failures[issue] = category
+		}
+	})
+}
+
+func (r *runner) lastFailure(issue string) (issueState, bool) {
+	if r.state == nil {
+		return issueState{}, false
+	}
+	st, ok := r.state.get(issue)
+	if !ok || st.Failure == "" || (st.Status != statusFailed && st.Status != statusNeedsReview) {
+		return issueState{}, false
+	}
+	return st, true
+}
+
+func (r *runner) printFailureSummary() {
+	if len(r.failures) == 0 {
+		return
+	}
+	counts := make(map[failureCategory]int)
+	for _, category := range r.failures {
+		counts[category]++
+	}
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, string(category))
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		r.printf(r.colors.Red, "  %s: %d\n", category, counts[failureCategory(category)])
+	}
+}
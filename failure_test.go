@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestClassifyAgentExit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		log      string
+		agent    string
+		exitCode int
+		want     failureCategory
+	}{
+		{name: "crash", log: "panic: boom", agent: "claude", exitCode: 1, want: failureAgentCrash},
+		{name: "timeout", agent: "codex", exitCode: timeoutExitCode, want: failureTimeout},
+		{name: "cursor quota", log: "Error: monthly quota exceeded", agent: "cursor-agent", exitCode: 1, want: failureLimit},
+		{name: "cursor crash", log: "segfault", agent: "cursor-agent", exitCode: 2, want: failureAgentCrash},
+		{name: "aider quota", log: "Error: insufficient_quota", agent: "aider", exitCode: 1, want: failureLimit},
+		{name: "opencode balance", log: "Your credit balance is too low to access the API", agent: "opencode", exitCode: 1, want: failureLimit},
+	}
+
+	for _, tt := range tests {
+		if got := classifyAgentExit(tt.log, tt.agent, tt.exitCode); got != tt.want {
+			t.Fatalf("%s: classifyAgentExit() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProcessIssueRecordsFetchFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := writeFakeBin(t, "gh", `echo "HTTP 502" >&2; exit 1`)
+	opts := options{
+		GHBin: gh,
+	}
+	r := newTestRunner(t, repo, opts)
+
+	if result := r.processIssue(1, 1, issueEntry{ID: "4"}); result != resultFailed {
+		t.Fatalf("result = %v, want resultFailed", result)
+	}
+	if got := r.failures["4"]; got != failureFetch {
+		t.Fatalf("failure category = %q, want %q", got, failureFetch)
+	}
+}
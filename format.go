@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	formatFixCommit = "commit"
+	formatFixAmend  = "amend"
+)
+
+// formatterPresets are the formatters --formatter knows by name.
+var formatterPresets = map[string]string{
+	"gofmt":     "*.go=gofmt -w",
+	"goimports": "*.go=goimports -w",
+	"prettier":  "*.js,*.jsx,*.mjs,*.cjs,*.ts,*.tsx,*.css,*.scss,*.html,*.json,*.vue=prettier --write",
+	"black":     "*.py=black -q",
+}
+
+// formatter runs Cmd with the changed files matching one of Globs as its
+// arguments.
+type formatter struct {
+	Globs []string
+	Cmd   string
+}
+
+// parseFormatter reads a preset name or "<glob>[,<glob>...]=<command>".
+func parseFormatter(spec string) (formatter, error) {
+	if preset, ok := formatterPresets[spec]; ok {
+		spec = preset
+	}
+	globs, cmd, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(globs) == "" || strings.TrimSpace(cmd) == "" {
+		return formatter{}, fmt.Errorf("formatter %q must be one of %s or <glob>[,<glob>...]=<command>", spec, strings.Join(sortedKeys(formatterPresets), ", "))
+	}
+	f := formatter{Cmd: strings.TrimSpace(cmd)}
+	for _, glob := range strings.Split(globs, ",") {
+		glob = strings.TrimSpace(glob)
+		if _, err := path.Match(glob, ""); err != nil || glob == "" {
+			return formatter{}, fmt.Errorf("formatter %q: bad pattern %q", spec, glob)
+		}
+		f.Globs = append(f.Globs, glob)
+	}
+	return f, nil
+}
+
+// matches compares a repository path with the globs, by its full path or by
+// its base name.
+func (f formatter) matches(file string) bool {
+	for _, glob := range f.Globs {
+		if ok, _ := path.Match(glob, file); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, path.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFiles runs every --formatter over the files it matches. Deleted
+// files are left out; a failing formatter is reported and the others still
+// run.
+func (r *runner) formatFiles(files []string) {
+	for _, spec := range r.opts.Formatters {
+		f, err := parseFormatter(spec)
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+			continue
+		}
+		var matched []string
+		for _, file := range files {
+			if !f.matches(file) {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(r.repoRoot, file)); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			matched = append(matched, file)
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		cmd := exec.Command("sh", append([]string{"-c", f.Cmd + ` "$@"`, "sh"}, matched...)...)
+		cmd.Dir = r.repoRoot
+		cmd.Env = r.cacheEnv
+		if out, err := cmd.CombinedOutput(); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: formatter %q failed: %v\n", f.Cmd, err)
+			for _, line := range compactMultiline(tailLines(string(out), 10), 10, 2000) {
+				r.printf(r.colors.Yellow, "  %s\n", line)
+			}
+		}
+	}
+}
+
+// formatStaged formats the staged files before the runner commits for the
+// agent, and stages the result.
+func (r *runner) formatStaged() error {
+	if len(r.opts.Formatters) == 0 {
+		return nil
+	}
+	staged, err := r.gitOutput("diff", "--cached", "--name-only", "--diff-filter=ACMR")
+	if err != nil {
+		return err
+	}
+	r.formatFiles(nonEmptyLines(staged))
+	_, err = r.gitOutput("add", "-A")
+	return err
+}
+
+// formatCommits formats what the agent's own commits since base changed.
+// When that changes anything, the result is committed on top, or folded into
+// the last commit with --format-fix amend. It returns the new HEAD, or ""
+// when nothing was committed.
+func (r *runner) formatCommits(issue, base string) string {
+	if len(r.opts.Formatters) == 0 {
+		return ""
+	}
+	changed, err := r.gitOutput("diff", "--name-only", "--diff-filter=ACMR", base+"..HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not list the files #%s changed for formatting: %v\n", issue, err)
+		return ""
+	}
+	files := nonEmptyLines(changed)
+	if len(files) == 0 {
+		return ""
+	}
+	r.formatFiles(files)
+	reformatted, err := r.gitOutput(append([]string{"diff", "--name-only", "--"}, files...)...)
+	if err != nil || strings.TrimSpace(reformatted) == "" {
+		return ""
+	}
+	if _, err := r.gitOutput(append([]string{"add", "--"}, nonEmptyLines(reformatted)...)...); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not stage the formatting of #%s: %v\n", issue, err)
+		return ""
+	}
+	args := []string{"commit", "--no-verify", "-m", fmt.Sprintf(r.tr("style: format #%s"), issue)}
+	if r.opts.FormatFix == formatFixAmend {
+		args = []string{"commit", "--no-verify", "--amend", "--no-edit"}
+	}
+	if _, err := r.gitOutput(args...); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not commit the formatting of #%s: %v\n", issue, err)
+		return ""
+	}
+	head, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	r.printf(r.colors.Blue, "Formatted %d file(s) of #%s\n", len(nonEmptyLines(reformatted)), issue)
+	return head
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
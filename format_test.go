@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFormatter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec      string
+		wantGlobs string
+		wantCmd   string
+		wantErr   string
+	}{
+		{spec: "gofmt", wantGlobs: "*.go", wantCmd: "gofmt -w"},
+		{spec: "black", wantGlobs: "*.py", wantCmd: "black -q"},
+		{spec: "*.ts, web/*.css = npx prettier --write", wantGlobs: "*.ts,web/*.css", wantCmd: "npx prettier --write"},
+		{spec: "rustfmt", wantErr: "must be one of black, gofmt, goimports, prettier"},
+		{spec: "*.go=", wantErr: "must be one of"},
+		{spec: "[.go=gofmt -w", wantErr: "bad pattern"},
+	}
+	for _, tt := range tests {
+		f, err := parseFormatter(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parseFormatter(%q) = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || strings.Join(f.Globs, ",") != tt.wantGlobs || f.Cmd != tt.wantCmd {
+			t.Fatalf("parseFormatter(%q) = %+v, %v", tt.spec, f, err)
+		}
+	}
+
+	f, _ := parseFormatter("*.go,web/*.ts=fmt")
+	for file, want := range map[string]bool{"main.go": true, "cmd/app/main.go": true, "web/app.ts": true, "web/sub/app.ts": false, "README.md": false} {
+		if got := f.matches(file); got != want {
+			t.Fatalf("matches(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestFormatBeforeCommit(t *testing.T) {
+	t.Parallel()
+
+	commitAgent := "git add -A\ngit commit -q -m \"feat: add notes (#5)\""
+	tests := []struct {
+		name        string
+		agentCommit string
+		formatFix   string
+		wantSubject string
+	}{
+		{name: "runner commit", wantSubject: "feat: implement #5 - Add notes"},
+		{name: "agent commit", agentCommit: commitAgent, formatFix: formatFixCommit, wantSubject: "style: format #5\nfeat: add notes (#5)"},
+		{name: "agent commit amended", agentCommit: commitAgent, formatFix: formatFixAmend, wantSubject: "feat: add notes (#5)"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := fakeIssueGH(t, "Add notes", "add notes")
+			agent := fakeAgent(t, "printf 'a  \\n' > notes.txt\nprintf 'b  \\n' > keep.md\n"+tt.agentCommit)
+			// The formatter strips trailing blanks from the files it is given.
+			strip := writeFakeBin(t, "strip", `for f in "$@"; do sed 's/ *$//' "$f" > "$f.tmp" && mv "$f.tmp" "$f"; done`)
+			opts := options{
+				Agent:       "claude",
+				ClaudeBin:   agent,
+				GHBin:       gh,
+				SingleIssue: "5",
+				Formatters:  []string{"*.txt=" + strip},
+				FormatFix:   tt.formatFix,
+			}
+			r := newTestRunner(t, repo, opts)
+			r.runQueue()
+			if got := r.failures["5"]; got != "" {
+				t.Fatalf("failure = %q", got)
+			}
+
+			if got := runGit(t, repo, "log", "--format=%s"); got != tt.wantSubject+"\ninit" {
+				t.Fatalf("subjects = %q, want %q", got, tt.wantSubject)
+			}
+			if data, err := os.ReadFile(filepath.Join(repo, "keep.md")); err != nil || string(data) != "b  \n" {
+				t.Fatalf("keep.md = %q, %v; want it left alone", data, err)
+			}
+			if status := runGit(t, repo, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+				t.Fatalf("working tree not clean: %q", status)
+			}
+			if data, err := os.ReadFile(filepath.Join(repo, "notes.txt")); err != nil || string(data) != "a\n" {
+				t.Fatalf("notes.txt = %q, %v", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	commandFreeze       = "freeze"
+	commandThaw         = "thaw"
+	frozenQueueFileName = "queue.frozen.json"
+)
+
+// Flags that resolve the queue; they are ignored while it is frozen, so
+// passing one is an error rather than a silent no-op.
+var queueSourceFlags = []string{"--issues", "--issues-file", "--sarif", "--junit", "--workflow", "--source", "--todo-tags", "--todo-path", "--sentry-project", "--sentry-limit", "--assigned-to-me", "--assignee", "--priority-labels"}
+
+// frozenQueue is <log-dir>/queue.frozen.json: the queue as it was resolved
+// by `ghir freeze`, used by every later run until `ghir thaw`.
+type frozenQueue struct {
+	FrozenAt string        `json:"frozen_at"`
+	Entries  []frozenEntry `json:"entries"`
+}
+
+// frozenEntry keeps the title and body of synthetic tasks, which the issue
+// file format leaves out.
+type frozenEntry struct {
+	issueEntryFields
+	Source string `json:"source,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+func (r *runner) frozenQueuePath() string {
+	return filepath.Join(r.opts.LogDir, frozenQueueFileName)
+}
+
+// loadFrozenQueue returns the frozen queue, or false when the queue is not
+// frozen.
+func (r *runner) loadFrozenQueue() (frozenQueue, bool, error) {
+	var queue frozenQueue
+	data, err := os.ReadFile(r.frozenQueuePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return queue, false, nil
+		}
+		return queue, false, fmt.Errorf("read frozen queue: %w", err)
+	}
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return queue, false, fmt.Errorf("parse frozen queue %s: %w", r.frozenQueuePath(), err)
+	}
+	return queue, true, nil
+}
+
+func (q frozenQueue) issueEntries() []issueEntry {
+	entries := make([]issueEntry, 0, len(q.Entries))
+	for _, frozen := range q.Entries {
+		entry := issueEntry(frozen.issueEntryFields)
+		entry.Source, entry.Title, entry.Body = frozen.Source, frozen.Title, frozen.Body
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// frozenIssues is the frozen queue, or nil when the queue is dynamic.
+func (r *runner) frozenIssues() ([]issueEntry, error) {
+	queue, ok, err := r.loadFrozenQueue()
+	if err != nil || !ok {
+		return nil, err
+	}
+	for _, flag := range queueSourceFlags {
+		if r.opts.flagSet(flag) {
+			return nil, fmt.Errorf("%s has no effect: the queue was frozen at %s (run ghir thaw to resolve it again)", flag, queue.FrozenAt)
+		}
+	}
+	r.printf(r.colors.Blue, "Using the queue frozen at %s (%d issue(s)); run ghir thaw to resolve it again\n", queue.FrozenAt, len(queue.Entries))
+	return queue.issueEntries(), nil
+}
+
+// freezeQueue resolves the queue once (issue file, assignee, scanners, priority
+// labels) and writes it to the frozen queue file, so label or assignment
+// changes during a long batch do not change which issues it runs.
+func (r *runner) freezeQueue() error {
+	if existing, ok, err := r.loadFrozenQueue(); err != nil {
+		return err
+	} else if ok && !r.opts.Force {
+		return fmt.Errorf("the queue is already frozen (since %s); use --force to freeze it again or run ghir thaw", existing.FrozenAt)
+	}
+	entries, err := r.loadIssueEntries()
+	if err != nil {
+		return err
+	}
+	if entries, err = r.orderByPriority(entries); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("the queue is empty, nothing to freeze")
+	}
+
+	queue := frozenQueue{FrozenAt: r.timestamp(r.now())}
+	for _, entry := range entries {
+		queue.Entries = append(queue.Entries, frozenEntry{
+			issueEntryFields: issueEntryFields(entry),
+			Source:           entry.Source,
+			Title:            entry.Title,
+			Body:             entry.Body,
+		})
+	}
+	data, err := json.MarshalIndent(queue, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.opts.LogDir, 0o755); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+	if err := os.WriteFile(r.frozenQueuePath(), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write frozen queue: %w", err)
+	}
+	r.printf(r.colors.Green, "Froze the queue: %d issue(s) in %s\n", len(entries), r.frozenQueuePath())
+	return nil
+}
+
+func (r *runner) thawQueue() error {
+	err := os.Remove(r.frozenQueuePath())
+	if errors.Is(err, os.ErrNotExist) {
+		r.printf(r.colors.Yellow, "The queue is not frozen\n")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("remove frozen queue: %w", err)
+	}
+	r.printf(r.colors.Green, "Thawed the queue: runs resolve it again from the configured sources\n")
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFreezeAndThawQueue(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	issuesFile := filepath.Join(t.TempDir(), "issues.txt")
+	if err := os.WriteFile(issuesFile, []byte("3\n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := options{IssuesFile: issuesFile, LogDir: filepath.Join(t.TempDir(), "logs")}
+	r := newTestRunner(t, repo, opts)
+
+	if err := r.freezeQueue(); err != nil {
+		t.Fatalf("freezeQueue() error = %v", err)
+	}
+	if err := r.freezeQueue(); err == nil || !strings.Contains(err.Error(), "already frozen") {
+		t.Fatalf("second freezeQueue() error = %v, want already frozen", err)
+	}
+	if err := os.WriteFile(issuesFile, []byte("7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := r.loadIssues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(issues) != "3,1" {
+		t.Fatalf("frozen queue = %s, want 3,1", ids(issues))
+	}
+
+	r.opts.explicit = map[string]struct{}{"--issues": {}}
+	if _, err := r.loadIssues(); err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Fatalf("loadIssues() with --issues error = %v, want frozen queue error", err)
+	}
+	r.opts.explicit = nil
+
+	if err := r.thawQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if issues, err = r.loadIssues(); err != nil || ids(issues) != "7" {
+		t.Fatalf("thawed queue = %s (%v), want 7", ids(issues), err)
+	}
+}
+
+func TestFrozenQueueKeepsSyntheticTasks(t *testing.T) {
+	t.Parallel()
+
+	queue := frozenQueue{FrozenAt: "2026-01-02T03:04:05Z"}
+	entry := issueEntry{ID: "todo-0123456789", Branch: "fix/todo", Source: sourceTodos, Title: "TODO in main.go", Body: "details"}
+	queue.Entries = append(queue.Entries, frozenEntry{issueEntryFields: issueEntryFields(entry), Source: entry.Source, Title: entry.Title, Body: entry.Body})
+
+	data, err := json.Marshal(queue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded frozenQueue
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	got := loaded.issueEntries()
+	if len(got) != 1 || got[0] != entry {
+		t.Fatalf("issueEntries() = %+v, want %+v", got, entry)
+	}
+}
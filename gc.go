@@ -0,0 +1,345 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commandGC      = "gc"
+	defaultGCKeep  = "30d"
+	zstdBinaryName = "zstd"
+)
+
+// gcItem is one run artifact older than the retention period.
+type gcItem struct {
+	path     string
+	rel      string
+	kind     string
+	modified time.Time
+	size     int64
+}
+
+// gcSubdirs hold one artifact per entry.
+var gcSubdirs = map[string]string{
+	diagnosticsDirName:  "diagnostics",
+	refinementsDirName:  "refinement",
+	reproDirName:        "repro",
+	experimentsDirName:  "experiment",
+	worktreesDirName:    "worktree",
+	translationsDirName: "translation",
+}
+
+// parseRetention reads --keep: a number of days (30d) or weeks (2w), or a Go
+// duration (36h).
+func parseRetention(value string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	switch {
+	case strings.HasSuffix(value, "d"), strings.HasSuffix(value, "w"):
+		var n int
+		n, err = strconv.Atoi(strings.TrimRight(value, "dw"))
+		d = time.Duration(n) * 24 * time.Hour
+		if strings.HasSuffix(value, "w") {
+			d *= 7
+		}
+	default:
+		d, err = time.ParseDuration(value)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("--keep must be a positive number of days (30d), weeks (2w) or a duration (36h): %q", value)
+	}
+	return d, nil
+}
+
+// gcCandidates lists the run artifacts in the log dir that were last written
+// before the cutoff: run directories, run journals, top-level logs and the
+// entries of the diagnostics, refinements, repro, experiments and worktrees
+// directories. State, the done file and anything else are never touched.
+func (r *runner) gcCandidates(cutoff time.Time) ([]gcItem, error) {
+	entries, err := os.ReadDir(r.opts.LogDir)
+	if err != nil {
+		return nil, fmt.Errorf("read log dir: %w", err)
+	}
+	var items []gcItem
+	add := func(path, kind string) error {
+		modified, size, err := newestModTime(path)
+		if err != nil {
+			return err
+		}
+		if modified.Before(cutoff) {
+			rel, _ := filepath.Rel(r.opts.LogDir, path)
+			items = append(items, gcItem{path: path, rel: filepath.ToSlash(rel), kind: kind, modified: modified, size: size})
+		}
+		return nil
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(r.opts.LogDir, name)
+		switch {
+		case entry.IsDir() && gcSubdirs[name] != "":
+			children, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				if err := add(filepath.Join(path, child.Name()), gcSubdirs[name]); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case entry.IsDir() && isFileStamp(name):
+			err = add(path, "run")
+		case !entry.IsDir() && strings.HasPrefix(name, "run-") && strings.HasSuffix(name, ".jsonl"):
+			err = add(path, "journal")
+		case !entry.IsDir() && strings.HasSuffix(name, ".log"):
+			err = add(path, "log")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].rel < items[j].rel })
+	return items, nil
+}
+
+func isFileStamp(name string) bool {
+	_, err := time.Parse(fileStampLayout, name)
+	return err == nil
+}
+
+// newestModTime is the latest modification time of the files under path,
+// so a run directory ages from its last write, and their total size. An
+// empty directory ages from its own time.
+func newestModTime(path string) (time.Time, int64, error) {
+	var own, newest time.Time
+	var size int64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if p == path {
+			own = info.ModTime()
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+			if info.ModTime().After(newest) {
+				newest = info.ModTime()
+			}
+		}
+		return nil
+	})
+	if newest.IsZero() {
+		newest = own
+	}
+	return newest, size, err
+}
+
+func (r *runner) runGC() error {
+	keep, err := parseRetention(r.opts.GCKeep)
+	if err != nil {
+		return err
+	}
+	items, err := r.gcCandidates(time.Now().Add(-keep))
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		r.printf(r.colors.Green, "Nothing in %s is older than %s\n", r.opts.LogDir, r.opts.GCKeep)
+		return nil
+	}
+	var total int64
+	for _, item := range items {
+		total += item.size
+		prefix := "Removing"
+		if r.opts.DryRun {
+			prefix = "[DRY RUN] Would remove"
+		}
+		r.printf("", "%s %-10s %s (%s, last written %s)\n", prefix, item.kind, item.rel, formatSize(item.size), r.timestamp(item.modified))
+	}
+	if r.opts.DryRun {
+		r.printf(r.colors.Yellow, "[DRY RUN] %d item(s), %s\n", len(items), formatSize(total))
+		return nil
+	}
+
+	if r.opts.GCArchive != "" {
+		if err := writeGCArchive(r.opts.GCArchive, items); err != nil {
+			return err
+		}
+		r.printf(r.colors.Green, "Archived %d item(s) to %s\n", archivedCount(items), r.opts.GCArchive)
+	}
+	removed := 0
+	for _, item := range items {
+		if item.kind == "worktree" {
+			// git keeps a record of every worktree; the branch stays.
+			if _, err := r.gitOutput("worktree", "remove", "--force", item.path); err != nil {
+				r.printf(r.colors.Yellow, "WARNING: could not remove worktree %s: %v\n", item.rel, err)
+				continue
+			}
+		} else if err := os.RemoveAll(item.path); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not remove %s: %v\n", item.rel, err)
+			continue
+		}
+		removed++
+	}
+	r.printf(r.colors.Green, "Removed %d item(s), %s, older than %s\n", removed, formatSize(total), r.opts.GCKeep)
+	return nil
+}
+
+func archivedCount(items []gcItem) int {
+	n := 0
+	for _, item := range items {
+		if item.kind != "worktree" {
+			n++
+		}
+	}
+	return n
+}
+
+// writeGCArchive packs the items, except worktrees whose commits live on
+// their branches, into a tar archive compressed by its extension: .tar.zst
+// (with the zstd command), .tar.gz or .tgz, or .tar. An existing archive is
+// not overwritten.
+func writeGCArchive(path string, items []gcItem) (err error) {
+	if _, statErr := os.Stat(path); statErr == nil {
+		return fmt.Errorf("archive %s already exists", path)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	var out io.WriteCloser
+	var wait func() error
+	switch {
+	case strings.HasSuffix(path, ".tar.zst"), strings.HasSuffix(path, ".tzst"):
+		if _, lookErr := exec.LookPath(zstdBinaryName); lookErr != nil {
+			return fmt.Errorf("%s archives need the zstd command; install it or use a .tar.gz archive", filepath.Ext(path))
+		}
+		cmd := exec.Command(zstdBinaryName, "-q", "-c")
+		cmd.Stdout = f
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if out, err = cmd.StdinPipe(); err != nil {
+			return err
+		}
+		if err = cmd.Start(); err != nil {
+			return fmt.Errorf("start zstd: %w", err)
+		}
+		wait = func() error {
+			if err := cmd.Wait(); err != nil {
+				return fmt.Errorf("zstd: %v: %s", err, strings.TrimSpace(stderr.String()))
+			}
+			return nil
+		}
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		out = gzip.NewWriter(f)
+	case strings.HasSuffix(path, ".tar"):
+		out = nopWriteCloser{f}
+	default:
+		return fmt.Errorf("archive must end in .tar.zst, .tar.gz, .tgz or .tar: %s", path)
+	}
+
+	tw := tar.NewWriter(out)
+	for _, item := range items {
+		if item.kind == "worktree" {
+			continue
+		}
+		if err = addToTar(tw, item.path, item.rel); err != nil {
+			_ = out.Close()
+			if wait != nil {
+				_ = wait()
+			}
+			return fmt.Errorf("archive %s: %w", item.rel, err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if wait != nil {
+		return wait()
+	}
+	return nil
+}
+
+func addToTar(tw *tar.Writer, root, rel string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+		sub, _ := filepath.Rel(root, p)
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(rel, sub))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, src)
+		if closeErr := src.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n), []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(suffix)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix[i])
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "2w", want: 14 * 24 * time.Hour},
+		{value: "36h", want: 36 * time.Hour},
+		{value: "0d", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseRetention(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("parseRetention(%q) = %v, %v", tt.value, got, err)
+			}
+		})
+	}
+}
+
+func TestGCArchivesAndRemovesOldRunData(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	logDir := filepath.Join(t.TempDir(), "logs")
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	write := func(rel string, aged bool) {
+		t.Helper()
+		path := filepath.Join(logDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := rel
+		if rel == "state.json" {
+			content = "{}"
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if aged {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write("20260101T020000Z/5.attempt-1.log", true)
+	write("20260101T020000Z/5.attempt-1.prompt.md", true)
+	write("run-20260101T020000Z.jsonl", true)
+	write("diagnostics/20260101T020000Z-issue-5.zip", true)
+	write("5.verify.log", true)
+	write("state.json", true)
+	write(".completed", true)
+	write("20260301T020000Z/6.attempt-1.log", false)
+
+	archive := filepath.Join(t.TempDir(), "old.tar.gz")
+	opts := options{LogDir: logDir, GCKeep: "30d", GCArchive: archive}
+	opts.DoneFile = filepath.Join(logDir, defaultDoneFileName)
+	r := newTestRunner(t, repo, opts)
+	if err := r.runGC(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rel := range []string{"20260101T020000Z", "run-20260101T020000Z.jsonl", "diagnostics/20260101T020000Z-issue-5.zip", "5.verify.log"} {
+		if _, err := os.Stat(filepath.Join(logDir, rel)); !os.IsNotExist(err) {
+			t.Fatalf("%s was not removed (%v)", rel, err)
+		}
+	}
+	for _, rel := range []string{"state.json", ".completed", "20260301T020000Z/6.attempt-1.log"} {
+		if _, err := os.Stat(filepath.Join(logDir, rel)); err != nil {
+			t.Fatalf("%s should be kept: %v", rel, err)
+		}
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var files []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			files = append(files, hdr.Name)
+		}
+	}
+	sort.Strings(files)
+	want := []string{"20260101T020000Z/5.attempt-1.log", "20260101T020000Z/5.attempt-1.prompt.md", "5.verify.log", "diagnostics/20260101T020000Z-issue-5.zip", "run-20260101T020000Z.jsonl"}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Fatalf("archive = %v, want %v", files, want)
+	}
+	if err := writeGCArchive(archive, nil); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("writeGCArchive over an existing archive = %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGHWriteIntervalSec = 1
+	ghWriteRetries            = 3
+	ghSecondaryLimitWait      = time.Minute
+)
+
+var (
+	secondaryRateLimitPattern = regexp.MustCompile(`(?i)secondary rate limit|abuse detection|submitted too quickly`)
+	retryAfterPattern         = regexp.MustCompile(`(?i)\bretry-after:\s*(\d+)\b`)
+)
+
+// ghWriter paces the GitHub writes of a run (comments, labels, assignees,
+// PRs, closing and reopening issues). GitHub's secondary rate limits punish
+// bursts of mutations, which a long queue produces when every issue finishes
+// with a label change, a comment and a PR. Writes go out one at a time, at
+// least --gh-write-interval apart, across all parallel workers.
+type ghWriter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+	sleep    func(time.Duration)
+}
+
+func newGHWriter(intervalSec int) *ghWriter {
+	return &ghWriter{interval: time.Duration(intervalSec) * time.Second, sleep: time.Sleep}
+}
+
+// ghWrite runs a mutating gh command. When GitHub answers with a secondary
+// rate limit it backs off (for the Retry-After GitHub asked for, else one
+// minute, doubling) and tries again up to ghWriteRetries times; every other
+// write waits meanwhile.
+func (r *runner) ghWrite(args ...string) (string, error) {
+	w := r.writes
+	if w == nil {
+		return r.commandOutput(r.opts.GHBin, args...)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	backoff := ghSecondaryLimitWait
+	for attempt := 1; ; attempt++ {
+		if wait := w.interval - time.Since(w.last); wait > 0 {
+			w.sleep(wait)
+		}
+		out, err := r.commandOutput(r.opts.GHBin, args...)
+		w.last = time.Now()
+		if err == nil || attempt > ghWriteRetries || !secondaryRateLimitPattern.MatchString(err.Error()) {
+			return out, err
+		}
+		wait := backoff
+		if m := retryAfterPattern.FindStringSubmatch(err.Error()); m != nil {
+			if sec, convErr := strconv.Atoi(m[1]); convErr == nil && sec > 0 {
+				wait = time.Duration(sec) * time.Second
+			}
+		}
+		r.printf(r.colors.Yellow, "GitHub secondary rate limit on gh %s; retrying in %s (%d/%d)\n", strings.Join(args[:min(2, len(args))], " "), wait, attempt, ghWriteRetries)
+		w.sleep(wait)
+		backoff *= 2
+	}
+}
+
+// issueEdit collects the label and assignee changes made at one point of
+// processIssue, so they go out as a single gh issue edit.
+type issueEdit struct {
+	issue    string
+	add      []string
+	remove   []string
+	assign   bool
+	unassign bool
+}
+
+func (e issueEdit) empty() bool {
+	return len(e.add) == 0 && len(e.remove) == 0 && !e.assign && !e.unassign
+}
+
+func (e issueEdit) args() []string {
+	args := []string{"issue", "edit", e.issue}
+	for _, label := range e.add {
+		args = append(args, "--add-label", label)
+	}
+	for _, label := range e.remove {
+		args = append(args, "--remove-label", label)
+	}
+	if e.assign {
+		args = append(args, "--add-assignee", "@me")
+	}
+	if e.unassign {
+		args = append(args, "--remove-assignee", "@me")
+	}
+	return args
+}
+
+// describe lists the changes for warnings.
+func (e issueEdit) describe() string {
+	var parts []string
+	if labels := append(append([]string(nil), e.add...), e.remove...); len(labels) > 0 {
+		parts = append(parts, "labels "+strings.Join(labels, ", "))
+	}
+	if e.assign || e.unassign {
+		parts = append(parts, "assignee")
+	}
+	return strings.Join(parts, " and ")
+}
+
+// applyIssueEdit makes the collected changes. When it fails and labels are
+// being added, they may not exist in the repo yet, so they are created and
+// the edit is tried once more. Label and assignee trouble never fails the
+// issue; it reports whether the edit went through.
+func (r *runner) applyIssueEdit(edit issueEdit) bool {
+	if edit.empty() {
+		return true
+	}
+	_, err := r.ghWrite(edit.args()...)
+	if err != nil && len(edit.add) > 0 {
+		for _, label := range edit.add {
+			color := wipLabelColor
+			if label == r.opts.DoneLabel {
+				color = doneLabelColor
+			}
+			_, _ = r.ghWrite("label", "create", label, "--color", color, "--description", "Managed by ghir")
+		}
+		_, err = r.ghWrite(edit.args()...)
+	}
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not update %s on #%s: %v\n", edit.describe(), edit.issue, err)
+		return false
+	}
+	return true
+}
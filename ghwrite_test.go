@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGHWriteRetriesSecondaryRateLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		failures  int
+		message   string
+		wantErr   bool
+		wantCalls int
+		wantWaits []time.Duration
+	}{
+		{
+			name:      "succeeds first time",
+			wantCalls: 1,
+		},
+		{
+			name:      "backs off and retries",
+			failures:  2,
+			message:   "You have exceeded a secondary rate limit",
+			wantCalls: 3,
+			wantWaits: []time.Duration{time.Minute, 2 * time.Minute},
+		},
+		{
+			name:      "honours retry-after",
+			failures:  1,
+			message:   "HTTP 403: secondary rate limit (Retry-After: 7)",
+			wantCalls: 2,
+			wantWaits: []time.Duration{7 * time.Second},
+		},
+		{
+			name:      "gives up after the retries",
+			failures:  10,
+			message:   "was submitted too quickly",
+			wantErr:   true,
+			wantCalls: ghWriteRetries + 1,
+			wantWaits: []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute},
+		},
+		{
+			name:      "other errors are not retried",
+			failures:  1,
+			message:   "HTTP 404: Not Found",
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			calls := filepath.Join(dir, "calls")
+			gh := writeFakeBin(t, "gh", `echo x >> `+calls+`
+if [ "$(wc -l < `+calls+`)" -le `+strconv.Itoa(tt.failures)+` ]; then echo "`+tt.message+`" >&2; exit 1; fi
+echo ok`)
+			var waits []time.Duration
+			r := &runner{opts: options{GHBin: gh, NoColor: true}, repoRoot: dir, writes: newGHWriter(0)}
+			r.writes.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			_, err := r.ghWrite("issue", "comment", "5", "--body", "hi")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ghWrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			data, _ := os.ReadFile(calls)
+			if got := strings.Count(string(data), "x"); got != tt.wantCalls {
+				t.Fatalf("gh ran %d time(s), want %d", got, tt.wantCalls)
+			}
+			if len(waits) != len(tt.wantWaits) {
+				t.Fatalf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+			for i := range waits {
+				if waits[i] != tt.wantWaits[i] {
+					t.Fatalf("waits = %v, want %v", waits, tt.wantWaits)
+				}
+			}
+		})
+	}
+}
+
+func TestGHWriteSpacesWrites(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	gh := writeFakeBin(t, "gh", "exit 0")
+	var waits []time.Duration
+	r := &runner{opts: options{GHBin: gh}, repoRoot: dir, writes: newGHWriter(5)}
+	r.writes.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.ghWrite("issue", "comment", "5", "--body", "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(waits) != 2 {
+		t.Fatalf("waits = %v, want one before each write after the first", waits)
+	}
+	for _, wait := range waits {
+		if wait <= 4*time.Second || wait > 5*time.Second {
+			t.Fatalf("waits = %v, want just under the 5s interval", waits)
+		}
+	}
+}
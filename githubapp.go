@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+	appJWTLifetime      = 9 * time.Minute
+	appTokenRefreshSkew = 5 * time.Minute
+)
+
+var remoteRepoPattern = regexp.MustCompile(`[:/]([^/:]+)/([^/]+?)(?:\.git)?/?$`)
+
+// githubApp mints installation tokens for a GitHub App. They are handed to
+// gh through GH_TOKEN so every gh call runs as the app installation instead of
+// the logged-in user.
+type githubApp struct {
+	id           string
+	key          *rsa.PrivateKey
+	installation string
+	apiURL       string
+	repo         string
+	org          string
+	client       *http.Client
+	now          func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func newGitHubApp(opts options, repoRoot string) (*githubApp, error) {
+	data, err := os.ReadFile(opts.AppKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read GitHub App key: %w", err)
+	}
+	key, err := parseAppKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub App key %s: %w", opts.AppKeyFile, err)
+	}
+	app := &githubApp{
+		id:           opts.AppID,
+		key:          key,
+		installation: opts.AppInstallation,
+		apiURL:       defaultGitHubAPIURL,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		now:          time.Now,
+	}
+	if api := os.Getenv("GITHUB_API_URL"); api != "" {
+		app.apiURL = strings.TrimRight(api, "/")
+	}
+	if app.installation == "" && opts.Org != "" {
+		app.org = opts.Org
+	} else if app.installation == "" {
+		out, err := exec.Command("git", "-C", repoRoot, "remote", "get-url", "origin").Output()
+		remote := strings.TrimSpace(string(out))
+		if err != nil {
+			return nil, fmt.Errorf("find installation: %w (or pass --app-installation)", err)
+		}
+		owner, name, ok := parseRemoteRepo(remote)
+		if !ok {
+			return nil, fmt.Errorf("cannot tell owner/repo from origin %q; pass --app-installation", remote)
+		}
+		app.repo = owner + "/" + name
+	}
+	return app, nil
+}
+
+func parseAppKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App keys must be RSA")
+	}
+	return key, nil
+}
+
+func parseRemoteRepo(remote string) (string, string, bool) {
+	match := remoteRepoPattern.FindStringSubmatch(strings.TrimSpace(remote))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+func (a *githubApp) jwt() (string, error) {
+	now := a.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		// Backdated to tolerate clock drift between us and GitHub.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign GitHub App JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// installationToken returns a cached token, minting a new one when the
+// current one is missing or about to expire.
+func (a *githubApp) installationToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && a.now().Add(appTokenRefreshSkew).Before(a.expiresAt) {
+		return a.token, nil
+	}
+
+	jwt, err := a.jwt()
+	if err != nil {
+		return "", err
+	}
+	if a.installation == "" {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		path, owner := "/repos/"+a.repo+"/installation", a.repo
+		if a.org != "" {
+			path, owner = "/orgs/"+a.org+"/installation", a.org
+		}
+		if err := a.call(http.MethodGet, path, jwt, &installation); err != nil {
+			return "", fmt.Errorf("find GitHub App installation for %s: %w", owner, err)
+		}
+		a.installation = strconv.FormatInt(installation.ID, 10)
+	}
+
+	var minted struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := a.call(http.MethodPost, "/app/installations/"+a.installation+"/access_tokens", jwt, &minted); err != nil {
+		return "", fmt.Errorf("create installation token: %w", err)
+	}
+	if minted.Token == "" {
+		return "", errors.New("create installation token: empty token in response")
+	}
+	a.token, a.expiresAt = minted.Token, minted.ExpiresAt
+	return a.token, nil
+}
+
+func (a *githubApp) call(method, path, jwt string, out any) error {
+	req, err := http.NewRequest(method, a.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// ghEnv is the environment for gh subprocesses; nil keeps the inherited one.
+func (r *runner) ghEnv() ([]string, error) {
+	if r.app == nil {
+		return nil, nil
+	}
+	token, err := r.app.installationToken()
+	if err != nil {
+		return nil, err
+	}
+	env := make([]string, 0, len(os.Environ())+1)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "GH_TOKEN=") && !strings.HasPrefix(kv, "GITHUB_TOKEN=") {
+			env = append(env, kv)
+		}
+	}
+	return append(env, "GH_TOKEN="+token), nil
+}
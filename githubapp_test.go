@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGitHubAppInstallationToken(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var minted atomic.Int32
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/widgets/installation", func(w http.ResponseWriter, req *http.Request) {
+		if err := verifyAppJWT(req, &key.PublicKey, "42"); err != nil {
+			http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 777}`)
+	})
+	mux.HandleFunc("/app/installations/777/access_tokens", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := verifyAppJWT(req, &key.PublicKey, "42"); err != nil {
+			http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusUnauthorized)
+			return
+		}
+		n := minted.Add(1)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, n, now.Add(time.Hour).Format(time.RFC3339))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	app := &githubApp{
+		id:     "42",
+		key:    key,
+		apiURL: server.URL,
+		repo:   "acme/widgets",
+		client: server.Client(),
+		now:    func() time.Time { return now },
+	}
+	for i := 0; i < 2; i++ {
+		token, err := app.installationToken()
+		if err != nil {
+			t.Fatalf("installationToken: %v", err)
+		}
+		if token != "ghs_1" {
+			t.Fatalf("token = %q, want cached ghs_1", token)
+		}
+	}
+	if app.installation != "777" {
+		t.Fatalf("installation = %q", app.installation)
+	}
+
+	now = now.Add(56 * time.Minute)
+	if token, err := app.installationToken(); err != nil || token != "ghs_2" {
+		t.Fatalf("token near expiry = %q, %v; want refreshed ghs_2", token, err)
+	}
+
+	app.id = "99"
+	app.token = ""
+	if _, err := app.installationToken(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401 error for wrong app id, got %v", err)
+	}
+}
+
+func verifyAppJWT(req *http.Request, pub *rsa.PublicKey, appID string) error {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return fmt.Errorf("missing bearer token")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed jwt")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("bad signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var claims struct {
+		Iss string `json:"iss"`
+		Iat int64  `json:"iat"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	if claims.Iss != appID || claims.Exp-claims.Iat > 600 {
+		return fmt.Errorf("bad claims")
+	}
+	return nil
+}
+
+func TestParseAppKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, block := range map[string]*pem.Block{
+		"pkcs1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := parseAppKey(pem.EncodeToMemory(block))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !parsed.Equal(key) {
+			t.Fatalf("%s: parsed key differs", name)
+		}
+	}
+	if _, err := parseAppKey([]byte("not a key")); err == nil {
+		t.Fatal("expected error for non-PEM input")
+	}
+}
+
+func TestParseRemoteRepo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		remote      string
+		owner, repo string
+		ok          bool
+	}{
+		{remote: "git@github.com:acme/widgets.git", owner: "acme", repo: "widgets", ok: true},
+		{remote: "https://github.com/acme/widgets", owner: "acme", repo: "widgets", ok: true},
+		{remote: "ssh://git@github.example.com/acme/widgets.git/", owner: "acme", repo: "widgets", ok: true},
+		{remote: "widgets", ok: false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := parseRemoteRepo(tt.remote)
+		if owner != tt.owner || repo != tt.repo || ok != tt.ok {
+			t.Fatalf("parseRemoteRepo(%q) = %q, %q, %v", tt.remote, owner, repo, ok)
+		}
+	}
+}
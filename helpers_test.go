@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Fixtures shared by the tests that drive the runner end to end against a
+// scratch repository and fake agent and gh binaries.
+
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		runGit(t, repo, args...)
+	}
+	return repo
+}
+
+func commitFile(t *testing.T, repo, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", name)
+	runGit(t, repo, "commit", "-q", "-m", "update "+name)
+}
+
+func runGit(t *testing.T, repo string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFakeBin(t *testing.T, name, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestRunner builds a runner for repo. Options left empty get test
+// defaults: logs and the done file in a temporary directory and the raw
+// stream view; output is never coloured or streamed to the console.
+func newTestRunner(t *testing.T, repo string, opts options) *runner {
+	t.Helper()
+
+	if opts.LogDir == "" {
+		opts.LogDir = filepath.Join(t.TempDir(), "logs")
+	}
+	if opts.DoneFile == "" {
+		opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+	}
+	if opts.StreamView == "" {
+		opts.StreamView = streamViewRaw
+	}
+	opts.NoColor, opts.Quiet = true, true
+	r, err := newRunner(opts, repo)
+	if err != nil {
+		t.Fatalf("newRunner: %v", err)
+	}
+	return r
+}
+
+// fakeAgent writes a claude stand-in that answers --version and otherwise
+// runs script in the repository.
+func fakeAgent(t *testing.T, script string) string {
+	t.Helper()
+
+	return writeFakeBin(t, "claude", "[ \"$1\" = --version ] && exit 0\n"+script)
+}
+
+// fakeIssueGH writes a gh stand-in that shows every issue as an open issue
+// with this title and body.
+func fakeIssueGH(t *testing.T, title, body string) string {
+	t.Helper()
+
+	return writeFakeBin(t, "gh", `echo '{"title":"`+title+`","body":"`+body+`","state":"OPEN","labels":[]}'`)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const defaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// translatedMessages are the messages every catalog translates: the run
+// banner, progress and summary lines, the commit messages the runner writes
+// itself, and the default PR body and issue comments. Other output stays in
+// English. The catalogs must cover
+// exactly this list, and a message reworded in the source must be reworded
+// here too (see i18n_test.go).
+var translatedMessages = []string{
+	"  #%s done\n",
+	"  #%s pending\n",
+	"  #%s skipped\n",
+	"  waiting... %d minutes remaining\n",
+	"## Summary",
+	"## Verification artifacts",
+	"%d file(s) changed, +%d -%d",
+	"%s did not commit. Uncommitted changes found, committing now.\n",
+	"%s ran but made no modifications. Check log: %s\n",
+	"- ... %d more file(s)\n",
+	"- `%s` (binary)\n",
+	"Agent: %s\n",
+	"Already completed #%s, skipping (use --force to reprocess)\n",
+	"Branch: %s\n",
+	"Branch: `%s`",
+	"Check log: %s\n",
+	"Completion status:\n",
+	"ERROR: uncommitted changes detected. Commit or stash before running.\n",
+	"FAILED: %s exited with code %d for issue #%s\n",
+	"FAILED: cannot determine git status: %v\n",
+	"FAILED: could not mark #%s completed: %v\n",
+	"FAILED: no changes produced for issue #%s\n",
+	"FAILED: unable to fetch issue #%s: %v\n",
+	"Failed: %d\n",
+	"Failure: %s\n",
+	"Implemented by ghir in %s and verified with `%s`.",
+	"Implemented by ghir in %s.",
+	"Implemented by ghir in {{COMMIT}}.\n\n{{SUMMARY}}\n\n{{LINK}}\n",
+	"Issue #%s is already closed on GitHub, skipping and marking done (use --include-closed to process it)\n",
+	"Log: %s\n",
+	"Model override: %s\n",
+	"Pull request: %s",
+	"Reset all completion tracking\n",
+	"Retrying issue #%s after session limit reset...\n",
+	"SESSION LIMIT HIT - waiting until %s (%ds)\n",
+	"SUCCESS: Issue #%s committed by %s\n",
+	"SUCCESS: Issue #%s committed by runner\n",
+	"Session limit hit mid-work. Committing partial progress...\n",
+	"Session limit should be reset. Resuming...\n",
+	"Skipped: %d\n",
+	"Skipping issue #%s: labeled %s\n",
+	"Starting %s for issue #%s...\n",
+	"Stopping due to failure on issue #%s\n",
+	"Stream view: %s\n",
+	"Succeeded: %d\n",
+	"Total: %d | Completed: %d | Remaining: %d\n",
+	"Total: %d | Completed: %d | Skipped: %d | Remaining: %d\n",
+	"WARNING: new commit(s) do not mention #%s in subject lines.\n",
+	"[%d/%d] Issue #%s: %s\n",
+	"[%d/%d] Skipping issue #%s (skip list)\n",
+	"[DRY RUN] Already completed #%s, would skip\n",
+	"[DRY RUN] Issue #%s is closed on GitHub, would skip and mark done\n",
+	"[DRY RUN] Would process issue #%s\n",
+	"[DRY RUN] Would skip issue #%s (labeled %s)\n",
+	"chore: restore runner files changed for #%s",
+	"feat: implement #%s - %s",
+	"fix: address verification failures for #%s",
+	"style: format #%s",
+	"wip: partial work on #%s - %s (session limit hit)",
+	"{{ISSUE_TITLE}}\n\n{{SUMMARY}}## Changes\n\n{{COMMITS}}\n\n{{ARTIFACTS}}{{CLOSES}}\n",
+}
+
+var languageEnvVars = []string{"GHIR_LANG", "LC_ALL", "LC_MESSAGES", "LANG"}
+
+func supportedLanguages() []string {
+	languages := []string{defaultLanguage}
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return languages
+	}
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func isSupportedLanguage(lang string) bool {
+	for _, supported := range supportedLanguages() {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeLanguage(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexAny(value, "_-"); i >= 0 {
+		value = value[:i]
+	}
+	if value == "c" || value == "posix" {
+		return defaultLanguage
+	}
+	return value
+}
+
+func detectLanguage(explicit string, getenv func(string) string) string {
+	if explicit != "" {
+		return normalizeLanguage(explicit)
+	}
+	for _, name := range languageEnvVars {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		lang := normalizeLanguage(value)
+		if isSupportedLanguage(lang) {
+			return lang
+		}
+		return defaultLanguage
+	}
+	return defaultLanguage
+}
+
+func loadCatalog(lang string) (map[string]string, error) {
+	if lang == defaultLanguage {
+		return nil, nil
+	}
+	data, err := localeFS.ReadFile(path.Join("locales", lang+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported language %q (available: %s)", lang, strings.Join(supportedLanguages(), ", "))
+	}
+	catalog := make(map[string]string)
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("parse %s catalog: %w", lang, err)
+	}
+	return catalog, nil
+}
+
+func (r *runner) tr(message string) string {
+	if translated, ok := r.catalog[message]; ok {
+		return translated
+	}
+	return message
+}
+
+func runnerLanguage(opts options) string {
+	return detectLanguage(opts.Lang, os.Getenv)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	formatVerbPattern  = regexp.MustCompile(`%[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+	placeholderPattern = regexp.MustCompile(`\{\{[A-Z_]+\}\}`)
+)
+
+func TestCatalogsKeepFormatVerbs(t *testing.T) {
+	t.Parallel()
+
+	for _, lang := range supportedLanguages() {
+		if lang == defaultLanguage {
+			continue
+		}
+		catalog, err := loadCatalog(lang)
+		if err != nil {
+			t.Fatalf("loadCatalog(%q): %v", lang, err)
+		}
+		if len(catalog) == 0 {
+			t.Fatalf("catalog %q is empty", lang)
+		}
+		for source, translated := range catalog {
+			want := formatVerbPattern.FindAllString(source, -1)
+			got := formatVerbPattern.FindAllString(translated, -1)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s: verbs %v in %q do not match %v in %q", lang, got, translated, want, source)
+			}
+			if got, want := placeholderPattern.FindAllString(translated, -1), placeholderPattern.FindAllString(source, -1); !slices.Equal(got, want) {
+				t.Fatalf("%s: placeholders %v in %q do not match %v in %q", lang, got, translated, want, source)
+			}
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	t.Parallel()
+
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	tests := []struct {
+		name     string
+		explicit string
+		env      map[string]string
+		want     string
+	}{
+		{name: "default", want: "en"},
+		{name: "explicit wins", explicit: "de", env: map[string]string{"LANG": "sv_SE.UTF-8"}, want: "de"},
+		{name: "LANG with region and encoding", env: map[string]string{"LANG": "sv_SE.UTF-8"}, want: "sv"},
+		{name: "GHIR_LANG before LC_ALL", env: map[string]string{"GHIR_LANG": "es", "LC_ALL": "de_DE"}, want: "es"},
+		{name: "POSIX locale", env: map[string]string{"LC_ALL": "C.UTF-8", "LANG": "de_DE"}, want: "en"},
+		{name: "unsupported locale falls back", env: map[string]string{"LANG": "ja_JP.UTF-8"}, want: "en"},
+	}
+
+	for _, tt := range tests {
+		if got := detectLanguage(tt.explicit, env(tt.env)); got != tt.want {
+			t.Fatalf("%s: detectLanguage() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunnerTranslatesMessages(t *testing.T) {
+	t.Parallel()
+
+	catalog, err := loadCatalog("sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{catalog: catalog}
+	if got := r.tr("Succeeded: %d\n"); got != "Lyckades: %d\n" {
+		t.Fatalf("tr() = %q", got)
+	}
+	if got := r.tr("untranslated message"); got != "untranslated message" {
+		t.Fatalf("tr() should fall back to the source text, got %q", got)
+	}
+	if _, err := loadCatalog("xx"); err == nil {
+		t.Fatal("expected error for unknown language")
+	}
+}
+
+func TestCatalogsCoverTranslatedMessages(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source strings.Builder
+	for _, file := range files {
+		// i18n.go lists the messages; they must appear where they are used.
+		if strings.HasSuffix(file, "_test.go") || file == "i18n.go" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		source.Write(data)
+	}
+
+	for _, message := range translatedMessages {
+		if !strings.Contains(source.String(), strconv.Quote(message)) {
+			t.Fatalf("translated message %q no longer appears in the source", message)
+		}
+	}
+
+	for _, lang := range supportedLanguages() {
+		if lang == defaultLanguage {
+			continue
+		}
+		catalog, err := loadCatalog(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, message := range translatedMessages {
+			if _, ok := catalog[message]; !ok {
+				t.Fatalf("%s catalog has no entry for %q", lang, message)
+			}
+		}
+		for key := range catalog {
+			if !slices.Contains(translatedMessages, key) {
+				t.Fatalf("%s catalog key %q is not in translatedMessages", lang, key)
+			}
+		}
+	}
+}
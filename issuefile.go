@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type issueEntry struct {
+	ID             string `json:"id" yaml:"id"`
+	Agent          string `json:"agent,omitempty" yaml:"agent,omitempty"`
+	Model          string `json:"model,omitempty" yaml:"model,omitempty"`
+	PromptTemplate string `json:"prompt_template,omitempty" yaml:"prompt_template,omitempty"`
+	Instructions   string `json:"instructions,omitempty" yaml:"instructions,omitempty"`
+	Branch         string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Priority       string `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	Source string `json:"-" yaml:"-"`
+	Title  string `json:"-" yaml:"-"`
+	Body   string `json:"-" yaml:"-"`
+}
+
+type issueEntryFields issueEntry
+
+func (e *issueEntry) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] != '{' {
+		var id json.Number
+		if err := json.Unmarshal(trimmed, &id); err != nil {
+			var text string
+			if strErr := json.Unmarshal(trimmed, &text); strErr != nil {
+				return fmt.Errorf("issue entry must be an id or an object")
+			}
+			id = json.Number(text)
+		}
+		*e = issueEntry{ID: id.String()}
+		return nil
+	}
+
+	var raw struct {
+		issueEntryFields
+		ID       json.RawMessage `json:"id"`
+		Priority json.RawMessage `json:"priority"`
+	}
+	if err := json.Unmarshal(trimmed, &raw); err != nil {
+		return err
+	}
+	*e = issueEntry(raw.issueEntryFields)
+	e.ID = strings.Trim(string(raw.ID), `"`)
+	e.Priority = strings.Trim(string(raw.Priority), `"`)
+	return nil
+}
+
+func (e *issueEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = issueEntry{ID: node.Value}
+		return nil
+	}
+	var fields issueEntryFields
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	*e = issueEntry(fields)
+	return nil
+}
+
+func isStructuredIssueFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func parseStructuredIssues(data []byte, source string) ([]issueEntry, error) {
+	var entries []issueEntry
+	var err error
+	if strings.ToLower(filepath.Ext(source)) == ".json" || looksLikeJSON(data) {
+		entries, err = decodeJSONIssues(data)
+	} else {
+		entries, err = decodeYAMLIssues(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", source, err)
+	}
+
+	var issues []issueEntry
+	seen := make(map[string]struct{})
+	for i, entry := range entries {
+		entry.ID = strings.TrimPrefix(strings.TrimSpace(entry.ID), "#")
+		entry.Agent = strings.ToLower(strings.TrimSpace(entry.Agent))
+		if !issuePattern.MatchString(entry.ID) {
+			return nil, fmt.Errorf("invalid issue id in %s entry %d: %q", source, i+1, entry.ID)
+		}
+		if entry.Agent != "" && !isSupportedAgent(entry.Agent) {
+			return nil, fmt.Errorf("unsupported agent for issue #%s in %s: %q", entry.ID, source, entry.Agent)
+		}
+		entry.Priority = strings.TrimSpace(entry.Priority)
+		if _, _, err := parsePriority(entry.Priority); err != nil {
+			return nil, fmt.Errorf("invalid priority for issue #%s in %s: %w", entry.ID, source, err)
+		}
+		if _, exists := seen[entry.ID]; exists {
+			continue
+		}
+		issues = append(issues, entry)
+		seen[entry.ID] = struct{}{}
+	}
+
+	if len(issues) == 0 {
+		return nil, fmt.Errorf("no issue ids found in %s", source)
+	}
+	return issues, nil
+}
+
+func looksLikeJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+}
+
+func decodeJSONIssues(data []byte) ([]issueEntry, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var doc struct {
+			Issues []issueEntry `json:"issues"`
+		}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, err
+		}
+		return doc.Issues, nil
+	}
+	var entries []issueEntry
+	if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func decodeYAMLIssues(data []byte) ([]issueEntry, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+	doc := root.Content[0]
+	if doc.Kind == yaml.MappingNode {
+		var wrapped struct {
+			Issues []issueEntry `yaml:"issues"`
+		}
+		if err := doc.Decode(&wrapped); err != nil {
+			return nil, err
+		}
+		return wrapped.Issues, nil
+	}
+	var entries []issueEntry
+	if err := doc.Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func entriesFromIDs(ids []string) []issueEntry {
+	entries := make([]issueEntry, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, issueEntry{ID: id})
+	}
+	return entries
+}
+
+func issueIDs(entries []issueEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func (r *runner) forIssue(entry issueEntry) *runner {
+	scoped := *r
+	if entry.Agent != "" {
+		scoped.opts.Agent = entry.Agent
+	}
+	if entry.Model != "" {
+		scoped.opts.Model = entry.Model
+	} else if entry.Agent != "" && entry.Agent != r.opts.Agent {
+		scoped.opts.Model = ""
+	}
+	if entry.PromptTemplate != "" {
+		scoped.opts.PromptTemplate = resolvePath(r.repoRoot, entry.PromptTemplate)
+	} else if entry.synthetic() {
+		// Repo templates are written for GitHub issues.
+		scoped.opts.PromptTemplate = ""
+	}
+	return &scoped
+}
+
+// checkIssueModels fails before the run when an entry picks an agent that
+// needs a model (api, local) without one to run it with.
+func (r *runner) checkIssueModels(issues []issueEntry) error {
+	for _, entry := range issues {
+		scoped := r.forIssue(entry)
+		if (scoped.opts.Agent == "api" || scoped.opts.Agent == "local") && scoped.opts.Model == "" {
+			return fmt.Errorf("issue #%s runs the %s agent, which needs a model: set model on its entry", entry.ID, scoped.opts.Agent)
+		}
+	}
+	return nil
+}
+
+func (r *runner) checkoutIssueBranch(branch string) (string, error) {
+	original, err := r.gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("determine current branch: %w", err)
+	}
+	if original == branch {
+		return "", nil
+	}
+	if _, err := r.gitOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		_, err = r.gitOutput("checkout", branch)
+		if err != nil {
+			return "", err
+		}
+		return original, nil
+	}
+	if _, err := r.gitOutput("checkout", "-b", branch); err != nil {
+		return "", err
+	}
+	return original, nil
+}
+
+func appendInstructions(prompt, instructions string) string {
+	instructions = strings.TrimSpace(instructions)
+	if instructions == "" {
+		return prompt
+	}
+	return strings.TrimRight(prompt, "\n") + "\n\n## Additional Instructions\n\n" + instructions + "\n"
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStructuredIssues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		source    string
+		input     string
+		want      []issueEntry
+		wantError string
+	}{
+		{
+			name:   "yaml list with scalars and objects",
+			source: "issues.yaml",
+			input: `
+- 1721
+- id: 1706
+  agent: Codex
+  model: gpt-5
+  branch: fix/1706
+- id: "#1710"
+  prompt_template: .ticket-runner/small.tmpl
+  instructions: Keep the change minimal.
+- 1721
+`,
+			want: []issueEntry{
+				{ID: "1721"},
+				{ID: "1706", Agent: "codex", Model: "gpt-5", Branch: "fix/1706"},
+				{ID: "1710", PromptTemplate: ".ticket-runner/small.tmpl", Instructions: "Keep the change minimal."},
+			},
+		},
+		{
+			name:   "yaml document with issues key",
+			source: "issues.yml",
+			input:  "issues:\n  - 5\n  - id: 6\n    model: opus\n",
+			want:   []issueEntry{{ID: "5"}, {ID: "6", Model: "opus"}},
+		},
+		{
+			name:   "json list with numbers and objects",
+			source: "issues.json",
+			input:  `[12, "13", {"id": 14, "agent": "gemini", "instructions": "add tests", "priority": 1}]`,
+			want: []issueEntry{
+				{ID: "12"},
+				{ID: "13"},
+				{ID: "14", Agent: "gemini", Instructions: "add tests", Priority: "1"},
+			},
+		},
+		{
+			name:   "json object with issues key",
+			source: "issues.json",
+			input:  `{"issues": [{"id": "7", "branch": "agent/7"}]}`,
+			want:   []issueEntry{{ID: "7", Branch: "agent/7"}},
+		},
+		{
+			name:      "invalid id",
+			source:    "issues.yaml",
+			input:     "- id: abc\n",
+			wantError: `invalid issue id in issues.yaml entry 1: "abc"`,
+		},
+		{
+			name:      "invalid priority",
+			source:    "issues.yaml",
+			input:     "- id: 4\n  priority: urgent\n",
+			wantError: `invalid priority for issue #4 in issues.yaml`,
+		},
+		{
+			name:      "unsupported agent",
+			source:    "issues.json",
+			input:     `[{"id": 3, "agent": "nope"}]`,
+			wantError: `unsupported agent for issue #3 in issues.json: "nope"`,
+		},
+		{
+			name:      "empty document",
+			source:    "issues.yaml",
+			input:     "issues: []\n",
+			wantError: "no issue ids found in issues.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseStructuredIssues([]byte(tt.input), tt.source)
+			if tt.wantError != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantError)
+				}
+				if !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("unexpected error: got %q want substring %q", err.Error(), tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStructuredIssues returned unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("entries mismatch:\ngot  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadIssuesFileDetectsFormat(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "issues.txt")
+	structured := filepath.Join(dir, "issues.yaml")
+	if err := os.WriteFile(plain, []byte("1\n2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(structured, []byte("- id: 3\n  agent: codex\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readIssuesFile(plain)
+	if err != nil {
+		t.Fatalf("readIssuesFile(plain): %v", err)
+	}
+	if !reflect.DeepEqual(got, []issueEntry{{ID: "1"}, {ID: "2"}}) {
+		t.Fatalf("plain entries mismatch: %+v", got)
+	}
+
+	got, err = readIssuesFile(structured)
+	if err != nil {
+		t.Fatalf("readIssuesFile(structured): %v", err)
+	}
+	if !reflect.DeepEqual(got, []issueEntry{{ID: "3", Agent: "codex"}}) {
+		t.Fatalf("structured entries mismatch: %+v", got)
+	}
+}
+
+func TestRunnerForIssueAppliesOverrides(t *testing.T) {
+	t.Parallel()
+
+	base := &runner{
+		repoRoot: "/repo",
+		opts: options{
+			Agent:          "claude",
+			Model:          "sonnet",
+			PromptTemplate: "/repo/.ticket-runner/prompt.tmpl",
+		},
+	}
+
+	scoped := base.forIssue(issueEntry{ID: "1", Agent: "codex", PromptTemplate: "custom.tmpl"})
+	if scoped.opts.Agent != "codex" {
+		t.Fatalf("agent mismatch: got %q", scoped.opts.Agent)
+	}
+	if scoped.opts.Model != "" {
+		t.Fatalf("model should reset when switching agents, got %q", scoped.opts.Model)
+	}
+	if scoped.opts.PromptTemplate != "/repo/custom.tmpl" {
+		t.Fatalf("template mismatch: got %q", scoped.opts.PromptTemplate)
+	}
+	if base.opts.Agent != "claude" || base.opts.Model != "sonnet" {
+		t.Fatalf("base runner was mutated: %+v", base.opts)
+	}
+
+	scoped = base.forIssue(issueEntry{ID: "2", Model: "opus"})
+	if scoped.opts.Agent != "claude" || scoped.opts.Model != "opus" {
+		t.Fatalf("unexpected overrides: %+v", scoped.opts)
+	}
+}
+
+func TestAppendInstructions(t *testing.T) {
+	t.Parallel()
+
+	if got := appendInstructions("prompt\n", "  "); got != "prompt\n" {
+		t.Fatalf("empty instructions changed prompt: %q", got)
+	}
+	want := "prompt\n\n## Additional Instructions\n\nDo X.\n"
+	if got := appendInstructions("prompt\n", "Do X."); got != want {
+		t.Fatalf("appendInstructions() = %q, want %q", got, want)
+	}
+}
+
+func TestPerIssueModels(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{Agent: "claude", Model: "sonnet"}}
+	issues := []issueEntry{
+		{ID: "12", Agent: "codex", Model: "gpt-5.3-codex"},
+		{ID: "13"},
+		{ID: "14", Model: "opus"},
+		{ID: "15", Model: "opus"},
+		{ID: "16", Agent: "codex"},
+	}
+	want := "Claude (opus): 2, Claude (sonnet): 1, Codex: 1, Codex (gpt-5.3-codex): 1"
+	if got := agentBreakdown(r.queueAgents(issues)); got != want {
+		t.Fatalf("breakdown = %q, want %q", got, want)
+	}
+	if err := r.checkIssueModels(issues); err != nil {
+		t.Fatalf("checkIssueModels() = %v", err)
+	}
+
+	err := r.checkIssueModels([]issueEntry{{ID: "7", Agent: "local"}})
+	if err == nil || !strings.Contains(err.Error(), "issue #7 runs the local agent, which needs a model") {
+		t.Fatalf("checkIssueModels() = %v", err)
+	}
+	if err := r.checkIssueModels([]issueEntry{{ID: "7", Agent: "local", Model: "qwen2.5-coder:7b"}}); err != nil {
+		t.Fatalf("checkIssueModels() = %v", err)
+	}
+}
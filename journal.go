@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	journalRunStarted    = "run_started"
+	journalIssueFetched  = "issue_fetched"
+	journalFetchFailed   = "issue_fetch_failed"
+	journalAgentInvoked  = "agent_invoked"
+	journalAgentExited   = "agent_exited"
+	journalLimitDetected = "limit_detected"
+	journalLimitWaiting  = "limit_waiting"
+	journalLimitResumed  = "limit_resumed"
+	journalCommitCreated = "commit_created"
+	journalVerified      = "verified"
+	journalLinted        = "linted"
+	journalBuilt         = "built"
+	journalPRCreated     = "pr_created"
+	journalBisected      = "bisected"
+	journalPushed        = "pushed"
+	journalIssueClosed   = "issue_closed"
+	journalReverted      = "reverted"
+	journalRolledBack    = "rolled_back"
+	journalIssueFinished = "issue_finished"
+	journalRunFinished   = "run_finished"
+)
+
+// journalEntry is one line of run-<timestamp>.jsonl. Fields that do not apply
+// to an event are left out.
+type journalEntry struct {
+	Time        string  `json:"time"`
+	Event       string  `json:"event"`
+	Issue       string  `json:"issue,omitempty"`
+	Title       string  `json:"title,omitempty"`
+	State       string  `json:"state,omitempty"`
+	Agent       string  `json:"agent,omitempty"`
+	Model       string  `json:"model,omitempty"`
+	Environment string  `json:"environment,omitempty"`
+	LogPath     string  `json:"log_path,omitempty"`
+	PromptBytes int     `json:"prompt_bytes,omitempty"`
+	ExitCode    *int    `json:"exit_code,omitempty"`
+	DurationSec float64 `json:"duration_sec,omitempty"`
+	WaitSec     int     `json:"wait_sec,omitempty"`
+	ResumeAt    string  `json:"resume_at,omitempty"`
+	Commit      string  `json:"commit,omitempty"`
+	Subject     string  `json:"subject,omitempty"`
+	URL         string  `json:"url,omitempty"`
+	Branch      string  `json:"branch,omitempty"`
+	Passed      *bool   `json:"passed,omitempty"`
+	Result      string  `json:"result,omitempty"`
+	Failure     string  `json:"failure,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	Issues      *int    `json:"issues,omitempty"`
+	Succeeded   *int    `json:"succeeded,omitempty"`
+	Failed      *int    `json:"failed,omitempty"`
+	Skipped     *int    `json:"skipped,omitempty"`
+}
+
+type runJournal struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func (r *runner) openJournal(stamp string) error {
+	path := filepath.Join(r.opts.LogDir, "run-"+stamp+".jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open run journal: %w", err)
+	}
+	r.journal = &runJournal{path: path, file: f}
+	r.openEvents()
+	return nil
+}
+
+func (r *runner) closeJournal() {
+	if r.journal == nil {
+		return
+	}
+	r.events.close()
+	r.events = nil
+	r.journal.mu.Lock()
+	defer r.journal.mu.Unlock()
+	_ = r.journal.file.Close()
+}
+
+// record appends an event and hands it to the --events sinks. Journal write
+// errors are reported once per event but never fail the run.
+func (r *runner) record(entry journalEntry) {
+	if r.journal == nil {
+		return
+	}
+	entry.Time = r.timestamp(r.now())
+	data, err := json.Marshal(entry)
+	if err == nil {
+		r.journal.mu.Lock()
+		_, err = r.journal.file.Write(append(data, '\n'))
+		r.events.publish(data)
+		r.journal.mu.Unlock()
+	}
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not write run journal %s: %v\n", r.journal.path, err)
+	}
+}
+
+// recordCommits journals every commit in from..to, oldest first.
+func (r *runner) recordCommits(issue, from, to string) {
+	if r.journal == nil {
+		return
+	}
+	out, err := r.gitOutput("log", "--reverse", "--pretty=format:%H %s", from+".."+to)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(out, "\n") {
+		sha, subject, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if sha != "" {
+			r.record(journalEntry{Event: journalCommitCreated, Issue: issue, Commit: sha, Subject: subject})
+		}
+	}
+}
+
+func (res issueResult) String() string {
+	switch res {
+	case resultSuccess:
+		return "success"
+	case resultFailed:
+		return "failed"
+	case resultRetry:
+		return "retry"
+	case resultSkipped:
+		return "skipped"
+	}
+	return "unknown"
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunJournalRecordsIssueSteps(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, `cat >/dev/null
+echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"
+echo done`)
+
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		VerifyCmd: "test -f greeting.txt",
+	}
+	r := newTestRunner(t, repo, opts)
+	if err := r.openJournal("20260102T030405Z"); err != nil {
+		t.Fatal(err)
+	}
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("result = %v", result)
+	}
+	r.closeJournal()
+
+	data, err := os.ReadFile(r.journal.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(r.journal.path), "run-") {
+		t.Fatalf("journal name = %s", r.journal.path)
+	}
+	var events []string
+	var entries []journalEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e journalEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("bad journal line %q: %v", line, err)
+		}
+		if e.Time == "" || (e.Issue != "5") {
+			t.Fatalf("entry without time or issue: %+v", e)
+		}
+		events = append(events, e.Event)
+		entries = append(entries, e)
+	}
+	want := []string{journalIssueFetched, journalAgentInvoked, journalAgentExited, journalCommitCreated, journalVerified, journalIssueFinished}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	if entries[2].ExitCode == nil || *entries[2].ExitCode != 0 {
+		t.Fatalf("agent_exited = %+v", entries[2])
+	}
+	if entries[3].Subject != "feat: add greeting (#5)" || len(entries[3].Commit) != 40 {
+		t.Fatalf("commit_created = %+v", entries[3])
+	}
+	if entries[4].Passed == nil || !*entries[4].Passed || entries[5].Result != "success" {
+		t.Fatalf("verified/finished = %+v / %+v", entries[4], entries[5])
+	}
+}
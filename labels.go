@@ -0,0 +1,30 @@
+package main
+
+const (
+	wipLabelColor  = "fbca04"
+	doneLabelColor = "0e8a16"
+)
+
+// markInProgress puts --wip-label on the issue so people can see the runner
+// is working on it.
+func (r *runner) markInProgress(edit *issueEdit) {
+	if r.opts.WIPLabel == "" {
+		return
+	}
+	edit.add = append(edit.add, r.opts.WIPLabel)
+}
+
+// finishInProgress takes --wip-label off again, adding --done-label on
+// success. A session-limit retry keeps the label: the issue is still being
+// worked on.
+func (r *runner) finishInProgress(edit *issueEdit, result issueResult) {
+	if result == resultRetry {
+		return
+	}
+	if r.opts.WIPLabel != "" {
+		edit.remove = append(edit.remove, r.opts.WIPLabel)
+	}
+	if result == resultSuccess && r.opts.DoneLabel != "" {
+		edit.add = append(edit.add, r.opts.DoneLabel)
+	}
+}
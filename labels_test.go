@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWIPLabelLifecycle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		agent     string
+		want      issueResult
+		wantCalls []string
+	}{
+		{
+			name:  "success",
+			agent: "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"",
+			want:  resultSuccess,
+			wantCalls: []string{
+				"issue edit 5 --add-label agent-in-progress",
+				"label create agent-in-progress --color " + wipLabelColor + " --description Managed by ghir",
+				"issue edit 5 --add-label agent-in-progress",
+				"issue edit 5 --add-label agent-done --remove-label agent-in-progress",
+			},
+		},
+		{
+			name:  "failure",
+			agent: "true",
+			want:  resultFailed,
+			wantCalls: []string{
+				"issue edit 5 --add-label agent-in-progress",
+				"label create agent-in-progress --color " + wipLabelColor + " --description Managed by ghir",
+				"issue edit 5 --add-label agent-in-progress",
+				"issue edit 5 --remove-label agent-in-progress",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			dir := t.TempDir()
+			calls := filepath.Join(dir, "gh.calls")
+			labels := filepath.Join(dir, "labels")
+			if err := os.WriteFile(labels, []byte("agent-done\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			gh := writeFakeBin(t, "gh", `case "$1 $2" in
+"issue edit")
+  echo "$*" >> `+calls+`
+  shift 3
+  while [ $# -gt 0 ]; do
+    if [ "$1" = --add-label ] && ! grep -qx "$2" `+labels+`; then exit 1; fi
+    shift 2
+  done
+  ;;
+"label create")
+  echo "$*" >> `+calls+`
+  echo "$3" >> `+labels+`
+  ;;
+*)
+  echo '{"title":"Add greeting","body":"say hi","state":"OPEN","labels":[]}'
+  ;;
+esac`)
+			agent := fakeAgent(t, tt.agent)
+			opts := options{
+				Agent:     "claude",
+				ClaudeBin: agent,
+				GHBin:     gh,
+				LogDir:    filepath.Join(dir, "logs"),
+				WIPLabel:  "agent-in-progress",
+				DoneLabel: "agent-done",
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+			data, err := os.ReadFile(calls)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Split(strings.TrimSpace(string(data)), "\n"); strings.Join(got, "\n") != strings.Join(tt.wantCalls, "\n") {
+				t.Fatalf("gh calls:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.wantCalls, "\n"))
+			}
+		})
+	}
+}
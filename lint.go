@@ -0,0 +1,30 @@
+package main
+
+import "path/filepath"
+
+// lintIssue runs --lint-cmd next to --verify-cmd. Its result is logged,
+// journaled and categorized on its own, so a run summary tells style
+// violations apart from broken tests.
+func (r *runner) lintIssue(issue string) (passed bool) {
+	if r.opts.LintCmd == "" {
+		return true
+	}
+	command := expandVerifyCommand(r.opts.LintCmd, issue)
+	r.printf(r.colors.Yellow, "Linting issue #%s: %s\n", issue, command)
+	result, err := r.runCheck(r.repoRoot, command, filepath.Join(r.opts.LogDir, issue+".lint.log"))
+	r.record(journalEntry{Event: journalLinted, Issue: issue, Passed: boolPtr(err == nil && result.Passed), ExitCode: intPtr(result.ExitCode), LogPath: result.LogPath})
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: lint could not run for #%s: %v\n", issue, err)
+		return false
+	}
+	if result.Passed {
+		r.printf(r.colors.Green, "Lint passed for issue #%s\n", issue)
+		return true
+	}
+	r.printf(r.colors.Red, "FAILED: lint exited with code %d for issue #%s\n", result.ExitCode, issue)
+	for _, line := range compactMultiline(tailLines(result.Output, 20), 20, 4000) {
+		r.printf(r.colors.Red, "  %s\n", line)
+	}
+	r.printf(r.colors.Red, "Check log: %s\n", result.LogPath)
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintFailuresAreReportedApartFromVerification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		verifyCmd   string
+		lintCmd     string
+		wantFailure failureCategory
+		wantLinted  bool
+	}{
+		{name: "lint fails", verifyCmd: "true", lintCmd: "echo 'f.txt:1: trailing space'; exit 3", wantFailure: failureLint},
+		{name: "both fail", verifyCmd: "false", lintCmd: "exit 1", wantFailure: failureVerification},
+		{name: "both pass", verifyCmd: "true", lintCmd: "true", wantLinted: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := fakeIssueGH(t, "Add f", "add f")
+			agent := fakeAgent(t, "echo f > f.txt\ngit add f.txt\ngit commit -q -m \"feat: add f (#5)\"")
+			opts := options{
+				Agent:       "claude",
+				ClaudeBin:   agent,
+				GHBin:       gh,
+				SingleIssue: "5",
+				LogDir:      filepath.Join(t.TempDir(), "logs"),
+				VerifyCmd:   tt.verifyCmd,
+				LintCmd:     tt.lintCmd,
+			}
+			r := newTestRunner(t, repo, opts)
+			r.runQueue()
+			if got := r.failures["5"]; got != tt.wantFailure {
+				t.Fatalf("failure = %q, want %q", got, tt.wantFailure)
+			}
+
+			journals, _ := filepath.Glob(filepath.Join(r.opts.LogDir, "run-*.jsonl"))
+			if len(journals) != 1 {
+				t.Fatalf("journals = %v", journals)
+			}
+			f, err := os.Open(journals[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			var linted *journalEntry
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var entry journalEntry
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil && entry.Event == journalLinted {
+					linted = &entry
+				}
+			}
+			if linted == nil || linted.Passed == nil || *linted.Passed != tt.wantLinted {
+				t.Fatalf("linted event = %+v, want passed=%v", linted, tt.wantLinted)
+			}
+			if linted.LogPath != filepath.Join(r.opts.LogDir, "5.lint.log") {
+				t.Fatalf("lint log = %q", linted.LogPath)
+			}
+		})
+	}
+}
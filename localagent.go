@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	commandLocalAgent = "local-agent"
+
+	defaultOllamaURL = "http://localhost:11434"
+
+	localMaxRounds       = 12
+	localMaxListedFiles  = 400
+	localMaxContextBytes = 60000
+)
+
+// contextFilesForLocal are added to the local agent's instructions. Local
+// models have small context windows, so only AGENTS.md is.
+var contextFilesForLocal = []string{"AGENTS.md"}
+
+const localSystemPrompt = `You are a coding agent working on a git repository. You cannot run commands; you change files by replying with edit blocks, which are applied for you.
+
+To change a file, write its path on a line of its own, followed by:
+
+<<<<<<< SEARCH
+exact lines currently in the file
+=======
+the lines that replace them
+>>>>>>> REPLACE
+
+The SEARCH part must match the file exactly, including indentation, and only once; include a few surrounding lines when needed. To create a new file, leave the SEARCH part empty; an existing file is never replaced that way. Use one block per change.
+
+To see a file that is not shown yet, reply with a line "READ <path>" and nothing else that round.
+
+When the task is done, reply with a short summary of the change followed by a line "DONE".`
+
+var localReadPattern = regexp.MustCompile(`(?m)^READ\s+(\S+)\s*$`)
+
+// localEdit is one SEARCH/REPLACE block of a reply.
+type localEdit struct {
+	Path    string
+	Search  string
+	Replace string
+}
+
+// parseLocalReply finds the files the model asks to read, its edit blocks
+// and whether it says it is done.
+func parseLocalReply(reply string) (reads []string, edits []localEdit, done bool) {
+	for _, m := range localReadPattern.FindAllStringSubmatch(reply, -1) {
+		reads = append(reads, strings.Trim(m[1], "`"))
+	}
+	lines := strings.Split(reply, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.TrimSpace(line) == "DONE" {
+			done = true
+			continue
+		}
+		if strings.TrimSpace(line) != "<<<<<<< SEARCH" {
+			continue
+		}
+		edit := localEdit{Path: localEditPath(lines[:i])}
+		var search, replace []string
+		part := &search
+		closed := false
+		for i++; i < len(lines); i++ {
+			text := strings.TrimRight(lines[i], "\r")
+			switch strings.TrimSpace(text) {
+			case "=======":
+				part = &replace
+				continue
+			case ">>>>>>> REPLACE":
+				closed = true
+			}
+			if closed {
+				break
+			}
+			*part = append(*part, text)
+		}
+		if !closed || edit.Path == "" {
+			continue
+		}
+		edit.Search = joinLines(search)
+		edit.Replace = joinLines(replace)
+		edits = append(edits, edit)
+	}
+	return reads, edits, done
+}
+
+// localEditPath is the last line before a block that is not a code fence.
+func localEditPath(before []string) string {
+	for i := len(before) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(before[i])
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "FILE:")
+		return strings.Trim(strings.TrimSpace(line), "`*")
+	}
+	return ""
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ollamaChat streams replies from Ollama's /api/chat.
+type ollamaChat struct {
+	url         string
+	model       string
+	temperature string
+	seed        string
+	http        *apiHTTP
+}
+
+func (c *ollamaChat) send(ctx context.Context, messages []map[string]any, out io.Writer) (reply string, input, output int, err error) {
+	body := map[string]any{"model": c.model, "messages": messages, "stream": true}
+	options := map[string]any{}
+	if t, err := strconv.ParseFloat(c.temperature, 64); err == nil {
+		options["temperature"] = t
+	}
+	if s, err := strconv.Atoi(c.seed); err == nil {
+		options["seed"] = s
+	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
+	resp, err := c.http.post(ctx, strings.TrimRight(c.url, "/")+"/api/chat", nil, body)
+	if err != nil {
+		var statusErr *apiStatusError
+		if errors.As(err, &statusErr) {
+			return "", 0, 0, fmt.Errorf("ollama: %s", ollamaErrorText(statusErr))
+		}
+		return "", 0, 0, fmt.Errorf("cannot reach Ollama at %s (is `ollama serve` running?): %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var chunk struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Done            bool   `json:"done"`
+			Error           string `json:"error"`
+			PromptEvalCount int    `json:"prompt_eval_count"`
+			EvalCount       int    `json:"eval_count"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != "" {
+			return b.String(), input, output, fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		fmt.Fprint(out, chunk.Message.Content)
+		b.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			input, output = chunk.PromptEvalCount, chunk.EvalCount
+		}
+	}
+	fmt.Fprintln(out)
+	return b.String(), input, output, scanner.Err()
+}
+
+func ollamaErrorText(err *apiStatusError) string {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal([]byte(err.Body), &body) == nil && body.Error != "" {
+		return fmt.Sprintf("%s (HTTP %d)", body.Error, err.Status)
+	}
+	return err.Error()
+}
+
+// localAgent is the edit/apply loop of --agent local: the model answers
+// with edit blocks and READ requests, and gets back what happened, until it
+// says DONE or runs out of rounds.
+type localAgent struct {
+	chat  *ollamaChat
+	files *apiAgent
+	out   io.Writer
+}
+
+func (a *localAgent) run(ctx context.Context, system, prompt string) (input, output int, err error) {
+	messages := []map[string]any{
+		{"role": "system", "content": system},
+		{"role": "user", "content": prompt},
+	}
+	applied, nudged := 0, false
+	for round := 0; round < localMaxRounds; round++ {
+		reply, in, out, err := a.chat.send(ctx, messages, a.out)
+		input += in
+		output += out
+		if err != nil {
+			return input, output, err
+		}
+		messages = append(messages, map[string]any{"role": "assistant", "content": reply})
+
+		reads, edits, done := parseLocalReply(reply)
+		var feedback []string
+		failed := false
+		for _, edit := range edits {
+			text, editFailed := a.apply(ctx, edit)
+			failed = failed || editFailed
+			if !editFailed {
+				applied++
+			}
+			feedback = append(feedback, text)
+		}
+		for _, path := range reads {
+			text, readFailed := a.files.runTool(ctx, apiToolCall{Name: "read_file", Input: localToolInput(map[string]string{"path": path})})
+			if readFailed {
+				feedback = append(feedback, fmt.Sprintf("READ %s failed: %s", path, text))
+				continue
+			}
+			feedback = append(feedback, fmt.Sprintf("%s:\n```\n%s\n```", path, strings.TrimRight(text, "\n")))
+		}
+		switch {
+		case done && !failed && len(reads) == 0:
+			return input, output, nil
+		case len(feedback) == 0 && (applied > 0 || nudged):
+			// Nothing to act on: take the reply as the end of the task.
+			return input, output, nil
+		case len(feedback) == 0:
+			nudged = true
+			feedback = append(feedback, "No edit blocks or READ requests were found in your reply. Make the change with SEARCH/REPLACE blocks as described, or reply DONE if nothing needs to change.")
+		default:
+			feedback = append(feedback, "Continue. Reply DONE when the task is complete.")
+		}
+		messages = append(messages, map[string]any{"role": "user", "content": strings.Join(feedback, "\n\n")})
+	}
+	return input, output, fmt.Errorf("stopped after %d rounds", localMaxRounds)
+}
+
+// apply runs an edit block through the file tools of the api agent, which
+// keep it inside the repository.
+func (a *localAgent) apply(ctx context.Context, edit localEdit) (string, bool) {
+	if edit.Search == "" {
+		// An empty SEARCH only creates files; on an existing file it would
+		// silently replace everything.
+		if path, err := a.files.resolve(edit.Path); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Sprintf("%s already exists, so the block with an empty SEARCH part was not applied. Resend it with SEARCH quoting the exact lines to change.", edit.Path), true
+			}
+		}
+		text, failed := a.files.runTool(ctx, apiToolCall{Name: "write_file", Input: localToolInput(map[string]string{"path": edit.Path, "content": edit.Replace})})
+		if failed {
+			return fmt.Sprintf("Creating %s failed: %s", edit.Path, text), true
+		}
+		return "Created " + edit.Path + ".", false
+	}
+	text, failed := a.files.runTool(ctx, apiToolCall{Name: "edit_file", Input: localToolInput(map[string]string{"path": edit.Path, "old_text": edit.Search, "new_text": edit.Replace})})
+	if failed {
+		return fmt.Sprintf("The edit to %s was not applied: %s. Read the file again and resend the block.", edit.Path, text), true
+	}
+	return "Applied the edit to " + edit.Path + ".", false
+}
+
+func localToolInput(fields map[string]string) string {
+	data, _ := json.Marshal(fields)
+	return string(data)
+}
+
+// localPrompt adds what a model without tools needs to start: the tracked
+// files and the contents of those the prompt mentions.
+func localPrompt(root, prompt string) string {
+	out, err := exec.Command("git", "-C", root, "ls-files").Output()
+	if err != nil {
+		return prompt
+	}
+	files := nonEmptyLines(string(out))
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(prompt, "\n"))
+	b.WriteString("\n\n## Repository files\n\n")
+	for i, file := range files {
+		if i == localMaxListedFiles {
+			fmt.Fprintf(&b, "... and %d more\n", len(files)-i)
+			break
+		}
+		b.WriteString(file + "\n")
+	}
+	budget := localMaxContextBytes
+	for _, file := range files {
+		if !strings.Contains(prompt, file) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil || len(data) > budget {
+			continue
+		}
+		budget -= len(data)
+		fmt.Fprintf(&b, "\n## %s\n\n```\n%s```\n", file, data)
+	}
+	return b.String()
+}
+
+func localSystemPromptFor(root string) string {
+	system := localSystemPrompt
+	for _, name := range contextFilesForLocal {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			system += "\n\n# " + name + "\n\n" + strings.TrimSpace(string(data))
+		}
+	}
+	return system
+}
+
+// ollamaURL is --ollama-url, else OLLAMA_HOST as the ollama CLI reads it,
+// else the default port on this machine.
+func ollamaURL(value string) string {
+	if value == "" {
+		value = os.Getenv("OLLAMA_HOST")
+	}
+	if value == "" {
+		return defaultOllamaURL
+	}
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+	return strings.TrimRight(value, "/")
+}
+
+// runLocalAgent is the local agent: like api-agent, ghir runs itself with
+// local-agent, the prompt on stdin and the repository as its directory.
+func runLocalAgent(opts options) int {
+	prompt, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return exitCode(fmt.Errorf("read prompt: %w", err))
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return exitCode(err)
+	}
+	h := &apiHTTP{client: &http.Client{}, out: os.Stdout, sleep: time.Sleep}
+	agent := &localAgent{
+		chat:  &ollamaChat{url: ollamaURL(opts.OllamaURL), model: opts.Model, temperature: opts.Temperature, seed: opts.Seed, http: h},
+		files: &apiAgent{root: root, out: os.Stdout},
+		out:   os.Stdout,
+	}
+	input, output, err := agent.run(context.Background(), localSystemPromptFor(root), localPrompt(root, string(prompt)))
+	usage, _ := json.Marshal(map[string]any{"type": "result", "usage": map[string]int{"input_tokens": input, "output_tokens": output}})
+	fmt.Println(string(usage))
+	return exitCode(err)
+}
+
+// localAgentArgs runs ghir's own local-agent command for --agent local.
+func (r *runner) localAgentArgs() []string {
+	args := []string{commandLocalAgent, "--model", r.opts.Model}
+	if r.opts.OllamaURL != "" {
+		args = append(args, "--ollama-url", r.opts.OllamaURL)
+	}
+	return args
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLocalReply(t *testing.T) {
+	t.Parallel()
+
+	reply := "I'll rename the function.\n\n" +
+		"greet.go\n```go\n<<<<<<< SEARCH\nfunc hello() string {\n=======\nfunc greeting() string {\n>>>>>>> REPLACE\n```\n\n" +
+		"FILE: `docs/new.md`\n<<<<<<< SEARCH\n=======\n# New\n>>>>>>> REPLACE\n\n" +
+		"READ main.go\n" +
+		"broken.go\n<<<<<<< SEARCH\nnever closed\n"
+	reads, edits, done := parseLocalReply(reply)
+	if done {
+		t.Fatal("done = true")
+	}
+	if len(reads) != 1 || reads[0] != "main.go" {
+		t.Fatalf("reads = %v", reads)
+	}
+	want := []localEdit{
+		{Path: "greet.go", Search: "func hello() string {\n", Replace: "func greeting() string {\n"},
+		{Path: "docs/new.md", Replace: "# New\n"},
+	}
+	if len(edits) != len(want) {
+		t.Fatalf("edits = %+v", edits)
+	}
+	for i := range want {
+		if edits[i] != want[i] {
+			t.Fatalf("edit %d = %+v, want %+v", i, edits[i], want[i])
+		}
+	}
+
+	if _, _, done := parseLocalReply("Renamed hello to greeting.\nDONE\n"); !done {
+		t.Fatal("DONE not recognised")
+	}
+}
+
+// fakeOllama answers /api/chat with one canned reply per request, streamed
+// as NDJSON, and keeps the messages it was sent.
+type fakeOllama struct {
+	mu       sync.Mutex
+	replies  []string
+	requests []map[string]any
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.URL.Path != "/api/chat" {
+		http.NotFound(w, req)
+		return
+	}
+	var body map[string]any
+	_ = json.NewDecoder(req.Body).Decode(&body)
+	f.requests = append(f.requests, body)
+	if len(f.replies) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+		return
+	}
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	enc := json.NewEncoder(w)
+	half := len(reply) / 2
+	for _, part := range []string{reply[:half], reply[half:]} {
+		_ = enc.Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": part}, "done": false})
+	}
+	_ = enc.Encode(map[string]any{"done": true, "prompt_eval_count": 100, "eval_count": 10})
+}
+
+func TestLocalAgentLoop(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "greet.go"), []byte("package main\n\nfunc hello() string {\n\treturn \"hi\"\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ollama := &fakeOllama{replies: []string{
+		// A block that does not match, and a file to read.
+		"greet.go\n<<<<<<< SEARCH\nfunc hi() string {\n=======\nfunc greeting() string {\n>>>>>>> REPLACE\nREAD greet.go\n",
+		"greet.go\n<<<<<<< SEARCH\nfunc hello() string {\n=======\nfunc greeting() string {\n>>>>>>> REPLACE\n",
+		"Renamed hello to greeting.\nDONE\n",
+	}}
+	server := httptest.NewServer(ollama)
+	defer server.Close()
+
+	var out bytes.Buffer
+	agent := &localAgent{
+		chat:  &ollamaChat{url: server.URL, model: "qwen2.5-coder:7b", temperature: "0", seed: "42", http: &apiHTTP{client: server.Client(), out: &out, sleep: func(time.Duration) {}}},
+		files: &apiAgent{root: root, out: &out},
+		out:   &out,
+	}
+	input, output, err := agent.run(context.Background(), localSystemPrompt, "Rename hello to greeting")
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, out.String())
+	}
+	if input != 300 || output != 30 {
+		t.Fatalf("tokens = %d in, %d out", input, output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "greet.go")); !strings.Contains(string(data), "func greeting() string {") {
+		t.Fatalf("greet.go = %s", data)
+	}
+	if !strings.Contains(out.String(), "Renamed hello to greeting.") {
+		t.Fatalf("reply not streamed to the log:\n%s", out.String())
+	}
+
+	if len(ollama.requests) != 3 {
+		t.Fatalf("requests = %d", len(ollama.requests))
+	}
+	first := ollama.requests[0]
+	if first["model"] != "qwen2.5-coder:7b" || first["stream"] != true {
+		t.Fatalf("first request = %v", first)
+	}
+	if opts, _ := first["options"].(map[string]any); opts["temperature"] != 0.0 || opts["seed"] != 42.0 {
+		t.Fatalf("options = %v", first["options"])
+	}
+	messages, _ := ollama.requests[1]["messages"].([]any)
+	feedback, _ := messages[len(messages)-1].(map[string]any)["content"].(string)
+	if !strings.Contains(feedback, "The edit to greet.go was not applied: old_text not found") || !strings.Contains(feedback, "func hello() string {") {
+		t.Fatalf("feedback = %q", feedback)
+	}
+}
+
+func TestLocalAgentEmptySearch(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	original := "package main\n\nfunc hello() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "greet.go"), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	agent := &localAgent{files: &apiAgent{root: root, out: &out}, out: &out}
+
+	feedback, failed := agent.apply(context.Background(), localEdit{Path: "greet.go", Replace: "package main\n"})
+	if !failed || !strings.Contains(feedback, "greet.go already exists") {
+		t.Fatalf("apply() = %q, %v", feedback, failed)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "greet.go")); string(data) != original {
+		t.Fatalf("greet.go = %q, want it unchanged", data)
+	}
+
+	feedback, failed = agent.apply(context.Background(), localEdit{Path: "new.go", Replace: "package main\n"})
+	if failed {
+		t.Fatalf("apply() = %q, want new.go created", feedback)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "new.go")); string(data) != "package main\n" {
+		t.Fatalf("new.go = %q", data)
+	}
+}
+
+func TestLocalAgentErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(&fakeOllama{})
+	defer server.Close()
+	var out bytes.Buffer
+	h := &apiHTTP{client: server.Client(), out: &out, sleep: func(time.Duration) {}}
+	agent := &localAgent{chat: &ollamaChat{url: server.URL, model: "missing", http: h}, files: &apiAgent{root: t.TempDir(), out: &out}, out: &out}
+	if _, _, err := agent.run(context.Background(), "", "x"); err == nil || !strings.Contains(err.Error(), `model "missing" not found`) {
+		t.Fatalf("err = %v", err)
+	}
+
+	agent.chat.url = "http://127.0.0.1:1"
+	if _, _, err := agent.run(context.Background(), "", "x"); err == nil || !strings.Contains(err.Error(), "cannot reach Ollama") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestLocalAgentCommand(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{Agent: "local", Model: "qwen2.5-coder:7b", OllamaURL: "http://gpu-box:11434", Seed: "7"}}
+	cmd, err := r.buildAgentCommand("fix #5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "local-agent --model qwen2.5-coder:7b --ollama-url http://gpu-box:11434 --seed 7"
+	if got := strings.Join(cmd.Args[1:], " "); got != want {
+		t.Fatalf("command = %s, want %s", got, want)
+	}
+	if r.coAuthorTrailer() != "" {
+		t.Fatalf("trailer = %q", r.coAuthorTrailer())
+	}
+
+	for value, want := range map[string]string{"gpu-box:11434": "http://gpu-box:11434", "https://ollama.example.com/": "https://ollama.example.com"} {
+		if got := ollamaURL(value); got != want {
+			t.Fatalf("ollamaURL(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ import (
 const (
 	defaultIssueFilePath     = ".ticket-runner/issues.txt"
 	defaultPromptTemplate    = ".ticket-runner/prompt.tmpl"
+	defaultSkipFilePath      = ".ticket-runner/skip.txt"
 	defaultLogDirName        = ".ticket-runs"
 	defaultDoneFileName      = ".completed"
 	defaultFallbackWaitSec   = 1800
@@ -26,6 +28,11 @@ const (
 	countdownIntervalSeconds = 300
 	streamViewPretty         = "pretty"
 	streamViewRaw            = "raw"
+	stdinIssuesFile          = "-"
+	commandReverify          = "reverify"
+	commandBoard             = "board"
+	commandExportMetrics     = "export-metrics"
+	assigneeIssueLimit       = 500
 )
 
 var (
@@ -36,32 +43,154 @@ var (
 	geminiSessionLimitPattern = regexp.MustCompile(`(?is)(terminalquotaerror|quota\s+exceeded|rate\s+limit)`)
 	geminiResetDurationRegex  = regexp.MustCompile(`(?i)resets?\s+(?:after\s+)?(\d+h)?(\d+m)?(\d+s)?`)
 	geminiDurationPartRegex   = regexp.MustCompile(`(?i)(\d+)([hms])`)
+	qwenSessionLimitPattern   = regexp.MustCompile(`(?i)(free allocated quota|daily quota|throttling\.|insufficient_quota)`)
+	opencodeRateLimitPattern  = regexp.MustCompile(`(?i)(rate[_ ]limit(ed|_error| exceeded| reached)|too many requests|status ?code"?: ?429)`)
 	issuePattern              = regexp.MustCompile(`^\d+$`)
 )
 
+var directiveLabels = []string{"ghir:skip", "ghir:blocked", "ghir:needs-human"}
+
 type options struct {
-	DryRun         bool
-	SingleIssue    string
-	Force          bool
-	Status         bool
-	Reset          bool
-	ResetIssue     string
-	IssuesCSV      string
-	IssuesFile     string
-	LogDir         string
-	DoneFile       string
-	PromptTemplate string
-	Agent          string
-	Model          string
-	ClaudeBin      string
-	CodexBin       string
-	GeminiBin      string
-	CursorBin      string
-	GHBin          string
-	StreamView     string
-	NoColor        bool
-	Help           bool
-	WaitBufferSec  int
+	Command           string
+	DryRun            bool
+	SingleIssue       string
+	Force             bool
+	Status            bool
+	Reset             bool
+	ResetIssue        string
+	ResetFailed       bool
+	ResetNeedsReview  bool
+	ResetSince        string
+	GCKeep            string
+	GCArchive         string
+	IssuesCSV         string
+	IssuesFile        string
+	LogDir            string
+	DoneFile          string
+	PromptTemplate    string
+	Templates         string
+	RedactPatterns    []string
+	Seed              string
+	Temperature       string
+	ReproAttempt      int
+	Timestamps        bool
+	EnvTools          []string
+	CreatePR          bool
+	PRTemplate        string
+	PRDraft           bool
+	Push              bool
+	PushRemote        string
+	CommentOnIssue    bool
+	CommentTemplate   string
+	DiffSummary       string
+	DiffSummaryCmd    string
+	SummaryModel      string
+	WIPLabel          string
+	AssignSelf        bool
+	CloseOnSuccess    bool
+	DoneLabel         string
+	Sample            int
+	Canary            string
+	Parallel          int
+	Stratify          string
+	Caches            []string
+	ShareDirs         []string
+	Notify            []string
+	Events            []string
+	Manifest          string
+	manifest          *runManifest
+	VerifyScope       string
+	VerifyFullAtEnd   bool
+	E2ECmd            string
+	Bisect            bool
+	RevertBad         bool
+	RollbackOnFailure bool
+	AutoStash         bool
+	Agent             string
+	FallbackAgents    []string
+	Model             string
+	ClaudeBin         string
+	CodexBin          string
+	GeminiBin         string
+	CursorBin         string
+	AiderBin          string
+	OpenCodeBin       string
+	CopilotBin        string
+	QwenBin           string
+	CustomAgents      map[string]customAgent
+	APIProvider       string
+	APIBaseURL        string
+	APIMaxTokens      int
+	OllamaURL         string
+	Translate         bool
+	TranslateModel    string
+	GHBin             string
+	StreamView        string
+	CoAuthor          string
+	VerifyRetries     int
+	LintCmd           string
+	ContentGate       string
+	DebugPatterns     []string
+	Artifacts         []string
+	Formatters        []string
+	FormatFix         string
+	ProtectFiles      string
+	BuildCmd          string
+	NoColor           bool
+	Plain             bool
+	Timezone          string
+	TUI               bool
+	Pick              bool
+	SarifFile         string
+	JUnitFile         string
+	Workflow          string
+	BenchCmd          string
+	BenchThreshold    float64
+	BenchLabel        string
+	Source            string
+	TodoTags          string
+	TodoPaths         string
+	SentryProject     string
+	SentryLimit       int
+	Org               string
+	Label             string
+	Workdir           string
+	AppID             string
+	AppKeyFile        string
+	AppInstallation   string
+	Help              bool
+	WaitBufferSec     int
+	OnLimit           string
+	GHWriteInterval   int
+	VerifyCmd         string
+	Reopen            bool
+	Assignee          string
+	Baseline          string
+	SnapshotFails     bool
+	IncludeClosed     bool
+	PriorityLabels    bool
+	SkipCSV           string
+	SkipFile          string
+	Serve             bool
+	Addr              string
+	ExportFormat      string
+	Out               string
+	EmitManifest      bool
+	ConfigFile        string
+	Profile           string
+	ProfileAction     string
+	ConfigAction      string
+	ProfileName       string
+	ProfileRef        string
+	Args              []string
+	Lang              string
+	LogsTail          int
+	LogsVerify        bool
+	Output            string
+	NoSync            bool
+	Verbosity         int
+	Quiet             bool
+	explicit          map[string]struct{}
 }
 
 type palette struct {
@@ -73,16 +202,70 @@ type palette struct {
 }
 
 type runner struct {
-	opts     options
-	repoRoot string
-	doneFile string
-	doneSet  map[string]struct{}
-	colors   palette
+	opts options
+	// mu is set while issues run in parallel; see locked.
+	mu *sync.Mutex
+	// writes paces GitHub mutations; shared by every copy of the runner.
+	writes *ghWriter
+	// limits pauses every parallel worker of an agent once one of them
+	// hits its session limit; nil in sequential runs.
+	limits *sessionLimits
+	// baseBranch is the branch issue branches were cut from when ghir
+	// created them in a worktree; PRs target it.
+	baseBranch string
+	repoRoot   string
+	doneFile   string
+	doneSet    map[string]doneRecord
+	skipSet    map[string]struct{}
+	state      *stateStore
+	colors     palette
+	baselines  map[string]verifyResult
+	snapshot   *verifyResult
+	failures   map[string]failureCategory
+	fallbacks  map[string]string
+	outcomes   map[string]agentOutcome
+	estimates  map[string]*promptEstimate
+	catalog    map[string]string
+	loc        *time.Location
+	tui        *tuiScreen
+	journal    *runJournal
+	events     *eventBus
+	runDir     string
+	app        *githubApp
+	redactor   *redactor
+	// stampedOut is stdout with --timestamps prefixes, shared by every
+	// per-issue copy of the runner so partial lines are tracked once.
+	stampedOut *timestampWriter
+	// envFingerprint identifies the toolchain of this run (environment.go).
+	envFingerprint string
+	// cacheEnv points the build cache variables of --cache at their shared
+	// directories; nil keeps the inherited environment.
+	cacheEnv []string
+	// promptOverride replaces the built prompt (refine).
+	promptOverride string
+	// limitedAgents holds the reset time of agents left at a session limit
+	// by --on-limit switch (agentchain.go).
+	limitedAgents map[string]time.Time
+	// switchOnLimit is set on the runner of every agent of the chain but
+	// the last.
+	switchOnLimit bool
 }
 
 type issueDetails struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	State     string          `json:"state"`
+	Labels    []issueLabel    `json:"labels"`
+	Assignees []issueAssignee `json:"assignees"`
+	Source    string          `json:"-"`
+}
+
+type issueAssignee struct {
+	Login string `json:"login"`
+}
+
+type issueLabel struct {
+	Name string `json:"name"`
 }
 
 type issueResult int
@@ -91,17 +274,25 @@ const (
 	resultSuccess issueResult = iota
 	resultFailed
 	resultRetry
+	resultSkipped
 )
 
 func main() {
+	preloadCustomAgents(os.Args[1:])
 	opts, err := parseArgs(os.Args[1:])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n\n", err)
-		printUsage()
+		printCommandUsage(opts.Command)
 		os.Exit(2)
 	}
 	if opts.Help {
-		printUsage()
+		printCommandUsage(opts.Command)
+		return
+	}
+	if cmd, _ := lookupCommand(opts.Command); cmd.standalone != nil {
+		if code := cmd.standalone(opts); code != 0 {
+			os.Exit(code)
+		}
 		return
 	}
 
@@ -111,86 +302,77 @@ func main() {
 		os.Exit(1)
 	}
 
-	applyRepoDefaults(&opts, repoRoot)
-
-	r, err := newRunner(opts, repoRoot)
-	if err != nil {
+	if err := applyRepoDefaults(&opts, repoRoot); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-
-	if opts.Reset {
-		if err := r.handleReset(); err != nil {
-			fmt.Fprintf(os.Stderr, "error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	if err := validateOptions(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n\n", err)
+		printCommandUsage(opts.Command)
+		os.Exit(2)
 	}
 
-	issues, err := r.loadIssues()
+	r, err := newRunner(opts, repoRoot)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if opts.Status {
-		r.printStatus(issues)
-		return
-	}
-
-	r.printBanner(issues)
-
-	if opts.SingleIssue != "" {
-		r.opts.Force = true
-		result := r.processIssue(1, len(issues), issues[0])
-		if result != resultSuccess {
-			os.Exit(1)
-		}
-		return
-	}
-
-	succeeded, failed := 0, 0
-	for i, issue := range issues {
-		idx := i + 1
-		result := r.processIssue(idx, len(issues), issue)
-		for result == resultRetry {
-			r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", issue)
-			result = r.processIssue(idx, len(issues), issue)
-		}
-		if result == resultSuccess {
-			succeeded++
-			continue
-		}
-		failed++
-		r.printf(r.colors.Red, "Stopping due to failure on issue #%s\n", issue)
-		break
-	}
-
-	fmt.Println()
-	r.printf(r.colors.Blue, "============================================================\n")
-	r.printf(r.colors.Green, "Succeeded: %d\n", succeeded)
-	r.printf(r.colors.Red, "Failed: %d\n", failed)
-	r.printf(r.colors.Blue, "============================================================\n")
-
-	if failed > 0 {
-		os.Exit(1)
+	cmd, _ := lookupCommand(opts.Command)
+	if code := cmd.run(r); code != 0 {
+		os.Exit(code)
 	}
 }
 
 func parseArgs(args []string) (options, error) {
 	opts := options{
-		Agent:         "claude",
-		ClaudeBin:     "claude",
-		CodexBin:      "codex",
-		GeminiBin:     "gemini",
-		CursorBin:     "cursor-agent",
-		GHBin:         "gh",
-		StreamView:    streamViewPretty,
-		WaitBufferSec: defaultSessionBufferSec,
+		Agent:           "claude",
+		Addr:            defaultBoardAddr,
+		ExportFormat:    exportFormatCSV,
+		Output:          outputText,
+		Source:          sourceGitHub,
+		TodoTags:        defaultTodoTags,
+		SentryLimit:     defaultSentryLimit,
+		BenchLabel:      defaultBenchLabel,
+		BenchThreshold:  defaultBenchThreshold,
+		ClaudeBin:       "claude",
+		CodexBin:        "codex",
+		GeminiBin:       "gemini",
+		CursorBin:       "cursor-agent",
+		AiderBin:        "aider",
+		OpenCodeBin:     "opencode",
+		CopilotBin:      "copilot",
+		QwenBin:         "qwen",
+		TranslateModel:  defaultTranslateModel,
+		DiffSummary:     diffSummaryStats,
+		SummaryModel:    defaultDiffSummaryModel,
+		GHBin:           "gh",
+		StreamView:      streamViewPretty,
+		ContentGate:     contentGateWarn,
+		FormatFix:       formatFixCommit,
+		ProtectFiles:    protectRevert,
+		APIProvider:     apiProviderAnthropic,
+		VerifyScope:     verifyScopeFull,
+		WaitBufferSec:   defaultSessionBufferSec,
+		OnLimit:         onLimitWait,
+		GCKeep:          defaultGCKeep,
+		GHWriteInterval: defaultGHWriteIntervalSec,
+		explicit:        make(map[string]struct{}),
+	}
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if _, ok := lookupCommand(args[0]); !ok {
+			return opts, fmt.Errorf("unknown command: %s", args[0])
+		}
+		opts.Command = args[0]
+		args = args[1:]
 	}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			opts.explicit[arg] = struct{}{}
+		}
 		switch arg {
 		case "--dry-run":
 			opts.DryRun = true
@@ -199,125 +381,814 @@ func parseArgs(args []string) (options, error) {
 			if err != nil {
 				return opts, err
 			}
-			opts.SingleIssue = val
+			opts.SingleIssue = val
+			i = next
+		case "--force":
+			opts.Force = true
+		case "--status":
+			opts.Status = true
+		case "--repro":
+			if opts.Command == "" {
+				opts.Command = commandRepro
+			}
+		case "--seed":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if opts.Seed, err = parseSeed(val); err != nil {
+				return opts, err
+			}
+			i = next
+		case "--temperature":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if opts.Temperature, err = parseTemperature(val); err != nil {
+				return opts, err
+			}
+			i = next
+		case "--reset":
+			opts.Reset = true
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				opts.ResetIssue = args[i+1]
+				i++
+			}
+		case "--keep":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if _, err := parseRetention(val); err != nil {
+				return opts, err
+			}
+			opts.GCKeep = val
+			i = next
+		case "--archive":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.GCArchive = val
+			i = next
+		case "--failed-only":
+			opts.ResetFailed = true
+		case "--needs-review":
+			opts.ResetNeedsReview = true
+		case "--since":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ResetSince = val
+			i = next
+		case "--issues":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.IssuesCSV = val
+			i = next
+		case "--issues-file":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.IssuesFile = val
+			i = next
+		case "--log-dir":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.LogDir = val
+			i = next
+		case "--done-file":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.DoneFile = val
+			i = next
+		case "--prompt-template":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.PromptTemplate = val
+			i = next
+		case "--templates":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Templates = val
+			i = next
+		case "--agent":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if opts.Agent, opts.FallbackAgents, err = parseAgentChain(val); err != nil {
+				return opts, fmt.Errorf("--agent %w", err)
+			}
+			i = next
+		case "--model":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Model = val
+			i = next
+		case "--co-author":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.CoAuthor = val
+			i = next
+		case "--claude-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ClaudeBin = val
+			i = next
+		case "--codex-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.CodexBin = val
+			i = next
+		case "--gemini-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.GeminiBin = val
+			i = next
+		case "--cursor-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.CursorBin = val
+			i = next
+		case "--aider-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.AiderBin = val
+			i = next
+		case "--opencode-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.OpenCodeBin = val
+			i = next
+		case "--translate":
+			opts.Translate = true
+		case "--translate-model":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.TranslateModel = val
+			i = next
+		case "--qwen-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.QwenBin = val
+			i = next
+		case "--api-provider":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.APIProvider = val
+			i = next
+		case "--api-base-url":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.APIBaseURL = val
+			i = next
+		case "--api-max-tokens":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			maxTokens, convErr := strconv.Atoi(val)
+			if convErr != nil || maxTokens <= 0 {
+				return opts, fmt.Errorf("--api-max-tokens must be a positive integer")
+			}
+			opts.APIMaxTokens = maxTokens
+			i = next
+		case "--ollama-url":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.OllamaURL = val
+			i = next
+		case "--copilot-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.CopilotBin = val
+			i = next
+		case "--gh-bin":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.GHBin = val
+			i = next
+		case "--wait-buffer-sec":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			waitSec, convErr := strconv.Atoi(val)
+			if convErr != nil || waitSec < 0 {
+				return opts, fmt.Errorf("--wait-buffer-sec must be a non-negative integer")
+			}
+			opts.WaitBufferSec = waitSec
+			i = next
+		case "--gh-write-interval":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			interval, convErr := strconv.Atoi(val)
+			if convErr != nil || interval < 0 {
+				return opts, fmt.Errorf("--gh-write-interval must be a non-negative integer")
+			}
+			opts.GHWriteInterval = interval
+			i = next
+		case "--stream-view":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.StreamView = strings.ToLower(val)
+			i = next
+		case "--assigned-to-me":
+			if opts.Assignee != "" && opts.Assignee != "@me" {
+				return opts, fmt.Errorf("--assigned-to-me cannot be combined with --assignee")
+			}
+			opts.Assignee = "@me"
+		case "--assignee":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if opts.Assignee != "" {
+				return opts, fmt.Errorf("--assigned-to-me cannot be combined with --assignee")
+			}
+			opts.Assignee = val
+			i = next
+		case "--verify-cmd":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.VerifyCmd = val
+			i = next
+		case "--baseline":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Baseline = val
+			i = next
+		case "--snapshot-failures":
+			opts.SnapshotFails = true
+		case "--skip":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.SkipCSV = val
+			i = next
+		case "--serve":
+			opts.Serve = true
+		case "--addr":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Addr = val
+			i = next
+		case "--output":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Output = strings.ToLower(val)
+			i = next
+		case "--source":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Source = strings.ToLower(val)
+			i = next
+		case "--todo-tags":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.TodoTags = val
+			i = next
+		case "--cache":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if _, _, err := parseCacheSpec(val); err != nil {
+				return opts, err
+			}
+			opts.Caches = append(opts.Caches, val)
+			i = next
+		case "--on-limit":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.OnLimit = strings.ToLower(val)
+			i = next
+		case "--verify-scope":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.VerifyScope = val
+			i = next
+		case "--verify-full-at-end":
+			opts.VerifyFullAtEnd = true
+		case "--e2e-cmd":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.E2ECmd = val
+			i = next
+		case "--bisect":
+			opts.Bisect = true
+		case "--revert-bad":
+			opts.RevertBad = true
+		case "--rollback-on-failure":
+			opts.RollbackOnFailure = true
+		case "--autostash":
+			opts.AutoStash = true
+		case "--events":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if err := validateEventSink(val); err != nil {
+				return opts, err
+			}
+			opts.Events = append(opts.Events, val)
+			i = next
+		case "--notify":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if err := validateNotifyTarget(val); err != nil {
+				return opts, err
+			}
+			opts.Notify = append(opts.Notify, val)
+			i = next
+		case "-f", "--manifest":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Manifest = val
+			i = next
+		case "--share-dir":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ShareDirs = append(opts.ShareDirs, filepath.Clean(val))
+			i = next
+		case "--redact":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if _, err := compileRedactPatterns([]string{val}); err != nil {
+				return opts, err
+			}
+			opts.RedactPatterns = append(opts.RedactPatterns, val)
+			i = next
+		case "--todo-path":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.TodoPaths = val
+			i = next
+		case "--sentry-project":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.SentryProject = val
+			i = next
+		case "--sample":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			n, convErr := strconv.Atoi(val)
+			if convErr != nil || n <= 0 {
+				return opts, fmt.Errorf("--sample must be a positive integer: %q", val)
+			}
+			opts.Sample = n
+			i = next
+		case "--canary":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Canary = val
+			i = next
+		case "--build-cmd":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.BuildCmd = val
+			i = next
+		case "--lint-cmd":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.LintCmd = val
+			i = next
+		case "--content-gate":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ContentGate = val
+			i = next
+		case "--debug-pattern":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if _, err := compileDebugPatterns([]string{val}); err != nil {
+				return opts, err
+			}
+			opts.DebugPatterns = append(opts.DebugPatterns, val)
+			i = next
+		case "--artifact":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if err := validArtifactPattern(val); err != nil {
+				return opts, err
+			}
+			opts.Artifacts = append(opts.Artifacts, val)
+			i = next
+		case "--formatter":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			if _, err := parseFormatter(val); err != nil {
+				return opts, err
+			}
+			opts.Formatters = append(opts.Formatters, val)
+			i = next
+		case "--format-fix":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.FormatFix = val
+			i = next
+		case "--protect-runner-files":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ProtectFiles = val
+			i = next
+		case "--verify-retries":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			n, convErr := strconv.Atoi(val)
+			if convErr != nil || n < 0 {
+				return opts, fmt.Errorf("--verify-retries must be a non-negative integer: %q", val)
+			}
+			opts.VerifyRetries = n
+			i = next
+		case "--parallel":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			n, convErr := strconv.Atoi(val)
+			if convErr != nil || n <= 0 {
+				return opts, fmt.Errorf("--parallel must be a positive integer: %q", val)
+			}
+			opts.Parallel = n
+			i = next
+		case "--stratify":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Stratify = val
+			i = next
+		case "--sentry-limit":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			n, convErr := strconv.Atoi(val)
+			if convErr != nil || n <= 0 {
+				return opts, fmt.Errorf("--sentry-limit must be a positive integer: %q", val)
+			}
+			opts.SentryLimit = n
 			i = next
-		case "--force":
-			opts.Force = true
-		case "--status":
-			opts.Status = true
-		case "--reset":
-			opts.Reset = true
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
-				opts.ResetIssue = args[i+1]
-				i++
+		case "--format":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
 			}
-		case "--issues":
+			opts.ExportFormat = strings.ToLower(val)
+			i = next
+		case "--emit-manifest":
+			opts.EmitManifest = true
+		case "--out":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.IssuesCSV = val
+			opts.Out = val
 			i = next
-		case "--issues-file":
+		case "--config":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.IssuesFile = val
+			opts.ConfigFile = val
 			i = next
-		case "--log-dir":
+		case "--profile":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.LogDir = val
+			opts.Profile = val
 			i = next
-		case "--done-file":
+		case "--lang":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.DoneFile = val
+			opts.Lang = normalizeLanguage(val)
 			i = next
-		case "--prompt-template":
+		case "--include-closed":
+			opts.IncludeClosed = true
+		case "--priority-labels":
+			opts.PriorityLabels = true
+		case "--reopen":
+			opts.Reopen = true
+		case "--no-sync":
+			opts.NoSync = true
+		case "--no-color":
+			opts.NoColor = true
+		case "--plain":
+			opts.Plain = true
+		case "--tui":
+			opts.TUI = true
+		case "--pick":
+			opts.Pick = true
+		case "--sarif":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.PromptTemplate = val
+			opts.SarifFile = val
 			i = next
-		case "--agent":
+		case "--bench-cmd":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.Agent = strings.ToLower(val)
+			opts.BenchCmd = val
 			i = next
-		case "--model":
+		case "--bench-threshold":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.Model = val
+			pct, convErr := strconv.ParseFloat(strings.TrimSuffix(val, "%"), 64)
+			if convErr != nil || pct < 0 {
+				return opts, fmt.Errorf("--bench-threshold must be a non-negative percentage: %q", val)
+			}
+			opts.BenchThreshold = pct
 			i = next
-		case "--claude-bin":
+		case "--bench-label":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.ClaudeBin = val
+			opts.BenchLabel = val
 			i = next
-		case "--codex-bin":
+		case "--junit":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.CodexBin = val
+			opts.JUnitFile = val
 			i = next
-		case "--gemini-bin":
+		case "--workflow":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.GeminiBin = val
+			opts.Workflow = val
 			i = next
-		case "--cursor-bin":
+		case "--org":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.CursorBin = val
+			opts.Org = val
 			i = next
-		case "--gh-bin":
+		case "--label":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.GHBin = val
+			opts.Label = val
 			i = next
-		case "--wait-buffer-sec":
+		case "--workdir":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			waitSec, convErr := strconv.Atoi(val)
-			if convErr != nil || waitSec < 0 {
-				return opts, fmt.Errorf("--wait-buffer-sec must be a non-negative integer")
+			opts.Workdir = val
+			i = next
+		case "--app-id":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
 			}
-			opts.WaitBufferSec = waitSec
+			opts.AppID = val
 			i = next
-		case "--stream-view":
+		case "--app-key":
 			val, next, err := requireValue(arg, args, i)
 			if err != nil {
 				return opts, err
 			}
-			opts.StreamView = strings.ToLower(val)
+			opts.AppKeyFile = val
+			i = next
+		case "--app-installation":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.AppInstallation = val
+			i = next
+		case "--timezone":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.Timezone = val
+			i = next
+		case "--ref":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ProfileRef = val
+			i = next
+		case "--name":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.ProfileName = val
+			i = next
+		case "--tail":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return opts, fmt.Errorf("--tail must be a positive integer")
+			}
+			opts.LogsTail = n
+			i = next
+		case "--verify":
+			opts.LogsVerify = true
+		case "-v":
+			opts.Verbosity++
+		case "-vv":
+			opts.Verbosity += 2
+		case "-q", "--quiet":
+			opts.Quiet = true
+		case "--timestamps":
+			opts.Timestamps = true
+		case "--create-pr":
+			opts.CreatePR = true
+		case "--pr-draft":
+			opts.PRDraft = true
+		case "--push":
+			opts.Push = true
+		case "--close-on-success":
+			opts.CloseOnSuccess = true
+		case "--assign-self":
+			opts.AssignSelf = true
+		case "--wip-label":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.WIPLabel = val
+			i = next
+		case "--done-label":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.DoneLabel = val
+			i = next
+		case "--comment-on-issue":
+			opts.CommentOnIssue = true
+		case "--comment-template":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.CommentTemplate = val
+			i = next
+		case "--diff-summary":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.DiffSummary = val
+			i = next
+		case "--diff-summary-cmd":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.DiffSummaryCmd = val
+			i = next
+		case "--diff-summary-model":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.SummaryModel = val
+			i = next
+		case "--push-remote":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.PushRemote = val
+			i = next
+		case "--pr-template":
+			val, next, err := requireValue(arg, args, i)
+			if err != nil {
+				return opts, err
+			}
+			opts.PRTemplate = val
 			i = next
-		case "--no-color":
-			opts.NoColor = true
 		case "-h", "--help":
 			opts.Help = true
 		default:
+			if opts.Command != "" && !strings.HasPrefix(arg, "-") {
+				opts.Args = append(opts.Args, arg)
+				continue
+			}
 			return opts, fmt.Errorf("unknown option: %s", arg)
 		}
 	}
@@ -325,19 +1196,218 @@ func parseArgs(args []string) (options, error) {
 	if opts.SingleIssue != "" && !issuePattern.MatchString(opts.SingleIssue) {
 		return opts, fmt.Errorf("--issue must be numeric: %q", opts.SingleIssue)
 	}
-	if opts.ResetIssue != "" && !issuePattern.MatchString(opts.ResetIssue) {
+	if opts.ResetIssue != "" && !isIssueID(opts.ResetIssue) {
 		return opts, fmt.Errorf("--reset issue must be numeric: %q", opts.ResetIssue)
 	}
-	if opts.Agent != "claude" && opts.Agent != "codex" && opts.Agent != "gemini" && opts.Agent != "cursor-agent" {
-		return opts, fmt.Errorf("--agent must be one of: claude, codex, gemini, cursor-agent")
+	if !isSupportedAgent(opts.Agent) {
+		return opts, fmt.Errorf("--agent must be one of: %s", strings.Join(knownAgents(), ", "))
 	}
 	if opts.StreamView != streamViewPretty && opts.StreamView != streamViewRaw {
 		return opts, fmt.Errorf("--stream-view must be one of: %s, %s", streamViewPretty, streamViewRaw)
 	}
+	if opts.OnLimit != onLimitWait && opts.OnLimit != onLimitSwitch {
+		return opts, fmt.Errorf("--on-limit must be one of: %s, %s", onLimitWait, onLimitSwitch)
+	}
+	if opts.APIProvider != apiProviderAnthropic && opts.APIProvider != apiProviderOpenAI {
+		return opts, fmt.Errorf("--api-provider must be one of: %s", strings.Join(apiProviders, ", "))
+	}
+	if opts.ContentGate != contentGateOff && opts.ContentGate != contentGateWarn && opts.ContentGate != contentGateFail {
+		return opts, fmt.Errorf("--content-gate must be one of: %s, %s, %s", contentGateOff, contentGateWarn, contentGateFail)
+	}
+	if opts.FormatFix != formatFixCommit && opts.FormatFix != formatFixAmend {
+		return opts, fmt.Errorf("--format-fix must be one of: %s, %s", formatFixCommit, formatFixAmend)
+	}
+	if opts.DiffSummary != diffSummaryStats && opts.DiffSummary != diffSummaryModel && opts.DiffSummary != diffSummaryCommand {
+		return opts, fmt.Errorf("--diff-summary must be one of: %s, %s, %s", diffSummaryStats, diffSummaryModel, diffSummaryCommand)
+	}
+	if opts.ProtectFiles != protectRevert && opts.ProtectFiles != protectFail && opts.ProtectFiles != protectOff {
+		return opts, fmt.Errorf("--protect-runner-files must be one of: %s, %s, %s", protectRevert, protectFail, protectOff)
+	}
+	if opts.VerifyScope != verifyScopeFull && opts.VerifyScope != verifyScopeChanged {
+		return opts, fmt.Errorf("--verify-scope must be one of: %s, %s", verifyScopeFull, verifyScopeChanged)
+	}
+	if opts.ExportFormat != exportFormatCSV && opts.ExportFormat != exportFormatParquet {
+		return opts, fmt.Errorf("--format must be one of: %s, %s", exportFormatCSV, exportFormatParquet)
+	}
+	switch opts.Source {
+	case sourceGitHub, sourceTodos, sourceSentry, sourceActions:
+	default:
+		return opts, fmt.Errorf("--source must be one of: %s, %s, %s, %s", sourceGitHub, sourceTodos, sourceSentry, sourceActions)
+	}
+	if opts.Verbosity > verbosityDebug {
+		opts.Verbosity = verbosityDebug
+	}
+	if opts.Quiet && opts.Verbosity > 0 {
+		return opts, fmt.Errorf("--quiet cannot be combined with -v/-vv")
+	}
+	if opts.Output != outputText && opts.Output != outputJSON {
+		return opts, fmt.Errorf("--output must be one of: %s, %s", outputText, outputJSON)
+	}
+	if _, err := loadTimezone(opts.Timezone); err != nil {
+		return opts, fmt.Errorf("--timezone: %w", err)
+	}
+	if opts.Lang != "" && !isSupportedLanguage(opts.Lang) {
+		return opts, fmt.Errorf("--lang must be one of: %s", strings.Join(supportedLanguages(), ", "))
+	}
+	if err := finishCommandArgs(&opts); err != nil {
+		return opts, err
+	}
 
 	return opts, nil
 }
 
+func validateOptions(opts options) error {
+	if opts.Command == commandReverify && opts.VerifyCmd == "" {
+		return fmt.Errorf("reverify requires --verify-cmd")
+	}
+	if opts.Command == commandExperiment {
+		if opts.Templates == "" || opts.VerifyCmd == "" {
+			return fmt.Errorf("experiment requires --templates and --verify-cmd")
+		}
+		if _, err := parseTemplateList(opts.Templates); err != nil {
+			return err
+		}
+	}
+	if (opts.ResetFailed || opts.ResetNeedsReview || opts.ResetSince != "") && !opts.Reset {
+		return fmt.Errorf("--failed-only, --needs-review and --since only apply to reset")
+	}
+	if opts.Reset && opts.ResetIssue != "" && opts.resetFiltered() {
+		return fmt.Errorf("reset takes an issue id or filters, not both")
+	}
+	if opts.ResetSince != "" {
+		if _, err := parseResetSince(opts.ResetSince, time.UTC); err != nil {
+			return err
+		}
+	}
+	if (opts.AppID == "") != (opts.AppKeyFile == "") {
+		return fmt.Errorf("--app-id and --app-key must be used together")
+	}
+	if opts.AppInstallation != "" && !issuePattern.MatchString(opts.AppInstallation) {
+		return fmt.Errorf("--app-installation must be numeric: %q", opts.AppInstallation)
+	}
+	if opts.AppID != "" && opts.Assignee == "@me" {
+		return fmt.Errorf("--assigned-to-me does not work with GitHub App auth (the app has no assigned issues); use --assignee <user>")
+	}
+	if opts.SarifFile != "" && opts.JUnitFile != "" {
+		return fmt.Errorf("--sarif and --junit cannot be combined")
+	}
+	for _, file := range []struct{ flag, value string }{{"--sarif", opts.SarifFile}, {"--junit", opts.JUnitFile}} {
+		if file.value != "" && (opts.IssuesCSV != "" || opts.Assignee != "") {
+			return fmt.Errorf("%s cannot be combined with --issues, --assigned-to-me or --assignee", file.flag)
+		}
+	}
+	if opts.Source != sourceGitHub && (opts.IssuesCSV != "" || opts.Assignee != "" || opts.SarifFile != "" || opts.JUnitFile != "") {
+		return fmt.Errorf("--source %s cannot be combined with --issues, --sarif, --junit, --assigned-to-me or --assignee", opts.Source)
+	}
+	if opts.Workflow != "" && opts.Source != sourceActions {
+		return fmt.Errorf("--workflow requires --source actions")
+	}
+	if opts.flagSet("--todo-tags", "--todo-path") && opts.Source != sourceTodos {
+		return fmt.Errorf("--todo-tags and --todo-path require --source todos")
+	}
+	if opts.Source == sourceSentry && opts.SentryProject == "" {
+		return fmt.Errorf("--source sentry requires --sentry-project <org>/<project>")
+	}
+	if opts.flagSet("--sentry-project", "--sentry-limit") && opts.Source != sourceSentry {
+		return fmt.Errorf("--sentry-project and --sentry-limit require --source sentry")
+	}
+	if opts.Output == outputJSON && !opts.Status && opts.Command != commandMergeReport {
+		return fmt.Errorf("--output json is only supported with status and merge-report")
+	}
+	if opts.Pick && opts.SingleIssue != "" {
+		return fmt.Errorf("--pick cannot be combined with --issue")
+	}
+	if opts.TUI && opts.Plain {
+		return fmt.Errorf("--tui cannot be combined with --plain")
+	}
+	if opts.TUI && opts.Quiet {
+		return fmt.Errorf("--tui cannot be combined with --quiet")
+	}
+	if opts.flagSet("--bench-threshold", "--bench-label") && opts.BenchCmd == "" {
+		return fmt.Errorf("--bench-threshold and --bench-label require --bench-cmd")
+	}
+	if opts.flagSet("--pr-template") && !opts.CreatePR {
+		return fmt.Errorf("--pr-template requires --create-pr")
+	}
+	if opts.PRDraft && !opts.CreatePR {
+		return fmt.Errorf("--pr-draft requires --create-pr")
+	}
+	if opts.Command == commandPlan && opts.flagSet("--out") && !opts.EmitManifest {
+		return fmt.Errorf("plan --out requires --emit-manifest")
+	}
+	if opts.Parallel > 1 && (opts.VerifyFullAtEnd || opts.E2ECmd != "") {
+		return fmt.Errorf("--parallel cannot be combined with --verify-full-at-end or --e2e-cmd: parallel issues land on separate branches")
+	}
+	if opts.Stratify != "" && opts.Sample == 0 {
+		return fmt.Errorf("--stratify requires --sample")
+	}
+	if opts.Sample > 0 && (opts.Pick || opts.SingleIssue != "") {
+		return fmt.Errorf("--sample cannot be combined with --pick or --issue")
+	}
+	if err := validCoAuthor(opts.CoAuthor); err != nil {
+		return fmt.Errorf("--co-author: %w", err)
+	}
+	if opts.Canary != "" && opts.SingleIssue != "" {
+		return fmt.Errorf("--canary cannot be combined with --issue")
+	}
+	if opts.flagSet("--verify-retries") && opts.VerifyCmd == "" {
+		return fmt.Errorf("--verify-retries requires --verify-cmd")
+	}
+	if opts.flagSet("--comment-template") && !opts.CommentOnIssue {
+		return fmt.Errorf("--comment-template requires --comment-on-issue")
+	}
+	if opts.DiffSummary == diffSummaryCommand && opts.DiffSummaryCmd == "" {
+		return fmt.Errorf("--diff-summary command requires --diff-summary-cmd")
+	}
+	if opts.CloseOnSuccess && opts.CreatePR {
+		return fmt.Errorf("--close-on-success cannot be combined with --create-pr (merging the PR closes the issue)")
+	}
+	if opts.flagSet("--push-remote") && !opts.Push && !opts.CreatePR {
+		return fmt.Errorf("--push-remote requires --push or --create-pr")
+	}
+	if opts.Baseline != "" && opts.VerifyCmd == "" {
+		return fmt.Errorf("--baseline requires --verify-cmd")
+	}
+	for _, agent := range append([]string{opts.Agent}, opts.FallbackAgents...) {
+		if _, ok := opts.CustomAgents[agent]; !ok && isCustomAgent(agent) {
+			return fmt.Errorf("agent %s is not defined in the selected config or profile", agent)
+		}
+	}
+	if opts.Agent == "api" && opts.Model == "" {
+		return fmt.Errorf("--agent api requires --model")
+	}
+	if opts.Agent == "local" && opts.Model == "" {
+		return fmt.Errorf("--agent local requires --model (an Ollama model, e.g. qwen2.5-coder:7b)")
+	}
+	if opts.OnLimit == onLimitSwitch && len(opts.FallbackAgents) == 0 {
+		return fmt.Errorf("--on-limit switch needs an agent list to switch to, e.g. --agent claude,codex")
+	}
+	if opts.VerifyScope == verifyScopeChanged && opts.VerifyCmd == "" {
+		return fmt.Errorf("--verify-scope changed requires --verify-cmd")
+	}
+	if opts.VerifyFullAtEnd && opts.VerifyCmd == "" {
+		return fmt.Errorf("--verify-full-at-end requires --verify-cmd")
+	}
+	if opts.Bisect && !opts.VerifyFullAtEnd && opts.E2ECmd == "" {
+		return fmt.Errorf("--bisect requires --verify-full-at-end or --e2e-cmd")
+	}
+	if opts.RevertBad && !opts.Bisect {
+		return fmt.Errorf("--revert-bad requires --bisect")
+	}
+	if opts.SnapshotFails {
+		if opts.VerifyCmd == "" {
+			return fmt.Errorf("--snapshot-failures requires --verify-cmd")
+		}
+		if opts.Baseline != "" {
+			return fmt.Errorf("--snapshot-failures cannot be combined with --baseline")
+		}
+		if strings.Contains(opts.VerifyCmd, "{{ISSUE_NUMBER}}") {
+			return fmt.Errorf("--snapshot-failures needs a --verify-cmd that does not depend on {{ISSUE_NUMBER}}")
+		}
+	}
+	return nil
+}
+
 func requireValue(flag string, args []string, idx int) (string, int, error) {
 	if idx+1 >= len(args) {
 		return "", idx, fmt.Errorf("%s requires a value", flag)
@@ -353,31 +1423,147 @@ func printUsage() {
 
 Usage:
   ticket-runner [options]
+  ticket-runner <command> [arguments] [options]
+  ticket-runner <command> --help
+
+Commands:
+`)
+	for _, cmd := range cliCommands {
+		fmt.Printf("  %-30s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Print("\nOptions:\n" + optionsHelp)
+}
 
-Options:
-  --dry-run                     Show what would run without invoking the agent CLI
+const optionsHelp = `  --dry-run                     Show what would run, with prompt sizes and a cost estimate, without invoking the agent CLI
   --issue <id>                  Process exactly one issue (forced re-run)
-  --force                       Re-run even if issue is marked completed
+  -f, --manifest <path>         Run the issues and settings of a run manifest (YAML); archived with the results
+  --notify <url>                POST a JSON summary to this webhook when the run finishes (repeatable)
+  --events <sink>               Stream every run event as JSON to stdout, file:<path>, an http(s) URL or nats://host/subject (repeatable)
+  --force                       Re-run even if issue is marked completed (with init: overwrite existing files; with freeze: replace the frozen queue)
   --status                      Show completion status for configured issues
+  --output <text|json>          With status or merge-report: output format (json includes completion time, agent, commit and log path)
   --reset [id]                  Reset all completions, or one issue if id is provided
+  --failed-only                 With reset: only failed issues
+  --needs-review                With reset: only issues marked needs-review
+  --since <date>                With reset: only issues finished on or after this date (2006-01-02 or RFC 3339)
+  --keep <age>                  With gc: keep run data written in this period, e.g. 30d, 2w or 36h (default: 30d)
+  --archive <path>              With gc: pack what is removed into this .tar.zst, .tar.gz or .tar first
+  --pick                        Choose which pending issues of the queue to run from a checkbox list
+  --sample <n>                  Run n randomly chosen pending issues of the queue (reproducible with --seed)
+  --parallel <n>                Run n issues at a time, each in its own worktree and branch (default: ghir/issue-<id>)
+  --stratify <label[,label]>    With --sample: spread the sample over these labels in proportion to the queue
+  --canary <id|first>           Run this issue (or the first pending one) first and stop unless it passes every gate
+  --create-pr                   After a successful issue, push its branch (default: ghir/issue-<id>) and open a PR with gh
+  --pr-template <path>          With --create-pr: PR body template (default: .ticket-runner/pr.tmpl if present)
+  --pr-draft                    With --create-pr: open the PR as a draft that a human has to mark ready for review
+  --push                        Push the issue's branch (or the current branch) after each success; a rejected push stops the run
+  --push-remote <remote>        With --push or --create-pr: remote to push to (default: origin)
+  --close-on-success            Close the issue with a comment naming the commit once it succeeds (and passes --verify-cmd)
+  --assign-self                 Assign issues to the gh user when the agent starts on them; undone if the issue fails
+  --wip-label <label>           Label issues with this while the agent works on them (e.g. agent-in-progress)
+  --done-label <label>          Label issues with this when they succeed (e.g. agent-done)
+  --comment-on-issue            Keep one status comment on each issue: the commit, a diff stat and the branch or PR on success, also pauses and failures
+  --comment-template <path>     With --comment-on-issue: comment template (default: .ticket-runner/comment.tmpl if present)
+  --diff-summary <mode>         How changes are summarized for PRs, comments, state and notifications: stats (default), model or command
+  --diff-summary-cmd <cmd>      With --diff-summary command: shell command that gets the diff on stdin and prints the summary
+  --diff-summary-model <model>  With --diff-summary model: Claude model that writes the summary (default: haiku)
   --issues <id1,id2,...>        Comma-separated issue list (overrides file)
-  --issues-file <path>          Issue list file (default: .ticket-runner/issues.txt)
+  --issues-file <path>          Issue list file, or - for stdin (default: .ticket-runner/issues.txt)
+  --skip <id1,id2,...>          Never process these issues (also read from .ticket-runner/skip.txt)
+  --sarif <path>                Build the queue from SARIF findings, one task per rule and file
+  --junit <path|glob>           Build the queue from JUnit XML reports, one task per failing test
+  --source <github|todos|sentry|actions> Where tasks come from (default: github); todos scans tracked files for TODO/FIXME comments, sentry reads unresolved Sentry errors, actions reads the failed jobs of the latest failed GitHub Actions run
+  --workflow <name>             With --source actions: only look at runs of this workflow
+  --todo-tags <list>            With --source todos: comma-separated tags to look for (default: TODO,FIXME)
+  --sentry-project <org/proj>   With --source sentry: Sentry project to read unresolved issues from (token: SENTRY_AUTH_TOKEN)
+  --sentry-limit <n>            With --source sentry: number of issues to queue, most frequent first (default: 10)
+  --todo-path <list>            With --source todos: only scan these directories or globs (comma-separated)
+  --assigned-to-me              Build the queue from open issues assigned to you
+  --assignee <user>             Build the queue from open issues assigned to <user>
   --prompt-template <path>      Optional template with {{ISSUE_NUMBER}}, {{ISSUE_TITLE}}, {{ISSUE_BODY}}
-  --agent <claude|codex|gemini|cursor-agent> Agent CLI to run (default: claude)
+  --seed <n>                    Pass a sampling seed to agents that accept one; always recorded for repro
+  --temperature <t>             Pass a sampling temperature (0-2) to agents that accept one; always recorded
+  --redact <regex>              Also redact matches of this pattern in agent output and logs (repeatable)
+  --templates <a,b,...>         With experiment: prompt templates to compare on the same issues
+  --agent <claude|codex|gemini|cursor-agent|aider|opencode|copilot|qwen> Agent CLI to run (default: claude); a list such as claude,codex,gemini falls back to the next agent when one fails
   --model <model-id>            Override model for selected agent
+  --translate                   Add an English translation to issue bodies that look non-English (via the claude CLI)
+  --translate-model <model-id>  Claude model for --translate (default: haiku)
   --log-dir <path>              Log directory (default: .ticket-runs)
   --done-file <path>            Completion file (default: <log-dir>/.completed)
   --claude-bin <name/path>      Claude CLI command (default: claude)
   --codex-bin <name/path>       Codex CLI command (default: codex)
   --gemini-bin <name/path>      Gemini CLI command (default: gemini)
   --cursor-bin <name/path>      Cursor-agent CLI command (default: cursor-agent)
+  --aider-bin <name/path>       Aider CLI command (default: aider)
+  --opencode-bin <name/path>    OpenCode CLI command (default: opencode)
+  --copilot-bin <name/path>     GitHub Copilot CLI command (default: copilot)
+  --qwen-bin <name/path>        Qwen Code CLI command (default: qwen)
+  --api-provider <name>         API of --agent api: anthropic (default, ANTHROPIC_API_KEY) or openai (OPENAI_API_KEY)
+  --api-base-url <url>          Endpoint of --agent api, for proxies and OpenAI-compatible servers (default: the provider's)
+  --api-max-tokens <n>          Output token limit of each --agent api reply (default: 16384 for anthropic, the server's for openai)
+  --ollama-url <url>            Ollama server of --agent local (default: $OLLAMA_HOST or http://localhost:11434)
   --gh-bin <name/path>          GitHub CLI command (default: gh)
+  --app-id <id>                 Authenticate gh as this GitHub App instead of the logged-in user (needs --app-key)
+  --app-key <path>              GitHub App private key (PEM)
+  --app-installation <id>       GitHub App installation id (default: looked up from the origin remote)
   --stream-view <pretty|raw>    Console streaming view (default: pretty)
   --wait-buffer-sec <seconds>   Extra wait seconds after reset time (default: 120)
+  --on-limit <wait|switch>      At a session limit, wait for the reset or hand the issue to the next agent in the --agent list (default: wait)
+  --co-author <trailer|none>    Co-Authored-By trailer ("Name <email>") on commits ghir makes (default: the agent and model)
+  --gh-write-interval <seconds> Minimum seconds between GitHub writes (comments, labels, PRs); secondary rate limits are retried (default: 1, 0 disables)
+  --priority-labels             Order issues without an explicit priority by GitHub labels like p0/p1
+  --config <path>               Repo config file (default: .ticket-runner/config.yaml)
+  --profile <name>              Apply a named profile from the config file (flags still win)
+  --lang <code>                 Language for runner output and commit boilerplate (default: from GHIR_LANG/LC_ALL/LANG, else en)
+  --include-closed              Process issues even if they are already closed on GitHub
+  --verify-cmd <cmd>            Shell command that must pass before an issue is marked done ({{ISSUE_NUMBER}} is substituted)
+  --build-cmd <cmd>             Build command run after the agent; if it fails nothing is committed for the issue
+  --lint-cmd <cmd>              Lint command run next to --verify-cmd; its failures are reported as "lint", not "verification"
+  --content-gate <mode>         Check added lines for conflict markers, debug statements and disabled tests: warn (default), fail, off
+  --debug-pattern <regex>       Debug statement pattern for --content-gate, replacing the built-in ones (repeatable)
+  --formatter <spec>            Formatter run over changed files before commits: gofmt, goimports, prettier, black or <glob>=<cmd> (repeatable)
+  --format-fix <mode>           How formatting of the agent's own commits is committed: commit (a follow-up commit, default) or amend
+  --protect-runner-files <mode> Undo agent changes to .ticket-runner/, the issues file, templates and state: revert (default), fail, off
+  --artifact <glob>             Repository files a verification leaves behind (reports, screenshots) to keep with the issue's logs (repeatable)
+  --verify-retries <n>          When --verify-cmd fails, give the agent its output and let it fix the change, up to n times (default: 0)
+  --baseline <ref>              Also verify <ref> and only fail issues that introduce new failures
+  --bench-cmd <cmd>             For issues labeled --bench-label: run this Go-format benchmark command before and after the change
+  --bench-threshold <pct>       With --bench-cmd: fail when a metric gets worse by more than this (default: 5)
+  --bench-label <label>         With --bench-cmd: label that enables benchmarking (default: performance)
+  --snapshot-failures           Record failures on the clean tree at batch start and tolerate them during verification
+  --verify-scope <full|changed> changed: only run the tests of the packages an issue touched and their importers (default: full)
+  --verify-full-at-end          After the queue, run the full --verify-cmd once on the combined result
+  --e2e-cmd <cmd>               After the queue, run this end-to-end command on the combined result
+  --bisect                      When an end-of-batch check fails, git bisect the batch and mark the issue that broke it needs-review
+  --revert-bad                  With --bisect: revert the implicated issue's commits so the batch result is green again
+  --autostash                   Stash uncommitted changes before each issue and restore them afterwards instead of refusing to run
+  --rollback-on-failure         When an issue fails, stash its uncommitted changes and drop its commits (kept as refs/ghir/failed/<id>)
+  --cache <VAR=dir>             Point a build cache variable (GOCACHE, npm_config_cache, ...) at a directory shared by every issue (repeatable)
+  --share-dir <path>            Symlink this repo directory (node_modules, ...) into worktrees ghir creates (repeatable)
+  --reopen                      With reverify: reopen regressed issues on GitHub
+  --no-sync                     With merge-report: use the recorded PR states instead of asking GitHub
+  --tail <n>                    With logs: only print the last n lines
+  --verify                      With logs: show the verification log instead of the agent log
+  --serve                       With board: serve the board over HTTP, auto-refreshing during a run
+  --addr <host:port>            With board --serve: listen address (default: 127.0.0.1:8765)
+  --format <csv|parquet>        With export-metrics: output format (default: csv; parquet needs the duckdb CLI in PATH)
+  --out <path>                  With export-metrics or plan --emit-manifest: output file (default: stdout)
+  --emit-manifest               With plan: print the plan as a run manifest for run -f instead of a table
+  --org <org>                   With org run: organization to search
+  --label <label[,label]>       With org run or reset: only issues with these labels
+  --workdir <path>              With org run: where repos are cloned (default: <user cache>/ghir/org/<org>)
+  --ref <ref>                   With profile install/update: pin a branch, tag or commit
+  --name <name>                 With profile install: package name (default: repo name)
   --no-color                    Disable ANSI colors
+  -v, -vv                       More output: -v adds the agent command line and prompt size, -vv also every git/gh command run
+  -q, --quiet                   Do not mirror agent output to the console (it still goes to the log file)
+  --timestamps                  Prefix runner output and every log line with an RFC3339 timestamp
+  --tui                         Full-screen view with queue, live agent output and session-limit countdown panes
+  --timezone <zone>             Time zone for reset times, timestamps and file names: IANA name, UTC or Local (default: UTC)
+  --plain                       Screen-reader friendly output: no colors, separators or terminal control sequences
   -h, --help                    Show this help
-`)
-}
+`
 
 func findRepoRoot() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
@@ -388,10 +1574,55 @@ func findRepoRoot() (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
-func applyRepoDefaults(opts *options, repoRoot string) {
+func applyRepoDefaults(opts *options, repoRoot string) error {
+	configPath := filepath.Join(repoRoot, defaultConfigPath)
+	if opts.ConfigFile != "" {
+		configPath = resolvePath(repoRoot, opts.ConfigFile)
+	}
+	cfg, found, err := loadRepoConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if !found && opts.ConfigFile != "" {
+		return fmt.Errorf("config file not found: %s", configPath)
+	}
+	if opts.Manifest != "" {
+		if opts.manifest, err = loadRunManifest(resolvePath(repoRoot, opts.Manifest)); err != nil {
+			return err
+		}
+		if opts.Profile == "" {
+			opts.Profile = opts.manifest.Profile
+		}
+	}
+	if opts.Profile != "" {
+		if _, ok := cfg.Profiles[opts.Profile]; !ok {
+			installed, ok, err := findInstalledProfile(repoRoot, opts.Profile)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if cfg.Profiles == nil {
+					cfg.Profiles = make(map[string]repoConfig)
+				}
+				cfg.Profiles[opts.Profile] = installed
+			}
+		}
+		if cfg, err = cfg.withProfile(opts.Profile); err != nil {
+			return err
+		}
+		found = true
+	}
+	if opts.manifest != nil {
+		cfg = cfg.overlay(opts.manifest.Settings)
+		found = true
+	}
+	if found {
+		cfg.applyTo(opts)
+	}
+
 	if opts.IssuesFile == "" {
 		opts.IssuesFile = filepath.Join(repoRoot, defaultIssueFilePath)
-	} else {
+	} else if opts.IssuesFile != stdinIssuesFile {
 		opts.IssuesFile = resolvePath(repoRoot, opts.IssuesFile)
 	}
 
@@ -407,15 +1638,48 @@ func applyRepoDefaults(opts *options, repoRoot string) {
 		opts.DoneFile = resolvePath(repoRoot, opts.DoneFile)
 	}
 
+	if opts.SkipFile == "" {
+		opts.SkipFile = filepath.Join(repoRoot, defaultSkipFilePath)
+	} else {
+		opts.SkipFile = resolvePath(repoRoot, opts.SkipFile)
+	}
+
+	if opts.AppKeyFile != "" {
+		opts.AppKeyFile = resolvePath(repoRoot, opts.AppKeyFile)
+	}
+	if opts.SarifFile != "" {
+		opts.SarifFile = resolvePath(repoRoot, opts.SarifFile)
+	}
+	if opts.JUnitFile != "" {
+		opts.JUnitFile = resolvePath(repoRoot, opts.JUnitFile)
+	}
+
+	if opts.PRTemplate != "" {
+		opts.PRTemplate = resolvePath(repoRoot, opts.PRTemplate)
+	} else if candidate := filepath.Join(repoRoot, defaultPRTemplate); opts.CreatePR {
+		if _, err := os.Stat(candidate); err == nil {
+			opts.PRTemplate = candidate
+		}
+	}
+
+	if opts.CommentTemplate != "" {
+		opts.CommentTemplate = resolvePath(repoRoot, opts.CommentTemplate)
+	} else if candidate := filepath.Join(repoRoot, defaultCommentTemplate); opts.CommentOnIssue {
+		if _, err := os.Stat(candidate); err == nil {
+			opts.CommentTemplate = candidate
+		}
+	}
+
 	if opts.PromptTemplate != "" {
 		opts.PromptTemplate = resolvePath(repoRoot, opts.PromptTemplate)
-		return
+		return nil
 	}
 
 	candidate := filepath.Join(repoRoot, defaultPromptTemplate)
 	if _, err := os.Stat(candidate); err == nil {
 		opts.PromptTemplate = candidate
 	}
+	return nil
 }
 
 func resolvePath(repoRoot, value string) string {
@@ -437,25 +1701,76 @@ func newRunner(opts options, repoRoot string) (*runner, error) {
 	if err != nil {
 		return nil, err
 	}
+	skip, err := loadSkipSet(opts.SkipCSV, opts.SkipFile)
+	if err != nil {
+		return nil, err
+	}
+	state, err := loadStateStore(filepath.Join(opts.LogDir, defaultStateFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	colors := newPalette(opts)
+	catalog, err := loadCatalog(runnerLanguage(opts))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := loadTimezone(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(opts.RedactPatterns, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	cacheEnv, err := buildCacheEnv(opts.Caches, repoRoot, os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	var app *githubApp
+	if opts.AppID != "" {
+		if app, err = newGitHubApp(opts, repoRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &runner{
+		opts:      opts,
+		repoRoot:  repoRoot,
+		doneFile:  opts.DoneFile,
+		doneSet:   done,
+		skipSet:   skip,
+		state:     state,
+		colors:    colors,
+		baselines: make(map[string]verifyResult),
+		failures:  make(map[string]failureCategory),
+		fallbacks: make(map[string]string),
+		outcomes:  make(map[string]agentOutcome),
+		estimates: make(map[string]*promptEstimate),
+		catalog:   catalog,
+		loc:       loc,
+		app:       app,
+		redactor:  redactor,
+		cacheEnv:  cacheEnv,
+		writes:    newGHWriter(opts.GHWriteInterval),
+	}
+	if opts.Timestamps {
+		r.stampedOut = newTimestampWriter(os.Stdout, func() string { return r.timestamp(r.now()) })
+	}
+	return r, nil
+}
 
-	colors := palette{
+func newPalette(opts options) palette {
+	if opts.NoColor || opts.Plain || os.Getenv("NO_COLOR") != "" {
+		return palette{}
+	}
+	return palette{
 		Red:    "\033[0;31m",
 		Green:  "\033[0;32m",
 		Yellow: "\033[1;33m",
 		Blue:   "\033[0;34m",
 		Reset:  "\033[0m",
 	}
-	if opts.NoColor || os.Getenv("NO_COLOR") != "" {
-		colors = palette{}
-	}
-
-	return &runner{
-		opts:     opts,
-		repoRoot: repoRoot,
-		doneFile: opts.DoneFile,
-		doneSet:  done,
-		colors:   colors,
-	}, nil
 }
 
 func ensureFile(path string) error {
@@ -466,32 +1781,155 @@ func ensureFile(path string) error {
 	return f.Close()
 }
 
-func loadDoneSet(path string) (map[string]struct{}, error) {
+func loadDoneSet(path string) (map[string]doneRecord, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read done file: %w", err)
 	}
-	done := make(map[string]struct{})
+	done := make(map[string]doneRecord)
 	for _, raw := range strings.Split(string(data), "\n") {
-		id := strings.TrimSpace(raw)
-		if id == "" {
+		rec, ok := parseDoneLine(raw)
+		if !ok {
 			continue
 		}
-		done[id] = struct{}{}
+		done[rec.ID] = rec
 	}
 	return done, nil
 }
 
-func (r *runner) loadIssues() ([]string, error) {
+var supportedAgents = []string{"claude", "codex", "gemini", "cursor-agent", "aider", "opencode", "copilot", "qwen", "api", "local"}
+
+func isSupportedAgent(agent string) bool {
+	for _, supported := range supportedAgents {
+		if agent == supported {
+			return true
+		}
+	}
+	return isCustomAgent(agent)
+}
+
+func loadSkipSet(csv, path string) (map[string]struct{}, error) {
+	skip := make(map[string]struct{})
+	if strings.TrimSpace(csv) != "" {
+		ids, err := parseCSVIssues(csv)
+		if err != nil {
+			return nil, fmt.Errorf("--skip: %w", err)
+		}
+		for _, id := range ids {
+			skip[id] = struct{}{}
+		}
+	}
+	if path == "" {
+		return skip, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return skip, nil
+		}
+		return nil, fmt.Errorf("read skip file: %w", err)
+	}
+	ids, err := parseIssueLines(string(data), path)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		skip[id] = struct{}{}
+	}
+	return skip, nil
+}
+
+func (r *runner) loadIssues() ([]issueEntry, error) {
 	if r.opts.SingleIssue != "" {
-		return []string{r.opts.SingleIssue}, nil
+		return []issueEntry{{ID: r.opts.SingleIssue}}, nil
+	}
+	if listed, err := r.manifestIssues(); err != nil || listed != nil {
+		return listed, err
+	}
+	if frozen, err := r.frozenIssues(); err != nil || frozen != nil {
+		return frozen, err
 	}
+	entries, err := r.loadIssueEntries()
+	if err != nil {
+		return nil, err
+	}
+	return r.orderByPriority(entries)
+}
+
+func (r *runner) loadIssueEntries() ([]issueEntry, error) {
 	if r.opts.IssuesCSV != "" {
-		return parseCSVIssues(r.opts.IssuesCSV)
+		ids, err := parseCSVIssues(r.opts.IssuesCSV)
+		if err != nil {
+			return nil, err
+		}
+		return entriesFromIDs(ids), nil
+	}
+	if r.opts.SarifFile != "" {
+		return loadSarifIssues(r.opts.SarifFile, r.repoRoot)
+	}
+	if r.opts.JUnitFile != "" {
+		return loadJUnitIssues(r.opts.JUnitFile)
+	}
+	if r.opts.Source == sourceActions {
+		return r.loadActionsIssues()
+	}
+	if r.opts.Source == sourceTodos {
+		return r.loadTodoIssues()
+	}
+	if r.opts.Source == sourceSentry {
+		return r.loadSentryIssues()
+	}
+	if r.opts.Assignee != "" {
+		return r.fetchAssignedIssues()
 	}
 	return readIssuesFile(r.opts.IssuesFile)
 }
 
+func (r *runner) fetchAssignedIssues() ([]issueEntry, error) {
+	out, err := r.commandOutput(
+		r.opts.GHBin, "issue", "list",
+		"--assignee", r.opts.Assignee,
+		"--state", "open",
+		"--limit", strconv.Itoa(assigneeIssueLimit),
+		"--json", "number",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list issues assigned to %s: %w", r.opts.Assignee, err)
+	}
+	ids, err := parseIssueNumbersJSON(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no open issues assigned to %s", r.opts.Assignee)
+	}
+	return entriesFromIDs(ids), nil
+}
+
+func parseIssueNumbersJSON(data string) ([]string, error) {
+	var listed []struct {
+		Number int `json:"number"`
+	}
+	if err := json.Unmarshal([]byte(data), &listed); err != nil {
+		return nil, fmt.Errorf("parse gh issue list output: %w", err)
+	}
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, item := range listed {
+		if item.Number <= 0 {
+			continue
+		}
+		id := strconv.Itoa(item.Number)
+		if _, exists := seen[id]; exists {
+			continue
+		}
+		ids = append(ids, id)
+		seen[id] = struct{}{}
+	}
+	sortStringsNumeric(ids)
+	return ids, nil
+}
+
 func parseCSVIssues(value string) ([]string, error) {
 	parts := strings.Split(value, ",")
 	var issues []string
@@ -516,7 +1954,22 @@ func parseCSVIssues(value string) ([]string, error) {
 	return issues, nil
 }
 
-func readIssuesFile(path string) ([]string, error) {
+func readIssuesFile(path string) ([]issueEntry, error) {
+	if path == stdinIssuesFile {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read issues from stdin: %w", err)
+		}
+		if looksLikeJSON(data) {
+			return parseStructuredIssues(data, "stdin")
+		}
+		ids, err := parseIssueList(string(data), "stdin")
+		if err != nil {
+			return nil, err
+		}
+		return entriesFromIDs(ids), nil
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -524,8 +1977,29 @@ func readIssuesFile(path string) ([]string, error) {
 		}
 		return nil, fmt.Errorf("read issues file: %w", err)
 	}
+	if isStructuredIssueFile(path) {
+		return parseStructuredIssues(data, path)
+	}
+	ids, err := parseIssueList(string(data), path)
+	if err != nil {
+		return nil, err
+	}
+	return entriesFromIDs(ids), nil
+}
+
+func parseIssueList(data, source string) ([]string, error) {
+	issues, err := parseIssueLines(data, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return nil, fmt.Errorf("no issue ids found in %s", source)
+	}
+	return issues, nil
+}
 
-	lines := strings.Split(string(data), "\n")
+func parseIssueLines(data, source string) ([]string, error) {
+	lines := strings.Split(data, "\n")
 	var issues []string
 	seen := make(map[string]struct{})
 	for i, raw := range lines {
@@ -536,7 +2010,7 @@ func readIssuesFile(path string) ([]string, error) {
 		fields := strings.Fields(line)
 		id := fields[0]
 		if !issuePattern.MatchString(id) {
-			return nil, fmt.Errorf("invalid issue id at %s:%d: %q", path, i+1, id)
+			return nil, fmt.Errorf("invalid issue id at %s:%d: %q", source, i+1, id)
 		}
 		if _, exists := seen[id]; exists {
 			continue
@@ -544,19 +2018,35 @@ func readIssuesFile(path string) ([]string, error) {
 		issues = append(issues, id)
 		seen[id] = struct{}{}
 	}
-
-	if len(issues) == 0 {
-		return nil, fmt.Errorf("no issue ids found in %s", path)
-	}
 	return issues, nil
 }
 
 func (r *runner) handleReset() error {
+	if r.opts.resetFiltered() {
+		return r.resetMatching()
+	}
 	if r.opts.ResetIssue != "" {
 		delete(r.doneSet, r.opts.ResetIssue)
+		if err := r.markPending([]string{r.opts.ResetIssue}); err != nil {
+			return err
+		}
 		return r.rewriteDoneFile(fmt.Sprintf("Reset completion for issue #%s\n", r.opts.ResetIssue))
 	}
-	r.doneSet = make(map[string]struct{})
+	var ids []string
+	for id := range r.doneSet {
+		ids = append(ids, id)
+	}
+	if r.state != nil {
+		for id, st := range r.state.snapshot() {
+			if _, ok := r.doneSet[id]; !ok && st.Status == statusDone {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if err := r.markPending(ids); err != nil {
+		return err
+	}
+	r.doneSet = make(map[string]doneRecord)
 	if err := os.WriteFile(r.doneFile, []byte{}, 0o644); err != nil {
 		return fmt.Errorf("reset done file: %w", err)
 	}
@@ -565,19 +2055,38 @@ func (r *runner) handleReset() error {
 }
 
 func (r *runner) rewriteDoneFile(message string) error {
+	if err := r.writeDoneFile(); err != nil {
+		return err
+	}
+	r.printf(r.colors.Green, message)
+	return nil
+}
+
+// unmarkCompleted takes the issue out of the done file again.
+func (r *runner) unmarkCompleted(issue string) (err error) {
+	r.locked(func() {
+		if _, ok := r.doneSet[issue]; !ok {
+			return
+		}
+		delete(r.doneSet, issue)
+		err = r.writeDoneFile()
+	})
+	return err
+}
+
+func (r *runner) writeDoneFile() error {
 	var ids []string
 	for id := range r.doneSet {
 		ids = append(ids, id)
 	}
 	sortStringsNumeric(ids)
-	content := strings.Join(ids, "\n")
-	if content != "" {
-		content += "\n"
+	var content string
+	for _, id := range ids {
+		content += r.doneSet[id].line() + "\n"
 	}
 	if err := os.WriteFile(r.doneFile, []byte(content), 0o644); err != nil {
 		return fmt.Errorf("rewrite done file: %w", err)
 	}
-	r.printf(r.colors.Green, message)
 	return nil
 }
 
@@ -599,54 +2108,149 @@ func sortStringsNumeric(values []string) {
 	}
 }
 
-func (r *runner) printStatus(issues []string) {
+func (r *runner) printStatus(issues []issueEntry) {
 	r.printf(r.colors.Blue, "Completion status:\n")
-	for _, issue := range issues {
-		if r.isCompleted(issue) {
-			r.printf(r.colors.Green, "  #%s done\n", issue)
+	for _, entry := range issues {
+		if r.isCompleted(entry.ID) {
+			r.printf(r.colors.Green, "  #%s done\n", entry.ID)
+		} else if r.isSkipped(entry.ID) {
+			r.printf(r.colors.Blue, "  #%s skipped\n", entry.ID)
+		} else if st, ok := r.lastFailure(entry.ID); ok {
+			r.printf(r.colors.Red, "  #%s %s (%s)\n", entry.ID, st.Status, st.Failure)
 		} else {
-			r.printf(r.colors.Yellow, "  #%s pending\n", issue)
+			r.printf(r.colors.Yellow, "  #%s pending\n", entry.ID)
 		}
 	}
 }
 
-func (r *runner) printBanner(issues []string) {
-	completed := 0
-	for _, issue := range issues {
-		if r.isCompleted(issue) {
+func (r *runner) printBanner(issues []issueEntry) {
+	completed, skipped := 0, 0
+	for _, entry := range issues {
+		if r.isCompleted(entry.ID) {
 			completed++
+		} else if r.isSkipped(entry.ID) {
+			skipped++
 		}
 	}
-	remaining := len(issues) - completed
-	r.printf(r.colors.Blue, "============================================================\n")
-	r.printf(r.colors.Blue, "                     Ticket Runner\n")
-	r.printf(r.colors.Blue, "============================================================\n")
+	remaining := len(issues) - completed - skipped
+	r.rule(r.colors.Blue, "=")
+	r.heading(r.colors.Blue, "Ticket Runner")
+	r.rule(r.colors.Blue, "=")
 	r.printf(r.colors.Blue, "Agent: %s\n", agentDisplayName(r.opts.Agent))
+	if counts := r.queueAgents(issues); len(counts) > 1 {
+		r.printf(r.colors.Blue, "Agents: %s\n", agentBreakdown(counts))
+	}
+	if r.app != nil {
+		r.printf(r.colors.Blue, "GitHub auth: app %s\n", r.app.id)
+	}
 	if r.opts.Model != "" {
 		r.printf(r.colors.Blue, "Model override: %s\n", r.opts.Model)
 	}
 	r.printf(r.colors.Blue, "Stream view: %s\n", r.opts.StreamView)
-	r.printf(r.colors.Blue, "Total: %d | Completed: %d | Remaining: %d\n", len(issues), completed, remaining)
-	r.printf(r.colors.Blue, "============================================================\n")
-	fmt.Println()
+	if skipped > 0 {
+		r.printf(r.colors.Blue, "Total: %d | Completed: %d | Skipped: %d | Remaining: %d\n", len(issues), completed, skipped, remaining)
+	} else {
+		r.printf(r.colors.Blue, "Total: %d | Completed: %d | Remaining: %d\n", len(issues), completed, remaining)
+	}
+	r.rule(r.colors.Blue, "=")
+	fmt.Fprintln(r.stdout())
 }
 
-func (r *runner) processIssue(idx, total int, issue string) issueResult {
-	details, err := r.fetchIssueDetails(issue)
+func (r *runner) runIssue(idx, total int, entry issueEntry) (result issueResult) {
+	r = r.forIssue(entry)
+	issue := entry.ID
+
+	if r.isSkipped(issue) {
+		r.printf(r.colors.Yellow, "[%d/%d] Skipping issue #%s (skip list)\n", idx, total, issue)
+		return resultSkipped
+	}
+
+	var attempt *issueAttempt
+	var title string
+	var failure failureCategory
+	var failureCause error
+	fail := func(category failureCategory, cause error) issueResult {
+		failure = category
+		failureCause = cause
+		return resultFailed
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			r.writeDiagnostics(issue, failureUnclassified, fmt.Errorf("panic: %v\n%s", p, debug.Stack()), attempt)
+			panic(p)
+		}
+		if result == resultFailed {
+			r.recordFailure(issue, failure)
+			if attempt != nil {
+				attempt.failure = failure
+			}
+			if failure == failureUnclassified || failure == failureAgentCrash {
+				r.writeDiagnostics(issue, failure, failureCause, attempt)
+			}
+			if attempt != nil {
+				r.postProgress(issue, entry, fmt.Sprintf("ghir could not finish this issue: %s failure.", failure))
+			}
+		}
+		if attempt != nil {
+			r.finishAttempt(attempt, title, result)
+		}
+		r.recordAgentOutcome(issue, result)
+		r.record(journalEntry{Event: journalIssueFinished, Issue: issue, Agent: r.opts.Agent, Model: r.opts.Model, Result: result.String(), Failure: string(failure)})
+	}()
+
+	details, err := r.entryDetails(entry)
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: unable to fetch issue #%s: %v\n", issue, err)
-		return resultFailed
+		r.record(journalEntry{Event: journalFetchFailed, Issue: issue, Error: err.Error()})
+		return fail(failureFetch, err)
 	}
+	r.record(journalEntry{Event: journalIssueFetched, Issue: issue, Title: details.Title, State: details.State})
 
-	r.printf(r.colors.Blue, "------------------------------------------------------------\n")
+	r.rule(r.colors.Blue, "-")
+	title = details.Title
 	r.printf(r.colors.Blue, "[%d/%d] Issue #%s: %s\n", idx, total, issue, details.Title)
-	r.printf(r.colors.Blue, "------------------------------------------------------------\n")
+	if r.tui != nil {
+		r.tui.setCurrent(issue, details.Title)
+	}
+	r.rule(r.colors.Blue, "-")
+
+	if strings.EqualFold(details.State, "closed") && !r.opts.IncludeClosed {
+		if r.opts.DryRun {
+			r.printf(r.colors.Yellow, "[DRY RUN] Issue #%s is closed on GitHub, would skip and mark done\n", issue)
+			fmt.Fprintln(r.stdout())
+			return resultSkipped
+		}
+		r.printf(r.colors.Yellow, "Issue #%s is already closed on GitHub, skipping and marking done (use --include-closed to process it)\n", issue)
+		if err := r.markCompleted(issue, attempt); err != nil {
+			r.printf(r.colors.Red, "FAILED: could not mark #%s completed: %v\n", issue, err)
+			return fail(failureUnclassified, err)
+		}
+		fmt.Fprintln(r.stdout())
+		return resultSkipped
+	}
+
+	if label := directiveLabel(details); label != "" {
+		if r.opts.DryRun {
+			r.printf(r.colors.Yellow, "[DRY RUN] Would skip issue #%s (labeled %s)\n", issue, label)
+		} else {
+			r.printf(r.colors.Yellow, "Skipping issue #%s: labeled %s\n", issue, label)
+		}
+		fmt.Fprintln(r.stdout())
+		return resultSkipped
+	}
 
 	if r.opts.DryRun {
 		if r.isCompleted(issue) {
 			r.printf(r.colors.Green, "[DRY RUN] Already completed #%s, would skip\n", issue)
 		} else {
 			r.printf(r.colors.Yellow, "[DRY RUN] Would process issue #%s\n", issue)
+			if r.opts.Translate && looksNonEnglish(details.Body) {
+				r.printf(r.colors.Yellow, "[DRY RUN] Would add an English translation of #%s to the prompt\n", issue)
+			}
+			r.estimatePrompt(entry, details)
+			if r.opts.AssignSelf && !entry.synthetic() {
+				r.printf(r.colors.Yellow, "[DRY RUN] Would assign #%s to @me\n", issue)
+			}
 		}
 		return resultSuccess
 	}
@@ -656,114 +2260,341 @@ func (r *runner) processIssue(idx, total int, issue string) issueResult {
 		return resultSuccess
 	}
 
+	attempt = r.beginAttempt(issue)
+	if !entry.synthetic() {
+		// Label and assignee changes at the start and at the end of the
+		// issue each go out as one edit.
+		start := issueEdit{issue: issue}
+		r.markInProgress(&start)
+		assigned := r.opts.AssignSelf && r.assignSelf(&start, details)
+		if !r.applyIssueEdit(start) {
+			assigned = false
+		}
+		defer func() {
+			end := issueEdit{issue: issue, unassign: assigned && result == resultFailed}
+			r.finishInProgress(&end, result)
+			if r.applyIssueEdit(end) && end.unassign {
+				r.printf(r.colors.Yellow, "Unassigned #%s\n", issue)
+			}
+		}()
+	}
+
 	dirty, err := r.workingTreeDirty()
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: cannot determine git status: %v\n", err)
-		return resultFailed
+		return fail(failureGit, err)
 	}
-	if dirty {
+	if dirty && !r.opts.AutoStash {
 		r.printf(r.colors.Red, "ERROR: uncommitted changes detected. Commit or stash before running.\n")
-		return resultFailed
+		return fail(failureGit, nil)
+	}
+	if dirty {
+		stash, err := r.autostash(issue)
+		if err != nil {
+			r.printf(r.colors.Red, "FAILED: cannot stash uncommitted changes before #%s: %v\n", issue, err)
+			return fail(failureGit, err)
+		}
+		// Registered before the branch switch and the rollback, so it runs
+		// after both, on the branch the changes were stashed from.
+		defer r.restoreAutostash(issue, stash)
+	}
+
+	if r.opts.CreatePR {
+		entry.Branch = issueBranch(entry)
+	}
+	baseBranch := r.baseBranch
+	if entry.Branch != "" {
+		original, err := r.checkoutIssueBranch(entry.Branch)
+		if err != nil {
+			r.printf(r.colors.Red, "FAILED: cannot switch to branch %s for #%s: %v\n", entry.Branch, issue, err)
+			return fail(failureGit, err)
+		}
+		r.printf(r.colors.Blue, "Branch: %s\n", entry.Branch)
+		if original != "" {
+			baseBranch = original
+			defer func() {
+				if _, err := r.gitOutput("checkout", original); err != nil {
+					r.printf(r.colors.Yellow, "WARNING: could not switch back to %s: %v\n", original, err)
+				}
+			}()
+		}
 	}
 
 	startHead, err := r.gitOutput("rev-parse", "HEAD")
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: cannot determine pre-run git HEAD: %v\n", err)
-		return resultFailed
+		return fail(failureGit, err)
+	}
+	if r.opts.RollbackOnFailure {
+		// Runs before switching back from the issue branch, which needs a
+		// clean tree.
+		defer func() {
+			if result == resultFailed {
+				r.rollbackIssue(issue, startHead, attempt)
+			}
+		}()
 	}
 
-	prompt, err := r.buildPrompt(issue, details)
+	var benchBefore benchResult
+	if r.benchApplies(details) {
+		if benchBefore, err = r.runBench(issue, "before"); err != nil {
+			r.printf(r.colors.Red, "FAILED: cannot benchmark #%s before the change: %v\n", issue, err)
+			return fail(failureGate, err)
+		}
+	}
+
+	tracking := ""
+	if entry.Source == sourceSentry {
+		if tracking, err = r.trackingIssue(entry); err != nil {
+			r.printf(r.colors.Red, "FAILED: %v\n", err)
+			return fail(failureFetch, err)
+		}
+		r.printf(r.colors.Blue, "Tracking issue: #%s\n", tracking)
+		details.Body += fmt.Sprintf("\nTracking issue: #%s. End the commit message with \"Closes #%s\".\n", tracking, tracking)
+	}
+
+	promptDetails := details
+	promptDetails.Body = r.withTranslation(issue, details.Body)
+	prompt, err := r.buildPrompt(issue, promptDetails)
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: cannot build prompt for #%s: %v\n", issue, err)
-		return resultFailed
+		return fail(failureUnclassified, err)
+	}
+	prompt = appendInstructions(prompt, entry.Instructions)
+	criteria := parseAcceptanceCriteria(details.Body)
+	prompt = appendAcceptanceCriteria(prompt, criteria)
+	if r.promptOverride != "" {
+		prompt = r.promptOverride
 	}
 
-	logPath := filepath.Join(r.opts.LogDir, issue+".log")
+	r.awaitSessionLimit(issue)
+	logPath, err := r.attemptLogPath(issue)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: cannot create log for #%s: %v\n", issue, err)
+		return fail(failureUnclassified, err)
+	}
 	r.printf(r.colors.Yellow, "Starting %s for issue #%s...\n", agentDisplayName(r.opts.Agent), issue)
-	fmt.Printf("Log: %s\n", logPath)
-
+	r.printf("", "Log: %s\n", logPath)
+
+	attempt.logPath = logPath
+	attempt.promptPath = r.savePrompt(logPath, prompt)
+	r.saveAttemptSettings(issue, logPath, attempt.promptPath, prompt, startHead, entry.Branch)
+	r.record(journalEntry{Event: journalAgentInvoked, Issue: issue, Agent: r.opts.Agent, Model: r.opts.Model, LogPath: logPath, PromptBytes: len(prompt)})
+	agentStarted := time.Now()
+	runnerFiles := r.snapshotRunnerFiles()
 	exitCode, logOutput, err := r.runAgent(prompt, logPath)
+	attempt.logOutput = logOutput
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: %s invocation failed for #%s: %v\n", r.opts.Agent, issue, err)
-		return resultFailed
+		r.record(journalEntry{Event: journalAgentExited, Issue: issue, DurationSec: time.Since(agentStarted).Round(time.Second).Seconds(), Error: err.Error()})
+		r.guardRunnerFiles(issue, startHead, runnerFiles)
+		return fail(failureAgentCrash, err)
+	}
+	r.record(journalEntry{Event: journalAgentExited, Issue: issue, ExitCode: intPtr(exitCode), DurationSec: time.Since(agentStarted).Round(time.Second).Seconds()})
+	if !r.guardRunnerFiles(issue, startHead, runnerFiles) {
+		attempt.needsReview = true
+		return fail(failureGate, errors.New("the agent changed the runner's own files"))
 	}
 
-	if detectSessionLimit(logOutput, r.opts.Agent, exitCode) {
+	if r.sessionLimitHit(logOutput, exitCode) {
 		if dirtyNow, dirtyErr := r.workingTreeDirty(); dirtyErr == nil && dirtyNow {
 			r.printf(r.colors.Yellow, "Session limit hit mid-work. Committing partial progress...\n")
-			message := fmt.Sprintf(
-				"wip: partial work on #%s - %s (session limit hit)\n\nCo-Authored-By: Claude Opus 4.6 <noreply@anthropic.com>",
-				issue, details.Title,
-			)
+			message := fmt.Sprintf(r.tr("wip: partial work on #%s - %s (session limit hit)"), issue, details.Title) +
+				r.coAuthorTrailer()
 			if commitErr := r.commitAll(message); commitErr != nil {
 				r.printf(r.colors.Red, "FAILED: could not commit partial progress: %v\n", commitErr)
-				return resultFailed
+				return fail(failureGit, commitErr)
 			}
 		}
-		waitSeconds, resetTime := waitDuration(logOutput, time.Now().UTC(), r.opts.WaitBufferSec, r.opts.Agent)
-		r.waitForSessionReset(waitSeconds, resetTime)
+		if head, headErr := r.gitOutput("rev-parse", "HEAD"); headErr == nil && head != startHead {
+			r.recordCommits(issue, startHead, head)
+		}
+		waitSeconds, resetTime := waitDuration(logOutput, r.now(), r.opts.WaitBufferSec, r.opts.Agent)
+		r.record(journalEntry{Event: journalLimitDetected, Issue: issue, Agent: r.opts.Agent, WaitSec: waitSeconds, ResumeAt: r.timestamp(resetTime)})
+		if r.switchOnLimit {
+			r.locked(func() { r.limitedAgents[r.opts.Agent] = resetTime })
+			return resultRetry
+		}
+		r.tuiStatus(issue, tuiStatusWaiting)
+		r.postProgress(issue, entry, fmt.Sprintf("Paused by the %s session limit; ghir resumes at %s.", agentDisplayName(r.opts.Agent), resetTime.In(r.location()).Format("2006-01-02 15:04 MST")))
+		if r.limits == nil {
+			r.waitForSessionReset(issue, waitSeconds, resetTime)
+		} else if r.limits.pause(r.limitKey(), resetTime) {
+			r.printf(r.colors.Yellow, "Pausing every worker using %s until the session limit resets\n", agentDisplayName(r.opts.Agent))
+			r.waitForSessionReset(issue, waitSeconds, resetTime)
+		}
 		return resultRetry
 	}
 
 	if exitCode != 0 {
 		r.printf(r.colors.Red, "FAILED: %s exited with code %d for issue #%s\n", r.opts.Agent, exitCode, issue)
 		r.printf(r.colors.Red, "Check log: %s\n", logPath)
-		return resultFailed
+		return fail(classifyAgentExit(logOutput, r.opts.Agent, exitCode), fmt.Errorf("%s exited with code %d", r.opts.Agent, exitCode))
 	}
 
 	endHead, err := r.gitOutput("rev-parse", "HEAD")
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: cannot determine post-run git HEAD: %v\n", err)
-		return resultFailed
+		return fail(failureGit, err)
+	}
+	if r.opts.BuildCmd != "" {
+		dirtyNow, _ := r.workingTreeDirty()
+		if (endHead != startHead || dirtyNow) && !r.buildIssue(issue) {
+			if endHead != startHead {
+				attempt.commit = endHead
+				r.recordCommits(issue, startHead, endHead)
+			}
+			r.shelveUnbuilt(issue)
+			attempt.needsReview = true
+			return fail(failureBuild, nil)
+		}
+	}
+
+	run := issueRun{
+		issue:       issue,
+		entry:       entry,
+		details:     details,
+		startHead:   startHead,
+		baseBranch:  baseBranch,
+		tracking:    tracking,
+		prompt:      prompt,
+		logOutput:   logOutput,
+		criteria:    criteria,
+		benchBefore: benchBefore,
+		attempt:     attempt,
 	}
 
 	if endHead != startHead {
+		if head := r.formatCommits(issue, startHead); head != "" {
+			endHead = head
+		}
 		headMsg, _ := r.gitOutput("log", "-1", "--pretty=format:%s")
 		rangeSubjects, rangeErr := r.gitOutput("log", "--pretty=format:%s", fmt.Sprintf("%s..%s", startHead, endHead))
 		hasIssueRef := rangeErr == nil && issueMentionedInSubjects(rangeSubjects, issue)
+		attempt.commit = endHead
+		r.recordCommits(issue, startHead, endHead)
 
-		if err := r.markCompleted(issue); err != nil {
-			r.printf(r.colors.Red, "FAILED: could not mark #%s completed: %v\n", issue, err)
-			return resultFailed
-		}
-		r.printf(r.colors.Green, "SUCCESS: Issue #%s committed by %s\n", issue, agentDisplayName(r.opts.Agent))
-		if strings.TrimSpace(headMsg) != "" {
-			r.printf(r.colors.Green, "Commit: %s\n", headMsg)
-		}
-		if !hasIssueRef {
-			r.printf(r.colors.Yellow, "WARNING: new commit(s) do not mention #%s in subject lines.\n", issue)
+		category, err := r.finishIssue(run, func() {
+			r.printf(r.colors.Green, "SUCCESS: Issue #%s committed by %s\n", issue, agentDisplayName(r.opts.Agent))
+			if strings.TrimSpace(headMsg) != "" {
+				r.printf(r.colors.Green, "Commit: %s\n", headMsg)
+			}
+			if !hasIssueRef && !entry.synthetic() {
+				r.printf(r.colors.Yellow, "WARNING: new commit(s) do not mention #%s in subject lines.\n", issue)
+			}
+		})
+		if category != "" {
+			return fail(category, err)
 		}
-		fmt.Println()
 		return resultSuccess
 	}
 
 	dirty, err = r.workingTreeDirty()
 	if err != nil {
 		r.printf(r.colors.Red, "FAILED: cannot determine post-run git status: %v\n", err)
-		return resultFailed
+		return fail(failureGit, err)
 	}
 	if dirty {
 		r.printf(r.colors.Yellow, "%s did not commit. Uncommitted changes found, committing now.\n", agentDisplayName(r.opts.Agent))
-		message := fmt.Sprintf(
-			"feat: implement #%s - %s\n\nCloses #%s\n\nCo-Authored-By: Claude Opus 4.6 <noreply@anthropic.com>",
-			issue, details.Title, issue,
-		)
+		message := fmt.Sprintf(r.tr("feat: implement #%s - %s"), issue, details.Title)
+		if !entry.synthetic() {
+			message += fmt.Sprintf("\n\nCloses #%s", issue)
+		} else if tracking != "" {
+			message += fmt.Sprintf("\n\nCloses #%s", tracking)
+		}
+		message += r.coAuthorTrailer()
 		if err := r.commitAll(message); err != nil {
 			r.printf(r.colors.Red, "FAILED: fallback commit failed for #%s: %v\n", issue, err)
-			return resultFailed
+			return fail(failureGit, err)
 		}
-		if err := r.markCompleted(issue); err != nil {
-			r.printf(r.colors.Red, "FAILED: could not mark #%s completed: %v\n", issue, err)
-			return resultFailed
+		if head, err := r.gitOutput("rev-parse", "HEAD"); err == nil {
+			attempt.commit = head
+			r.recordCommits(issue, startHead, head)
+		}
+
+		category, err := r.finishIssue(run, func() {
+			r.printf(r.colors.Green, "SUCCESS: Issue #%s committed by runner\n", issue)
+		})
+		if category != "" {
+			return fail(category, err)
 		}
-		r.printf(r.colors.Green, "SUCCESS: Issue #%s committed by runner\n", issue)
-		fmt.Println()
 		return resultSuccess
 	}
 
 	r.printf(r.colors.Red, "FAILED: no changes produced for issue #%s\n", issue)
 	r.printf(r.colors.Red, "%s ran but made no modifications. Check log: %s\n", agentDisplayName(r.opts.Agent), logPath)
-	return resultFailed
+	return fail(failureNoChanges, nil)
+}
+
+// issueRun is what runIssue hands finishIssue once the issue's change is
+// committed.
+type issueRun struct {
+	issue       string
+	entry       issueEntry
+	details     issueDetails
+	startHead   string
+	baseBranch  string
+	tracking    string
+	prompt      string
+	logOutput   string
+	criteria    []string
+	benchBefore benchResult
+	attempt     *issueAttempt
+}
+
+// finishIssue runs the gates over the committed change and, when they pass,
+// pushes it and opens the PR, marks the issue completed, then comments on and
+// closes it.
+// announce prints the success lines. A failed step returns its category.
+func (r *runner) finishIssue(run issueRun, announce func()) (failureCategory, error) {
+	issue, entry, attempt := run.issue, run.entry, run.attempt
+	verified := r.verifyWithRetries(issue, run.startHead, run.prompt, attempt)
+	linted := r.lintIssue(issue)
+	if !verified || !r.confirmTodoRemoved(entry) {
+		attempt.needsReview = true
+		return failureVerification, nil
+	}
+	if !linted {
+		attempt.needsReview = true
+		return failureLint, nil
+	}
+	if !r.contentGate(issue, run.startHead, attempt) {
+		attempt.needsReview = true
+		return failureGate, nil
+	}
+	if run.benchBefore != nil && !r.benchGate(issue, run.benchBefore, attempt) {
+		attempt.needsReview = true
+		return failureGate, nil
+	}
+	r.checkAcceptanceCriteria(run.criteria, run.startHead, run.logOutput, attempt)
+	// The issue only counts as done once it is pushed, so a rejected push
+	// is retried by the next run.
+	if r.opts.Push {
+		if err := r.pushIssue(issue, entry.Branch); err != nil {
+			r.printf(r.colors.Red, "FAILED: %v\n", err)
+			attempt.needsReview = true
+			return failureGit, err
+		}
+	}
+	attempt.changes = r.summarizeChanges(issue, run.startHead)
+	prURL := ""
+	if r.opts.CreatePR {
+		prURL = r.openPullRequest(issue, entry, run.details, entry.Branch, run.baseBranch, run.startHead, run.tracking, attempt.artifacts, attempt.changes)
+	}
+	if err := r.markCompleted(issue, attempt); err != nil {
+		r.printf(r.colors.Red, "FAILED: could not mark #%s completed: %v\n", issue, err)
+		return failureUnclassified, err
+	}
+	attempt.completed = true
+	announce()
+	if r.opts.CommentOnIssue {
+		r.commentOnIssue(issue, entry, run.details, run.startHead, entry.Branch, prURL, run.tracking, attempt.changes)
+	}
+	if r.opts.CloseOnSuccess {
+		r.closeIssue(issue, entry, entry.Branch)
+	}
+	fmt.Fprintln(r.stdout())
+	return "", nil
 }
 
 func issueMentionedInSubjects(subjects, issue string) bool {
@@ -791,8 +2622,20 @@ func issueMentionedInSubjects(subjects, issue string) bool {
 	return false
 }
 
+func directiveLabel(details issueDetails) string {
+	for _, label := range details.Labels {
+		name := strings.ToLower(strings.TrimSpace(label.Name))
+		for _, directive := range directiveLabels {
+			if name == directive {
+				return directive
+			}
+		}
+	}
+	return ""
+}
+
 func (r *runner) fetchIssueDetails(issue string) (issueDetails, error) {
-	out, err := r.commandOutput(r.opts.GHBin, "issue", "view", issue, "--json", "title,body")
+	out, err := r.commandOutput(r.opts.GHBin, "issue", "view", issue, "--json", "title,body,state,labels,assignees")
 	if err != nil {
 		return issueDetails{}, err
 	}
@@ -814,11 +2657,14 @@ func (r *runner) buildPrompt(issue string, details issueDetails) (string, error)
 			return "", fmt.Errorf("read prompt template: %w", err)
 		}
 		templateBody = string(data)
+	} else if details.Source != "" {
+		templateBody = syntheticPromptBody
 	} else {
 		templateBody = defaultPromptBody
 	}
 
 	replacer := strings.NewReplacer(
+		"{{ISSUE_SOURCE}}", details.Source,
 		"{{ISSUE_NUMBER}}", issue,
 		"{{ISSUE_TITLE}}", details.Title,
 		"{{ISSUE_BODY}}", details.Body,
@@ -840,22 +2686,35 @@ func (r *runner) runAgent(prompt, logPath string) (int, string, error) {
 	if notice != "" {
 		r.printf(r.colors.Yellow, "%s\n", notice)
 	}
+	if r.opts.Plain || r.tui != nil {
+		renderer = &plainStreamRenderer{inner: renderer}
+	}
 
+	logWriter := r.stampLog(logFile)
 	var output io.Writer
 	var consoleWriter *consoleStreamWriter
-	if r.opts.StreamView == streamViewPretty && r.opts.Agent == "codex" {
-		consoleWriter = newConsoleStreamWriter(os.Stdout, renderer)
-		output = io.MultiWriter(logFile, consoleWriter)
+	if r.opts.Quiet {
+		output = logWriter
+	} else if (r.opts.StreamView == streamViewPretty && r.opts.Agent == "codex") || r.opts.Plain || r.tui != nil {
+		consoleWriter = newConsoleStreamWriter(r.stdout(), renderer)
+		output = io.MultiWriter(logWriter, consoleWriter)
 	} else {
-		output = io.MultiWriter(logFile, os.Stdout)
+		output = io.MultiWriter(logWriter, r.stdout())
 	}
 	cmd, err := r.buildAgentCommand(prompt)
 	if err != nil {
 		return 0, "", err
 	}
+	// Secrets are redacted before anything reaches the log or the console.
+	// raw keeps the unstamped output for limit and token parsing.
+	var raw bytes.Buffer
+	redacting := newRedactingWriter(io.MultiWriter(output, &raw), r.redactor)
 	cmd.Dir = r.repoRoot
-	cmd.Stdout = output
-	cmd.Stderr = output
+	cmd.Env = r.cacheEnv
+	cmd.Stdout = redacting
+	cmd.Stderr = redacting
+	r.debugf(verbosityVerbose, "Agent command: %s\n", describeAgentCommand(cmd, prompt))
+	r.debugf(verbosityVerbose, "Prompt: %d bytes, %d lines\n", len(prompt), strings.Count(prompt, "\n")+1)
 
 	err = cmd.Run()
 	exitCode := 0
@@ -867,6 +2726,9 @@ func (r *runner) runAgent(prompt, logPath string) (int, string, error) {
 			return 0, "", fmt.Errorf("start %s: %w", r.opts.Agent, err)
 		}
 	}
+	if flushErr := redacting.Flush(); flushErr != nil {
+		return exitCode, "", fmt.Errorf("flush agent output: %w", flushErr)
+	}
 	if consoleWriter != nil {
 		if flushErr := consoleWriter.Flush(); flushErr != nil {
 			return exitCode, "", fmt.Errorf("flush stream output: %w", flushErr)
@@ -876,12 +2738,7 @@ func (r *runner) runAgent(prompt, logPath string) (int, string, error) {
 	if syncErr := logFile.Sync(); syncErr != nil {
 		return exitCode, "", fmt.Errorf("sync log file: %w", syncErr)
 	}
-	data, readErr := os.ReadFile(logPath)
-	if readErr != nil {
-		return exitCode, "", fmt.Errorf("read log file: %w", readErr)
-	}
-
-	return exitCode, string(data), nil
+	return exitCode, raw.String(), nil
 }
 
 type streamRenderer interface {
@@ -1190,6 +3047,7 @@ func prefixMultiline(firstPrefix, nextPrefix, value string) []string {
 }
 
 func (r *runner) buildAgentCommand(prompt string) (*exec.Cmd, error) {
+	sampling, _ := r.samplingArgs()
 	switch r.opts.Agent {
 	case "claude":
 		args := []string{
@@ -1201,6 +3059,7 @@ func (r *runner) buildAgentCommand(prompt string) (*exec.Cmd, error) {
 		if r.opts.Model != "" {
 			args = append(args, "--model", r.opts.Model)
 		}
+		args = append(args, sampling...)
 		cmd := exec.Command(r.opts.ClaudeBin, args...)
 		cmd.Stdin = strings.NewReader(prompt)
 		return cmd, nil
@@ -1213,10 +3072,12 @@ func (r *runner) buildAgentCommand(prompt string) (*exec.Cmd, error) {
 		if r.opts.Model != "" {
 			args = append(args, "--model", r.opts.Model)
 		}
+		args = append(args, sampling...)
 		args = append(args, prompt)
 		cmd := exec.Command(r.opts.CodexBin, args...)
 		return cmd, nil
-	case "gemini":
+	case "gemini", "qwen":
+		// Qwen Code is a fork of the Gemini CLI and takes the same flags.
 		args := []string{
 			"--output-format",
 			"json",
@@ -1225,8 +3086,9 @@ func (r *runner) buildAgentCommand(prompt string) (*exec.Cmd, error) {
 		if r.opts.Model != "" {
 			args = append(args, "-m", r.opts.Model)
 		}
+		args = append(args, sampling...)
 		args = append(args, "-p", prompt)
-		cmd := exec.Command(r.opts.GeminiBin, args...)
+		cmd := exec.Command(r.agentBin(), args...)
 		return cmd, nil
 	case "cursor-agent":
 		args := []string{
@@ -1238,14 +3100,68 @@ func (r *runner) buildAgentCommand(prompt string) (*exec.Cmd, error) {
 		if r.opts.Model != "" {
 			args = append(args, "--model", r.opts.Model)
 		}
+		args = append(args, sampling...)
 		args = append(args, prompt)
 		cmd := exec.Command(r.opts.CursorBin, args...)
 		return cmd, nil
+	case "aider":
+		if err := r.ignoreAiderFiles(); err != nil {
+			return nil, fmt.Errorf("ignore aider files: %w", err)
+		}
+		return exec.Command(r.opts.AiderBin, r.aiderArgs(prompt, sampling)...), nil
+	case "opencode":
+		args := []string{"run"}
+		if r.opts.Model != "" {
+			args = append(args, "--model", r.opts.Model)
+		}
+		args = append(args, sampling...)
+		args = append(args, prompt)
+		cmd := exec.Command(r.opts.OpenCodeBin, args...)
+		return cmd, nil
+	case "copilot":
+		return exec.Command(r.opts.CopilotBin, r.copilotArgs(prompt, sampling)...), nil
+	case "api":
+		cmd := exec.Command(ghirExecutable(), append(r.apiAgentArgs(), sampling...)...)
+		cmd.Stdin = strings.NewReader(prompt)
+		return cmd, nil
+	case "local":
+		cmd := exec.Command(ghirExecutable(), append(r.localAgentArgs(), sampling...)...)
+		cmd.Stdin = strings.NewReader(prompt)
+		return cmd, nil
 	default:
+		if agent, ok := r.opts.CustomAgents[r.opts.Agent]; ok {
+			return r.customAgentCommand(agent, prompt), nil
+		}
 		return nil, fmt.Errorf("unsupported agent: %s", r.opts.Agent)
 	}
 }
 
+func (r *runner) agentBin() string {
+	switch r.opts.Agent {
+	case "codex":
+		return r.opts.CodexBin
+	case "gemini":
+		return r.opts.GeminiBin
+	case "cursor-agent":
+		return r.opts.CursorBin
+	case "aider":
+		return r.opts.AiderBin
+	case "opencode":
+		return r.opts.OpenCodeBin
+	case "copilot":
+		return r.opts.CopilotBin
+	case "qwen":
+		return r.opts.QwenBin
+	case "api", "local":
+		return ghirExecutable()
+	default:
+		if agent, ok := r.opts.CustomAgents[r.opts.Agent]; ok {
+			return agent.Cmd[0]
+		}
+		return r.opts.ClaudeBin
+	}
+}
+
 func (r *runner) workingTreeDirty() (bool, error) {
 	out, err := r.gitOutput("status", "--porcelain")
 	if err != nil {
@@ -1258,14 +3174,24 @@ func (r *runner) commitAll(message string) error {
 	if _, err := r.gitOutput("add", "-A"); err != nil {
 		return err
 	}
+	if err := r.formatStaged(); err != nil {
+		return err
+	}
 	if _, err := r.gitOutput("commit", "--no-verify", "-m", message); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *runner) markCompleted(issue string) error {
-	if r.isCompleted(issue) {
+func (r *runner) markCompleted(issue string, attempt *issueAttempt) (err error) {
+	r.locked(func() {
+		err = r.appendDoneRecord(issue, attempt)
+	})
+	return err
+}
+
+func (r *runner) appendDoneRecord(issue string, attempt *issueAttempt) error {
+	if _, ok := r.doneSet[issue]; ok {
 		return nil
 	}
 	f, err := os.OpenFile(r.doneFile, os.O_APPEND|os.O_WRONLY, 0o644)
@@ -1275,27 +3201,49 @@ func (r *runner) markCompleted(issue string) error {
 	defer func() {
 		_ = f.Close()
 	}()
-	if _, err := f.WriteString(issue + "\n"); err != nil {
+	rec := doneRecord{ID: issue, CompletedAt: r.timestamp(r.now())}
+	if attempt != nil {
+		rec.Agent = r.opts.Agent
+		rec.Commit = attempt.commit
+		rec.LogPath = attempt.logPath
+	}
+	if _, err := f.WriteString(rec.line() + "\n"); err != nil {
 		return fmt.Errorf("write done file: %w", err)
 	}
-	r.doneSet[issue] = struct{}{}
+	r.doneSet[issue] = rec
 	return nil
 }
 
-func (r *runner) isCompleted(issue string) bool {
-	_, ok := r.doneSet[issue]
+func (r *runner) isCompleted(issue string) (ok bool) {
+	r.locked(func() {
+		_, ok = r.doneSet[issue]
+	})
 	return ok
 }
 
-func (r *runner) waitForSessionReset(waitSeconds int, resetTime time.Time) {
-	r.printf(r.colors.Yellow, "============================================================\n")
-	r.printf(r.colors.Yellow, "SESSION LIMIT HIT - waiting until %s (%ds)\n", resetTime.Format("2006-01-02 15:04 UTC"), waitSeconds)
-	r.printf(r.colors.Yellow, "============================================================\n")
+func (r *runner) isSkipped(issue string) bool {
+	_, ok := r.skipSet[issue]
+	return ok
+}
+
+func (r *runner) waitForSessionReset(issue string, waitSeconds int, resetTime time.Time) {
+	r.rule(r.colors.Yellow, "=")
+	r.printf(r.colors.Yellow, "SESSION LIMIT HIT - waiting until %s (%ds)\n", resetTime.In(r.location()).Format("2006-01-02 15:04 MST"), waitSeconds)
+	r.rule(r.colors.Yellow, "=")
+	if r.tui != nil {
+		r.tui.setResume(time.Now().Add(time.Duration(waitSeconds) * time.Second))
+		defer r.tui.setResume(time.Time{})
+	}
 
 	remaining := waitSeconds
+	announced := 0
 	for remaining > 0 {
 		minutes := remaining / 60
 		r.printf(r.colors.Yellow, "  waiting... %d minutes remaining\n", minutes)
+		if remaining == waitSeconds || announced-remaining >= countdownNotifySeconds {
+			r.announceWait(issue, resetTime, remaining)
+			announced = remaining
+		}
 		sleepFor := countdownIntervalSeconds
 		if remaining < sleepFor {
 			sleepFor = remaining
@@ -1305,15 +3253,22 @@ func (r *runner) waitForSessionReset(waitSeconds int, resetTime time.Time) {
 	}
 
 	r.printf(r.colors.Green, "Session limit should be reset. Resuming...\n")
+	r.announceResume(issue)
 }
 
 func waitDuration(logOutput string, now time.Time, bufferSec int, agent string) (int, time.Time) {
 	if agent == "codex" {
 		return waitDurationCodex(logOutput, now, bufferSec)
 	}
-	if agent == "gemini" {
+	if agent == "gemini" || agent == "qwen" {
 		return waitDurationGemini(logOutput, now, bufferSec)
 	}
+	if agent == "copilot" {
+		return waitDurationCopilot(logOutput, now, bufferSec)
+	}
+	if agent == "api" {
+		return waitDurationAPI(logOutput, now, bufferSec)
+	}
 	return waitDurationClaude(logOutput, now, bufferSec)
 }
 
@@ -1364,7 +3319,13 @@ func waitDurationClaude(logOutput string, now time.Time, bufferSec int) (int, ti
 		return wait, now.Add(time.Duration(wait) * time.Second)
 	}
 
-	reset := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.UTC)
+	// A reset time without "(UTC)" is read in the runner's --timezone.
+	loc := now.Location()
+	if strings.EqualFold(match[4], "UTC") {
+		loc = time.UTC
+	}
+	day := now.In(loc)
+	reset := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
 	if !reset.After(now) {
 		reset = reset.Add(24 * time.Hour)
 	}
@@ -1443,18 +3404,35 @@ func detectSessionLimit(logOutput, agent string, exitCode int) bool {
 		}
 		return false
 	}
-	if agent == "gemini" {
+	if agent == "gemini" || agent == "qwen" {
 		if detectGeminiErrorPayloadLimit(logOutput) {
 			return true
 		}
 		if exitCode == 0 {
 			return false
 		}
-		return geminiSessionLimitPattern.MatchString(logOutput)
+		return geminiSessionLimitPattern.MatchString(logOutput) ||
+			agent == "qwen" && qwenSessionLimitPattern.MatchString(logOutput)
 	}
 	if agent == "cursor-agent" {
 		return false
 	}
+	if agent == "aider" {
+		return aiderRateLimitPattern.MatchString(logOutput) && !apiQuotaPattern.MatchString(logOutput)
+	}
+	if agent == "opencode" {
+		return exitCode != 0 && opencodeRateLimitPattern.MatchString(logOutput) && !apiQuotaPattern.MatchString(logOutput)
+	}
+	if agent == "copilot" {
+		return exitCode != 0 && copilotLimitPattern.MatchString(logOutput)
+	}
+	if agent == "api" {
+		return exitCode != 0 && apiRateLimitPattern.MatchString(logOutput) && !apiQuotaPattern.MatchString(logOutput)
+	}
+	if agent == "local" {
+		// A local model has no quota to run out of.
+		return false
+	}
 	return claudeSessionLimitPattern.MatchString(logOutput)
 }
 
@@ -1560,12 +3538,22 @@ func parseGeminiDurationSeconds(durationText string) int {
 func (r *runner) commandOutput(name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
 	cmd.Dir = r.repoRoot
+	if name == r.opts.GHBin {
+		env, err := r.ghEnv()
+		if err != nil {
+			return "", fmt.Errorf("GitHub App auth: %w", err)
+		}
+		cmd.Env = env
+	}
 
 	var buf bytes.Buffer
 	cmd.Stdout = &buf
 	cmd.Stderr = &buf
 
-	if err := cmd.Run(); err != nil {
+	started := time.Now()
+	err := cmd.Run()
+	r.debugCommand(name, args, started, err)
+	if err != nil {
 		out := strings.TrimSpace(buf.String())
 		if out == "" {
 			return "", fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
@@ -1581,13 +3569,27 @@ func (r *runner) gitOutput(args ...string) (string, error) {
 }
 
 func (r *runner) printf(color, format string, values ...any) {
+	format = r.tr(format)
+	out := r.stdout()
 	if color == "" {
-		fmt.Printf(format, values...)
+		fmt.Fprintf(out, format, values...)
 		return
 	}
-	fmt.Print(color)
-	fmt.Printf(format, values...)
-	fmt.Print(r.colors.Reset)
+	// Reset before the trailing newline, so --timestamps stamps start a
+	// clean line.
+	text := fmt.Sprintf(format, values...)
+	body := strings.TrimRight(text, "\n")
+	fmt.Fprint(out, color+body+r.colors.Reset+text[len(body):])
+}
+
+func (r *runner) stdout() io.Writer {
+	if r.tui != nil {
+		return r.tui
+	}
+	if r.stampedOut != nil {
+		return r.stampedOut
+	}
+	return os.Stdout
 }
 
 func agentDisplayName(agent string) string {
@@ -1598,8 +3600,22 @@ func agentDisplayName(agent string) string {
 		return "Gemini"
 	case "cursor-agent":
 		return "Cursor Agent"
-	default:
+	case "aider":
+		return "Aider"
+	case "opencode":
+		return "OpenCode"
+	case "copilot":
+		return "Copilot"
+	case "qwen":
+		return "Qwen Code"
+	case "api":
+		return "API agent"
+	case "local":
+		return "Ollama"
+	case "claude", "":
 		return "Claude"
+	default:
+		return agent
 	}
 }
 
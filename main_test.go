@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -22,6 +23,12 @@ func TestParseArgsSupportedAgents(t *testing.T) {
 		{name: "codex", agent: "codex"},
 		{name: "gemini", agent: "gemini"},
 		{name: "cursor-agent", agent: "cursor-agent"},
+		{name: "aider", agent: "aider"},
+		{name: "opencode", agent: "opencode"},
+		{name: "copilot", agent: "copilot"},
+		{name: "qwen", agent: "qwen"},
+		{name: "api", agent: "api"},
+		{name: "local", agent: "local"},
 	}
 
 	for _, tt := range tests {
@@ -343,6 +350,126 @@ func TestParseCSVIssuesValidation(t *testing.T) {
 	}
 }
 
+func TestParseIssueList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		want      []string
+		wantError string
+	}{
+		{
+			name:  "skips comments and blank lines",
+			input: "# queue\n\n12\n  7 trailing note\n12\n",
+			want:  []string{"12", "7"},
+		},
+		{
+			name:      "reports line of invalid id",
+			input:     "12\nabc\n",
+			wantError: `invalid issue id at stdin:2: "abc"`,
+		},
+		{
+			name:      "empty input",
+			input:     "# nothing here\n",
+			wantError: "no issue ids found in stdin",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseIssueList(tt.input, "stdin")
+			if tt.wantError != "" {
+				if err == nil {
+					t.Fatalf("expected error containing %q, got nil", tt.wantError)
+				}
+				if !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("unexpected error: got %q want substring %q", err.Error(), tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIssueList returned unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("issues mismatch: got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRepoDefaultsKeepsStdinIssuesFile(t *testing.T) {
+	t.Parallel()
+
+	opts := options{IssuesFile: stdinIssuesFile}
+	if err := applyRepoDefaults(&opts, t.TempDir()); err != nil {
+		t.Fatalf("applyRepoDefaults: %v", err)
+	}
+	if opts.IssuesFile != stdinIssuesFile {
+		t.Fatalf("issues file mismatch: got %q want %q", opts.IssuesFile, stdinIssuesFile)
+	}
+}
+
+func TestParseArgsAssignee(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		args      []string
+		want      string
+		wantError string
+	}{
+		{name: "assigned to me", args: []string{"--assigned-to-me"}, want: "@me"},
+		{name: "explicit assignee", args: []string{"--assignee", "octocat"}, want: "octocat"},
+		{
+			name:      "both flags",
+			args:      []string{"--assignee", "octocat", "--assigned-to-me"},
+			wantError: "--assigned-to-me cannot be combined with --assignee",
+		},
+		{name: "missing assignee", args: []string{"--assignee"}, wantError: "--assignee requires a value"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := parseArgs(tt.args)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs returned unexpected error: %v", err)
+			}
+			if opts.Assignee != tt.want {
+				t.Fatalf("assignee mismatch: got %q want %q", opts.Assignee, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIssueNumbersJSON(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseIssueNumbersJSON(`[{"number":42},{"number":7},{"number":42},{"number":100}]`)
+	if err != nil {
+		t.Fatalf("parseIssueNumbersJSON returned unexpected error: %v", err)
+	}
+	if want := []string{"7", "42", "100"}; !slices.Equal(got, want) {
+		t.Fatalf("issues mismatch: got %v want %v", got, want)
+	}
+
+	if _, err := parseIssueNumbersJSON("not json"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
 func TestLoadIssuesFromCSVValidation(t *testing.T) {
 	t.Parallel()
 
@@ -383,7 +510,7 @@ func TestLoadIssuesFromCSVValidation(t *testing.T) {
 			if err != nil {
 				t.Fatalf("loadIssues returned unexpected error: %v", err)
 			}
-			if !slices.Equal(got, tt.want) {
+			if !slices.Equal(issueIDs(got), tt.want) {
 				t.Fatalf("issues mismatch: got %v want %v", got, tt.want)
 			}
 		})
@@ -457,6 +584,97 @@ func TestIssueMentionedInSubjects(t *testing.T) {
 	}
 }
 
+func TestDirectiveLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   string
+	}{
+		{name: "no labels", want: ""},
+		{name: "unrelated labels", labels: []string{"bug", "p1"}, want: ""},
+		{name: "skip label", labels: []string{"bug", "ghir:skip"}, want: "ghir:skip"},
+		{name: "blocked label is case insensitive", labels: []string{"GHIR:Blocked"}, want: "ghir:blocked"},
+		{name: "needs human label", labels: []string{"ghir:needs-human"}, want: "ghir:needs-human"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var details issueDetails
+			for _, name := range tt.labels {
+				details.Labels = append(details.Labels, issueLabel{Name: name})
+			}
+			if got := directiveLabel(details); got != tt.want {
+				t.Fatalf("directiveLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessIssueSkipsClosedIssues(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := writeFakeBin(t, "gh", `echo '{"title":"Done already","body":"","state":"CLOSED","labels":[]}'`)
+
+	for _, includeClosed := range []bool{false, true} {
+		opts := options{
+			GHBin:         gh,
+			DryRun:        includeClosed,
+			IncludeClosed: includeClosed,
+		}
+		r := newTestRunner(t, repo, opts)
+
+		result := r.processIssue(1, 1, issueEntry{ID: "5"})
+		if includeClosed {
+			if result != resultSuccess || r.isCompleted("5") {
+				t.Fatalf("--include-closed dry run: result=%v completed=%v", result, r.isCompleted("5"))
+			}
+			continue
+		}
+		if result != resultSkipped {
+			t.Fatalf("result = %v, want resultSkipped", result)
+		}
+		if !r.isCompleted("5") {
+			t.Fatal("closed issue should be marked completed")
+		}
+	}
+}
+
+func TestLoadSkipSet(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	skipFile := filepath.Join(dir, "skip.txt")
+	if err := os.WriteFile(skipFile, []byte("# parked\n34\n\n56 waiting on design\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadSkipSet("12,34", skipFile)
+	if err != nil {
+		t.Fatalf("loadSkipSet returned unexpected error: %v", err)
+	}
+	for _, id := range []string{"12", "34", "56"} {
+		if _, ok := got[id]; !ok {
+			t.Fatalf("expected %s in skip set %v", id, got)
+		}
+	}
+	if len(got) != 3 {
+		t.Fatalf("unexpected skip set size: %v", got)
+	}
+
+	if got, err := loadSkipSet("", filepath.Join(dir, "missing.txt")); err != nil || len(got) != 0 {
+		t.Fatalf("missing skip file should be ignored, got %v, %v", got, err)
+	}
+	if _, err := loadSkipSet("12,x", ""); err == nil || !strings.Contains(err.Error(), "--skip") {
+		t.Fatalf("expected --skip validation error, got %v", err)
+	}
+}
+
 func TestDetectSessionLimitByAgent(t *testing.T) {
 	t.Parallel()
 
@@ -523,6 +741,48 @@ func TestDetectSessionLimitByAgent(t *testing.T) {
 			exitCode: 1,
 			retry:    false,
 		},
+		{
+			name:     "qwen retryable when the free quota is used up",
+			agent:    "qwen",
+			log:      "[API Error: Free allocated quota exceeded.]",
+			exitCode: 1,
+			retry:    true,
+		},
+		{
+			name:     "qwen retryable when throttled",
+			agent:    "qwen",
+			log:      `[API Error: 429 Throttling.RateQuota: Requests throttling triggered.]`,
+			exitCode: 1,
+			retry:    true,
+		},
+		{
+			name:     "qwen non retryable for unrelated error",
+			agent:    "qwen",
+			log:      "authentication failed",
+			exitCode: 1,
+			retry:    false,
+		},
+		{
+			name:     "api retryable for a rate limit it could not wait out",
+			agent:    "api",
+			log:      `error: API rate limit (HTTP 429): {"type":"error","error":{"type":"rate_limit_error"}}; try again in 600 seconds`,
+			exitCode: 1,
+			retry:    true,
+		},
+		{
+			name:     "api non retryable for an exhausted balance",
+			agent:    "api",
+			log:      "error: API rate limit (HTTP 429): insufficient_quota",
+			exitCode: 1,
+			retry:    false,
+		},
+		{
+			name:     "local models have no limit",
+			agent:    "local",
+			log:      "ollama: rate limit exceeded (HTTP 429)",
+			exitCode: 1,
+			retry:    false,
+		},
 		{
 			name:     "cursor agent is always non retryable even with limit text",
 			agent:    "cursor-agent",
@@ -530,6 +790,48 @@ func TestDetectSessionLimitByAgent(t *testing.T) {
 			exitCode: 1,
 			retry:    false,
 		},
+		{
+			name:     "aider retryable after litellm gives up on a rate limit",
+			agent:    "aider",
+			log:      "litellm.RateLimitError: AnthropicException - rate_limit_error\nThe API provider has rate limited you. Try again later or check your quotas.",
+			exitCode: 0,
+			retry:    true,
+		},
+		{
+			name:     "aider non retryable for an exhausted quota",
+			agent:    "aider",
+			log:      "litellm.RateLimitError: OpenAIException - You exceeded your current quota (insufficient_quota)",
+			exitCode: 1,
+			retry:    false,
+		},
+		{
+			name:     "opencode retryable for a provider rate limit",
+			agent:    "opencode",
+			log:      `AI_APICallError: {"type":"error","error":{"type":"rate_limit_error","message":"Number of request tokens has exceeded your per-minute rate limit"}}`,
+			exitCode: 1,
+			retry:    true,
+		},
+		{
+			name:     "opencode non retryable on a successful run mentioning rate limits",
+			agent:    "opencode",
+			log:      "Added a rate limit to the API client",
+			exitCode: 0,
+			retry:    false,
+		},
+		{
+			name:     "copilot retryable when premium requests run out",
+			agent:    "copilot",
+			log:      "Error: You have exceeded your monthly premium request allowance. Upgrade your plan or wait for it to reset.",
+			exitCode: 1,
+			retry:    true,
+		},
+		{
+			name:     "copilot non retryable on a crash",
+			agent:    "copilot",
+			log:      "Error: could not read file",
+			exitCode: 1,
+			retry:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -579,6 +881,22 @@ func TestWaitDurationClaude(t *testing.T) {
 			wantWaitSec: 1320,
 			wantReset:   time.Date(2026, 1, 3, 0, 12, 0, 0, time.UTC),
 		},
+		{
+			name:        "reads bare reset time in the runner time zone",
+			log:         "Usage limit hit, resets at 5pm",
+			now:         time.Date(2026, 1, 2, 15, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+			bufferSec:   0,
+			wantWaitSec: 7200,
+			wantReset:   time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "explicit UTC reset ignores the runner time zone",
+			log:         "Usage limit hit, resets at 5pm (UTC)",
+			now:         time.Date(2026, 1, 2, 11, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+			bufferSec:   0,
+			wantWaitSec: 3600,
+			wantReset:   time.Date(2026, 1, 2, 17, 0, 0, 0, time.UTC),
+		},
 		{
 			name:        "falls back when reset text missing",
 			log:         "hit your usage limit; try again later",
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	manifestArchiveName     = "manifest.yaml"
+	manifestResultsFileName = "results.json"
+)
+
+// runManifest describes a whole run in one reviewable file: the issues (with
+// the same per-issue overrides as a YAML issue file) and any config.yaml
+// setting, layered over the repo config like a profile. Flags still win.
+type runManifest struct {
+	Name        string       `yaml:"name"`
+	Description string       `yaml:"description"`
+	Profile     string       `yaml:"profile"`
+	Settings    repoConfig   `yaml:",inline"`
+	Issues      []issueEntry `yaml:"issues"`
+
+	path string
+	data []byte
+}
+
+func loadRunManifest(path string) (*runManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	m := &runManifest{path: path, data: data}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(m.Settings.Profiles) > 0 {
+		return nil, fmt.Errorf("%s: profiles cannot be defined in a manifest (select one with profile:)", path)
+	}
+	if err := m.Settings.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(m.Issues) > 0 {
+		// Normalize and check the entries the way an issue file is checked.
+		if m.Issues, err = parseStructuredIssues(data, path); err != nil {
+			return nil, err
+		}
+	}
+	if m.Name == "" {
+		m.Name = trimExt(filepath.Base(path))
+	}
+	return m, nil
+}
+
+func trimExt(name string) string {
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+// manifestIssues is the manifest's issue list, or nil when there is no
+// manifest or it leaves the queue to the configured sources.
+func (r *runner) manifestIssues() ([]issueEntry, error) {
+	m := r.opts.manifest
+	if m == nil || len(m.Issues) == 0 {
+		return nil, nil
+	}
+	for _, flag := range queueSourceFlags {
+		if r.opts.flagSet(flag) {
+			return nil, fmt.Errorf("%s cannot be combined with a manifest that lists issues (%s)", flag, m.path)
+		}
+	}
+	return append([]issueEntry(nil), m.Issues...), nil
+}
+
+func (r *runner) manifestName() string {
+	if r.opts.manifest == nil {
+		return ""
+	}
+	return r.opts.manifest.Name
+}
+
+// manifestOutcome is one issue's line in <run-dir>/results.json.
+type manifestOutcome struct {
+	Issue   string       `json:"issue"`
+	Result  string       `json:"result"`
+	Failure string       `json:"failure,omitempty"`
+	Commit  string       `json:"commit,omitempty"`
+	Agent   string       `json:"agent,omitempty"`
+	Changes *diffSummary `json:"changes,omitempty"`
+}
+
+type manifestResults struct {
+	Manifest   string            `json:"manifest"`
+	StartedAt  string            `json:"started_at"`
+	FinishedAt string            `json:"finished_at"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     int               `json:"failed"`
+	Skipped    int               `json:"skipped"`
+	Issues     []manifestOutcome `json:"issues"`
+}
+
+// archiveManifest copies the manifest into the run dir, next to the logs and
+// prompts of the run it describes.
+func (r *runner) archiveManifest() {
+	m := r.opts.manifest
+	if m == nil || r.runDir == "" {
+		return
+	}
+	if err := os.WriteFile(filepath.Join(r.runDir, manifestArchiveName), m.data, 0o644); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not archive the manifest: %v\n", err)
+	}
+}
+
+func (r *runner) writeManifestResults(results manifestResults) {
+	if r.opts.manifest == nil || r.runDir == "" {
+		return
+	}
+	results.Manifest = r.opts.manifest.Name
+	results.FinishedAt = r.timestamp(r.now())
+	for i, outcome := range results.Issues {
+		if st, ok := r.state.get(outcome.Issue); ok {
+			results.Issues[i].Commit = st.Commit
+			results.Issues[i].Agent = st.Agent
+			if outcome.Result == resultSuccess.String() {
+				results.Issues[i].Changes = st.Changes
+			}
+		}
+		if failure, ok := r.failures[outcome.Issue]; ok && outcome.Result != resultSuccess.String() {
+			results.Issues[i].Failure = string(failure)
+		}
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(r.runDir, manifestResultsFileName), append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not write the manifest results: %v\n", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadRunManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			manifest: `name: nightly
+agent: codex
+verify_cmd: go test ./...
+issues:
+  - 12
+  - id: "#34"
+    agent: Claude
+    model: opus
+`,
+		},
+		{name: "unknown key", manifest: "agnet: codex\n", wantErr: "agnet"},
+		{name: "profiles", manifest: "profiles:\n  fast:\n    agent: codex\n", wantErr: "profiles cannot be defined"},
+		{name: "bad issue", manifest: "issues:\n  - abc\n", wantErr: "invalid issue id"},
+		{name: "bad notify target", manifest: "notify:\n  - ftp://example.com\n", wantErr: "http(s) URL"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "run.yaml")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := loadRunManifest(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadRunManifest() error = %v, want substring %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Name != "nightly" || m.Settings.Agent != "codex" || m.Settings.VerifyCmd != "go test ./..." {
+				t.Fatalf("manifest = %+v", m)
+			}
+			if len(m.Issues) != 2 || m.Issues[1].ID != "34" || m.Issues[1].Agent != "claude" || m.Issues[1].Model != "opus" {
+				t.Fatalf("issues = %+v", m.Issues)
+			}
+		})
+	}
+}
+
+func TestManifestLayersOverConfig(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	writeRepoConfig(t, repo, "agent: claude\nmodel: sonnet\nverify_cmd: make test\nprofiles:\n  slow:\n    wait_buffer_sec: 600\n")
+	if err := os.WriteFile(filepath.Join(repo, "nightly.yaml"), []byte("profile: slow\nagent: codex\nnotify:\n  - https://hooks.example.com/ghir\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := parseArgs([]string{"run", "-f", "nightly.yaml", "--verify-cmd", "go test ./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRepoDefaults(&opts, repo); err != nil {
+		t.Fatal(err)
+	}
+	if opts.Agent != "codex" || opts.Model != "" || opts.WaitBufferSec != 600 {
+		t.Fatalf("agent=%q model=%q wait=%d, want the manifest and its profile applied", opts.Agent, opts.Model, opts.WaitBufferSec)
+	}
+	if opts.VerifyCmd != "go test ./..." {
+		t.Fatalf("verify cmd = %q, want the flag to win", opts.VerifyCmd)
+	}
+	if len(opts.Notify) != 1 || opts.manifest == nil || opts.manifest.Name != "nightly" {
+		t.Fatalf("notify=%v manifest=%+v", opts.Notify, opts.manifest)
+	}
+}
+
+func TestRunManifestArchivesResultsAndNotifies(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var posted []notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		var n notification
+		if err := json.Unmarshal(body, &n); err != nil {
+			t.Errorf("notification body %q: %v", body, err)
+		}
+		mu.Lock()
+		posted = append(posted, n)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	repo := initTestRepo(t)
+	manifest := "name: smoke\nissues:\n  - 5\n"
+	path := filepath.Join(t.TempDir(), "smoke.yaml")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loadRunManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"")
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		Notify:    []string{server.URL},
+		manifest:  m,
+	}
+	r := newTestRunner(t, repo, opts)
+	if code := r.runQueue(); code != 0 {
+		t.Fatalf("runQueue() = %d", code)
+	}
+
+	archived, err := os.ReadFile(filepath.Join(r.runDir, manifestArchiveName))
+	if err != nil || string(archived) != manifest {
+		t.Fatalf("archived manifest = %q (%v)", archived, err)
+	}
+	data, err := os.ReadFile(filepath.Join(r.runDir, manifestResultsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results manifestResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatal(err)
+	}
+	head := runGit(t, repo, "rev-parse", "HEAD")
+	if results.Manifest != "smoke" || results.Succeeded != 1 || len(results.Issues) != 1 || results.Issues[0].Commit != head {
+		t.Fatalf("results = %+v", results)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != 1 || posted[0].Event != journalRunFinished || posted[0].Manifest != "smoke" || posted[0].Succeeded != 1 {
+		t.Fatalf("notifications = %+v", posted)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	commandMergeReport = "merge-report"
+
+	prStateOpen   = "open"
+	prStateMerged = "merged"
+	prStateClosed = "closed"
+)
+
+// pullRequestView is the part of gh pr view --json we need.
+type pullRequestView struct {
+	State    string `json:"state"`
+	MergedAt string `json:"mergedAt"`
+	ClosedAt string `json:"closedAt"`
+}
+
+func (r *runner) viewPullRequest(url string) (pullRequestView, error) {
+	var view pullRequestView
+	out, err := r.commandOutput(r.opts.GHBin, "pr", "view", url, "--json", "state,mergedAt,closedAt")
+	if err != nil {
+		return view, err
+	}
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		return view, fmt.Errorf("parse gh pr view: %w", err)
+	}
+	return view, nil
+}
+
+func (v pullRequestView) state() string {
+	return strings.ToLower(v.State)
+}
+
+func (v pullRequestView) closedAt() string {
+	if v.state() == prStateMerged {
+		return v.MergedAt
+	}
+	return v.ClosedAt
+}
+
+// syncPullRequests asks GitHub for the state of every PR ghir opened that
+// has not merged yet and records it in the state store. Merged is final;
+// closed PRs are checked again because they can be reopened. It returns the
+// number of PRs whose state changed.
+func (r *runner) syncPullRequests() (int, error) {
+	if r.state == nil {
+		return 0, nil
+	}
+	states := r.state.snapshot()
+	ids := make([]string, 0, len(states))
+	for id, st := range states {
+		if st.PullRequest != "" && st.PRState != prStateMerged {
+			ids = append(ids, id)
+		}
+	}
+	sortStringsNumeric(ids)
+
+	changed := 0
+	for _, id := range ids {
+		st := states[id]
+		view, err := r.viewPullRequest(st.PullRequest)
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not look up %s for #%s: %v\n", st.PullRequest, id, err)
+			continue
+		}
+		if view.state() == st.PRState {
+			continue
+		}
+		if err := r.state.update(id, func(st *issueState) {
+			st.PRState, st.PRClosedAt = view.state(), view.closedAt()
+		}); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// mergeStats is the outcome of one agent/model's work measured by what
+// reached the codebase.
+type mergeStats struct {
+	Agent        string  `json:"agent"`
+	Model        string  `json:"model,omitempty"`
+	Issues       int     `json:"issues"`
+	PullRequests int     `json:"pull_requests"`
+	Merged       int     `json:"merged"`
+	Abandoned    int     `json:"abandoned"`
+	Open         int     `json:"open"`
+	CostUSD      float64 `json:"cost_usd"`
+	DurationSec  float64 `json:"duration_sec"`
+	// CostPerMerge is all spend of the agent/model, failed issues included,
+	// divided by its merged PRs.
+	CostPerMerge float64 `json:"cost_per_merge_usd,omitempty"`
+	// AbandonRate is the share of decided PRs (merged or closed) that were
+	// closed without merging.
+	AbandonRate float64 `json:"abandon_rate"`
+}
+
+func mergeReport(states map[string]issueState) []mergeStats {
+	byKey := make(map[string]*mergeStats)
+	for _, st := range states {
+		if st.Agent == "" {
+			continue
+		}
+		key := agentModelLabel(st.Agent, st.Model)
+		stats, ok := byKey[key]
+		if !ok {
+			stats = &mergeStats{Agent: st.Agent, Model: st.Model}
+			byKey[key] = stats
+		}
+		stats.Issues++
+		stats.CostUSD += st.CostUSD
+		stats.DurationSec += st.DurationSec
+		if st.PullRequest == "" {
+			continue
+		}
+		stats.PullRequests++
+		switch st.PRState {
+		case prStateMerged:
+			stats.Merged++
+		case prStateClosed:
+			stats.Abandoned++
+		default:
+			stats.Open++
+		}
+	}
+
+	report := make([]mergeStats, 0, len(byKey))
+	for _, stats := range byKey {
+		if stats.Merged > 0 {
+			stats.CostPerMerge = stats.CostUSD / float64(stats.Merged)
+		}
+		if decided := stats.Merged + stats.Abandoned; decided > 0 {
+			stats.AbandonRate = float64(stats.Abandoned) / float64(decided)
+		}
+		report = append(report, *stats)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return agentModelLabel(report[i].Agent, report[i].Model) < agentModelLabel(report[j].Agent, report[j].Model)
+	})
+	return report
+}
+
+// runMergeReport refreshes the PR states (unless --no-sync) and reports,
+// per agent and model, how much of the work merged and what each merged
+// change cost. Run it from cron to keep the states current.
+func (r *runner) runMergeReport() error {
+	if !r.opts.NoSync {
+		changed, err := r.syncPullRequests()
+		if err != nil {
+			return err
+		}
+		if r.opts.Output != outputJSON {
+			r.printf(r.colors.Blue, "Synced pull request states from GitHub (%d changed)\n", changed)
+		}
+	}
+	report := mergeReport(r.state.snapshot())
+	if r.opts.Output == outputJSON {
+		enc := json.NewEncoder(r.stdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Agents []mergeStats `json:"agents"`
+		}{report}); err != nil {
+			return fmt.Errorf("write merge report: %w", err)
+		}
+		return nil
+	}
+	if len(report) == 0 {
+		r.printf(r.colors.Yellow, "No agent runs recorded in %s yet\n", r.state.path)
+		return nil
+	}
+	r.printf("", "%-30s %6s %5s %6s %9s %5s %10s %12s %8s\n", "AGENT/MODEL", "ISSUES", "PRS", "MERGED", "ABANDONED", "OPEN", "COST", "COST/MERGE", "ABANDON")
+	for _, s := range report {
+		perMerge := "-"
+		if s.Merged > 0 {
+			perMerge = formatCost(s.CostPerMerge)
+		}
+		abandon := "-"
+		if s.Merged+s.Abandoned > 0 {
+			abandon = fmt.Sprintf("%.0f%%", s.AbandonRate*100)
+		}
+		r.printf("", "%-30s %6d %5d %6d %9d %5d %10s %12s %8s\n", truncateForConsole(agentModelLabel(s.Agent, s.Model), 30), s.Issues, s.PullRequests, s.Merged, s.Abandoned, s.Open, formatCost(s.CostUSD), perMerge, abandon)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeReport(t *testing.T) {
+	t.Parallel()
+
+	states := map[string]issueState{
+		"1": {Issue: "1", Agent: "claude", Model: "sonnet", CostUSD: 2, PullRequest: "u1", PRState: prStateMerged},
+		"2": {Issue: "2", Agent: "claude", Model: "sonnet", CostUSD: 3, PullRequest: "u2", PRState: prStateClosed},
+		"3": {Issue: "3", Agent: "claude", Model: "sonnet", CostUSD: 1, Status: statusFailed},
+		"4": {Issue: "4", Agent: "claude", Model: "sonnet", CostUSD: 2, PullRequest: "u4", PRState: prStateMerged},
+		"5": {Issue: "5", Agent: "codex", CostUSD: 4, PullRequest: "u5", PRState: prStateOpen},
+		"6": {Issue: "6"},
+	}
+	report := mergeReport(states)
+	if len(report) != 2 {
+		t.Fatalf("report = %+v, want two agent/model rows", report)
+	}
+	claude := report[0]
+	if claude.Agent != "claude" || claude.Issues != 4 || claude.PullRequests != 3 || claude.Merged != 2 || claude.Abandoned != 1 {
+		t.Fatalf("claude row = %+v", claude)
+	}
+	if claude.CostPerMerge != 4 {
+		t.Fatalf("cost per merge = %v, want 4 (all spend over merged PRs)", claude.CostPerMerge)
+	}
+	if claude.AbandonRate != 1.0/3 {
+		t.Fatalf("abandon rate = %v, want 1/3", claude.AbandonRate)
+	}
+	codex := report[1]
+	if codex.Open != 1 || codex.CostPerMerge != 0 || codex.AbandonRate != 0 {
+		t.Fatalf("codex row = %+v, want one open PR and no rates yet", codex)
+	}
+}
+
+func TestSyncPullRequests(t *testing.T) {
+	t.Parallel()
+
+	gh := writeFakeBin(t, "gh", `case "$3" in
+  */1) echo '{"state":"MERGED","mergedAt":"2026-03-01T10:00:00Z","closedAt":"2026-03-01T10:00:00Z"}' ;;
+  */2) echo '{"state":"OPEN","mergedAt":null,"closedAt":null}' ;;
+  */3) echo "should not be asked" >&2; exit 1 ;;
+esac`)
+	store, err := loadStateStore(filepath.Join(t.TempDir(), defaultStateFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Issues = map[string]*issueState{
+		"1": {Issue: "1", PullRequest: "https://github.com/o/r/pull/1", PRState: prStateOpen},
+		"2": {Issue: "2", PullRequest: "https://github.com/o/r/pull/2", PRState: prStateOpen},
+		"3": {Issue: "3", PullRequest: "https://github.com/o/r/pull/3", PRState: prStateMerged},
+	}
+	r := &runner{opts: options{GHBin: gh, NoColor: true, Quiet: true}, state: store}
+
+	changed, err := r.syncPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 {
+		t.Fatalf("changed = %d, want 1", changed)
+	}
+	st, _ := store.get("1")
+	if st.PRState != prStateMerged || st.PRClosedAt != "2026-03-01T10:00:00Z" {
+		t.Fatalf("#1 state = %+v, want merged with its merge time", st)
+	}
+	if st, _ := store.get("2"); st.PRState != prStateOpen {
+		t.Fatalf("#2 state = %q, want open", st.PRState)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const commandMigrate = "migrate"
+
+// legacyIssue is what an older runner left behind for one issue: a line in
+// the completion file, a <issue>.log, or both.
+type legacyIssue struct {
+	ID          string
+	Completed   bool
+	CompletedAt string
+	LogPath     string
+	logModified time.Time
+	// rawKey is how loadDoneSet keyed a line it could not read as an id.
+	rawKey string
+}
+
+// parseLegacyDoneLine reads a completion file line of the shell scripts and
+// early versions: a bare id, optionally with a leading '#', followed by an
+// optional date or RFC 3339 time and a '#' comment. Lines already in the
+// tab-separated format are read as they are.
+func parseLegacyDoneLine(line string) (doneRecord, bool) {
+	if strings.Contains(line, "\t") {
+		rec, ok := parseDoneLine(line)
+		return rec, ok && isIssueID(rec.ID)
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return doneRecord{}, false
+	}
+	rec := doneRecord{ID: strings.TrimPrefix(fields[0], "#")}
+	if !isIssueID(rec.ID) {
+		return doneRecord{}, false
+	}
+	if len(fields) > 1 && !strings.HasPrefix(fields[1], "#") {
+		if t, err := time.Parse(time.RFC3339, fields[1]); err == nil {
+			rec.CompletedAt = t.Format(time.RFC3339)
+		} else if t, err := time.Parse("2006-01-02", fields[1]); err == nil {
+			rec.CompletedAt = t.Format(time.RFC3339)
+		}
+	}
+	return rec, true
+}
+
+// legacyIssues collects the issues recorded in dir by an older runner: the
+// .completed file and the per-issue <issue>.log files at its top level.
+func (r *runner) legacyIssues(dir string) ([]legacyIssue, error) {
+	byID := make(map[string]*legacyIssue)
+	get := func(id string) *legacyIssue {
+		if byID[id] == nil {
+			byID[id] = &legacyIssue{ID: id}
+		}
+		return byID[id]
+	}
+
+	donePath := filepath.Join(dir, defaultDoneFileName)
+	data, err := os.ReadFile(donePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", donePath, err)
+	}
+	var doneModified time.Time
+	if info, err := os.Stat(donePath); err == nil {
+		doneModified = info.ModTime()
+	}
+	for _, raw := range strings.Split(string(data), "\n") {
+		rec, ok := parseLegacyDoneLine(raw)
+		if !ok {
+			continue
+		}
+		issue := get(rec.ID)
+		issue.Completed = true
+		issue.CompletedAt = rec.CompletedAt
+		if rec.LogPath != "" {
+			issue.LogPath = rec.LogPath
+		}
+		if key, ok := parseDoneLine(raw); ok && key.ID != rec.ID {
+			issue.rawKey = key.ID
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".log")
+		if !ok || entry.IsDir() || !isIssueID(id) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		issue := get(id)
+		if issue.LogPath == "" {
+			issue.LogPath = filepath.Join(dir, entry.Name())
+		}
+		issue.logModified = info.ModTime()
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sortStringsNumeric(ids)
+	issues := make([]legacyIssue, 0, len(ids))
+	for _, id := range ids {
+		issue := byID[id]
+		if issue.Completed && issue.CompletedAt == "" {
+			// The old runners wrote the log up to the moment they finished;
+			// without one the completion file's time is the best guess.
+			finished := issue.logModified
+			if finished.IsZero() {
+				finished = doneModified
+			}
+			issue.CompletedAt = finished.In(r.location()).Format(time.RFC3339)
+		}
+		issues = append(issues, *issue)
+	}
+	return issues, nil
+}
+
+// migrate imports the state of an older runner or of the shell scripts ghir
+// replaced, which kept bare ids in .completed and one <issue>.log per issue,
+// from dir (default: the log dir). Completed issues go into the done file
+// and state.json as done; issues with only a log were attempted and not
+// finished, so they are recorded as failed with their log. Issues ghir
+// already tracks are left alone, so running it twice changes nothing.
+func (r *runner) migrate(dir string) error {
+	if dir == "" {
+		dir = r.opts.LogDir
+	}
+	issues, err := r.legacyIssues(dir)
+	if err != nil {
+		return err
+	}
+
+	var imported []legacyIssue
+	for _, issue := range issues {
+		var st issueState
+		if r.state != nil {
+			st, _ = r.state.get(issue.ID)
+		}
+		rec, done := r.doneSet[issue.ID]
+		_, stale := r.doneSet[issue.rawKey]
+		switch {
+		case issue.Completed && done && !stale && rec.CompletedAt != "" && st.Status == statusDone:
+			continue
+		case !issue.Completed && (done || st.Status != ""):
+			continue
+		}
+		imported = append(imported, issue)
+		status := statusFailed
+		if issue.Completed {
+			status = statusDone
+		}
+		prefix := "Importing"
+		if r.opts.DryRun {
+			prefix = "[DRY RUN] Would import"
+		}
+		r.printf("", "%s #%s as %s", prefix, issue.ID, status)
+		if issue.LogPath != "" {
+			r.printf("", " (log %s)", issue.LogPath)
+		}
+		r.printf("", "\n")
+	}
+	if len(imported) == 0 {
+		r.printf(r.colors.Green, "Nothing to migrate in %s\n", dir)
+		return nil
+	}
+	if r.opts.DryRun {
+		r.printf(r.colors.Yellow, "[DRY RUN] %d issue(s) to migrate; nothing written\n", len(imported))
+		return nil
+	}
+
+	doneChanged := false
+	for id := range r.doneSet {
+		// Comments and headers of the old file were loaded as ids.
+		if !isIssueID(id) {
+			delete(r.doneSet, id)
+			doneChanged = true
+		}
+	}
+	for _, issue := range imported {
+		if issue.Completed {
+			rec := r.doneSet[issue.ID]
+			rec.ID = issue.ID
+			if rec.CompletedAt == "" {
+				rec.CompletedAt = issue.CompletedAt
+			}
+			if rec.LogPath == "" {
+				rec.LogPath = issue.LogPath
+			}
+			r.doneSet[issue.ID] = rec
+			doneChanged = true
+		}
+		if r.state == nil {
+			continue
+		}
+		if err := r.state.update(issue.ID, func(st *issueState) {
+			if st.LogPath == "" {
+				st.LogPath = issue.LogPath
+			}
+			if issue.Completed {
+				st.Status = statusDone
+				st.Failure = ""
+				if st.FinishedAt == "" {
+					st.FinishedAt = issue.CompletedAt
+				}
+				return
+			}
+			st.Status = statusFailed
+			st.Attempts = 1
+			st.Failure = string(failureUnclassified)
+			if !issue.logModified.IsZero() {
+				st.FinishedAt = issue.logModified.In(r.location()).Format(time.RFC3339)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+	if doneChanged {
+		return r.rewriteDoneFile(fmt.Sprintf("Migrated %d issue(s) from %s\n", len(imported), dir))
+	}
+	r.printf(r.colors.Green, "Migrated %d issue(s) from %s\n", len(imported), dir)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLegacyDoneLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line string
+		want doneRecord
+		ok   bool
+	}{
+		{line: "12", want: doneRecord{ID: "12"}, ok: true},
+		{line: "#12", want: doneRecord{ID: "12"}, ok: true},
+		{line: "12 2026-01-02 # flaky", want: doneRecord{ID: "12", CompletedAt: "2026-01-02T00:00:00Z"}, ok: true},
+		{line: "12 # done by hand", want: doneRecord{ID: "12"}, ok: true},
+		{line: "12\tcompleted_at=2026-01-02T03:04:05Z\tagent=codex", want: doneRecord{ID: "12", CompletedAt: "2026-01-02T03:04:05Z", Agent: "codex"}, ok: true},
+		{line: "# completed issues"},
+		{line: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseLegacyDoneLine(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("parseLegacyDoneLine(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMigrateImportsLegacyState(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	opts := options{LogDir: filepath.Join(repo, defaultLogDirName)}
+	opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+	if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	finished := time.Date(2025, 11, 3, 4, 5, 6, 0, time.UTC)
+	for name, content := range map[string]string{
+		defaultDoneFileName: "# done\n3\n#5 2025-10-01\n",
+		"3.log":             "claude output\n",
+		"7.log":             "Error: something broke\n",
+	} {
+		path := filepath.Join(opts.LogDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, finished, finished); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := newTestRunner(t, repo, opts)
+	if err := r.migrate(""); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(opts.DoneFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDone := "3\tcompleted_at=2025-11-03T04:05:06Z\tlog=" + filepath.Join(opts.LogDir, "3.log") + "\n" +
+		"5\tcompleted_at=2025-10-01T00:00:00Z\n"
+	if string(data) != wantDone {
+		t.Fatalf("done file = %q, want %q", data, wantDone)
+	}
+	for id, want := range map[string]string{"3": statusDone, "5": statusDone, "7": statusFailed} {
+		st, ok := r.state.get(id)
+		if !ok || st.Status != want {
+			t.Fatalf("#%s state = %+v, want %s", id, st, want)
+		}
+	}
+	if st, _ := r.state.get("7"); st.Failure != string(failureUnclassified) || !strings.HasSuffix(st.LogPath, "7.log") {
+		t.Fatalf("#7 state = %+v", st)
+	}
+
+	// A second run finds everything tracked already.
+	r = newTestRunner(t, repo, opts)
+	if err := r.migrate(""); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(opts.DoneFile); string(again) != wantDone {
+		t.Fatalf("second migrate rewrote the done file: %q", again)
+	}
+}
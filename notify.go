@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// notification is the JSON body POSTed to every --notify webhook.
+type notification struct {
+	Event     string `json:"event"`
+	Time      string `json:"time"`
+	Repo      string `json:"repo"`
+	Manifest  string `json:"manifest,omitempty"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Skipped   int    `json:"skipped"`
+	RunDir    string `json:"run_dir,omitempty"`
+	// Set on run_finished: the change summary of each issue done in the run.
+	Changes map[string]*diffSummary `json:"changes,omitempty"`
+	// Set on limit_waiting and limit_resumed (countdown.go).
+	Issue        string `json:"issue,omitempty"`
+	Agent        string `json:"agent,omitempty"`
+	ResumeAt     string `json:"resume_at,omitempty"`
+	RemainingSec int    `json:"remaining_sec,omitempty"`
+}
+
+func validateNotifyTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("notification target must be an http(s) URL (got %q)", target)
+	}
+	return nil
+}
+
+// notify POSTs the notification to every --notify target. A target that is
+// down or answers with an error only warns.
+func (r *runner) notify(n notification) {
+	if len(r.opts.Notify) == 0 {
+		return
+	}
+	n.Time = r.timestamp(r.now())
+	n.Repo = r.repoRoot
+	body, err := json.Marshal(n)
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not encode notification: %v\n", err)
+		return
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	for _, target := range r.opts.Notify {
+		// Webhook URLs carry their secret in the path, so warnings only
+		// name the host.
+		shown := redactURLs(target)
+		resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not notify %s: %s\n", shown, redactURLs(err.Error()))
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			r.printf(r.colors.Yellow, "WARNING: could not notify %s: %s\n", shown, resp.Status)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotifyPayload(t *testing.T) {
+	t.Parallel()
+
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s", req.Method, req.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(req.Body)
+		bodies <- data
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	r := &runner{opts: options{Notify: []string{server.URL + "/hook"}}, repoRoot: "/repo"}
+	r.stampedOut = newTimestampWriter(&out, func() string { return "" })
+	r.notify(notification{
+		Event:     journalRunFinished,
+		Succeeded: 2,
+		Failed:    1,
+		Changes:   map[string]*diffSummary{"7": parseNumstat("3\t1\tgreet.go\n")},
+	})
+
+	var got map[string]any
+	if err := json.Unmarshal(<-bodies, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["event"] != journalRunFinished || got["repo"] != "/repo" || got["succeeded"] != 2.0 || got["failed"] != 1.0 || got["skipped"] != 0.0 {
+		t.Fatalf("payload = %v", got)
+	}
+	if got["time"] == "" || got["issue"] != nil || got["resume_at"] != nil {
+		t.Fatalf("payload = %v", got)
+	}
+	changes, _ := got["changes"].(map[string]any)
+	if change, _ := changes["7"].(map[string]any); change["added"] != 3.0 {
+		t.Fatalf("changes = %v", got["changes"])
+	}
+	if out.String() != "" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
+
+func TestNotifyFailures(t *testing.T) {
+	t.Parallel()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	delivered := make(chan struct{}, 1)
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer working.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var out strings.Builder
+	r := &runner{opts: options{NoColor: true, Notify: []string{
+		failing.URL + "/services/T000/SECRET1",
+		down.URL + "/services/T000/SECRET2",
+		working.URL,
+	}}}
+	r.stampedOut = newTimestampWriter(&out, func() string { return "" })
+	r.notify(notification{Event: journalRunFinished})
+
+	select {
+	case <-delivered:
+	default:
+		t.Fatal("a failing target kept the next one from being notified")
+	}
+	text := out.String()
+	if !strings.Contains(text, "WARNING: could not notify "+failing.URL+"/[REDACTED]: 500 Internal Server Error") {
+		t.Fatalf("no warning for the failing target:\n%s", text)
+	}
+	if !strings.Contains(text, "WARNING: could not notify "+down.URL+"/[REDACTED]: ") {
+		t.Fatalf("no warning for the unreachable target:\n%s", text)
+	}
+	if strings.Contains(text, "SECRET") {
+		t.Fatalf("warnings leak the webhook path:\n%s", text)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpenCodeCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "default model", want: "run fix #5"},
+		{name: "model", model: "anthropic/claude-sonnet-4-5", want: "run --model anthropic/claude-sonnet-4-5 fix #5"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{opts: options{Agent: "opencode", OpenCodeBin: "/opt/opencode", Model: tt.model}}
+			cmd, err := r.buildAgentCommand("fix #5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(cmd.Args[1:], " "); cmd.Path != "/opt/opencode" || got != tt.want {
+				t.Fatalf("command = %s %s, want %s", cmd.Path, got, tt.want)
+			}
+		})
+	}
+}
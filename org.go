@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	commandOrg    = "org"
+	orgActionRun  = "run"
+	orgIssueLimit = 1000
+)
+
+type orgRepo struct {
+	Name   string
+	Issues []string
+}
+
+// runOrg discovers labelled issues across an organization, clones each repo
+// into the work dir and runs the normal queue there, so every repo's own
+// .ticket-runner/config.yaml, prompt and verify settings apply.
+func runOrg(opts options) int {
+	if err := validateOptions(opts); err != nil {
+		return exitCode(err)
+	}
+	workdir := opts.Workdir
+	if workdir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return exitCode(fmt.Errorf("pick a --workdir: %w", err))
+		}
+		workdir = filepath.Join(cache, "ghir", "org", opts.Org)
+	}
+	workdir, err := filepath.Abs(workdir)
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := os.MkdirAll(workdir, 0o755); err != nil {
+		return exitCode(fmt.Errorf("create work dir: %w", err))
+	}
+
+	catalog, err := loadCatalog(runnerLanguage(opts))
+	if err != nil {
+		return exitCode(err)
+	}
+	r := &runner{opts: opts, repoRoot: workdir, colors: newPalette(opts), catalog: catalog}
+	if opts.AppID != "" {
+		if r.app, err = newGitHubApp(opts, workdir); err != nil {
+			return exitCode(err)
+		}
+	}
+
+	repos, err := r.discoverOrgIssues()
+	if err != nil {
+		return exitCode(err)
+	}
+	if len(repos) == 0 {
+		r.printf(r.colors.Yellow, "No open issues labelled %s in %s\n", opts.Label, opts.Org)
+		return 0
+	}
+	total := 0
+	for _, repo := range repos {
+		total += len(repo.Issues)
+	}
+	r.printf(r.colors.Blue, "Found %d issue(s) in %d repo(s) of %s (work dir: %s)\n", total, len(repos), opts.Org, workdir)
+
+	failedRepos := 0
+	for i, repo := range repos {
+		fmt.Println()
+		r.rule(r.colors.Blue, "=")
+		r.printf(r.colors.Blue, "[%d/%d] %s: %s\n", i+1, len(repos), repo.Name, strings.Join(repo.Issues, ", "))
+		r.rule(r.colors.Blue, "=")
+		if opts.DryRun {
+			r.printf(r.colors.Yellow, "[DRY RUN] Would clone %s into %s and run issues %s\n", repo.Name, filepath.Join(workdir, repo.Name), strings.Join(repo.Issues, ","))
+			continue
+		}
+		if code := r.runOrgRepo(workdir, repo); code != 0 {
+			failedRepos++
+		}
+	}
+
+	fmt.Println()
+	r.printf(r.colors.Blue, "Org run finished: %d repo(s), %d with failures\n", len(repos), failedRepos)
+	if failedRepos > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (r *runner) discoverOrgIssues() ([]orgRepo, error) {
+	args := []string{"search", "issues", "--owner", r.opts.Org, "--state", "open", "--limit", strconv.Itoa(orgIssueLimit), "--json", "number,repository"}
+	for _, label := range strings.Split(r.opts.Label, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			args = append(args, "--label", label)
+		}
+	}
+	out, err := r.commandOutput(r.opts.GHBin, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search issues in %s: %w", r.opts.Org, err)
+	}
+	return parseOrgSearch(out)
+}
+
+func parseOrgSearch(data string) ([]orgRepo, error) {
+	var found []struct {
+		Number     int `json:"number"`
+		Repository struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal([]byte(data), &found); err != nil {
+		return nil, fmt.Errorf("parse gh search output: %w", err)
+	}
+	byRepo := map[string][]int{}
+	for _, issue := range found {
+		name := issue.Repository.NameWithOwner
+		if name == "" {
+			continue
+		}
+		byRepo[name] = append(byRepo[name], issue.Number)
+	}
+	repos := make([]orgRepo, 0, len(byRepo))
+	for name, numbers := range byRepo {
+		sort.Ints(numbers)
+		repo := orgRepo{Name: name}
+		for _, n := range numbers {
+			repo.Issues = append(repo.Issues, strconv.Itoa(n))
+		}
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+func (r *runner) runOrgRepo(workdir string, repo orgRepo) int {
+	dir := filepath.Join(workdir, filepath.FromSlash(repo.Name))
+	if err := r.syncOrgClone(repo.Name, dir); err != nil {
+		r.printf(r.colors.Red, "FAILED: %v\n", err)
+		return 1
+	}
+
+	opts := r.opts
+	opts.Command = commandRun
+	opts.IssuesCSV = strings.Join(repo.Issues, ",")
+	opts.explicit = make(map[string]struct{}, len(r.opts.explicit)+1)
+	for flag := range r.opts.explicit {
+		opts.explicit[flag] = struct{}{}
+	}
+	opts.explicit["--issues"] = struct{}{}
+	if err := applyRepoDefaults(&opts, dir); err != nil {
+		r.printf(r.colors.Red, "FAILED: %s: %v\n", repo.Name, err)
+		return 1
+	}
+	if err := validateOptions(opts); err != nil {
+		r.printf(r.colors.Red, "FAILED: %s: %v\n", repo.Name, err)
+		return 1
+	}
+	child, err := newRunner(opts, dir)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: %s: %v\n", repo.Name, err)
+		return 1
+	}
+	if r.app != nil {
+		child.app = r.app
+	}
+	return child.runQueue()
+}
+
+func (r *runner) syncOrgClone(name, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		r.printf(r.colors.Blue, "Updating %s\n", dir)
+		if _, err := r.commandOutput("git", "-C", dir, "pull", "--ff-only"); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not fast-forward %s, using the existing checkout: %v\n", name, err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	r.printf(r.colors.Blue, "Cloning %s\n", name)
+	if _, err := r.commandOutput(r.opts.GHBin, "repo", "clone", name, dir); err != nil {
+		return fmt.Errorf("clone %s: %w", name, err)
+	}
+	return nil
+}
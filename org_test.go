@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOrgSearch(t *testing.T) {
+	t.Parallel()
+
+	repos, err := parseOrgSearch(`[
+		{"number": 12, "repository": {"nameWithOwner": "acme/web"}},
+		{"number": 3, "repository": {"nameWithOwner": "acme/api"}},
+		{"number": 7, "repository": {"nameWithOwner": "acme/web"}}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []orgRepo{
+		{Name: "acme/api", Issues: []string{"3"}},
+		{Name: "acme/web", Issues: []string{"7", "12"}},
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Fatalf("repos = %+v, want %+v", repos, want)
+	}
+	if _, err := parseOrgSearch("not json"); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestParseArgsOrgRun(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"org", "run", "--org", "acme", "--label", "agent-ready", "--agent", "codex"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if opts.Org != "acme" || opts.Label != "agent-ready" || opts.Agent != "codex" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	for _, args := range [][]string{
+		{"org", "run", "--org", "acme"},
+		{"org", "list", "--org", "acme", "--label", "x"},
+		{"org", "run", "--org", "acme", "--label", "x", "--status"},
+	} {
+		if _, err := parseArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestRunOrgDryRunClonesNothing(t *testing.T) {
+	t.Parallel()
+
+	calls := filepath.Join(t.TempDir(), "calls")
+	gh := writeFakeBin(t, "gh", `echo "$@" >> `+calls+`
+echo '[{"number": 5, "repository": {"nameWithOwner": "acme/api"}}]'`)
+	workdir := t.TempDir()
+	opts, err := parseArgs([]string{"org", "run", "--org", "acme", "--label", "agent-ready,p1", "--dry-run", "--workdir", workdir, "--gh-bin", gh, "--no-color"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := runOrg(opts); code != 0 {
+		t.Fatalf("runOrg exit = %d", code)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(got, "search issues --owner acme") || !strings.Contains(got, "--label agent-ready --label p1") {
+		t.Fatalf("unexpected gh calls: %q", got)
+	}
+	if entries, _ := os.ReadDir(workdir); len(entries) != 0 {
+		t.Fatalf("dry run touched the work dir: %v", entries)
+	}
+}
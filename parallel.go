@@ -0,0 +1,224 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const worktreesDirName = "worktrees"
+
+// locked runs fn holding the lock that guards the done set, failure
+// categories and baseline cache while issues run in parallel. Sequential
+// runs have no lock.
+func (r *runner) locked(fn func()) {
+	if r.mu != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+	fn()
+}
+
+// runParallel works through the queue with --parallel workers. Every issue
+// runs in its own git worktree on its own branch (the entry's branch, or
+// ghir/issue-<id>) cut from the current HEAD, with its own agent process and
+// log, so the checked-out branch is never touched. After a failure no new
+// issues are started; the ones already running finish.
+func (r *runner) runParallel(issues []issueEntry) (succeeded, failed, skipped int, outcomes []manifestOutcome) {
+	r.mu = &sync.Mutex{}
+	r.limits = &sessionLimits{until: make(map[string]time.Time)}
+	start, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: cannot determine git HEAD: %v\n", err)
+		return 0, len(issues), 0, nil
+	}
+	base, _ := r.gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if base == "HEAD" {
+		base = ""
+	}
+	workers := min(r.opts.Parallel, len(issues))
+	r.printf(r.colors.Blue, "Running %d issue(s) with %d parallel worker(s), each in its own worktree\n", len(issues), workers)
+
+	results := make([]issueResult, len(issues))
+	started := make([]bool, len(issues))
+	var stop atomic.Bool
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if stop.Load() {
+					continue
+				}
+				started[i] = true
+				entry := issues[i]
+				r.tuiStatus(entry.ID, tuiStatusRunning)
+				result := r.runInWorktree(i+1, len(issues), entry, start, base)
+				results[i] = result
+				switch result {
+				case resultSuccess:
+					r.tuiStatus(entry.ID, tuiStatusDone)
+				case resultSkipped:
+					r.tuiStatus(entry.ID, tuiStatusSkipped)
+				default:
+					r.tuiStatus(entry.ID, tuiStatusFailed)
+					if !stop.Swap(true) {
+						r.printf(r.colors.Red, "Not starting new issues after the failure on #%s\n", entry.ID)
+					}
+				}
+			}
+		}()
+	}
+	for i := range issues {
+		if stop.Load() {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, entry := range issues {
+		if !started[i] {
+			continue
+		}
+		outcomes = append(outcomes, manifestOutcome{Issue: entry.ID, Result: results[i].String()})
+		switch results[i] {
+		case resultSuccess:
+			succeeded++
+		case resultSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	return succeeded, failed, skipped, outcomes
+}
+
+// runInWorktree processes one issue in a fresh worktree. The worktree is
+// removed afterwards unless the issue failed, so a failure can be inspected
+// where it happened; the branch is always kept.
+func (r *runner) runInWorktree(idx, total int, entry issueEntry, start, base string) issueResult {
+	if r.opts.DryRun || r.isSkipped(entry.ID) || (r.isCompleted(entry.ID) && !r.opts.Force) {
+		// Nothing to check out: processIssue only reports these.
+		return r.processIssue(idx, total, entry)
+	}
+	branch := issueBranch(entry)
+	dir := filepath.Join(r.opts.LogDir, worktreesDirName, entry.ID)
+	var err error
+	// git takes locks of its own for worktree changes, so they are made one
+	// at a time.
+	r.locked(func() {
+		err = r.addIssueWorktree(dir, branch, start)
+	})
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: cannot create a worktree for #%s: %v\n", entry.ID, err)
+		r.recordFailure(entry.ID, failureGit)
+		return resultFailed
+	}
+
+	worker := *r
+	worker.repoRoot = dir
+	worker.baseBranch = base
+	// Interleaved agent output from several issues is unreadable; it is
+	// still in each issue's log.
+	worker.opts.Quiet = true
+	entry.Branch = branch
+
+	result := worker.processIssue(idx, total, entry)
+	for result == resultRetry {
+		r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", entry.ID)
+		result = worker.processIssue(idx, total, entry)
+	}
+	if result == resultFailed {
+		r.printf(r.colors.Yellow, "Worktree of #%s kept for inspection: %s (branch %s)\n", entry.ID, dir, branch)
+		return result
+	}
+	r.locked(func() {
+		r.removeWorktree(dir)
+	})
+	return result
+}
+
+func (r *runner) addIssueWorktree(dir, branch, start string) error {
+	r.removeWorktree(dir)
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	args := []string{"worktree", "add", "-b", branch, dir, start}
+	if _, err := r.gitOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		args = []string{"worktree", "add", dir, branch}
+	}
+	if _, err := r.gitOutput(args...); err != nil {
+		return err
+	}
+	r.linkSharedDirs(dir)
+	return nil
+}
+
+func (r *runner) removeWorktree(dir string) {
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	if _, err := r.gitOutput("worktree", "remove", "--force", dir); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not remove worktree %s: %v\n", dir, err)
+		_ = os.RemoveAll(dir)
+		_, _ = r.gitOutput("worktree", "prune")
+	}
+}
+
+// sessionLimits holds, per agent account, when its session limit resets.
+// Workers check it before starting their agent, so once one worker hits the
+// limit the others wait for the same reset instead of each running into it.
+type sessionLimits struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// pause closes the gate of key until resetAt. It reports whether this call
+// moved the reset time, in which case the caller shows the countdown; the
+// other workers wait quietly.
+func (l *sessionLimits) pause(key string, resetAt time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !resetAt.After(l.until[key]) {
+		return false
+	}
+	l.until[key] = resetAt
+	return true
+}
+
+func (l *sessionLimits) resumeAt(key string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.until[key]
+}
+
+// limitKey identifies the account a session limit applies to: the agent and
+// the binary it is run with (which may be a wrapper for another account).
+func (r *runner) limitKey() string {
+	return r.opts.Agent + "\x00" + r.agentBin()
+}
+
+// awaitSessionLimit blocks until the session limit another worker hit for
+// this issue's agent has reset; all waiting workers resume at the same time.
+func (r *runner) awaitSessionLimit(issue string) {
+	if r.limits == nil {
+		return
+	}
+	for {
+		resumeAt := r.limits.resumeAt(r.limitKey())
+		wait := resumeAt.Sub(r.now())
+		if wait <= 0 {
+			return
+		}
+		r.printf(r.colors.Yellow, "Issue #%s waits for the %s session limit hit by another worker (resuming at %s)\n", issue, agentDisplayName(r.opts.Agent), resumeAt.In(r.location()).Format("2006-01-02 15:04 MST"))
+		r.tuiStatus(issue, tuiStatusWaiting)
+		time.Sleep(wait)
+		r.tuiStatus(issue, tuiStatusRunning)
+	}
+}
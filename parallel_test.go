@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunParallelUsesOneWorktreePerIssue(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	start := runGit(t, repo, "rev-parse", "HEAD")
+	gh := writeFakeBin(t, "gh", `echo '{"title":"Issue '"$3"'","body":"","state":"OPEN","labels":[]}'`)
+	agent := fakeAgent(t, `[ "$1" = --version ] && exit 0
+sleep 0.2
+echo "$(pwd)" > change.txt
+git add change.txt
+git commit -q -m "feat: change"`)
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		LogDir:    filepath.Join(t.TempDir(), "logs"),
+		Parallel:  2,
+	}
+	r := newTestRunner(t, repo, opts)
+
+	succeeded, failed, skipped, outcomes := r.runParallel([]issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3", Branch: "feature/three"}})
+	if succeeded != 3 || failed != 0 || skipped != 0 || len(outcomes) != 3 {
+		t.Fatalf("runParallel() = %d succeeded, %d failed, %d skipped, %v", succeeded, failed, skipped, outcomes)
+	}
+	if head := runGit(t, repo, "rev-parse", "HEAD"); head != start {
+		t.Fatalf("checked-out HEAD moved to %s", head)
+	}
+	if branch := runGit(t, repo, "rev-parse", "--abbrev-ref", "HEAD"); branch != "master" && branch != "main" {
+		t.Fatalf("checked-out branch changed to %s", branch)
+	}
+	for _, branch := range []string{"ghir/issue-1", "ghir/issue-2", "feature/three"} {
+		if count := runGit(t, repo, "rev-list", "--count", start+".."+branch); count != "1" {
+			t.Fatalf("%s has %s commit(s) on top of the start, want 1", branch, count)
+		}
+		changed := runGit(t, repo, "show", branch+":change.txt")
+		if !strings.Contains(changed, filepath.Join(worktreesDirName, "")) {
+			t.Fatalf("%s was committed in %s, not in a worktree", branch, changed)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(r.opts.LogDir, worktreesDirName)); len(entries) != 0 {
+		t.Fatalf("worktrees left behind: %v", entries)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if !r.isCompleted(id) {
+			t.Fatalf("#%s not marked completed", id)
+		}
+	}
+}
+
+func TestRunParallelStopsStartingIssuesAfterAFailure(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Issue", "")
+	agent := fakeAgent(t, "exit 0")
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		LogDir:    filepath.Join(t.TempDir(), "logs"),
+		Parallel:  2,
+	}
+	r := newTestRunner(t, repo, opts)
+
+	succeeded, failed, _, outcomes := r.runParallel([]issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	if succeeded != 0 || failed != 2 || len(outcomes) != 2 {
+		t.Fatalf("runParallel() = %d succeeded, %d failed, %v; want the two running issues to fail and #3 not to start", succeeded, failed, outcomes)
+	}
+	if _, err := os.Stat(filepath.Join(r.opts.LogDir, worktreesDirName, "1")); err != nil {
+		t.Fatalf("failed issue's worktree was not kept: %v", err)
+	}
+}
+
+func TestParallelOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--parallel", "0"}, wantErr: "--parallel must be a positive integer"},
+		{args: []string{"--parallel", "3", "--e2e-cmd", "make e2e"}, wantErr: "cannot be combined with --verify-full-at-end or --e2e-cmd"},
+		{args: []string{"--parallel", "3"}},
+	} {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Fatalf("options %v error = %v, want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSessionLimitsPause(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	limits := &sessionLimits{until: make(map[string]time.Time)}
+	steps := []struct {
+		key    string
+		reset  time.Time
+		leader bool
+	}{
+		{key: "claude", reset: base, leader: true},
+		{key: "claude", reset: base.Add(-time.Minute), leader: false},
+		{key: "claude", reset: base, leader: false},
+		{key: "codex", reset: base, leader: true},
+		{key: "claude", reset: base.Add(time.Minute), leader: true},
+	}
+	for i, step := range steps {
+		if got := limits.pause(step.key, step.reset); got != step.leader {
+			t.Fatalf("step %d: pause(%s, %s) = %v, want %v", i, step.key, step.reset, got, step.leader)
+		}
+	}
+	if got := limits.resumeAt("claude"); !got.Equal(base.Add(time.Minute)) {
+		t.Fatalf("resumeAt(claude) = %s", got)
+	}
+}
+
+func TestAwaitSessionLimitWaitsForTheSharedReset(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{Agent: "claude", ClaudeBin: "claude", Quiet: true}, limits: &sessionLimits{until: make(map[string]time.Time)}}
+	other := &runner{opts: options{Agent: "codex", CodexBin: "codex"}, limits: r.limits}
+
+	r.limits.pause(other.limitKey(), time.Now().Add(time.Hour))
+	start := time.Now()
+	r.awaitSessionLimit("1")
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Fatalf("a limit of another agent blocked for %s", waited)
+	}
+
+	resume := time.Now().Add(200 * time.Millisecond)
+	r.limits.pause(r.limitKey(), resume)
+	r.awaitSessionLimit("1")
+	if time.Now().Before(resume) {
+		t.Fatal("awaitSessionLimit returned before the shared reset time")
+	}
+}
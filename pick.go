@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type pickItem struct {
+	entry    issueEntry
+	title    string
+	selected bool
+}
+
+// pickIssues lets the user choose which pending issues of the queue to run.
+// An empty result means the user picked nothing.
+func (r *runner) pickIssues(entries []issueEntry) ([]issueEntry, error) {
+	var items []pickItem
+	for _, entry := range entries {
+		if r.isSkipped(entry.ID) || (r.isCompleted(entry.ID) && !r.opts.Force) {
+			continue
+		}
+		items = append(items, pickItem{entry: entry})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no pending issues to pick from")
+	}
+
+	titles, err := r.fetchOpenIssueTitles()
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not fetch issue titles: %v\n", err)
+	}
+	for i := range items {
+		items[i].title = titles[items[i].entry.ID]
+	}
+
+	in := io.Reader(os.Stdin)
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		in = tty
+	}
+	if err := runPicker(in, os.Stdout, items); err != nil {
+		return nil, err
+	}
+
+	var picked []issueEntry
+	for _, item := range items {
+		if item.selected {
+			picked = append(picked, item.entry)
+		}
+	}
+	return picked, nil
+}
+
+func (r *runner) fetchOpenIssueTitles() (map[string]string, error) {
+	out, err := r.commandOutput(
+		r.opts.GHBin, "issue", "list",
+		"--state", "open",
+		"--limit", strconv.Itoa(assigneeIssueLimit),
+		"--json", "number,title",
+	)
+	if err != nil {
+		return nil, err
+	}
+	var listed []struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse gh issue list output: %w", err)
+	}
+	titles := make(map[string]string, len(listed))
+	for _, issue := range listed {
+		titles[strconv.Itoa(issue.Number)] = issue.Title
+	}
+	return titles, nil
+}
+
+// runPicker shows a checkbox list and toggles entries from typed numbers and
+// ranges until an empty line confirms the selection. It reads whole lines so
+// it works without a raw terminal and with screen readers.
+func runPicker(in io.Reader, out io.Writer, items []pickItem) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintln(out)
+		for i, item := range items {
+			box := "[ ]"
+			if item.selected {
+				box = "[x]"
+			}
+			fmt.Fprintf(out, "%3d) %s #%s %s\n", i+1, box, item.entry.ID, item.title)
+		}
+		fmt.Fprint(out, "Toggle issues (e.g. 1 3-5), a = all, n = none, q = quit, Enter = run selected: ")
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+			clearSelection(items)
+			return nil
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch strings.ToLower(line) {
+		case "":
+			return nil
+		case "q", "quit":
+			clearSelection(items)
+			return nil
+		case "a", "all":
+			for i := range items {
+				items[i].selected = true
+			}
+			continue
+		case "n", "none":
+			clearSelection(items)
+			continue
+		}
+		indexes, err := parsePickSelection(line, len(items))
+		if err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+			continue
+		}
+		for _, idx := range indexes {
+			items[idx].selected = !items[idx].selected
+		}
+	}
+}
+
+func clearSelection(items []pickItem) {
+	for i := range items {
+		items[i].selected = false
+	}
+}
+
+func parsePickSelection(value string, count int) ([]int, error) {
+	var indexes []int
+	for _, token := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' }) {
+		first, last, isRange := strings.Cut(token, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %q", token)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("not a range: %q", token)
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("out of range: %q (1-%d)", token, count)
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	return indexes, nil
+}
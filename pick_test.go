@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunPicker(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "toggle numbers and ranges", input: "1 3-4\n4\n\n", want: []string{"10", "30"}},
+		{name: "all then toggle off", input: "a\n2\n\n", want: []string{"10", "30", "40"}},
+		{name: "invalid input is ignored", input: "9\nx\n2\n\n", want: []string{"20"}},
+		{name: "quit clears selection", input: "1 2\nq\n", want: nil},
+		{name: "eof clears selection", input: "1", want: nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			items := []pickItem{
+				{entry: issueEntry{ID: "10"}, title: "First"},
+				{entry: issueEntry{ID: "20"}},
+				{entry: issueEntry{ID: "30"}},
+				{entry: issueEntry{ID: "40"}},
+			}
+			var out bytes.Buffer
+			if err := runPicker(strings.NewReader(tt.input), &out, items); err != nil {
+				t.Fatalf("runPicker: %v", err)
+			}
+			var got []string
+			for _, item := range items {
+				if item.selected {
+					got = append(got, item.entry.ID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("selected = %v, want %v\n%s", got, tt.want, out.String())
+			}
+			if !strings.Contains(out.String(), "  1) [ ] #10 First") {
+				t.Fatalf("missing checkbox list:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestParsePickSelection(t *testing.T) {
+	t.Parallel()
+
+	got, err := parsePickSelection("1, 3-4 2", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("indexes = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"0", "5", "3-2", "a-b", "1-x"} {
+		if _, err := parsePickSelection(bad, 4); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
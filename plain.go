@@ -0,0 +1,76 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const ruleWidth = 60
+
+var ansiEscapePattern = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// rule prints a decorative separator line; plain output drops it entirely.
+func (r *runner) rule(color, char string) {
+	if r.opts.Plain {
+		return
+	}
+	r.printf(color, "%s\n", strings.Repeat(char, ruleWidth))
+}
+
+func (r *runner) heading(color, title string) {
+	title = r.tr(title)
+	if r.opts.Plain {
+		r.printf(color, "%s\n", title)
+		return
+	}
+	pad := (ruleWidth - len(title)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	r.printf(color, "%s%s\n", strings.Repeat(" ", pad), title)
+}
+
+type plainStreamRenderer struct {
+	inner streamRenderer
+}
+
+func (p *plainStreamRenderer) ConsumeLine(line string) []string {
+	return plainLines(p.inner.ConsumeLine(line))
+}
+
+func (p *plainStreamRenderer) FinalLines() []string {
+	return plainLines(p.inner.FinalLines())
+}
+
+func plainLines(lines []string) []string {
+	out := lines[:0]
+	for _, line := range lines {
+		// Drop lines that were nothing but control sequences.
+		if text := plainText(line); text != "" || line == "" {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+// plainText strips ANSI escapes and collapses carriage-return redraws
+// (progress bars, spinners) to the text a terminal would end up showing.
+func plainText(line string) string {
+	line = ansiEscapePattern.ReplaceAllString(line, "")
+	if strings.Contains(line, "\r") {
+		segments := strings.Split(line, "\r")
+		line = ""
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.TrimSpace(segments[i]) != "" {
+				line = segments[i]
+				break
+			}
+		}
+	}
+	return strings.TrimRight(strings.Map(func(r rune) rune {
+		if r == '\b' || r == '\a' {
+			return -1
+		}
+		return r
+	}, line), " ")
+}
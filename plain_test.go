@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "unchanged", line: "Running tests", want: "Running tests"},
+		{name: "color codes", line: "\x1b[0;32mok\x1b[0m  ghir", want: "ok  ghir"},
+		{name: "progress redraw", line: "10%\r50%\r100% done", want: "100% done"},
+		{name: "trailing clear", line: "building...\r\x1b[2K\r", want: "building..."},
+		{name: "osc title", line: "\x1b]0;title\x07text", want: "text"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := plainText(tt.line); got != tt.want {
+				t.Fatalf("plainText(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainStreamRendererDropsControlOnlyLines(t *testing.T) {
+	t.Parallel()
+
+	renderer := &plainStreamRenderer{inner: &rawStreamRenderer{}}
+	var got []string
+	for _, line := range []string{"start", "\x1b[2K", "", "\x1b[1mend\x1b[0m"} {
+		got = append(got, renderer.ConsumeLine(line)...)
+	}
+	if want := []string{"start", "", "end"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+}
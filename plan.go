@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const commandPlan = "plan"
+
+// plannedIssue is one pending issue of the plan with the agent and model it
+// would run with and what that has cost on average so far.
+type plannedIssue struct {
+	Entry   issueEntry
+	Title   string
+	Agent   string
+	Model   string
+	CostUSD float64
+	Basis   string
+}
+
+// costHistory averages the cost of completed issues in state.json by agent
+// and model.
+type costHistory struct {
+	byModel map[string][]float64
+	byAgent map[string][]float64
+	all     []float64
+}
+
+func newCostHistory(states map[string]issueState) costHistory {
+	h := costHistory{byModel: make(map[string][]float64), byAgent: make(map[string][]float64)}
+	for _, st := range states {
+		if st.Status != statusDone || st.CostUSD <= 0 {
+			continue
+		}
+		h.byModel[st.Agent+"/"+st.Model] = append(h.byModel[st.Agent+"/"+st.Model], st.CostUSD)
+		h.byAgent[st.Agent] = append(h.byAgent[st.Agent], st.CostUSD)
+		h.all = append(h.all, st.CostUSD)
+	}
+	return h
+}
+
+// estimate is the mean cost of earlier issues done by the same agent and
+// model, else by the same agent, else by any agent, and what it is based on.
+func (h costHistory) estimate(agent, model string) (float64, string) {
+	for _, level := range []struct {
+		costs []float64
+		basis string
+	}{
+		{h.byModel[agent+"/"+model], "this agent and model"},
+		{h.byAgent[agent], "this agent"},
+		{h.all, "all agents"},
+	} {
+		if len(level.costs) == 0 {
+			continue
+		}
+		sum := 0.0
+		for _, cost := range level.costs {
+			sum += cost
+		}
+		return sum / float64(len(level.costs)), fmt.Sprintf("mean of %d done issue(s), %s", len(level.costs), level.basis)
+	}
+	return 0, "no cost history"
+}
+
+// planQueue walks the pending issues of the queue in run order and assigns
+// each its agent and model (per-issue override, else the configured one) and
+// a cost estimate from earlier runs. Closed issues are left out unless
+// --include-closed is set, as the run would skip them.
+func (r *runner) planQueue() ([]plannedIssue, error) {
+	issues, err := r.loadIssues()
+	if err != nil {
+		return nil, err
+	}
+	var history costHistory
+	if r.state != nil {
+		history = newCostHistory(r.state.snapshot())
+	}
+	var plan []plannedIssue
+	for _, entry := range issues {
+		if r.isSkipped(entry.ID) || (r.isCompleted(entry.ID) && !r.opts.Force) {
+			continue
+		}
+		scoped := r.forIssue(entry)
+		item := plannedIssue{Entry: entry, Agent: scoped.opts.Agent, Model: scoped.opts.Model}
+		details, err := r.entryDetails(entry)
+		switch {
+		case err != nil:
+			item.Title = fmt.Sprintf("(could not fetch: %v)", err)
+		case strings.EqualFold(details.State, "closed") && !r.opts.IncludeClosed:
+			continue
+		default:
+			item.Title = details.Title
+		}
+		item.CostUSD, item.Basis = history.estimate(item.Agent, item.Model)
+		plan = append(plan, item)
+	}
+	return plan, nil
+}
+
+func (r *runner) runPlan() error {
+	plan, err := r.planQueue()
+	if err != nil {
+		return err
+	}
+	if r.opts.EmitManifest {
+		data, err := r.planManifest(plan)
+		if err != nil {
+			return err
+		}
+		if r.opts.Out == "" || r.opts.Out == "-" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(r.opts.Out, data, 0o644); err != nil {
+			return fmt.Errorf("write manifest: %w", err)
+		}
+		r.printf(r.colors.Green, "Wrote a run manifest for %d issue(s) to %s\n", len(plan), r.opts.Out)
+		return nil
+	}
+
+	if len(plan) == 0 {
+		r.printf(r.colors.Yellow, "No pending issues, nothing to plan\n")
+		return nil
+	}
+	r.printf(r.colors.Blue, "Plan: %d pending issue(s)\n", len(plan))
+	total := 0.0
+	for i, item := range plan {
+		r.printf("", "%3d. #%-8s %-40s %-22s %s\n", i+1, item.Entry.ID, truncateForConsole(item.Title, 40), agentModelLabel(item.Agent, item.Model), formatCost(item.CostUSD))
+		total += item.CostUSD
+	}
+	r.printf(r.colors.Blue, "Estimated cost: %s\n", formatCost(total))
+	return nil
+}
+
+// planManifest renders the plan as a run manifest for `ghir run -f`. The
+// agent and model are written out for every issue so a reviewer can change
+// them per issue; titles and estimates go in comments.
+func (r *runner) planManifest(plan []plannedIssue) ([]byte, error) {
+	total := 0.0
+	synthetic := 0
+	issues := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, item := range plan {
+		entry := item.Entry
+		if entry.synthetic() {
+			// Manifests list GitHub issues; scanner tasks come from their source.
+			synthetic++
+			continue
+		}
+		entry.Agent, entry.Model = item.Agent, item.Model
+		var node yaml.Node
+		if err := node.Encode(issueEntryFields(entry)); err != nil {
+			return nil, err
+		}
+		node.HeadComment = fmt.Sprintf("%s (est. %s, %s)", item.Title, formatCost(item.CostUSD), item.Basis)
+		issues.Content = append(issues.Content, &node)
+		total += item.CostUSD
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value *yaml.Node) {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	scalar := func(value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	}
+	add("name", scalar("plan-"+r.fileStamp(r.now())))
+	add("agent", scalar(r.opts.Agent))
+	if r.opts.Model != "" {
+		add("model", scalar(r.opts.Model))
+	}
+	if r.opts.VerifyCmd != "" {
+		add("verify_cmd", scalar(r.opts.VerifyCmd))
+	}
+	add("issues", issues)
+	root.HeadComment = fmt.Sprintf("yaml-language-server: $schema=%smanifest.schema.json\nProposed by ghir plan on %s: %d issue(s), estimated cost %s.\nReview and edit, then run it with: ghir run -f <this file>", schemaBaseURL, r.timestamp(r.now()), len(issues.Content), formatCost(total))
+	if synthetic > 0 {
+		root.HeadComment += fmt.Sprintf("\n%d synthetic task(s) left out: manifests list GitHub issues only.", synthetic)
+	}
+
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+func agentModelLabel(agent, model string) string {
+	if model == "" {
+		return agent
+	}
+	return agent + "/" + model
+}
+
+func formatCost(usd float64) string {
+	if usd == 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCostHistoryEstimate(t *testing.T) {
+	t.Parallel()
+
+	history := newCostHistory(map[string]issueState{
+		"1": {Status: statusDone, Agent: "claude", Model: "opus", CostUSD: 2},
+		"2": {Status: statusDone, Agent: "claude", Model: "opus", CostUSD: 4},
+		"3": {Status: statusDone, Agent: "claude", CostUSD: 1},
+		"4": {Status: statusFailed, Agent: "codex", CostUSD: 9},
+	})
+	tests := []struct {
+		agent, model string
+		want         float64
+		wantBasis    string
+	}{
+		{agent: "claude", model: "opus", want: 3, wantBasis: "this agent and model"},
+		{agent: "claude", model: "sonnet", want: 7.0 / 3, wantBasis: "this agent"},
+		{agent: "codex", want: 7.0 / 3, wantBasis: "all agents"},
+	}
+	for _, tt := range tests {
+		got, basis := history.estimate(tt.agent, tt.model)
+		if got != tt.want || !strings.HasSuffix(basis, tt.wantBasis) {
+			t.Fatalf("estimate(%s, %s) = %v (%s), want %v (%s)", tt.agent, tt.model, got, basis, tt.want, tt.wantBasis)
+		}
+	}
+	if got, basis := newCostHistory(nil).estimate("claude", ""); got != 0 || basis != "no cost history" {
+		t.Fatalf("empty history estimate = %v (%s)", got, basis)
+	}
+}
+
+func TestPlanEmitManifestRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	dir := t.TempDir()
+	issuesFile := filepath.Join(dir, "issues.yaml")
+	if err := os.WriteFile(issuesFile, []byte("- 1\n- id: 2\n  agent: codex\n- 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gh := writeFakeBin(t, "gh", `case "$3" in
+3) echo '{"title":"Old","body":"","state":"CLOSED","labels":[]}' ;;
+*) echo '{"title":"Issue '"$3"'","body":"","state":"OPEN","labels":[]}' ;;
+esac`)
+	opts := options{
+		Agent:        "claude",
+		Model:        "opus",
+		GHBin:        gh,
+		IssuesFile:   issuesFile,
+		LogDir:       filepath.Join(dir, "logs"),
+		EmitManifest: true,
+		Out:          filepath.Join(dir, "plan.yaml"),
+	}
+	r := newTestRunner(t, repo, opts)
+	if err := r.state.update("9", func(st *issueState) {
+		st.Status, st.Agent, st.Model, st.CostUSD = statusDone, "claude", "opus", 1.5
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.runPlan(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(opts.Out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2 issue(s), estimated cost $3.00", "# Issue 1 (est. $1.50, mean of 1 done issue(s), this agent and model)", "# Issue 2 (est. $1.50, mean of 1 done issue(s), all agents)"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("manifest missing %q:\n%s", want, data)
+		}
+	}
+	m, err := loadRunManifest(opts.Out)
+	if err != nil {
+		t.Fatalf("emitted manifest does not load: %v\n%s", err, data)
+	}
+	if m.Settings.Agent != "claude" || m.Settings.Model != "opus" || len(m.Issues) != 2 {
+		t.Fatalf("manifest = %+v", m)
+	}
+	if m.Issues[1].ID != "2" || m.Issues[1].Agent != "codex" || m.Issues[1].Model != "" {
+		t.Fatalf("issue 2 = %+v, want its codex override without the default model", m.Issues[1])
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultPRTemplate   = ".ticket-runner/pr.tmpl"
+	defaultBranchPrefix = "ghir/issue-"
+)
+
+// defaultPRBody is translated as a whole, placeholders included.
+const defaultPRBody = "{{ISSUE_TITLE}}\n\n{{SUMMARY}}## Changes\n\n{{COMMITS}}\n\n{{ARTIFACTS}}{{CLOSES}}\n"
+
+// issueBranch is the branch an issue runs on with --create-pr: its own
+// branch from the issues file, or ghir/issue-<id>.
+func issueBranch(entry issueEntry) string {
+	if entry.Branch != "" {
+		return entry.Branch
+	}
+	return defaultBranchPrefix + entry.ID
+}
+
+func (r *runner) renderPRBody(issue string, details issueDetails, summary, commits, artifacts, closes string) (string, error) {
+	body := r.tr(defaultPRBody)
+	if r.opts.PRTemplate != "" {
+		data, err := os.ReadFile(r.opts.PRTemplate)
+		if err != nil {
+			return "", fmt.Errorf("read PR template: %w", err)
+		}
+		body = string(data)
+	}
+	replacer := strings.NewReplacer(
+		"{{ISSUE_NUMBER}}", issue,
+		"{{ISSUE_TITLE}}", details.Title,
+		"{{ISSUE_BODY}}", details.Body,
+		"{{SUMMARY}}", summary,
+		"{{COMMITS}}", commits,
+		"{{ARTIFACTS}}", artifacts,
+		"{{CLOSES}}", closes,
+	)
+	return strings.TrimSpace(replacer.Replace(body)) + "\n", nil
+}
+
+// prCloses is the closing keyword for the PR body: the issue itself, or the
+// tracking issue of a synthetic task.
+func prCloses(issue string, entry issueEntry, tracking string) string {
+	switch {
+	case !entry.synthetic():
+		return "Closes #" + issue
+	case tracking != "":
+		return "Closes #" + tracking
+	}
+	return ""
+}
+
+func prTitle(issue string, entry issueEntry, title string) string {
+	if entry.synthetic() {
+		return title
+	}
+	return fmt.Sprintf("%s (#%s)", title, issue)
+}
+
+// openPullRequest pushes the issue branch and opens a PR into base, returning
+// its URL. The issue passed its gates, so failures are reported but do not
+// fail it.
+func (r *runner) openPullRequest(issue string, entry issueEntry, details issueDetails, branch, base, startHead, tracking string, artifacts []string, changes *diffSummary) string {
+	if _, err := r.gitOutput("push", "--set-upstream", r.pushRemote(), branch); err != nil {
+		r.printf(r.colors.Red, "WARNING: could not push %s for #%s: %v\n", branch, issue, err)
+		return ""
+	}
+	commits, err := r.gitOutput("log", "--reverse", "--pretty=format:- %s", startHead+"..HEAD")
+	if err != nil {
+		r.printf(r.colors.Red, "WARNING: could not list commits for the #%s PR: %v\n", issue, err)
+		return ""
+	}
+	body, err := r.renderPRBody(issue, details, r.prSummary(changes), commits, r.artifactsMarkdown(artifacts), prCloses(issue, entry, tracking))
+	if err != nil {
+		r.printf(r.colors.Red, "WARNING: %v\n", err)
+		return ""
+	}
+	args := []string{"pr", "create", "--head", branch, "--title", prTitle(issue, entry, details.Title), "--body", body}
+	if base != "" {
+		args = append(args, "--base", base)
+	}
+	if r.opts.PRDraft {
+		args = append(args, "--draft")
+	}
+	out, err := r.ghWrite(args...)
+	if err != nil {
+		r.printf(r.colors.Red, "WARNING: could not open a PR for #%s: %v\n", issue, err)
+		return ""
+	}
+	url := lastLine(out)
+	if r.opts.PRDraft {
+		r.printf(r.colors.Green, "Draft pull request: %s\n", url)
+	} else {
+		r.printf(r.colors.Green, "Pull request: %s\n", url)
+	}
+	r.record(journalEntry{Event: journalPRCreated, Issue: issue, URL: url})
+	if r.state != nil {
+		if err := r.state.update(issue, func(st *issueState) { st.PullRequest, st.PRState, st.PRClosedAt = url, prStateOpen, "" }); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not update state for #%s: %v\n", issue, err)
+		}
+	}
+	return url
+}
+
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderPRBody(t *testing.T) {
+	t.Parallel()
+
+	details := issueDetails{Title: "Add greeting", Body: "say hi"}
+	tests := []struct {
+		name      string
+		template  string
+		summary   string
+		artifacts string
+		want      string
+	}{
+		{name: "default", want: "Add greeting\n\n## Changes\n\n- feat: greet (#5)\n\nCloses #5\n"},
+		{
+			name:      "artifacts",
+			artifacts: (&runner{repoRoot: "/repo"}).artifactsMarkdown([]string{"/repo/.ticket-runs/run/5.artifacts/junit.xml", "/tmp/logs/5.artifacts/cover.html"}),
+			want:      "Add greeting\n\n## Changes\n\n- feat: greet (#5)\n\n## Verification artifacts\n\n- `.ticket-runs/run/5.artifacts/junit.xml`\n- `/tmp/logs/5.artifacts/cover.html`\n\nCloses #5\n",
+		},
+		{
+			name:    "summary",
+			summary: (&runner{}).prSummary(&diffSummary{Files: []fileChange{{Path: "greet.go", Added: 3}, {Path: "logo.png", Binary: true}}, Added: 3, Text: "Adds a greeting."}),
+			want:    "Add greeting\n\n## Summary\n\nAdds a greeting.\n\n2 file(s) changed, +3 -0:\n- `greet.go` (+3 -0)\n- `logo.png` (binary)\n\n## Changes\n\n- feat: greet (#5)\n\nCloses #5\n",
+		},
+		{name: "custom", template: "#{{ISSUE_NUMBER}}: {{ISSUE_BODY}}\n{{CLOSES}}\n", want: "#5: say hi\nCloses #5\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &runner{}
+			if tt.template != "" {
+				r.opts.PRTemplate = filepath.Join(t.TempDir(), "pr.tmpl")
+				if err := os.WriteFile(r.opts.PRTemplate, []byte(tt.template), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := r.renderPRBody("5", details, tt.summary, "- feat: greet (#5)", tt.artifacts, "Closes #5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("renderPRBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRClosesAndTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		entry      issueEntry
+		tracking   string
+		wantCloses string
+		wantTitle  string
+	}{
+		{name: "github issue", entry: issueEntry{ID: "5"}, wantCloses: "Closes #5", wantTitle: "Fix it (#5)"},
+		{name: "synthetic", entry: issueEntry{ID: "todo-ab12", Source: sourceTodos}, wantTitle: "Fix it"},
+		{name: "synthetic tracked", entry: issueEntry{ID: "todo-ab12", Source: sourceTodos}, tracking: "9", wantCloses: "Closes #9", wantTitle: "Fix it"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := prCloses(tt.entry.ID, tt.entry, tt.tracking); got != tt.wantCloses {
+				t.Fatalf("prCloses() = %q, want %q", got, tt.wantCloses)
+			}
+			if got := prTitle(tt.entry.ID, tt.entry, "Fix it"); got != tt.wantTitle {
+				t.Fatalf("prTitle() = %q, want %q", got, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestPROptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--pr-draft"}, wantErr: "--pr-draft requires --create-pr"},
+		{args: []string{"--pr-template", "pr.tmpl"}, wantErr: "--pr-template requires --create-pr"},
+		{args: []string{"--create-pr", "--pr-draft", "--pr-template", "pr.tmpl"}},
+		{args: []string{"status", "--create-pr"}, wantErr: "--create-pr is not supported by status"},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("options %v returned unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("options %v error = %v, want substring %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCreatePRPushesBranchAndOpensPR(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+	runGit(t, repo, "remote", "add", "origin", remote)
+	base := runGit(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+
+	calls := filepath.Join(t.TempDir(), "gh.calls")
+	gh := writeFakeBin(t, "gh", `if [ "$1" = pr ]; then
+  printf '%s\n' "$@" > `+calls+`
+  echo "https://github.com/o/r/pull/17"
+  exit 0
+fi
+echo '{"title":"Add greeting","body":"say hi","state":"OPEN","labels":[]}'`)
+	agent := fakeAgent(t, `echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		CreatePR:  true,
+		PRDraft:   true,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("processIssue() = %v", result)
+	}
+
+	if now := runGit(t, repo, "rev-parse", "--abbrev-ref", "HEAD"); now != base {
+		t.Fatalf("still on %s, want %s", now, base)
+	}
+	if pushed := runGit(t, remote, "log", "-1", "--pretty=%s", "ghir/issue-5"); pushed != "feat: add greeting (#5)" {
+		t.Fatalf("pushed branch head = %q", pushed)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("gh pr create was not called: %v", err)
+	}
+	args := string(data)
+	for _, want := range []string{"--head\nghir/issue-5\n", "--title\nAdd greeting (#5)\n", "- feat: add greeting (#5)", "Closes #5", "--base\n" + base + "\n", "--draft\n"} {
+		if !strings.Contains(args, want) {
+			t.Fatalf("gh args missing %q:\n%s", want, args)
+		}
+	}
+	if st, _ := r.state.get("5"); st.PullRequest != "https://github.com/o/r/pull/17" {
+		t.Fatalf("state pull_request = %q", st.PullRequest)
+	}
+}
+
+func TestRenderPRBodyAndCommentTranslated(t *testing.T) {
+	t.Parallel()
+
+	catalog, err := loadCatalog("sv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{catalog: catalog, repoRoot: "/repo"}
+	summary := r.prSummary(&diffSummary{Files: []fileChange{{Path: "logo.png", Binary: true}}})
+	body, err := r.renderPRBody("5", issueDetails{Title: "Add greeting"}, summary, "- feat: greet (#5)", r.artifactsMarkdown([]string{"/repo/5.artifacts/junit.xml"}), "Closes #5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Add greeting\n\n## Sammanfattning\n\n1 fil(er) ändrade, +0 -0:\n- `logo.png` (binär)\n\n## Ändringar\n\n- feat: greet (#5)\n\n## Verifieringsartefakter\n\n- `5.artifacts/junit.xml`\n\nCloses #5\n"
+	if body != want {
+		t.Fatalf("renderPRBody() = %q, want %q", body, want)
+	}
+
+	comment, err := r.renderComment("5", issueDetails{}, "abc123", "a.go", "", "", "https://github.com/o/r/pull/9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Implementerat av ghir i abc123.\n\na.go\n\nPull request: https://github.com/o/r/pull/9\n"; comment != want {
+		t.Fatalf("renderComment() = %q, want %q", comment, want)
+	}
+	r.opts.VerifyCmd = "go test ./..."
+	if got, want := r.closingComment("abc123", "ghir/issue-5"), "Implementerat av ghir i abc123 och verifierat med `go test ./...`.\n\nGren: `ghir/issue-5`\n"; got != want {
+		t.Fatalf("closingComment() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var priorityLabelPattern = regexp.MustCompile(`(?i)^(?:priority[:/ ]\s*)?p(\d+)$`)
+
+func parsePriority(value string) (int, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false, nil
+	}
+	digits := strings.TrimPrefix(strings.ToLower(value), "p")
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("%q (use a number or p0, p1, ...)", value)
+	}
+	return n, true, nil
+}
+
+func priorityFromLabels(labels []issueLabel) (int, bool) {
+	best, found := 0, false
+	for _, label := range labels {
+		match := priorityLabelPattern.FindStringSubmatch(strings.TrimSpace(label.Name))
+		if match == nil {
+			continue
+		}
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		if !found || n < best {
+			best, found = n, true
+		}
+	}
+	return best, found
+}
+
+func (r *runner) fetchIssueLabels(issue string) ([]issueLabel, error) {
+	out, err := r.commandOutput(r.opts.GHBin, "issue", "view", issue, "--json", "labels")
+	if err != nil {
+		return nil, err
+	}
+	var payload struct {
+		Labels []issueLabel `json:"labels"`
+	}
+	if err := json.Unmarshal([]byte(out), &payload); err != nil {
+		return nil, fmt.Errorf("parse labels for #%s: %w", issue, err)
+	}
+	return payload.Labels, nil
+}
+
+func (r *runner) orderByPriority(entries []issueEntry) ([]issueEntry, error) {
+	type ranked struct {
+		entry    issueEntry
+		priority int
+		has      bool
+	}
+	items := make([]ranked, 0, len(entries))
+	anyPriority := false
+	for _, entry := range entries {
+		n, has, err := parsePriority(entry.Priority)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority for issue #%s: %w", entry.ID, err)
+		}
+		if !has && r.opts.PriorityLabels && !entry.synthetic() {
+			labels, err := r.fetchIssueLabels(entry.ID)
+			if err != nil {
+				return nil, fmt.Errorf("fetch labels for #%s: %w", entry.ID, err)
+			}
+			n, has = priorityFromLabels(labels)
+		}
+		if has {
+			anyPriority = true
+		}
+		items = append(items, ranked{entry: entry, priority: n, has: has})
+	}
+	if !anyPriority {
+		return entries, nil
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].has != items[j].has {
+			return items[i].has
+		}
+		return items[i].has && items[i].priority < items[j].priority
+	})
+	ordered := make([]issueEntry, 0, len(items))
+	for _, item := range items {
+		ordered = append(ordered, item.entry)
+	}
+	return ordered, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    int
+		wantHas bool
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "0", want: 0, wantHas: true},
+		{value: "p1", want: 1, wantHas: true},
+		{value: " P2 ", want: 2, wantHas: true},
+		{value: "high", wantErr: true},
+		{value: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, has, err := parsePriority(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parsePriority(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want || has != tt.wantHas {
+			t.Fatalf("parsePriority(%q) = %d, %v; want %d, %v", tt.value, got, has, tt.want, tt.wantHas)
+		}
+	}
+}
+
+func TestPriorityFromLabels(t *testing.T) {
+	t.Parallel()
+
+	got, ok := priorityFromLabels([]issueLabel{{Name: "bug"}, {Name: "P2"}, {Name: "priority: p1"}})
+	if !ok || got != 1 {
+		t.Fatalf("priorityFromLabels() = %d, %v; want 1, true", got, ok)
+	}
+	if _, ok := priorityFromLabels([]issueLabel{{Name: "help wanted"}, {Name: "api"}}); ok {
+		t.Fatal("expected no priority for unrelated labels")
+	}
+}
+
+func TestOrderByPriority(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{}
+	entries := []issueEntry{
+		{ID: "1"},
+		{ID: "2", Priority: "p2"},
+		{ID: "3", Priority: "0"},
+		{ID: "4"},
+		{ID: "5", Priority: "p2"},
+	}
+	got, err := r.orderByPriority(entries)
+	if err != nil {
+		t.Fatalf("orderByPriority: %v", err)
+	}
+	if ids := issueIDs(got); !slices.Equal(ids, []string{"3", "2", "5", "1", "4"}) {
+		t.Fatalf("order = %v", ids)
+	}
+
+	unranked := []issueEntry{{ID: "9"}, {ID: "8"}}
+	got, err = r.orderByPriority(unranked)
+	if err != nil {
+		t.Fatalf("orderByPriority: %v", err)
+	}
+	if ids := issueIDs(got); !slices.Equal(ids, []string{"9", "8"}) {
+		t.Fatalf("file order not kept: %v", ids)
+	}
+}
+
+func TestOrderByPriorityLabels(t *testing.T) {
+	t.Parallel()
+
+	gh := writeFakeBin(t, "gh", `case "$3" in
+  10) echo '{"labels":[{"name":"p1"}]}' ;;
+  11) echo '{"labels":[{"name":"P0"}]}' ;;
+  *) echo '{"labels":[]}' ;;
+esac`)
+	r := &runner{opts: options{GHBin: gh, PriorityLabels: true}}
+	got, err := r.orderByPriority([]issueEntry{{ID: "12"}, {ID: "10"}, {ID: "11"}, {ID: "13", Priority: "p3"}})
+	if err != nil {
+		t.Fatalf("orderByPriority: %v", err)
+	}
+	if ids := issueIDs(got); !slices.Equal(ids, []string{"11", "10", "13", "12"}) {
+		t.Fatalf("order = %v", ids)
+	}
+}
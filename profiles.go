@@ -0,0 +1,437 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	commandProfile      = "profile"
+	profilesDirPath     = ".ticket-runner/profiles"
+	profileLockFileName = "lock.yaml"
+	profilePackageFile  = "profiles.yaml"
+
+	profileActionInstall = "install"
+	profileActionUpdate  = "update"
+	profileActionList    = "list"
+	profileActionRemove  = "remove"
+)
+
+var (
+	githubShorthandPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+$`)
+	profileNamePattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+type profileLock struct {
+	Packages map[string]profilePin `yaml:"packages"`
+}
+
+type profilePin struct {
+	Source      string `yaml:"source"`
+	Ref         string `yaml:"ref,omitempty"`
+	Commit      string `yaml:"commit"`
+	InstalledAt string `yaml:"installed_at"`
+}
+
+type profilePackage struct {
+	Profiles map[string]repoConfig `yaml:"profiles"`
+}
+
+func isProfileAction(action string) bool {
+	switch action {
+	case profileActionInstall, profileActionUpdate, profileActionList, profileActionRemove:
+		return true
+	}
+	return false
+}
+
+func validateProfileArgs(opts options) error {
+	switch opts.ProfileAction {
+	case profileActionInstall:
+		if len(opts.Args) != 1 {
+			return fmt.Errorf("profile install requires exactly one <url|owner/repo>")
+		}
+	case profileActionRemove:
+		if len(opts.Args) != 1 {
+			return fmt.Errorf("profile remove requires exactly one package name")
+		}
+	case profileActionUpdate:
+		if len(opts.Args) > 1 {
+			return fmt.Errorf("profile update accepts at most one package name")
+		}
+	case profileActionList:
+		if len(opts.Args) > 0 {
+			return fmt.Errorf("profile list takes no arguments")
+		}
+	}
+	if opts.ProfileName != "" && !profileNamePattern.MatchString(opts.ProfileName) {
+		return fmt.Errorf("invalid package name: %q", opts.ProfileName)
+	}
+	return nil
+}
+
+func (r *runner) profilesDir() string {
+	return filepath.Join(r.repoRoot, profilesDirPath)
+}
+
+func (r *runner) runProfileCommand() error {
+	switch r.opts.ProfileAction {
+	case profileActionInstall:
+		name := r.opts.ProfileName
+		if name == "" {
+			name = profilePackageName(r.opts.Args[0])
+		}
+		pin, err := r.installProfilePackage(name, r.opts.Args[0], r.opts.ProfileRef)
+		if err != nil {
+			return err
+		}
+		r.printf(r.colors.Green, "Installed %s from %s at %s\n", name, pin.Source, shortCommit(pin.Commit))
+		return nil
+	case profileActionUpdate:
+		return r.updateProfilePackages(r.opts.Args)
+	case profileActionList:
+		return r.listProfilePackages()
+	case profileActionRemove:
+		return r.removeProfilePackage(r.opts.Args[0])
+	default:
+		return fmt.Errorf("unknown profile action: %s", r.opts.ProfileAction)
+	}
+}
+
+func normalizeProfileSource(source string) string {
+	source = strings.TrimSpace(source)
+	if githubShorthandPattern.MatchString(source) {
+		if _, err := os.Stat(source); err != nil {
+			return "https://github.com/" + source + ".git"
+		}
+	}
+	if parsed, err := url.Parse(source); err == nil && parsed.Host == "gist.github.com" && !strings.HasSuffix(parsed.Path, ".git") {
+		id := parsed.Path[strings.LastIndex(parsed.Path, "/")+1:]
+		return "https://gist.github.com/" + id + ".git"
+	}
+	return source
+}
+
+func profilePackageName(source string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(source), "/")
+	name := strings.TrimSuffix(trimmed[strings.LastIndexAny(trimmed, "/:")+1:], ".git")
+	if !profileNamePattern.MatchString(name) {
+		return "profiles"
+	}
+	return name
+}
+
+func (r *runner) installProfilePackage(name, source, ref string) (profilePin, error) {
+	// Sources and refs also come from lock.yaml; one that git would read as
+	// an option (--upload-pack=...) could run commands.
+	if strings.HasPrefix(strings.TrimSpace(source), "-") {
+		return profilePin{}, fmt.Errorf("invalid profile source %q: must not start with -", source)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return profilePin{}, fmt.Errorf("invalid profile ref %q: must not start with -", ref)
+	}
+	cloneURL := normalizeProfileSource(source)
+	tmp, err := os.MkdirTemp("", "ghir-profile-")
+	if err != nil {
+		return profilePin{}, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	r.printf(r.colors.Blue, "Fetching %s...\n", cloneURL)
+	if _, err := r.gitOutput("clone", "--quiet", "--", cloneURL, tmp); err != nil {
+		return profilePin{}, fmt.Errorf("fetch %s: %w", cloneURL, err)
+	}
+	if ref != "" {
+		if _, err := r.gitOutput("-C", tmp, "checkout", "--quiet", "--detach", ref); err != nil {
+			return profilePin{}, fmt.Errorf("checkout %s: %w", ref, err)
+		}
+	}
+	commit, err := r.gitOutput("-C", tmp, "rev-parse", "HEAD")
+	if err != nil {
+		return profilePin{}, err
+	}
+
+	if _, err := os.Stat(filepath.Join(tmp, profilePackageFile)); err == nil {
+		if _, err := loadProfilePackage(filepath.Join(tmp, profilePackageFile)); err != nil {
+			return profilePin{}, err
+		}
+	} else {
+		r.printf(r.colors.Yellow, "WARNING: %s has no %s; only its templates will be usable\n", cloneURL, profilePackageFile)
+	}
+
+	dest := filepath.Join(r.profilesDir(), name)
+	if err := os.RemoveAll(dest); err != nil {
+		return profilePin{}, err
+	}
+	if err := copyTree(tmp, dest); err != nil {
+		return profilePin{}, fmt.Errorf("install %s: %w", name, err)
+	}
+
+	pin := profilePin{
+		Source:      source,
+		Ref:         ref,
+		Commit:      commit,
+		InstalledAt: r.timestamp(r.now()),
+	}
+	lock, err := loadProfileLock(r.profilesDir())
+	if err != nil {
+		return profilePin{}, err
+	}
+	lock.Packages[name] = pin
+	if err := saveProfileLock(r.profilesDir(), lock); err != nil {
+		return profilePin{}, err
+	}
+	return pin, nil
+}
+
+func (r *runner) updateProfilePackages(names []string) error {
+	lock, err := loadProfileLock(r.profilesDir())
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		for name := range lock.Packages {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		r.printf(r.colors.Yellow, "No profile packages installed\n")
+		return nil
+	}
+	for _, name := range names {
+		pin, ok := lock.Packages[name]
+		if !ok {
+			return fmt.Errorf("profile package %q is not installed", name)
+		}
+		ref := pin.Ref
+		if r.opts.ProfileRef != "" {
+			ref = r.opts.ProfileRef
+		}
+		updated, err := r.installProfilePackage(name, pin.Source, ref)
+		if err != nil {
+			return err
+		}
+		if updated.Commit == pin.Commit {
+			r.printf(r.colors.Green, "%s is up to date (%s)\n", name, shortCommit(pin.Commit))
+		} else {
+			r.printf(r.colors.Green, "Updated %s: %s -> %s\n", name, shortCommit(pin.Commit), shortCommit(updated.Commit))
+		}
+	}
+	return nil
+}
+
+func (r *runner) listProfilePackages() error {
+	lock, err := loadProfileLock(r.profilesDir())
+	if err != nil {
+		return err
+	}
+	if len(lock.Packages) == 0 {
+		r.printf(r.colors.Yellow, "No profile packages installed\n")
+		return nil
+	}
+	names := make([]string, 0, len(lock.Packages))
+	for name := range lock.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pin := lock.Packages[name]
+		ref := pin.Ref
+		if ref == "" {
+			ref = "default branch"
+		}
+		fmt.Printf("%s  %s (%s @ %s)\n", name, pin.Source, ref, shortCommit(pin.Commit))
+		pkg, err := loadProfilePackage(filepath.Join(r.profilesDir(), name, profilePackageFile))
+		if err != nil {
+			continue
+		}
+		for _, profile := range sortedProfileNames(pkg.Profiles) {
+			fmt.Printf("  %s/%s\n", name, profile)
+		}
+	}
+	return nil
+}
+
+func (r *runner) removeProfilePackage(name string) error {
+	lock, err := loadProfileLock(r.profilesDir())
+	if err != nil {
+		return err
+	}
+	if _, ok := lock.Packages[name]; !ok {
+		return fmt.Errorf("profile package %q is not installed", name)
+	}
+	if err := os.RemoveAll(filepath.Join(r.profilesDir(), name)); err != nil {
+		return err
+	}
+	delete(lock.Packages, name)
+	if err := saveProfileLock(r.profilesDir(), lock); err != nil {
+		return err
+	}
+	r.printf(r.colors.Green, "Removed %s\n", name)
+	return nil
+}
+
+func loadProfileLock(dir string) (profileLock, error) {
+	lock := profileLock{Packages: make(map[string]profilePin)}
+	data, err := os.ReadFile(filepath.Join(dir, profileLockFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lock, nil
+		}
+		return lock, fmt.Errorf("read profile lock: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("parse profile lock: %w", err)
+	}
+	if lock.Packages == nil {
+		lock.Packages = make(map[string]profilePin)
+	}
+	return lock, nil
+}
+
+func saveProfileLock(dir string, lock profileLock) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, profileLockFileName), data, 0o644)
+}
+
+func loadProfilePackage(path string) (profilePackage, error) {
+	var pkg profilePackage
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pkg, err
+	}
+	var cfg repoConfig
+	if err := decodeRepoConfig(data, &cfg); err != nil {
+		return pkg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return pkg, fmt.Errorf("%s: %w", path, err)
+	}
+	pkg.Profiles = cfg.Profiles
+	return pkg, nil
+}
+
+func findInstalledProfile(repoRoot, name string) (repoConfig, bool, error) {
+	dir := filepath.Join(repoRoot, profilesDirPath)
+	pkgName, profileName, qualified := strings.Cut(name, "/")
+
+	var packages []string
+	if qualified {
+		packages = []string{pkgName}
+	} else {
+		profileName = name
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return repoConfig{}, false, nil
+			}
+			return repoConfig{}, false, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				packages = append(packages, entry.Name())
+			}
+		}
+	}
+
+	var matches []string
+	var found repoConfig
+	for _, pkgName := range packages {
+		pkgDir := filepath.Join(dir, pkgName)
+		pkg, err := loadProfilePackage(filepath.Join(pkgDir, profilePackageFile))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return repoConfig{}, false, err
+		}
+		profile, ok := pkg.Profiles[profileName]
+		if !ok {
+			continue
+		}
+		if profile.PromptTemplate != "" && !filepath.IsAbs(profile.PromptTemplate) {
+			profile.PromptTemplate = filepath.Join(pkgDir, profile.PromptTemplate)
+		}
+		matches = append(matches, pkgName+"/"+profileName)
+		found = profile
+	}
+	if len(matches) > 1 {
+		return repoConfig{}, false, fmt.Errorf("profile %q is ambiguous (%s); use <package>/<profile>", name, strings.Join(matches, ", "))
+	}
+	return found, len(matches) == 1, nil
+}
+
+func sortedProfileNames(profiles map[string]repoConfig) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
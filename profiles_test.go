@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeProfileSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "acme/ghir-profiles", want: "https://github.com/acme/ghir-profiles.git"},
+		{source: "https://gist.github.com/octocat/0123abcd", want: "https://gist.github.com/0123abcd.git"},
+		{source: "git@github.com:acme/profiles.git", want: "git@github.com:acme/profiles.git"},
+		{source: "https://example.com/team/profiles.git", want: "https://example.com/team/profiles.git"},
+	}
+	for _, tt := range tests {
+		if got := normalizeProfileSource(tt.source); got != tt.want {
+			t.Fatalf("normalizeProfileSource(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+
+	if got := profilePackageName("git@github.com:acme/team-profiles.git"); got != "team-profiles" {
+		t.Fatalf("profilePackageName() = %q", got)
+	}
+}
+
+func TestProfileInstallUpdateAndResolve(t *testing.T) {
+	t.Parallel()
+
+	source := initTestRepo(t)
+	commitFile(t, source, "fast.tmpl", "Fix #{{ISSUE_NUMBER}} quickly\n")
+	commitFile(t, source, profilePackageFile, "profiles:\n  fast:\n    agent: codex\n    prompt_template: fast.tmpl\n")
+	pinned := runGit(t, source, "rev-parse", "HEAD")
+
+	repo := initTestRepo(t)
+	r := &runner{repoRoot: repo, opts: options{NoColor: true}, loc: time.FixedZone("CEST", 2*60*60)}
+	pin, err := r.installProfilePackage("team", source, "")
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if pin.Commit != pinned {
+		t.Fatalf("commit = %q, want %q", pin.Commit, pinned)
+	}
+	if !strings.HasSuffix(pin.InstalledAt, "+02:00") {
+		t.Fatalf("installed_at = %q, want the configured time zone", pin.InstalledAt)
+	}
+	if _, err := os.Stat(filepath.Join(repo, profilesDirPath, "team", ".git")); !os.IsNotExist(err) {
+		t.Fatalf(".git should not be copied: %v", err)
+	}
+
+	opts := options{Profile: "team/fast"}
+	if err := applyRepoDefaults(&opts, repo); err != nil {
+		t.Fatalf("applyRepoDefaults: %v", err)
+	}
+	wantTemplate := filepath.Join(repo, profilesDirPath, "team", "fast.tmpl")
+	if opts.Agent != "codex" || opts.PromptTemplate != wantTemplate {
+		t.Fatalf("profile not applied: agent=%q template=%q", opts.Agent, opts.PromptTemplate)
+	}
+
+	commitFile(t, source, profilePackageFile, "profiles:\n  fast:\n    agent: gemini\n")
+	if err := r.updateProfilePackages(nil); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	lock, err := loadProfileLock(filepath.Join(repo, profilesDirPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock.Packages["team"].Commit == pinned {
+		t.Fatal("update did not move to the latest commit")
+	}
+
+	r.opts.ProfileRef = pinned
+	if err := r.updateProfilePackages([]string{"team"}); err != nil {
+		t.Fatalf("pinned update: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(repo, profilesDirPath, "team", profilePackageFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "codex") {
+		t.Fatalf("pinned update did not restore %s: %s", pinned, data)
+	}
+
+	if err := r.removeProfilePackage("team"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, profilesDirPath, "team")); !os.IsNotExist(err) {
+		t.Fatalf("package dir still present: %v", err)
+	}
+}
+
+func TestProfileInstallRejectsOptionSources(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	marker := filepath.Join(t.TempDir(), "pwned")
+	r := &runner{repoRoot: repo, opts: options{NoColor: true}}
+	tests := []struct {
+		source string
+		ref    string
+		want   string
+	}{
+		{source: "--upload-pack=touch " + marker, want: "invalid profile source"},
+		{source: " -uhttps://example.com/x.git", want: "invalid profile source"},
+		{source: repo, ref: "--output=" + marker, want: "invalid profile ref"},
+	}
+	for _, tt := range tests {
+		if _, err := r.installProfilePackage("team", tt.source, tt.ref); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("install(%q, %q) err = %v, want %q", tt.source, tt.ref, err, tt.want)
+		}
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("git ran the injected option: %v", err)
+	}
+}
+
+func TestParseArgsProfileCommand(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"profile", "install", "acme/profiles", "--ref", "v1", "--name", "acme"})
+	if err != nil {
+		t.Fatalf("parseArgs: %v", err)
+	}
+	if opts.Command != commandProfile || opts.ProfileAction != profileActionInstall || opts.ProfileRef != "v1" || opts.ProfileName != "acme" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if len(opts.Args) != 1 || opts.Args[0] != "acme/profiles" {
+		t.Fatalf("args = %v", opts.Args)
+	}
+
+	for _, args := range [][]string{
+		{"profile"},
+		{"profile", "install"},
+		{"profile", "remove"},
+		{"profile", "list", "extra"},
+	} {
+		if _, err := parseArgs(args); err == nil {
+			t.Fatalf("parseArgs(%v) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	protectRevert = "revert"
+	protectFail   = "fail"
+	protectOff    = "off"
+)
+
+// runnerFile is a protected file as it was before the agent ran.
+type runnerFile struct {
+	data   []byte
+	mode   fs.FileMode
+	exists bool
+}
+
+// runnerFiles is a snapshot of the files an agent must not change: the
+// runner's config directory, the files it reads between issues and its own
+// bookkeeping.
+type runnerFiles struct {
+	dirs  []string
+	files map[string]runnerFile
+}
+
+// protectedRunnerPaths lists what --protect-runner-files guards. The state
+// and done files are shared by parallel workers, which write them while an
+// agent runs, so they are only guarded in a sequential run.
+func (r *runner) protectedRunnerPaths() (dirs, files []string) {
+	dirs = []string{filepath.Join(r.repoRoot, filepath.Dir(defaultConfigPath))}
+	files = []string{r.opts.SkipFile, r.opts.PromptTemplate, r.opts.PRTemplate}
+	if r.opts.IssuesFile != stdinIssuesFile {
+		files = append(files, r.opts.IssuesFile)
+	}
+	if r.opts.ConfigFile != "" {
+		files = append(files, resolvePath(r.repoRoot, r.opts.ConfigFile))
+	}
+	if r.mu == nil {
+		files = append(files, r.doneFile)
+		if r.state != nil {
+			files = append(files, r.state.path)
+		}
+	}
+	return dirs, files
+}
+
+// snapshotRunnerFiles records the protected files before an agent runs. It
+// returns nil when protection is off.
+func (r *runner) snapshotRunnerFiles() *runnerFiles {
+	if r.opts.ProtectFiles == protectOff {
+		return nil
+	}
+	dirs, files := r.protectedRunnerPaths()
+	snap := &runnerFiles{dirs: dirs, files: make(map[string]runnerFile)}
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Type().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	for _, path := range files {
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+		if _, ok := snap.files[path]; ok {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			snap.files[path] = runnerFile{}
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		snap.files[path] = runnerFile{data: data, mode: info.Mode().Perm(), exists: true}
+	}
+	return snap
+}
+
+// changed lists the protected files that differ from the snapshot, including
+// files added to a protected directory.
+func (s *runnerFiles) changed() []string {
+	var paths []string
+	for path, before := range s.files {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if before.exists {
+				paths = append(paths, path)
+			}
+		case err != nil:
+			continue
+		case !before.exists || !bytes.Equal(data, before.data):
+			paths = append(paths, path)
+		}
+	}
+	for _, dir := range s.dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if _, ok := s.files[path]; !ok {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *runnerFiles) restore(path string) error {
+	before, ok := s.files[path]
+	if !ok || !before.exists {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, before.data, before.mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, before.mode)
+}
+
+// guardRunnerFiles puts back what the agent changed among the protected
+// files. Changes the agent committed are undone in a commit of their own, so
+// the issue's branch does not carry them. It reports false when the files
+// were touched and --protect-runner-files fail turns that into a failure.
+func (r *runner) guardRunnerFiles(issue, base string, snap *runnerFiles) bool {
+	if snap == nil {
+		return true
+	}
+	changed := snap.changed()
+	if len(changed) == 0 {
+		return true
+	}
+	var shown, inRepo []string
+	for _, path := range changed {
+		if err := snap.restore(path); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not restore %s: %v\n", path, err)
+		}
+		if !pathWithin(path, r.repoRoot) {
+			shown = append(shown, path)
+			continue
+		}
+		rel, _ := filepath.Rel(r.repoRoot, path)
+		shown = append(shown, filepath.ToSlash(rel))
+		inRepo = append(inRepo, filepath.ToSlash(rel))
+	}
+	color, label := r.colors.Yellow, "WARNING"
+	if r.opts.ProtectFiles == protectFail {
+		color, label = r.colors.Red, "FAILED"
+	}
+	r.printf(color, "%s: %s changed the runner's own files while working on #%s; restored %s\n", label, agentDisplayName(r.opts.Agent), issue, strings.Join(shown, ", "))
+	r.commitRunnerRestore(issue, base, inRepo)
+	return r.opts.ProtectFiles != protectFail
+}
+
+// commitRunnerRestore commits the restored files the agent's commits since
+// base had changed.
+func (r *runner) commitRunnerRestore(issue, base string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	committed, err := r.gitOutput(append([]string{"diff", "--name-only", base + "..HEAD", "--"}, paths...)...)
+	if err != nil || strings.TrimSpace(committed) == "" {
+		return
+	}
+	files := nonEmptyLines(committed)
+	if _, err := r.gitOutput(append([]string{"add", "-A", "--"}, files...)...); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not stage the restored runner files of #%s: %v\n", issue, err)
+		return
+	}
+	args := append([]string{"commit", "--no-verify", "-m", fmt.Sprintf(r.tr("chore: restore runner files changed for #%s"), issue), "--"}, files...)
+	if _, err := r.gitOutput(args...); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not commit the restored runner files of #%s: %v\n", issue, err)
+	}
+}
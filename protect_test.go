@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProtectRunnerFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		mode         string
+		wantFailure  failureCategory
+		wantSubjects string
+		wantNotes    string
+	}{
+		{mode: protectRevert, wantSubjects: "chore: restore runner files changed for #5\nfeat: add f (#5)\ninit", wantNotes: "keep\n"},
+		{mode: protectFail, wantFailure: failureGate, wantSubjects: "chore: restore runner files changed for #5\nfeat: add f (#5)\ninit", wantNotes: "keep\n"},
+		{mode: protectOff, wantSubjects: "feat: add f (#5)\ninit", wantNotes: "changed\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			if err := os.MkdirAll(filepath.Join(repo, ".ticket-runner"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repo, ".ticket-runner", "notes.md"), []byte("keep\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			runGit(t, repo, "add", "-A")
+			runGit(t, repo, "commit", "-q", "--amend", "-m", "init")
+
+			logDir := filepath.Join(t.TempDir(), "logs")
+			gh := fakeIssueGH(t, "Add f", "add f")
+			agent := fakeAgent(t,
+				"echo changed > .ticket-runner/notes.md\n"+
+					"echo new > .ticket-runner/extra.txt\n"+
+					"echo f > f.txt\n"+
+					"git add -A\ngit commit -q -m \"feat: add f (#5)\"\n"+
+					"echo garbage > "+filepath.Join(logDir, defaultStateFileName)+"\n")
+			opts := options{
+				Agent:        "claude",
+				ClaudeBin:    agent,
+				GHBin:        gh,
+				SingleIssue:  "5",
+				LogDir:       logDir,
+				ProtectFiles: tt.mode,
+			}
+			r := newTestRunner(t, repo, opts)
+			r.runQueue()
+			if got := r.failures["5"]; got != tt.wantFailure {
+				t.Fatalf("failure = %q, want %q", got, tt.wantFailure)
+			}
+
+			if got := runGit(t, repo, "log", "--format=%s"); got != tt.wantSubjects {
+				t.Fatalf("subjects = %q, want %q", got, tt.wantSubjects)
+			}
+			if data, _ := os.ReadFile(filepath.Join(repo, ".ticket-runner", "notes.md")); string(data) != tt.wantNotes {
+				t.Fatalf("notes.md = %q, want %q", data, tt.wantNotes)
+			}
+			_, err := os.Stat(filepath.Join(repo, ".ticket-runner", "extra.txt"))
+			if removed := os.IsNotExist(err); removed != (tt.mode != protectOff) {
+				t.Fatalf("extra.txt removed = %v in mode %s", removed, tt.mode)
+			}
+			if tt.mode != protectOff {
+				if status := runGit(t, repo, "status", "--porcelain"); strings.TrimSpace(status) != "" {
+					t.Fatalf("working tree not clean: %q", status)
+				}
+				if _, err := loadStateStore(filepath.Join(logDir, defaultStateFileName)); err != nil {
+					t.Fatalf("state file not restored: %v", err)
+				}
+			}
+		})
+	}
+}
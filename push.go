@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+const defaultPushRemote = "origin"
+
+func (r *runner) pushRemote() string {
+	if r.opts.PushRemote != "" {
+		return r.opts.PushRemote
+	}
+	return defaultPushRemote
+}
+
+// pushIssue pushes the branch the issue was committed on: its issue branch,
+// or the branch checked out. A rejected push is returned so the caller fails
+// loudly instead of piling more unpushed work on top.
+func (r *runner) pushIssue(issue, branch string) error {
+	if branch == "" {
+		current, err := r.gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return fmt.Errorf("determine the current branch: %w", err)
+		}
+		if current == "HEAD" {
+			return errors.New("cannot push a detached HEAD")
+		}
+		branch = current
+	}
+	remote := r.pushRemote()
+	r.printf(r.colors.Blue, "Pushing %s to %s\n", branch, remote)
+	if _, err := r.gitOutput("push", remote, branch); err != nil {
+		return fmt.Errorf("push of %s to %s was rejected: %w", branch, remote, err)
+	}
+	r.record(journalEntry{Event: journalPushed, Issue: issue, Branch: branch})
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPushAfterSuccess(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		reject   bool
+		want     issueResult
+		wantStat string
+	}{
+		{name: "pushed", want: resultSuccess, wantStat: statusDone},
+		{name: "rejected", reject: true, want: resultFailed, wantStat: statusNeedsReview},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			remote := t.TempDir()
+			runGit(t, remote, "init", "-q", "--bare")
+			if tt.reject {
+				hook := filepath.Join(remote, "hooks", "pre-receive")
+				if err := os.WriteFile(hook, []byte("#!/bin/sh\necho protected branch >&2\nexit 1\n"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			runGit(t, repo, "remote", "add", "upstream", remote)
+			branch := runGit(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+
+			gh := fakeIssueGH(t, "Add greeting", "say hi")
+			agent := fakeAgent(t, `echo hi > greeting.txt
+git add greeting.txt
+git commit -q -m "feat: add greeting (#5)"`)
+			opts := options{
+				Agent:      "claude",
+				ClaudeBin:  agent,
+				GHBin:      gh,
+				Push:       true,
+				PushRemote: "upstream",
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+			if st, _ := r.state.get("5"); st.Status != tt.wantStat {
+				t.Fatalf("status = %q, want %q", st.Status, tt.wantStat)
+			}
+			// A rejected push leaves the issue out of the done file, so
+			// the next run tries it again.
+			done, err := os.ReadFile(r.opts.DoneFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := strings.Fields(string(done))
+			if completed := len(fields) > 0 && fields[0] == "5"; completed == tt.reject || r.isCompleted("5") == tt.reject {
+				t.Fatalf("done file = %q after %s push", done, tt.name)
+			}
+			if tt.reject {
+				return
+			}
+			if pushed := runGit(t, remote, "log", "-1", "--pretty=%s", branch); pushed != "feat: add greeting (#5)" {
+				t.Fatalf("pushed head = %q", pushed)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQwenCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{name: "default model", want: "--output-format json --yolo -p fix #5"},
+		{name: "model", model: "qwen3-coder-plus", want: "--output-format json --yolo -m qwen3-coder-plus -p fix #5"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := &runner{opts: options{Agent: "qwen", QwenBin: "/opt/qwen", GeminiBin: "gemini", Model: tt.model}}
+			cmd, err := r.buildAgentCommand("fix #5")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(cmd.Args[1:], " "); cmd.Path != "/opt/qwen" || got != tt.want {
+				t.Fatalf("command = %s %s, want %s", cmd.Path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitDurationQwen(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	wait, reset := waitDuration("[API Error: quota exceeded, resets after 1h15m]", now, 60, "qwen")
+	if wait != 4560 || !reset.Equal(now.Add(4560*time.Second)) {
+		t.Fatalf("waitDuration = %d, %s", wait, reset)
+	}
+}
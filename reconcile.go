@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const commandReconcile = "reconcile"
+
+// issueView is the part of gh issue view --json we need.
+type issueView struct {
+	State       string `json:"state"`
+	StateReason string `json:"stateReason"`
+	ClosedAt    string `json:"closedAt"`
+}
+
+// reconcileChange is one difference between the local state and GitHub.
+type reconcileChange struct {
+	Issue  string
+	From   string
+	To     string
+	Reason string
+	// PRFrom and PRTo are set when the PR state moved.
+	PRFrom     string
+	PRTo       string
+	prClosedAt string
+	closedAt   string
+}
+
+func (c reconcileChange) String() string {
+	var parts []string
+	if c.From != c.To {
+		parts = append(parts, fmt.Sprintf("%s -> %s (%s)", c.From, c.To, c.Reason))
+	}
+	if c.PRFrom != c.PRTo {
+		from := c.PRFrom
+		if from == "" {
+			from = "unknown"
+		}
+		parts = append(parts, fmt.Sprintf("PR %s -> %s", from, c.PRTo))
+	}
+	return fmt.Sprintf("#%s %s", c.Issue, strings.Join(parts, ", "))
+}
+
+// reconcile walks every issue the done file or state.json knows about,
+// compares it with the issue and PR on GitHub and brings the local state in
+// line: reopened issues and issues whose PR was closed unmerged go back to
+// pending, issues closed on GitHub are marked done (completed) or skipped
+// (not planned), and PR states are refreshed. With --dry-run it only reports.
+func (r *runner) reconcile() error {
+	ids := r.reconcileCandidates()
+	if len(ids) == 0 {
+		r.printf(r.colors.Yellow, "No issues recorded in %s or the done file yet\n", r.opts.LogDir)
+		return nil
+	}
+	r.printf(r.colors.Blue, "Reconciling %d issue(s) with GitHub...\n", len(ids))
+
+	var changes []reconcileChange
+	for _, id := range ids {
+		change, ok, err := r.reconcileIssue(id)
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not check #%s: %v\n", id, err)
+			continue
+		}
+		if ok {
+			changes = append(changes, change)
+			r.printf("", "  %s\n", change)
+		}
+	}
+	if len(changes) == 0 {
+		r.printf(r.colors.Green, "Local state matches GitHub\n")
+		return nil
+	}
+	if r.opts.DryRun {
+		r.printf(r.colors.Yellow, "%d difference(s) found; dry run, nothing written\n", len(changes))
+		return nil
+	}
+	return r.applyReconcile(changes)
+}
+
+// reconcileCandidates are the GitHub issues with local state; synthetic tasks
+// (scan findings, TODOs) have nothing to compare against.
+func (r *runner) reconcileCandidates() []string {
+	seen := make(map[string]bool)
+	for id := range r.doneSet {
+		seen[id] = true
+	}
+	if r.state != nil {
+		for id := range r.state.snapshot() {
+			seen[id] = true
+		}
+	}
+	var ids []string
+	for id := range seen {
+		if isIssueID(id) {
+			ids = append(ids, id)
+		}
+	}
+	sortStringsNumeric(ids)
+	return ids
+}
+
+func (r *runner) reconcileIssue(id string) (reconcileChange, bool, error) {
+	var st issueState
+	if r.state != nil {
+		st, _ = r.state.get(id)
+	}
+	local := st.Status
+	if local == "" {
+		local = statusPending
+	}
+	if r.isCompleted(id) {
+		local = statusDone
+	}
+	if local == statusInProgress {
+		// A run is working on it; leave it alone.
+		return reconcileChange{}, false, nil
+	}
+
+	out, err := r.commandOutput(r.opts.GHBin, "issue", "view", id, "--json", "state,stateReason,closedAt")
+	if err != nil {
+		return reconcileChange{}, false, err
+	}
+	var issue issueView
+	if err := json.Unmarshal([]byte(out), &issue); err != nil {
+		return reconcileChange{}, false, fmt.Errorf("parse gh issue view: %w", err)
+	}
+	change := reconcileChange{Issue: id, From: local, To: local, PRFrom: st.PRState, PRTo: st.PRState, prClosedAt: st.PRClosedAt, closedAt: issue.ClosedAt}
+	if st.PullRequest != "" && st.PRState != prStateMerged {
+		view, err := r.viewPullRequest(st.PullRequest)
+		if err != nil {
+			return reconcileChange{}, false, err
+		}
+		change.PRTo, change.prClosedAt = view.state(), view.closedAt()
+	}
+
+	open := strings.EqualFold(issue.State, "open")
+	reason := strings.ToLower(issue.StateReason)
+	switch {
+	case local == statusDone && open && reason == "reopened":
+		change.To, change.Reason = statusPending, "reopened on GitHub"
+	case local == statusDone && open && change.PRTo == prStateClosed && change.PRFrom != prStateClosed:
+		change.To, change.Reason = statusPending, "PR closed without merging"
+	case local != statusDone && !open && reason == "not_planned":
+		if local != statusSkipped {
+			change.To, change.Reason = statusSkipped, "closed as not planned on GitHub"
+		}
+	case local != statusDone && !open:
+		change.To, change.Reason = statusDone, "closed on GitHub"
+	case local != statusDone && change.PRTo == prStateMerged:
+		change.To, change.Reason = statusDone, "PR merged"
+	}
+	return change, change.From != change.To || change.PRFrom != change.PRTo, nil
+}
+
+func (r *runner) applyReconcile(changes []reconcileChange) error {
+	doneChanged := false
+	for _, c := range changes {
+		switch {
+		case c.To == statusPending && c.From == statusDone:
+			delete(r.doneSet, c.Issue)
+			doneChanged = true
+		case c.To == statusDone && c.From != statusDone:
+			completedAt := c.closedAt
+			if completedAt == "" {
+				completedAt = c.prClosedAt
+			}
+			r.doneSet[c.Issue] = doneRecord{ID: c.Issue, CompletedAt: completedAt}
+			doneChanged = true
+		}
+		if r.state == nil {
+			continue
+		}
+		if err := r.state.update(c.Issue, func(st *issueState) {
+			if c.From != c.To {
+				st.Status = c.To
+				st.Failure = ""
+			}
+			st.PRState, st.PRClosedAt = c.PRTo, c.prClosedAt
+		}); err != nil {
+			return err
+		}
+	}
+	if doneChanged {
+		return r.rewriteDoneFile(fmt.Sprintf("Reconciled %d issue(s) with GitHub\n", len(changes)))
+	}
+	r.printf(r.colors.Green, "Reconciled %d issue(s) with GitHub\n", len(changes))
+	return nil
+}
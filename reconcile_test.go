@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		dryRun   bool
+		wantDone string
+		want     map[string]string
+	}{
+		{
+			name:     "applies the differences",
+			wantDone: "4,5,6",
+			want:     map[string]string{"1": statusPending, "2": statusPending, "3": statusSkipped, "4": statusDone, "5": statusDone, "7": statusInProgress},
+		},
+		{
+			name:     "dry run writes nothing",
+			dryRun:   true,
+			wantDone: "1,2,6",
+			want:     map[string]string{"1": statusDone, "2": statusDone, "3": statusFailed, "4": statusFailed, "5": statusNeedsReview, "7": statusInProgress},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := writeFakeBin(t, "gh", `case "$1 $3" in
+  "issue 1") echo '{"state":"OPEN","stateReason":"REOPENED"}' ;;
+  "issue 3") echo '{"state":"CLOSED","stateReason":"NOT_PLANNED","closedAt":"2026-03-02T00:00:00Z"}' ;;
+  "issue 4") echo '{"state":"CLOSED","stateReason":"COMPLETED","closedAt":"2026-03-03T00:00:00Z"}' ;;
+  "issue 7") echo "in progress issues are not looked up" >&2; exit 1 ;;
+  "issue "*) echo '{"state":"OPEN","stateReason":""}' ;;
+  "pr "*/2) echo '{"state":"CLOSED","closedAt":"2026-03-01T00:00:00Z"}' ;;
+  "pr "*/5) echo '{"state":"MERGED","mergedAt":"2026-03-04T00:00:00Z"}' ;;
+esac`)
+			opts := options{
+				GHBin:  gh,
+				LogDir: filepath.Join(repo, defaultLogDirName),
+				DryRun: tt.dryRun,
+			}
+			opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+			if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(opts.DoneFile, []byte("1\n2\n6\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			state := map[string]map[string]*issueState{"issues": {
+				"1":          {Issue: "1", Status: statusDone},
+				"2":          {Issue: "2", Status: statusDone, PullRequest: "https://github.com/o/r/pull/2", PRState: prStateOpen},
+				"3":          {Issue: "3", Status: statusFailed},
+				"4":          {Issue: "4", Status: statusFailed},
+				"5":          {Issue: "5", Status: statusNeedsReview, PullRequest: "https://github.com/o/r/pull/5", PRState: prStateOpen},
+				"7":          {Issue: "7", Status: statusInProgress},
+				"sarif-0a1b": {Issue: "sarif-0a1b", Status: statusFailed},
+			}}
+			data, err := json.Marshal(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(opts.LogDir, defaultStateFileName), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			r := newTestRunner(t, repo, opts)
+			if err := r.reconcile(); err != nil {
+				t.Fatal(err)
+			}
+
+			done, err := os.ReadFile(opts.DoneFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, line := range strings.Split(strings.TrimSpace(string(done)), "\n") {
+				id, _, _ := strings.Cut(line, "\t")
+				ids = append(ids, id)
+			}
+			if got := strings.Join(ids, ","); got != tt.wantDone {
+				t.Fatalf("done file ids = %s, want %s", got, tt.wantDone)
+			}
+			for id, want := range tt.want {
+				st, _ := r.state.get(id)
+				if st.Status != want {
+					t.Errorf("#%s status = %q, want %q", id, st.Status, want)
+				}
+			}
+			if st, _ := r.state.get("5"); !tt.dryRun && (st.PRState != prStateMerged || st.PRClosedAt != "2026-03-04T00:00:00Z") {
+				t.Errorf("#5 PR = %s at %s, want merged", st.PRState, st.PRClosedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// redactFlushBytes bounds how much of an unterminated line is held back
+	// waiting for its newline.
+	redactFlushBytes     = 64 << 10
+	minSecretEnvValueLen = 8
+)
+
+var secretEnvNamePattern = regexp.MustCompile(`(?i)(token|secret|password|passwd|api_?key|private_?key|credential)`)
+
+// redactor hides secrets in agent output: the built-in token patterns, the
+// values of secret-looking environment variables, and --redact / `redact:`
+// patterns from the config.
+type redactor struct {
+	patterns []*regexp.Regexp
+	literals []string
+}
+
+func compileRedactPatterns(values []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(values))
+	for _, value := range values {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern %q: %w", value, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+func newRedactor(extra []string, environ []string) (*redactor, error) {
+	patterns, err := compileRedactPatterns(extra)
+	if err != nil {
+		return nil, err
+	}
+	x := &redactor{patterns: patterns}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && len(value) >= minSecretEnvValueLen && secretEnvNamePattern.MatchString(name) {
+			x.literals = append(x.literals, value)
+		}
+	}
+	// Longest first, so a value containing another is replaced whole.
+	sort.Slice(x.literals, func(i, j int) bool { return len(x.literals[i]) > len(x.literals[j]) })
+	return x, nil
+}
+
+func (x *redactor) redact(value string) string {
+	if x == nil {
+		return redactSecrets(value)
+	}
+	for _, literal := range x.literals {
+		value = strings.ReplaceAll(value, literal, redactedPlaceholder)
+	}
+	for _, pattern := range x.patterns {
+		value = pattern.ReplaceAllString(value, redactedPlaceholder)
+	}
+	return redactSecrets(value)
+}
+
+// redactingWriter redacts whole lines before passing them on, so a secret
+// split across two writes is still caught.
+type redactingWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	x   *redactor
+	buf []byte
+}
+
+func newRedactingWriter(out io.Writer, x *redactor) *redactingWriter {
+	return &redactingWriter{out: out, x: x}
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	cut := strings.LastIndexByte(string(w.buf), '\n') + 1
+	if cut == 0 && len(w.buf) >= redactFlushBytes {
+		cut = len(w.buf)
+	}
+	if cut == 0 {
+		return len(p), nil
+	}
+	chunk := string(w.buf[:cut])
+	w.buf = append(w.buf[:0], w.buf[cut:]...)
+	if _, err := io.WriteString(w.out, w.x.redact(chunk)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes a trailing line that never got its newline.
+func (w *redactingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	chunk := string(w.buf)
+	w.buf = w.buf[:0]
+	_, err := io.WriteString(w.out, w.x.redact(chunk))
+	return err
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	commandRefine      = "refine"
+	refinementsDirName = "refinements"
+	refineExcerptLines = 40
+)
+
+// refinement is <log-dir>/refinements/<issue>-<stamp>/refinement.json. Next
+// to it are original.md, refined.md and prompt.diff, so prompt template
+// changes can be mined from edits that turned a failure into a success.
+type refinement struct {
+	Issue          string `json:"issue"`
+	CreatedAt      string `json:"created_at"`
+	PreviousStatus string `json:"previous_status"`
+	Failure        string `json:"failure,omitempty"`
+	Agent          string `json:"agent,omitempty"`
+	Model          string `json:"model,omitempty"`
+	OriginalPrompt string `json:"original_prompt"`
+	Result         string `json:"result"`
+}
+
+// refineIssue shows why the last attempt failed, lets the prompt be edited in
+// $EDITOR and re-runs the issue with the edited prompt.
+func (r *runner) refineIssue(issue string) error {
+	st, ok := r.state.get(issue)
+	if !ok || st.PromptPath == "" {
+		return fmt.Errorf("no saved prompt for #%s; refine needs a previous attempt", issue)
+	}
+	if st.Status != statusFailed && st.Status != statusNeedsReview && !r.opts.Force {
+		return fmt.Errorf("#%s is %s, not failed (use --force to refine it anyway)", issue, st.Status)
+	}
+	original, err := os.ReadFile(st.PromptPath)
+	if err != nil {
+		return fmt.Errorf("read previous prompt: %w", err)
+	}
+	entry, err := r.refineEntry(issue)
+	if err != nil {
+		return err
+	}
+	r.printRefineContext(st, string(original))
+
+	stamp := r.fileStamp(r.now())
+	dir := filepath.Join(r.opts.LogDir, refinementsDirName, issue+"-"+stamp)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create refinement dir: %w", err)
+	}
+	originalPath := filepath.Join(dir, "original.md")
+	refinedPath := filepath.Join(dir, "refined.md")
+	for _, path := range []string{originalPath, refinedPath} {
+		if err := os.WriteFile(path, original, 0o644); err != nil {
+			return fmt.Errorf("write prompt copy: %w", err)
+		}
+	}
+	if err := editFile(refinedPath); err != nil {
+		return err
+	}
+	refined, err := os.ReadFile(refinedPath)
+	if err != nil {
+		return fmt.Errorf("read edited prompt: %w", err)
+	}
+	if strings.TrimSpace(string(refined)) == "" {
+		return fmt.Errorf("edited prompt is empty, not re-running #%s", issue)
+	}
+	if bytes.Equal(refined, original) {
+		r.printf(r.colors.Yellow, "Prompt unchanged, not re-running #%s\n", issue)
+		return os.RemoveAll(dir)
+	}
+	diff, err := promptDiff(dir, "original.md", "refined.md")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prompt.diff"), []byte(diff), 0o644); err != nil {
+		return fmt.Errorf("write prompt diff: %w", err)
+	}
+
+	rec := refinement{
+		Issue:          issue,
+		CreatedAt:      r.timestamp(r.now()),
+		PreviousStatus: st.Status,
+		Failure:        st.Failure,
+		Agent:          r.opts.Agent,
+		Model:          r.opts.Model,
+		OriginalPrompt: st.PromptPath,
+	}
+	if err := r.startRunDir(stamp); err != nil {
+		return err
+	}
+	if err := r.openJournal(stamp); err != nil {
+		return err
+	}
+	defer r.closeJournal()
+
+	r.opts.Force = true
+	r.promptOverride = string(refined)
+	r.printf(r.colors.Blue, "Re-running #%s with the refined prompt (saved in %s)\n", issue, dir)
+	result := r.processIssue(1, 1, entry)
+	for result == resultRetry {
+		r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", issue)
+		result = r.processIssue(1, 1, entry)
+	}
+	rec.Result = result.String()
+	if err := writeRefinement(filepath.Join(dir, "refinement.json"), rec); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+	}
+	if result != resultSuccess && result != resultSkipped {
+		return fmt.Errorf("refined run of #%s failed: %s", issue, r.failures[issue])
+	}
+	return nil
+}
+
+// refineEntry finds the queue entry for synthetic ids (which carry their own
+// title and body); GitHub issues only need the number.
+func (r *runner) refineEntry(issue string) (issueEntry, error) {
+	if issuePattern.MatchString(issue) {
+		return issueEntry{ID: issue}, nil
+	}
+	entries, err := r.loadIssueEntries()
+	if err != nil {
+		return issueEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.ID == issue {
+			return entry, nil
+		}
+	}
+	return issueEntry{}, fmt.Errorf("%s is not in the current queue; pass the source flags of the original run", issue)
+}
+
+func (r *runner) printRefineContext(st issueState, prompt string) {
+	r.rule(r.colors.Blue, "-")
+	r.printf(r.colors.Blue, "Issue #%s: %s\n", st.Issue, st.Title)
+	failure := firstNonEmpty(st.Failure, string(failureUnclassified))
+	r.printf(r.colors.Red, "Last attempt: %s (%s) with %s\n", st.Status, failure, firstNonEmpty(st.Agent, r.opts.Agent))
+	if failure == string(failureVerification) {
+		if data, err := os.ReadFile(filepath.Join(r.opts.LogDir, st.Issue+".verify.log")); err == nil {
+			r.printf(r.colors.Yellow, "\nVerification output (tail):\n")
+			r.printf("", "%s\n", tailLines(redactSecrets(string(data)), refineExcerptLines/2))
+		}
+	}
+	r.printf(r.colors.Yellow, "\nPrevious prompt (%s):\n", st.PromptPath)
+	r.printf("", "%s\n", strings.TrimRight(prompt, "\n"))
+	if st.LogPath != "" {
+		if data, err := os.ReadFile(st.LogPath); err == nil {
+			r.printf(r.colors.Yellow, "\nAgent output (last %d lines of %s):\n", refineExcerptLines, st.LogPath)
+			for _, line := range strings.Split(tailLines(redactSecrets(string(data)), refineExcerptLines), "\n") {
+				r.printf("", "%s\n", truncateForConsole(line, 200))
+			}
+		}
+	}
+	r.rule(r.colors.Blue, "-")
+}
+
+func editorCommand() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+	}
+	return "vi"
+}
+
+// editFile opens path in the user's editor. The editor value may carry
+// arguments ("code --wait"), so it goes through the shell.
+func editFile(path string) error {
+	editor := editorCommand()
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "editor", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+func promptDiff(dir, from, to string) (string, error) {
+	cmd := exec.Command("git", "diff", "--no-index", "--no-color", "--", from, to)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", fmt.Errorf("diff prompts: %w", err)
+	}
+	return string(out), nil
+}
+
+func writeRefinement(path string, rec refinement) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode refinement: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write refinement: %w", err)
+	}
+	return nil
+}
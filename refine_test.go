@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRefineIssueRerunsWithEditedPrompt(t *testing.T) {
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, `prompt=$(cat)
+case "$prompt" in
+*REFINED*)
+	echo hi > greeting.txt
+	git add greeting.txt
+	git commit -q -m "feat: add greeting (#5)"
+	;;
+esac
+echo done`)
+	t.Setenv("VISUAL", writeFakeBin(t, "editor", `echo "REFINED: write greeting.txt" >> "$1"`))
+
+	opts := options{
+		Agent:     "claude",
+		ClaudeBin: agent,
+		GHBin:     gh,
+		LogDir:    filepath.Join(t.TempDir(), "logs"),
+	}
+	r := newTestRunner(t, repo, opts)
+
+	if err := r.refineIssue("5"); err == nil || !strings.Contains(err.Error(), "no saved prompt") {
+		t.Fatalf("refine before any attempt: %v", err)
+	}
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultFailed {
+		t.Fatalf("first run = %v", result)
+	}
+	st, _ := r.state.get("5")
+	if st.PromptPath == "" || st.Failure != string(failureNoChanges) {
+		t.Fatalf("state after failure = %+v", st)
+	}
+
+	if err := r.refineIssue("5"); err != nil {
+		t.Fatalf("refineIssue: %v", err)
+	}
+	if !r.isCompleted("5") {
+		t.Fatal("refined run did not complete #5")
+	}
+	dirs, _ := filepath.Glob(filepath.Join(r.opts.LogDir, refinementsDirName, "5-*"))
+	if len(dirs) != 1 {
+		t.Fatalf("refinement dirs = %v", dirs)
+	}
+	diff, err := os.ReadFile(filepath.Join(dirs[0], "prompt.diff"))
+	if err != nil || !strings.Contains(string(diff), "+REFINED: write greeting.txt") {
+		t.Fatalf("prompt.diff = %q, %v", diff, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dirs[0], "refinement.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec refinement
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Result != "success" || rec.Failure != string(failureNoChanges) || rec.OriginalPrompt != st.PromptPath {
+		t.Fatalf("refinement = %+v", rec)
+	}
+
+	r.opts.Force = false
+	if err := r.refineIssue("5"); err == nil || !strings.Contains(err.Error(), "not failed") {
+		t.Fatalf("refine of a done issue: %v", err)
+	}
+}
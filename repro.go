@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	commandRepro        = "repro"
+	reproDirName        = "repro"
+	reproBranchRoot     = "ghir-repro"
+	settingsPromptToken = "{{PROMPT}}"
+)
+
+// samplingFlags maps an agent to the arguments that pin its seed and
+// temperature; for other agents the two are only recorded.
+var samplingFlags = map[string]struct {
+	seed        func(value string) []string
+	temperature func(value string) []string
+}{
+	"api": {temperature: func(value string) []string { return []string{"--temperature", value} }},
+	"local": {
+		seed:        func(value string) []string { return []string{"--seed", value} },
+		temperature: func(value string) []string { return []string{"--temperature", value} },
+	},
+}
+
+// attemptSettings is <log>.settings.json: everything needed to start the
+// same agent on the same prompt from the same commit again.
+type attemptSettings struct {
+	Issue       string   `json:"issue"`
+	RecordedAt  string   `json:"recorded_at"`
+	Version     string   `json:"runner_version"`
+	Agent       string   `json:"agent"`
+	Model       string   `json:"model,omitempty"`
+	Bin         string   `json:"bin"`
+	Args        []string `json:"args"`
+	PromptStdin bool     `json:"prompt_stdin,omitempty"`
+	PromptPath  string   `json:"prompt_path"`
+	LogPath     string   `json:"log_path"`
+	StartHead   string   `json:"start_head"`
+	Branch      string   `json:"branch,omitempty"`
+	Seed        string   `json:"seed,omitempty"`
+	Temperature string   `json:"temperature,omitempty"`
+	// Unpinned lists the requested settings the agent could not take.
+	Unpinned []string `json:"unpinned,omitempty"`
+}
+
+func parseSeed(value string) (string, error) {
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("--seed must be an integer: %q", value)
+	}
+	return value, nil
+}
+
+func parseTemperature(value string) (string, error) {
+	t, err := strconv.ParseFloat(value, 64)
+	if err != nil || t < 0 || t > 2 {
+		return "", fmt.Errorf("--temperature must be a number between 0 and 2: %q", value)
+	}
+	return value, nil
+}
+
+// samplingArgs returns the arguments that pass --seed and --temperature to
+// the agent, and the settings it has no way to take.
+func (r *runner) samplingArgs() ([]string, []string) {
+	flags, ok := samplingFlags[r.opts.Agent]
+	var args, unpinned []string
+	if r.opts.Seed != "" {
+		if ok && flags.seed != nil {
+			args = append(args, flags.seed(r.opts.Seed)...)
+		} else {
+			unpinned = append(unpinned, "seed")
+		}
+	}
+	if r.opts.Temperature != "" {
+		if ok && flags.temperature != nil {
+			args = append(args, flags.temperature(r.opts.Temperature)...)
+		} else {
+			unpinned = append(unpinned, "temperature")
+		}
+	}
+	return args, unpinned
+}
+
+// saveAttemptSettings writes <log>.settings.json next to the attempt log.
+func (r *runner) saveAttemptSettings(issue, logPath, promptPath, prompt, startHead, branch string) {
+	cmd, err := r.buildAgentCommand(prompt)
+	if err != nil {
+		return
+	}
+	_, unpinned := r.samplingArgs()
+	if len(unpinned) > 0 {
+		r.printf(r.colors.Yellow, "WARNING: %s cannot be given a %s; recorded in the attempt settings only\n", r.opts.Agent, strings.Join(unpinned, " or "))
+	}
+	args := make([]string, 0, len(cmd.Args)-1)
+	for _, arg := range cmd.Args[1:] {
+		if arg == prompt {
+			arg = settingsPromptToken
+		}
+		args = append(args, arg)
+	}
+	settings := attemptSettings{
+		Issue:       issue,
+		RecordedAt:  r.timestamp(r.now()),
+		Version:     runnerVersion(),
+		Agent:       r.opts.Agent,
+		Model:       r.opts.Model,
+		Bin:         r.agentBin(),
+		Args:        args,
+		PromptStdin: cmd.Stdin != nil,
+		PromptPath:  promptPath,
+		LogPath:     logPath,
+		StartHead:   startHead,
+		Branch:      branch,
+		Seed:        r.opts.Seed,
+		Temperature: r.opts.Temperature,
+		Unpinned:    unpinned,
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err == nil {
+		err = os.WriteFile(strings.TrimSuffix(logPath, ".log")+".settings.json", append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not save attempt settings: %v\n", err)
+	}
+}
+
+// attemptHistory lists every recorded attempt of an issue, oldest first:
+// <log-dir>/<issue>.settings.json and <log-dir>/<run>/<issue>.attempt-N.settings.json.
+func (r *runner) attemptHistory(issue string) ([]attemptSettings, error) {
+	paths, err := filepath.Glob(filepath.Join(r.opts.LogDir, "*", issue+".attempt-*.settings.json"))
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, filepath.Join(r.opts.LogDir, issue+".settings.json"))
+	var history []attemptSettings
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read attempt settings: %w", err)
+		}
+		var settings attemptSettings
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		history = append(history, settings)
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].RecordedAt < history[j].RecordedAt })
+	return history, nil
+}
+
+// runRepro re-runs attempt n (1-based, oldest first; 0 for the latest) of an
+// issue with the recorded agent, model, seed, temperature and prompt, on a
+// fresh branch cut from the commit that attempt started from. Its logs and
+// state go to <log-dir>/repro/<issue>-<stamp>/, so the real queue is not
+// touched.
+func (r *runner) runRepro(issue string, n int) error {
+	history, err := r.attemptHistory(issue)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("no recorded attempts for #%s", issue)
+	}
+	if n == 0 {
+		n = len(history)
+	}
+	if n > len(history) {
+		return fmt.Errorf("#%s has %d recorded attempt(s), not %d", issue, len(history), n)
+	}
+	settings := history[n-1]
+	prompt, err := os.ReadFile(settings.PromptPath)
+	if err != nil {
+		return fmt.Errorf("read recorded prompt: %w", err)
+	}
+	if dirty, err := r.workingTreeDirty(); err != nil {
+		return err
+	} else if dirty {
+		return fmt.Errorf("uncommitted changes detected; commit or stash before reproducing")
+	}
+
+	stamp := r.fileStamp(r.now())
+	branch := fmt.Sprintf("%s/%s-%s", reproBranchRoot, issue, stamp)
+	if _, err := r.gitOutput("branch", branch, settings.StartHead); err != nil {
+		return fmt.Errorf("create %s at %s: %w", branch, settings.StartHead, err)
+	}
+
+	opts := r.opts
+	opts.LogDir = filepath.Join(r.opts.LogDir, reproDirName, issue+"-"+stamp)
+	opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+	opts.Agent = settings.Agent
+	opts.Model = settings.Model
+	opts.Seed = settings.Seed
+	opts.Temperature = settings.Temperature
+	opts.Force = true
+	setAgentBin(&opts, settings.Agent, settings.Bin)
+	child, err := newRunner(opts, r.repoRoot)
+	if err != nil {
+		return err
+	}
+	child.promptOverride = string(prompt)
+
+	r.printf(r.colors.Blue, "Reproducing attempt %d of #%s (%s", n, issue, settings.Agent)
+	if settings.Model != "" {
+		r.printf(r.colors.Blue, ", model %s", settings.Model)
+	}
+	r.printf(r.colors.Blue, ") from %s on %s\n", shortSHA(settings.StartHead), branch)
+	if settings.Version != runnerVersion() {
+		r.printf(r.colors.Yellow, "WARNING: attempt was recorded by ghir %s, this is %s\n", settings.Version, runnerVersion())
+	}
+	if len(settings.Unpinned) > 0 {
+		r.printf(r.colors.Yellow, "WARNING: %s could not be pinned for %s, so output may differ\n", strings.Join(settings.Unpinned, " and "), settings.Agent)
+	} else if settings.Seed == "" {
+		r.printf(r.colors.Yellow, "WARNING: the attempt ran without --seed, so output may differ\n")
+	}
+
+	result := child.processIssue(1, 1, issueEntry{ID: issue, Branch: branch})
+	for result == resultRetry {
+		r.printf(r.colors.Blue, "Retrying issue #%s after session limit reset...\n", issue)
+		result = child.processIssue(1, 1, issueEntry{ID: issue, Branch: branch})
+	}
+	r.printf("", "Original log: %s\n", settings.LogPath)
+	r.printf("", "Repro logs:   %s\n", opts.LogDir)
+	if result != resultSuccess && result != resultSkipped {
+		return fmt.Errorf("repro of #%s failed: %s", issue, child.failures[issue])
+	}
+	return nil
+}
+
+func setAgentBin(opts *options, agent, bin string) {
+	if bin == "" {
+		return
+	}
+	switch agent {
+	case "codex":
+		opts.CodexBin = bin
+	case "gemini":
+		opts.GeminiBin = bin
+	case "cursor-agent":
+		opts.CursorBin = bin
+	case "aider":
+		opts.AiderBin = bin
+	case "opencode":
+		opts.OpenCodeBin = bin
+	case "copilot":
+		opts.CopilotBin = bin
+	case "qwen":
+		opts.QwenBin = bin
+	case "api", "local":
+		// api and local run ghir itself.
+	default:
+		opts.ClaudeBin = bin
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseReproArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        []string
+		wantAttempt int
+		wantErr     string
+	}{
+		{args: []string{"--repro", "12", "3"}, wantAttempt: 3},
+		{args: []string{"repro", "12"}},
+		{args: []string{"repro", "12", "0"}, wantErr: "positive number"},
+		{args: []string{"repro"}, wantErr: "usage: ticket-runner repro"},
+		{args: []string{"run", "--seed", "x"}, wantErr: "--seed must be an integer"},
+		{args: []string{"run", "--temperature", "3"}, wantErr: "--temperature must be a number"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			t.Parallel()
+			opts, err := parseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.Command != commandRepro || opts.ReproAttempt != tt.wantAttempt {
+				t.Fatalf("command = %q, attempt = %d", opts.Command, opts.ReproAttempt)
+			}
+		})
+	}
+}
+
+func TestReproRerunsRecordedAttemptFromItsStartCommit(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, `cat > prompt.seen
+git add prompt.seen
+git commit -q -m "feat: record prompt (#5)"`)
+
+	opts := options{
+		Agent:       "claude",
+		ClaudeBin:   agent,
+		GHBin:       gh,
+		Seed:        "42",
+		Temperature: "0",
+	}
+	r := newTestRunner(t, repo, opts)
+	startHead := strings.TrimSpace(runGit(t, repo, "rev-parse", "HEAD"))
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultSuccess {
+		t.Fatalf("first run = %v", result)
+	}
+	original, err := os.ReadFile(filepath.Join(repo, "prompt.seen"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := r.attemptHistory("5")
+	if err != nil || len(history) != 1 {
+		t.Fatalf("history = %+v, %v", history, err)
+	}
+	if s := history[0]; s.StartHead != startHead || s.Seed != "42" || strings.Join(s.Unpinned, ",") != "seed,temperature" || !s.PromptStdin {
+		t.Fatalf("settings = %+v", s)
+	}
+
+	head := strings.TrimSpace(runGit(t, repo, "rev-parse", "HEAD"))
+	if err := r.runRepro("5", 1); err != nil {
+		t.Fatalf("runRepro: %v", err)
+	}
+	if now := strings.TrimSpace(runGit(t, repo, "rev-parse", "HEAD")); now != head {
+		t.Fatalf("repro moved the original branch: %s -> %s", head, now)
+	}
+	branch := strings.TrimSpace(strings.TrimPrefix(runGit(t, repo, "branch", "--list", reproBranchRoot+"/*"), "*"))
+	if parent := strings.TrimSpace(runGit(t, repo, "rev-parse", branch+"^")); parent != startHead {
+		t.Fatalf("repro branch %s starts at %s, want %s", branch, parent, startHead)
+	}
+	if replayed := runGit(t, repo, "show", branch+":prompt.seen"); replayed != strings.TrimSpace(string(original)) {
+		t.Fatalf("repro prompt differs:\n%s\nvs\n%s", replayed, original)
+	}
+	if r.state.Issues["5"].Attempts != 1 {
+		t.Fatalf("repro touched the real state: %+v", r.state.Issues["5"])
+	}
+}
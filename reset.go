@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resetIssueLimit caps the gh issue list behind reset --label.
+const resetIssueLimit = 1000
+
+func (o options) resetFiltered() bool {
+	return o.ResetFailed || o.ResetNeedsReview || o.ResetSince != "" || o.Label != ""
+}
+
+func parseResetSince(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--since must be a date (2006-01-02) or an RFC 3339 time: %q", value)
+	}
+	return t, nil
+}
+
+// resetMatching resets the issues that match every reset filter: they leave
+// the done file and are pending again in state.json, with their failure and
+// attempt count cleared. Logs, commits and costs stay.
+func (r *runner) resetMatching() error {
+	ids, err := r.resetCandidates()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		r.printf(r.colors.Yellow, "No issues match the reset filters\n")
+		return nil
+	}
+	for _, id := range ids {
+		delete(r.doneSet, id)
+	}
+	if err := r.markPending(ids); err != nil {
+		return err
+	}
+	return r.rewriteDoneFile(fmt.Sprintf("Reset %d issue(s): #%s\n", len(ids), strings.Join(ids, ", #")))
+}
+
+// markPending makes the issues pending again in state.json, with their
+// failure and attempt count cleared, so the board and status agree with the
+// done file.
+func (r *runner) markPending(ids []string) error {
+	if r.state == nil {
+		return nil
+	}
+	for _, id := range ids {
+		err := r.state.update(id, func(st *issueState) {
+			st.Status = statusPending
+			st.Failure = ""
+			st.Attempts = 0
+		})
+		if err != nil {
+			return fmt.Errorf("reset state of #%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// resetCandidates are the issues in the done file or state.json that match
+// the filters. --failed-only and --needs-review together match either
+// status.
+func (r *runner) resetCandidates() ([]string, error) {
+	var since time.Time
+	if r.opts.ResetSince != "" {
+		var err error
+		if since, err = parseResetSince(r.opts.ResetSince, r.location()); err != nil {
+			return nil, err
+		}
+	}
+	var labeled map[string]bool
+	if r.opts.Label != "" {
+		var err error
+		if labeled, err = r.issuesWithLabels(r.opts.Label); err != nil {
+			return nil, err
+		}
+	}
+	states := make(map[string]issueState)
+	if r.state != nil {
+		states = r.state.snapshot()
+	}
+	known := make(map[string]bool)
+	for id := range r.doneSet {
+		known[id] = true
+	}
+	for id := range states {
+		known[id] = true
+	}
+
+	var ids []string
+	for id := range known {
+		st := states[id]
+		if r.opts.ResetFailed || r.opts.ResetNeedsReview {
+			if !(r.opts.ResetFailed && st.Status == statusFailed) && !(r.opts.ResetNeedsReview && st.Status == statusNeedsReview) {
+				continue
+			}
+		}
+		if !since.IsZero() {
+			finished, err := time.Parse(time.RFC3339, firstNonEmpty(st.FinishedAt, r.doneSet[id].CompletedAt, st.StartedAt))
+			if err != nil || finished.Before(since) {
+				continue
+			}
+		}
+		if labeled != nil && !labeled[id] {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sortStringsNumeric(ids)
+	return ids, nil
+}
+
+// issuesWithLabels lists the issues, open or closed, that carry all of the
+// comma-separated labels.
+func (r *runner) issuesWithLabels(labels string) (map[string]bool, error) {
+	args := []string{"issue", "list", "--state", "all", "--limit", strconv.Itoa(resetIssueLimit), "--json", "number"}
+	for _, label := range strings.Split(labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			args = append(args, "--label", label)
+		}
+	}
+	out, err := r.commandOutput(r.opts.GHBin, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list issues labelled %s: %w", labels, err)
+	}
+	ids, err := parseIssueNumbersJSON(out)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
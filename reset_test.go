@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResetFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "failed only", args: []string{"--failed-only"}, want: []string{"2"}},
+		{name: "failed or needs review", args: []string{"--failed-only", "--needs-review"}, want: []string{"2", "3"}},
+		{name: "since", args: []string{"--since", "2026-02-01"}, want: []string{"3", "4"}},
+		{name: "label", args: []string{"--label", "bug"}, want: []string{"1", "4"}},
+		{name: "label and since", args: []string{"--label", "bug", "--since", "2026-02-01"}, want: []string{"4"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			logDir := filepath.Join(t.TempDir(), "logs")
+			if err := os.MkdirAll(logDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			done := "1\tcompleted_at=2026-01-10T10:00:00Z\n4\tcompleted_at=2026-02-10T10:00:00Z\n"
+			if err := os.WriteFile(filepath.Join(logDir, defaultDoneFileName), []byte(done), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			gh := writeFakeBin(t, "gh", `case "$*" in
+*"--label bug"*) echo '[{"number":1},{"number":4}]' ;;
+*) echo '[]' ;;
+esac`)
+			opts, err := parseArgs(append([]string{"reset"}, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validateOptions(opts); err != nil {
+				t.Fatal(err)
+			}
+			opts.GHBin, opts.LogDir = gh, logDir
+			opts.DoneFile = filepath.Join(logDir, defaultDoneFileName)
+			r := newTestRunner(t, repo, opts)
+			for id, st := range map[string]issueState{
+				"1": {Status: statusDone, FinishedAt: "2026-01-10T10:00:00Z"},
+				"2": {Status: statusFailed, Failure: "verification", Attempts: 2, FinishedAt: "2026-01-20T10:00:00Z"},
+				"3": {Status: statusNeedsReview, Failure: "lint", FinishedAt: "2026-02-05T10:00:00Z"},
+				"4": {Status: statusDone, FinishedAt: "2026-02-10T10:00:00Z"},
+			} {
+				st := st
+				if err := r.state.update(id, func(s *issueState) { *s = st; s.Issue = id }); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := r.handleReset(); err != nil {
+				t.Fatal(err)
+			}
+			var reset []string
+			for _, id := range []string{"1", "2", "3", "4"} {
+				st, _ := r.state.get(id)
+				if st.Status == statusPending {
+					if st.Failure != "" || st.Attempts != 0 {
+						t.Fatalf("#%s state not cleared: %+v", id, st)
+					}
+					reset = append(reset, id)
+				}
+			}
+			if strings.Join(reset, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("reset %v, want %v", reset, tt.want)
+			}
+			data, err := os.ReadFile(opts.DoneFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range tt.want {
+				if strings.Contains(string(data), id+"\t") {
+					t.Fatalf("#%s still in the done file:\n%s", id, data)
+				}
+			}
+		})
+	}
+}
+
+func TestResetUpdatesState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		issue     string
+		wantReset []string
+	}{
+		{name: "one issue", issue: "1", wantReset: []string{"1"}},
+		{name: "everything", wantReset: []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			opts := options{LogDir: filepath.Join(t.TempDir(), "logs"), ResetIssue: tt.issue}
+			opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+			if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(opts.DoneFile, []byte("1\n2\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r := newTestRunner(t, repo, opts)
+			for _, id := range []string{"1", "2"} {
+				if err := r.state.update(id, func(s *issueState) { s.Status = statusDone; s.Attempts = 1 }); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := r.handleReset(); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{"1", "2"} {
+				wantStatus := statusDone
+				if slices.Contains(tt.wantReset, id) {
+					wantStatus = statusPending
+				}
+				if st, _ := r.state.get(id); st.Status != wantStatus {
+					t.Fatalf("#%s state = %q, want %q", id, st.Status, wantStatus)
+				}
+				if got := boardStatus(t, r, id); got != wantStatus {
+					t.Fatalf("#%s board status = %q, want %q", id, got, wantStatus)
+				}
+			}
+		})
+	}
+}
+
+// boardStatus is the status of the issue's card on the board.
+func boardStatus(t *testing.T, r *runner, id string) string {
+	t.Helper()
+
+	page, err := r.buildBoard(false)
+	if err != nil {
+		t.Fatalf("buildBoard: %v", err)
+	}
+	for _, column := range page.Columns {
+		for _, card := range column.Cards {
+			if card.Issue == id {
+				return card.Status
+			}
+		}
+	}
+	t.Fatalf("no card for #%s", id)
+	return ""
+}
+
+func TestResetFilterValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"--failed-only"},
+		{"reset", "5", "--failed-only"},
+		{"reset", "--since", "yesterday"},
+	} {
+		opts, err := parseArgs(args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Failed attempts rolled back by --rollback-on-failure are kept under this
+// ref prefix, one ref per issue.
+const rollbackRefPrefix = "refs/ghir/failed/"
+
+// rollbackIssue restores the state from before a failed issue: uncommitted
+// changes go to the stash, commits made since startHead are dropped from the
+// branch, and the tree is reset to startHead. Nothing is lost: the dropped
+// commits stay reachable from refs/ghir/failed/<id>. An issue the attempt
+// already marked completed leaves the done file, so the next run does not
+// skip work that is no longer on the branch. Rollback trouble only warns.
+func (r *runner) rollbackIssue(issue, startHead string, attempt *issueAttempt) {
+	if attempt != nil && attempt.completed {
+		if err := r.unmarkCompleted(issue); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+			return
+		}
+		attempt.completed = false
+	}
+	head, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+		return
+	}
+	dirty, err := r.workingTreeDirty()
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+		return
+	}
+	if head == startHead && !dirty {
+		return
+	}
+
+	var kept []string
+	if dirty {
+		message := fmt.Sprintf("ghir: uncommitted changes of failed #%s", issue)
+		if _, err := r.gitOutput("stash", "push", "--include-untracked", "-m", message); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+			return
+		}
+		kept = append(kept, fmt.Sprintf("uncommitted changes in the stash (%q)", message))
+	}
+	if head != startHead {
+		ref := rollbackRefPrefix + issue
+		count, err := r.gitOutput("rev-list", "--count", startHead+".."+head)
+		if err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+			return
+		}
+		if _, err := r.gitOutput("update-ref", ref, head); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+			return
+		}
+		if _, err := r.gitOutput("reset", "--hard", startHead); err != nil {
+			r.printf(r.colors.Yellow, "WARNING: could not roll back #%s: %v\n", issue, err)
+			return
+		}
+		kept = append(kept, fmt.Sprintf("%s commit(s) as %s", count, ref))
+	}
+	r.record(journalEntry{Event: journalRolledBack, Issue: issue, Commit: head})
+	r.printf(r.colors.Yellow, "Rolled back #%s to %s; kept %s\n", issue, shortCommit(startHead), strings.Join(kept, " and "))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRollbackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		agent     string
+		verify    string
+		want      issueResult
+		wantMoved bool
+		wantRef   bool
+		wantStash bool
+	}{
+		{
+			name:      "failed verification drops commits and stashes leftovers",
+			agent:     "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"\necho scratch > scratch.txt",
+			verify:    "false",
+			want:      resultFailed,
+			wantRef:   true,
+			wantStash: true,
+		},
+		{
+			name:      "crashed agent leaves a clean tree",
+			agent:     "echo half > half.txt\nexit 3",
+			want:      resultFailed,
+			wantStash: true,
+		},
+		{
+			name:      "success is kept",
+			agent:     "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"",
+			verify:    "true",
+			want:      resultSuccess,
+			wantMoved: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			start := runGit(t, repo, "rev-parse", "HEAD")
+			gh := fakeIssueGH(t, "Add greeting", "say hi")
+			agent := fakeAgent(t, tt.agent)
+			opts := options{
+				Agent:             "claude",
+				ClaudeBin:         agent,
+				GHBin:             gh,
+				VerifyCmd:         tt.verify,
+				RollbackOnFailure: true,
+			}
+			r := newTestRunner(t, repo, opts)
+			if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != tt.want {
+				t.Fatalf("processIssue() = %v, want %v", result, tt.want)
+			}
+
+			if moved := runGit(t, repo, "rev-parse", "HEAD") != start; moved != tt.wantMoved {
+				t.Fatalf("HEAD moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if status := runGit(t, repo, "status", "--porcelain"); status != "" {
+				t.Fatalf("tree not clean after the issue:\n%s", status)
+			}
+			_, refErr := r.gitOutput("rev-parse", "--verify", "--quiet", rollbackRefPrefix+"5")
+			if (refErr == nil) != tt.wantRef {
+				t.Fatalf("%s5 exists = %v, want %v", rollbackRefPrefix, refErr == nil, tt.wantRef)
+			}
+			if stash := runGit(t, repo, "stash", "list"); (stash != "") != tt.wantStash {
+				t.Fatalf("stash = %q, want an entry: %v", stash, tt.wantStash)
+			}
+		})
+	}
+}
+
+func TestRollbackAfterRejectedPush(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	start := runGit(t, repo, "rev-parse", "HEAD")
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+	hook := filepath.Join(remote, "hooks", "pre-receive")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho protected branch >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "remote", "add", "origin", remote)
+
+	gh := fakeIssueGH(t, "Add greeting", "say hi")
+	agent := fakeAgent(t, "echo hi > greeting.txt\ngit add greeting.txt\ngit commit -q -m \"feat: add greeting (#5)\"")
+	opts := options{
+		Agent:             "claude",
+		ClaudeBin:         agent,
+		GHBin:             gh,
+		Push:              true,
+		RollbackOnFailure: true,
+	}
+	r := newTestRunner(t, repo, opts)
+	if result := r.processIssue(1, 1, issueEntry{ID: "5"}); result != resultFailed {
+		t.Fatalf("processIssue() = %v, want failed", result)
+	}
+	if head := runGit(t, repo, "rev-parse", "HEAD"); head != start {
+		t.Fatal("the rejected commit is still on the branch")
+	}
+	if _, err := r.gitOutput("rev-parse", "--verify", "--quiet", rollbackRefPrefix+"5"); err != nil {
+		t.Fatalf("the rejected commit was not kept: %v", err)
+	}
+	// The work is gone from the branch, so the issue must not count as done.
+	if done, _ := os.ReadFile(r.opts.DoneFile); r.isCompleted("5") || strings.TrimSpace(string(done)) != "" {
+		t.Fatalf("issue still completed; done file = %q", done)
+	}
+	if st, _ := r.state.get("5"); st.Status == statusDone {
+		t.Fatalf("state = %q", st.Status)
+	}
+}
+
+func TestRollbackUnmarksCompletedIssue(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	start := runGit(t, repo, "rev-parse", "HEAD")
+	r := newTestRunner(t, repo, options{})
+	commitFile(t, repo, "greeting.txt", "hi\n")
+	attempt := &issueAttempt{issue: "5"}
+	if err := r.markCompleted("5", attempt); err != nil {
+		t.Fatal(err)
+	}
+	attempt.completed = true
+
+	r.rollbackIssue("5", start, attempt)
+	if r.isCompleted("5") {
+		t.Fatal("rolled-back issue is still completed")
+	}
+	if done, _ := os.ReadFile(r.opts.DoneFile); strings.TrimSpace(string(done)) != "" {
+		t.Fatalf("done file = %q", done)
+	}
+}
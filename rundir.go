@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// startRunDir gives the run its own <log-dir>/<timestamp>/ directory, so the
+// log of every attempt survives retries and later runs.
+func (r *runner) startRunDir(stamp string) error {
+	dir := filepath.Join(r.opts.LogDir, stamp)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create run log dir: %w", err)
+	}
+	r.runDir = dir
+	return nil
+}
+
+// attemptLogPath returns <run-dir>/<issue>.attempt-N.log for the next attempt
+// in this run. Without a run dir (dry runs, single calls) it is the flat
+// <log-dir>/<issue>.log.
+func (r *runner) attemptLogPath(issue string) (string, error) {
+	if r.runDir == "" {
+		return filepath.Join(r.opts.LogDir, issue+".log"), nil
+	}
+	existing, err := filepath.Glob(filepath.Join(r.runDir, issue+".attempt-*.log"))
+	if err != nil {
+		return "", err
+	}
+	for n := len(existing) + 1; ; n++ {
+		path := filepath.Join(r.runDir, fmt.Sprintf("%s.attempt-%d.log", issue, n))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path, nil
+		}
+	}
+}
+
+// savePrompt keeps the prompt next to the attempt log as <log>.prompt.md, so
+// `ghir refine` can start from it later.
+func (r *runner) savePrompt(logPath, prompt string) string {
+	path := strings.TrimSuffix(logPath, ".log") + ".prompt.md"
+	if err := os.WriteFile(path, []byte(prompt), 0o644); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not save prompt: %v\n", err)
+		return ""
+	}
+	return path
+}
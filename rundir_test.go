@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttemptLogPath(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	r := &runner{opts: options{LogDir: logDir}}
+	if got, _ := r.attemptLogPath("7"); got != filepath.Join(logDir, "7.log") {
+		t.Fatalf("without a run dir: %s", got)
+	}
+
+	if err := r.startRunDir("20260102T030405Z"); err != nil {
+		t.Fatal(err)
+	}
+	scoped := r.forIssue(issueEntry{ID: "7"})
+	for n, want := range []string{"7.attempt-1.log", "7.attempt-2.log", "7.attempt-3.log"} {
+		got, err := scoped.attemptLogPath("7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != filepath.Join(logDir, "20260102T030405Z", want) {
+			t.Fatalf("attempt %d: %s", n+1, got)
+		}
+		if err := os.WriteFile(got, []byte("log"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := scoped.attemptLogPath("8"); filepath.Base(got) != "8.attempt-1.log" {
+		t.Fatalf("other issue: %s", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sampleOtherStratum = "(other)"
+
+// sampleIssues picks n entries at random. With strata, every stratum gets a
+// share of the sample proportional to its size (largest remainder), and at
+// least one entry when n allows it, so small groups are still represented.
+// The picks keep their queue order.
+func sampleIssues(entries []issueEntry, n int, seed int64, stratum func(issueEntry) string) []issueEntry {
+	if n >= len(entries) {
+		return entries
+	}
+	rng := rand.New(rand.NewSource(seed))
+	if stratum == nil {
+		stratum = func(issueEntry) string { return "" }
+	}
+
+	groups := make(map[string][]int)
+	var names []string
+	for i, entry := range entries {
+		name := stratum(entry)
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], i)
+	}
+	sort.Strings(names)
+
+	quotas := allocateSample(names, groups, n, len(entries))
+	var picked []int
+	for _, name := range names {
+		idx := append([]int(nil), groups[name]...)
+		rng.Shuffle(len(idx), func(i, j int) { idx[i], idx[j] = idx[j], idx[i] })
+		picked = append(picked, idx[:quotas[name]]...)
+	}
+	sort.Ints(picked)
+
+	out := make([]issueEntry, 0, len(picked))
+	for _, i := range picked {
+		out = append(out, entries[i])
+	}
+	return out
+}
+
+func allocateSample(names []string, groups map[string][]int, n, total int) map[string]int {
+	quotas := make(map[string]int, len(names))
+	fracs := make(map[string]float64, len(names))
+	assigned := 0
+	for _, name := range names {
+		share := float64(n) * float64(len(groups[name])) / float64(total)
+		quotas[name] = int(share)
+		fracs[name] = share - float64(int(share))
+		assigned += quotas[name]
+	}
+	byRemainder := append([]string(nil), names...)
+	sort.SliceStable(byRemainder, func(i, j int) bool { return fracs[byRemainder[i]] > fracs[byRemainder[j]] })
+	for _, name := range byRemainder {
+		if assigned == n {
+			break
+		}
+		quotas[name]++
+		assigned++
+	}
+	// Give every stratum at least one pick, taken from the largest quota.
+	if n >= len(names) {
+		for _, name := range names {
+			if quotas[name] > 0 {
+				continue
+			}
+			largest := names[0]
+			for _, other := range names {
+				if quotas[other] > quotas[largest] {
+					largest = other
+				}
+			}
+			quotas[largest]--
+			quotas[name]++
+		}
+	}
+	return quotas
+}
+
+// sampleQueue narrows the queue to --sample pending issues. The seed is
+// --seed when given, otherwise random and printed so the sample can be
+// drawn again.
+func (r *runner) sampleQueue(entries []issueEntry) ([]issueEntry, error) {
+	var pending []issueEntry
+	for _, entry := range entries {
+		if r.isSkipped(entry.ID) || (r.isCompleted(entry.ID) && !r.opts.Force) {
+			continue
+		}
+		pending = append(pending, entry)
+	}
+	seed := time.Now().UnixNano()
+	if r.opts.Seed != "" {
+		parsed, err := strconv.ParseInt(r.opts.Seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("--seed must be an integer: %q", r.opts.Seed)
+		}
+		seed = parsed
+	}
+
+	var stratum func(issueEntry) string
+	if r.opts.Stratify != "" {
+		labels, err := r.fetchOpenIssueLabels()
+		if err != nil {
+			return nil, fmt.Errorf("fetch labels for --stratify: %w", err)
+		}
+		var strata []string
+		for _, label := range strings.Split(r.opts.Stratify, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				strata = append(strata, label)
+			}
+		}
+		stratum = func(entry issueEntry) string {
+			return issueStratum(labels[entry.ID], strata)
+		}
+	}
+
+	sample := sampleIssues(pending, r.opts.Sample, seed, stratum)
+	r.printf(r.colors.Blue, "Sample: %d of %d pending issue(s), seed %d\n", len(sample), len(pending), seed)
+	if stratum != nil {
+		counts := make(map[string]int)
+		for _, entry := range sample {
+			counts[stratum(entry)]++
+		}
+		for _, name := range sortedCountKeys(counts) {
+			r.printf(r.colors.Blue, "  %s: %d\n", name, counts[name])
+		}
+	}
+	return sample, nil
+}
+
+// issueStratum is the first --stratify label the issue carries.
+func issueStratum(labels, strata []string) string {
+	for _, want := range strata {
+		for _, label := range labels {
+			if strings.EqualFold(label, want) {
+				return want
+			}
+		}
+	}
+	return sampleOtherStratum
+}
+
+func (r *runner) fetchOpenIssueLabels() (map[string][]string, error) {
+	out, err := r.commandOutput(
+		r.opts.GHBin, "issue", "list",
+		"--state", "open",
+		"--limit", strconv.Itoa(assigneeIssueLimit),
+		"--json", "number,labels",
+	)
+	if err != nil {
+		return nil, err
+	}
+	var listed []struct {
+		Number int `json:"number"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal([]byte(out), &listed); err != nil {
+		return nil, fmt.Errorf("parse gh issue list output: %w", err)
+	}
+	labels := make(map[string][]string, len(listed))
+	for _, issue := range listed {
+		id := strconv.Itoa(issue.Number)
+		for _, label := range issue.Labels {
+			labels[id] = append(labels[id], label.Name)
+		}
+	}
+	return labels, nil
+}
+
+func sortedCountKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSampleIssues(t *testing.T) {
+	t.Parallel()
+
+	var entries []issueEntry
+	for i := 1; i <= 12; i++ {
+		entries = append(entries, issueEntry{ID: fmt.Sprint(i)})
+	}
+	docs := map[string]bool{"3": true, "11": true}
+	byLabel := func(e issueEntry) string {
+		if docs[e.ID] {
+			return "docs"
+		}
+		return "bug"
+	}
+
+	tests := []struct {
+		name      string
+		n         int
+		stratum   func(issueEntry) string
+		wantCount map[string]int
+	}{
+		{name: "plain", n: 5, wantCount: map[string]int{"": 5}},
+		{name: "proportional", n: 6, stratum: byLabel, wantCount: map[string]int{"bug": 5, "docs": 1}},
+		{name: "small stratum kept", n: 3, stratum: byLabel, wantCount: map[string]int{"bug": 2, "docs": 1}},
+		{name: "all", n: 20, wantCount: map[string]int{"": 12}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := sampleIssues(entries, tt.n, 42, tt.stratum)
+			again := sampleIssues(entries, tt.n, 42, tt.stratum)
+			if ids(got) != ids(again) {
+				t.Fatalf("same seed gave %s and %s", ids(got), ids(again))
+			}
+			counts := make(map[string]int)
+			last := 0
+			for _, e := range got {
+				key := ""
+				if tt.stratum != nil {
+					key = tt.stratum(e)
+				}
+				counts[key]++
+				var n int
+				fmt.Sscan(e.ID, &n)
+				if n <= last {
+					t.Fatalf("sample %s is not in queue order", ids(got))
+				}
+				last = n
+			}
+			if fmt.Sprint(counts) != fmt.Sprint(tt.wantCount) {
+				t.Fatalf("counts = %v, want %v", counts, tt.wantCount)
+			}
+		})
+	}
+}
+
+func ids(entries []issueEntry) string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.ID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSampleQueueSkipsCompletedAndStratifies(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	gh := writeFakeBin(t, "gh", `echo '[{"number":1,"labels":[{"name":"bug"}]},{"number":2,"labels":[{"name":"Docs"}]},{"number":3,"labels":[]},{"number":4,"labels":[{"name":"bug"}]}]'`)
+	opts := options{
+		GHBin:    gh,
+		Sample:   3,
+		Stratify: "docs,bug",
+		Seed:     "7",
+	}
+	r := newTestRunner(t, repo, opts)
+	r.doneSet["4"] = doneRecord{ID: "4"}
+
+	got, err := r.sampleQueue([]issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(got) != "1,2,3" {
+		t.Fatalf("sample = %s, want 1,2,3 (one per stratum, completed #4 left out)", ids(got))
+	}
+	if s := issueStratum([]string{"docs", "bug"}, []string{"bug", "docs"}); s != "bug" {
+		t.Fatalf("issueStratum() = %q, want the first --stratify label", s)
+	}
+}
+
+func TestSampleOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--sample", "0"}, wantErr: "--sample must be a positive integer"},
+		{args: []string{"--stratify", "bug"}, wantErr: "--stratify requires --sample"},
+		{args: []string{"--sample", "3", "--pick"}, wantErr: "cannot be combined with --pick"},
+		{args: []string{"--sample", "3", "--seed", "9", "--stratify", "bug,docs"}},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("options %v returned unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("options %v error = %v, want substring %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
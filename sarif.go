@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sourceSARIF          = "sarif"
+	sarifMaxListedResult = 50
+)
+
+type sarifLog struct {
+	Runs []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name  string      `json:"name"`
+			Rules []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+	HelpURI          string       `json:"helpUri"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID        string            `json:"ruleId"`
+	RuleIndex     *int              `json:"ruleIndex"`
+	Level         string            `json:"level"`
+	Message       sarifMessage      `json:"message"`
+	BaselineState string            `json:"baselineState"`
+	Suppressions  []json.RawMessage `json:"suppressions"`
+	Locations     []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region struct {
+				StartLine int `json:"startLine"`
+			} `json:"region"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+}
+
+type sarifGroup struct {
+	tool    string
+	rule    sarifRule
+	file    string
+	level   string
+	results []sarifResult
+}
+
+// loadSarifIssues turns SARIF findings into one synthetic issue per rule and
+// file. Suppressed findings and ones the scanner marks as fixed are skipped.
+func loadSarifIssues(path, repoRoot string) ([]issueEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read SARIF file: %w", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	groups := map[string]*sarifGroup{}
+	var order []string
+	for _, run := range log.Runs {
+		rules := map[string]sarifRule{}
+		for _, rule := range run.Tool.Driver.Rules {
+			rules[rule.ID] = rule
+		}
+		for _, result := range run.Results {
+			if len(result.Suppressions) > 0 || result.BaselineState == "absent" {
+				continue
+			}
+			rule := rules[result.RuleID]
+			if result.RuleID == "" && result.RuleIndex != nil && *result.RuleIndex < len(run.Tool.Driver.Rules) {
+				rule = run.Tool.Driver.Rules[*result.RuleIndex]
+			}
+			if rule.ID == "" {
+				rule.ID = result.RuleID
+			}
+			file := ""
+			if len(result.Locations) > 0 {
+				file = sarifRelativePath(result.Locations[0].PhysicalLocation.ArtifactLocation.URI, repoRoot)
+			}
+			key := run.Tool.Driver.Name + "\x00" + rule.ID + "\x00" + file
+			level := result.Level
+			if level == "" {
+				// SARIF's default level.
+				level = "warning"
+			}
+			group, ok := groups[key]
+			if !ok {
+				group = &sarifGroup{tool: run.Tool.Driver.Name, rule: rule, file: file, level: level}
+				groups[key] = group
+				order = append(order, key)
+			}
+			group.results = append(group.results, result)
+			if sarifLevelRank(level) > sarifLevelRank(group.level) {
+				group.level = level
+			}
+		}
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no open findings in %s", path)
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return sarifLevelRank(groups[order[i]].level) > sarifLevelRank(groups[order[j]].level)
+	})
+	entries := make([]issueEntry, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		entries = append(entries, issueEntry{
+			ID:     syntheticID(sourceSARIF, group.tool, group.rule.ID, group.file),
+			Source: sourceSARIF,
+			Title:  group.title(),
+			Body:   group.body(),
+		})
+	}
+	return entries, nil
+}
+
+func (g *sarifGroup) title() string {
+	where := g.file
+	if where == "" {
+		where = "the project"
+	}
+	title := fmt.Sprintf("%s %s in %s", g.tool, g.rule.ID, where)
+	if len(g.results) > 1 {
+		title += fmt.Sprintf(" (%d findings)", len(g.results))
+	}
+	return strings.TrimSpace(title)
+}
+
+func (g *sarifGroup) body() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Static analysis tool: %s\n", g.tool)
+	fmt.Fprintf(&b, "Rule: %s", g.rule.ID)
+	if g.rule.Name != "" && g.rule.Name != g.rule.ID {
+		fmt.Fprintf(&b, " (%s)", g.rule.Name)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Severity: %s\n", g.level)
+	if desc := firstNonEmpty(g.rule.FullDescription.Text, g.rule.ShortDescription.Text); desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", desc)
+	}
+	if g.rule.HelpURI != "" {
+		fmt.Fprintf(&b, "\nMore information: %s\n", g.rule.HelpURI)
+	}
+	b.WriteString("\nFindings:\n")
+	for i, result := range g.results {
+		if i == sarifMaxListedResult {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(g.results)-i)
+			break
+		}
+		location := g.file
+		if len(result.Locations) > 0 {
+			if line := result.Locations[0].PhysicalLocation.Region.StartLine; line > 0 {
+				location = fmt.Sprintf("%s:%d", location, line)
+			}
+		}
+		message := normalizeWhitespace(result.Message.Text)
+		if location == "" {
+			fmt.Fprintf(&b, "- %s\n", message)
+		} else {
+			fmt.Fprintf(&b, "- %s: %s\n", location, message)
+		}
+	}
+	return b.String()
+}
+
+func sarifRelativePath(uri, repoRoot string) string {
+	if strings.HasPrefix(uri, "file:") {
+		if parsed, err := url.Parse(uri); err == nil {
+			uri = parsed.Path
+		}
+	}
+	if filepath.IsAbs(uri) && repoRoot != "" {
+		if rel, err := filepath.Rel(repoRoot, uri); err == nil && !strings.HasPrefix(rel, "..") {
+			uri = rel
+		}
+	}
+	return filepath.ToSlash(strings.TrimPrefix(uri, "./"))
+}
+
+func sarifLevelRank(level string) int {
+	switch level {
+	case "error":
+		return 3
+	case "warning":
+		return 2
+	case "note":
+		return 1
+	}
+	return 0
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
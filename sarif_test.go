@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSARIF = `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "gosec", "rules": [
+      {"id": "G101", "name": "HardcodedCredentials", "shortDescription": {"text": "Potential hardcoded credentials"}, "helpUri": "https://example.com/G101"},
+      {"id": "G104", "shortDescription": {"text": "Errors unhandled"}}
+    ]}},
+    "results": [
+      {"ruleId": "G104", "level": "note", "message": {"text": "Errors unhandled."},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///repo/cmd/main.go"}, "region": {"startLine": 12}}}]},
+      {"ruleId": "G101", "level": "error", "message": {"text": "Potential hardcoded\ncredentials"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "config/db.go"}, "region": {"startLine": 3}}}]},
+      {"ruleId": "G101", "message": {"text": "Another one"},
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "config/db.go"}, "region": {"startLine": 9}}}]},
+      {"ruleId": "G101", "level": "error", "message": {"text": "ignored"}, "suppressions": [{"kind": "inSource"}],
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "config/other.go"}}}]},
+      {"ruleIndex": 1, "level": "warning", "message": {"text": "fixed already"}, "baselineState": "absent",
+       "locations": [{"physicalLocation": {"artifactLocation": {"uri": "config/gone.go"}}}]}
+    ]
+  }]
+}`
+
+func TestLoadSarifIssues(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "scan.sarif")
+	if err := os.WriteFile(path, []byte(testSARIF), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := loadSarifIssues(path, "/repo")
+	if err != nil {
+		t.Fatalf("loadSarifIssues: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+
+	first := entries[0]
+	if first.Title != "gosec G101 in config/db.go (2 findings)" {
+		t.Fatalf("first title = %q", first.Title)
+	}
+	for _, want := range []string{
+		"Rule: G101 (HardcodedCredentials)",
+		"Severity: error",
+		"More information: https://example.com/G101",
+		"- config/db.go:3: Potential hardcoded credentials",
+		"- config/db.go:9: Another one",
+	} {
+		if !strings.Contains(first.Body, want) {
+			t.Fatalf("body missing %q:\n%s", want, first.Body)
+		}
+	}
+	if !first.synthetic() || !isIssueID(first.ID) || !strings.HasPrefix(first.ID, "sarif-") {
+		t.Fatalf("unexpected entry: %+v", first)
+	}
+	if entries[1].Title != "gosec G104 in cmd/main.go" {
+		t.Fatalf("second title = %q", entries[1].Title)
+	}
+
+	again, err := loadSarifIssues(path, "/repo")
+	if err != nil || again[0].ID != first.ID {
+		t.Fatalf("ids are not stable: %v %v", again, err)
+	}
+}
+
+func TestSyntheticEntryUsesInlineDetails(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{GHBin: "/nonexistent/gh", PromptTemplate: "/nonexistent/prompt.tmpl"}}
+	entry := issueEntry{ID: "sarif-0123456789", Source: sourceSARIF, Title: "gosec G101", Body: "Findings: x"}
+	scoped := r.forIssue(entry)
+	details, err := scoped.entryDetails(entry)
+	if err != nil {
+		t.Fatalf("entryDetails: %v", err)
+	}
+	prompt, err := scoped.buildPrompt(entry.ID, details)
+	if err != nil {
+		t.Fatalf("buildPrompt: %v", err)
+	}
+	for _, want := range []string{"reported by sarif (runner task sarif-0123456789)", "## Task: gosec G101", "Findings: x"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "closes #") {
+		t.Fatalf("synthetic prompt should not ask to close a GitHub issue:\n%s", prompt)
+	}
+}
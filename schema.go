@@ -0,0 +1,640 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	commandConfig = "config"
+
+	configActionValidate = "validate"
+	configActionSchema   = "schema"
+
+	schemaKindConfig   = "config"
+	schemaKindManifest = "manifest"
+
+	schemaBaseURL = "https://raw.githubusercontent.com/pppontusw/ghir/main/schema/"
+)
+
+// jsonSchema is the subset of JSON Schema (2020-12) the config and manifest
+// schemas use. The same value is published for editors and checked by
+// `ghir config validate`, so the two cannot disagree.
+type jsonSchema struct {
+	Schema               string   `json:"$schema,omitempty"`
+	ID                   string   `json:"$id,omitempty"`
+	Ref                  string   `json:"$ref,omitempty"`
+	Title                string   `json:"title,omitempty"`
+	Description          string   `json:"description,omitempty"`
+	Type                 string   `json:"type,omitempty"`
+	Enum                 []string `json:"enum,omitempty"`
+	Pattern              string   `json:"pattern,omitempty"`
+	listOf               []string
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	OneOf                []*jsonSchema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// Config keys whose flag is not the key with dashes, and keys with no flag.
+var (
+	schemaKeyFlags = map[string]string{
+		"todo_paths":     "--todo-path",
+		"app_key_file":   "--app-key",
+		"caches":         "--cache",
+		"share_dirs":     "--share-dir",
+		"debug_patterns": "--debug-pattern",
+		"artifacts":      "--artifact",
+		"formatters":     "--formatter",
+	}
+	schemaDescriptions = map[string]string{
+		"skip_file": "File of issue ids never to process (default: .ticket-runner/skip.txt)",
+		"env_tools": "Extra tools whose versions are recorded in each run's environment.json",
+		"agents":    "Custom agents, by name: cmd with {{PROMPT}} (and optionally {{MODEL}}), prompt (arg or stdin), limit_patterns and co_author",
+		"caches":    "Build cache variables (GOCACHE, npm_config_cache, ...) mapped to directories shared by every issue",
+		"profiles":  "Named sets of settings selected with --profile; they cannot be nested",
+	}
+	schemaMinimums = map[string]float64{
+		"parallel":          1,
+		"api_max_tokens":    1,
+		"wait_buffer_sec":   0,
+		"gh_write_interval": 0,
+		"verify_retries":    0,
+		"bench_threshold":   0,
+	}
+	// Keys that take a comma-separated list of their enum values (agent
+	// takes a fallback chain).
+	schemaEnumLists = map[string]bool{
+		"agent": true,
+	}
+	helpLinePattern = regexp.MustCompile(`^\s+(?:-\w, )?(--[a-z0-9-]+)(?: [<\[][^>\]]*[>\]])?\s+(\S.*)$`)
+)
+
+func schemaEnums() map[string][]string {
+	return map[string][]string{
+		"agent":                supportedAgents,
+		"stream_view":          {streamViewPretty, streamViewRaw},
+		"verify_scope":         {verifyScopeFull, verifyScopeChanged},
+		"on_limit":             {onLimitWait, onLimitSwitch},
+		"content_gate":         {contentGateOff, contentGateWarn, contentGateFail},
+		"format_fix":           {formatFixCommit, formatFixAmend},
+		"diff_summary":         {diffSummaryStats, diffSummaryModel, diffSummaryCommand},
+		"protect_runner_files": {protectRevert, protectFail, protectOff},
+		"api_provider":         apiProviders,
+		"lang":                 supportedLanguages(),
+	}
+}
+
+// flagDescriptions maps each flag to its line in the options help.
+func flagDescriptions() map[string]string {
+	descriptions := make(map[string]string)
+	for _, line := range strings.Split(optionsHelp, "\n") {
+		if m := helpLinePattern.FindStringSubmatch(line); m != nil {
+			descriptions[m[1]] = m[2]
+		}
+	}
+	return descriptions
+}
+
+// settingsSchema describes every config.yaml setting except profiles.
+func settingsSchema() map[string]*jsonSchema {
+	helps := flagDescriptions()
+	enums := schemaEnums()
+	props := make(map[string]*jsonSchema)
+	t := reflect.TypeOf(repoConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		key := yamlKey(t.Field(i))
+		if key == "" || key == "profiles" {
+			continue
+		}
+		prop := typeSchema(t.Field(i).Type)
+		prop.Description = schemaDescriptions[key]
+		if prop.Description == "" {
+			flag := schemaKeyFlags[key]
+			if flag == "" {
+				flag = "--" + strings.ReplaceAll(key, "_", "-")
+			}
+			prop.Description = helps[flag]
+		}
+		prop.Enum = enums[key]
+		if schemaEnumLists[key] {
+			// The pattern leaves room for agents defined under agents:;
+			// validation checks the names against those.
+			prop.Enum, prop.listOf = nil, prop.Enum
+			prop.Pattern = "^[a-z0-9][a-z0-9_-]*(,[a-z0-9][a-z0-9_-]*)*$"
+		}
+		if minimum, ok := schemaMinimums[key]; ok {
+			prop.Minimum = &minimum
+		}
+		props[key] = prop
+	}
+	agent := props["agents"].AdditionalProperties.(*jsonSchema)
+	agent.Properties["prompt"].Enum = []string{promptModeArg, promptModeStdin}
+	return props
+}
+
+func yamlKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func typeSchema(t reflect.Type) *jsonSchema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice:
+		return &jsonSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]*jsonSchema)
+		for i := 0; i < t.NumField(); i++ {
+			if key := yamlKey(t.Field(i)); key != "" {
+				props[key] = typeSchema(t.Field(i).Type)
+			}
+		}
+		return &jsonSchema{Type: "object", Properties: props, AdditionalProperties: false}
+	default:
+		return &jsonSchema{Type: "string"}
+	}
+}
+
+// issueEntrySchema accepts what an issue file accepts: an id, or an object
+// with per-issue overrides.
+func issueEntrySchema() *jsonSchema {
+	fields := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema), AdditionalProperties: false}
+	t := reflect.TypeOf(issueEntry{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := yamlKey(t.Field(i)); key != "" {
+			fields.Properties[key] = &jsonSchema{Type: "string"}
+		}
+	}
+	fields.Properties["id"] = &jsonSchema{OneOf: []*jsonSchema{{Type: "integer"}, {Type: "string"}}}
+	fields.Properties["priority"] = &jsonSchema{OneOf: []*jsonSchema{{Type: "integer"}, {Type: "string"}}}
+	fields.Properties["agent"].listOf = schemaEnums()["agent"]
+	fields.Properties["agent"].Pattern = "^[a-z0-9][a-z0-9_-]*$"
+	return &jsonSchema{OneOf: []*jsonSchema{{Type: "integer"}, {Type: "string"}, fields}}
+}
+
+func configSchema() *jsonSchema {
+	props := settingsSchema()
+	props["profiles"] = &jsonSchema{
+		Type:                 "object",
+		Description:          schemaDescriptions["profiles"],
+		AdditionalProperties: &jsonSchema{Ref: "#/$defs/settings"},
+	}
+	return &jsonSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		ID:                   schemaBaseURL + "config.schema.json",
+		Title:                "ghir config (.ticket-runner/config.yaml)",
+		Type:                 "object",
+		Properties:           props,
+		AdditionalProperties: false,
+		Defs:                 map[string]*jsonSchema{"settings": {Type: "object", Properties: settingsSchema(), AdditionalProperties: false}},
+	}
+}
+
+func manifestSchema() *jsonSchema {
+	props := settingsSchema()
+	props["name"] = &jsonSchema{Type: "string", Description: "Name of the run in the journal and notifications (default: the file name)"}
+	props["description"] = &jsonSchema{Type: "string", Description: "What the run is for"}
+	props["profile"] = &jsonSchema{Type: "string", Description: "Profile of the repo config to layer the manifest over"}
+	props["issues"] = &jsonSchema{Type: "array", Description: "Issues to run, in order: ids or objects with per-issue overrides", Items: issueEntrySchema()}
+	return &jsonSchema{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		ID:                   schemaBaseURL + "manifest.schema.json",
+		Title:                "ghir run manifest (ghir run -f)",
+		Type:                 "object",
+		Properties:           props,
+		AdditionalProperties: false,
+	}
+}
+
+func schemaFor(kind string) (*jsonSchema, error) {
+	switch kind {
+	case schemaKindConfig:
+		return configSchema(), nil
+	case schemaKindManifest:
+		return manifestSchema(), nil
+	}
+	return nil, fmt.Errorf("unknown schema %q (supported: %s, %s)", kind, schemaKindConfig, schemaKindManifest)
+}
+
+func marshalSchema(s *jsonSchema) ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// configProblem is one mistake in a config or manifest file, at the line and
+// column of the offending key or value.
+type configProblem struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (p configProblem) String() string {
+	if p.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+}
+
+// check reports where node does not match the schema. Null values are
+// accepted everywhere, as they leave a setting unset.
+func (s *jsonSchema) check(node *yaml.Node, path string, defs map[string]*jsonSchema) []configProblem {
+	if s.Ref != "" {
+		s = defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		return nil
+	}
+	problem := func(n *yaml.Node, format string, values ...any) []configProblem {
+		return []configProblem{{Path: path, Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, values...)}}
+	}
+	if len(s.OneOf) > 0 {
+		var kinds []string
+		for _, alt := range s.OneOf {
+			if alt.accepts(node) {
+				return alt.check(node, path, defs)
+			}
+			kinds = append(kinds, alt.Type)
+		}
+		return problem(node, "expected %s, got %s", strings.Join(kinds, " or "), nodeKind(node))
+	}
+	if !s.accepts(node) {
+		return problem(node, "expected %s, got %s", s.Type, nodeKind(node))
+	}
+
+	switch s.Type {
+	case "object":
+		var problems []configProblem
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			prop, ok := s.Properties[key.Value]
+			if !ok {
+				prop, ok = s.AdditionalProperties.(*jsonSchema)
+			}
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if suggestion := closestKey(key.Value, s.Properties); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				problems = append(problems, configProblem{Path: path, Line: key.Line, Column: key.Column, Message: msg})
+				continue
+			}
+			problems = append(problems, prop.check(value, joinConfigPath(path, key.Value), defs)...)
+		}
+		return problems
+	case "array":
+		var problems []configProblem
+		for i, item := range node.Content {
+			problems = append(problems, s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), defs)...)
+		}
+		return problems
+	}
+	if len(s.Enum) > 0 && !containsFold(s.Enum, strings.TrimSpace(node.Value)) {
+		return problem(node, "must be one of: %s (got %q)", strings.Join(s.Enum, ", "), node.Value)
+	}
+	if len(s.listOf) > 0 {
+		for _, value := range strings.Split(node.Value, ",") {
+			if !containsFold(s.listOf, strings.TrimSpace(value)) {
+				return problem(node, "must be one of: %s (got %q)", strings.Join(s.listOf, ", "), node.Value)
+			}
+		}
+	}
+	if s.Minimum != nil {
+		if value, err := strconv.ParseFloat(node.Value, 64); err == nil && value < *s.Minimum {
+			return problem(node, "must be >= %g (got %s)", *s.Minimum, node.Value)
+		}
+	}
+	return nil
+}
+
+// accepts reports whether node has the schema's type, as the YAML decoder
+// sees it: any scalar decodes into a string, ints into numbers.
+func (s *jsonSchema) accepts(node *yaml.Node) bool {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch s.Type {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.ShortTag() == "!!int" || node.ShortTag() == "!!float")
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!bool"
+	}
+	return true
+}
+
+func nodeKind(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer " + node.Value
+	case "!!float":
+		return "number " + node.Value
+	case "!!bool":
+		return "boolean " + node.Value
+	}
+	return fmt.Sprintf("string %q", node.Value)
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestKey suggests the known key a typo was most likely meant to be.
+func closestKey(key string, props map[string]*jsonSchema) string {
+	best, bestDistance := "", 3
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// validateConfigData checks a config file or manifest: first against its
+// schema, then (when the shape is right) with the checks ghir runs when it
+// loads the file, placing those at the key they are about.
+func validateConfigData(kind string, data []byte, path string) []configProblem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []configProblem{yamlSyntaxProblem(err)}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	schema, err := schemaFor(kind)
+	if err != nil {
+		return []configProblem{{Message: err.Error()}}
+	}
+	// A manifest can also name the agents of the repo config.
+	agents := append([]string(nil), supportedAgents...)
+	if kind == schemaKindManifest {
+		agents = knownAgents()
+	}
+	for _, name := range definedAgents(root) {
+		if !containsFold(agents, name) {
+			agents = append(agents, name)
+		}
+	}
+	schema.allowAgents(agents)
+	if problems := schema.check(root, "", schema.Defs); len(problems) > 0 {
+		return problems
+	}
+
+	switch kind {
+	case schemaKindConfig:
+		var cfg repoConfig
+		if err = decodeRepoConfig(data, &cfg); err == nil {
+			err = cfg.validate()
+		}
+	case schemaKindManifest:
+		_, err = loadRunManifest(path)
+		if err != nil {
+			msg := strings.TrimPrefix(err.Error(), "parse "+path+": ")
+			err = fmt.Errorf("%s", strings.TrimPrefix(msg, path+": "))
+		}
+	}
+	if err != nil {
+		return []configProblem{locateProblem(root, err.Error())}
+	}
+	return nil
+}
+
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+func yamlSyntaxProblem(err error) configProblem {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return configProblem{Line: line, Column: 1, Message: m[2]}
+	}
+	return configProblem{Line: 1, Column: 1, Message: err.Error()}
+}
+
+var problemKeyPattern = regexp.MustCompile(`^([a-z_]+)\b`)
+
+// locateProblem places an error from validate() at the key its message
+// starts with, inside the profile it names if any.
+func locateProblem(root *yaml.Node, msg string) configProblem {
+	node, path := root, ""
+	if rest, ok := strings.CutPrefix(msg, `profile "`); ok {
+		if name, tail, ok := strings.Cut(rest, `": `); ok {
+			if profile := mappingValue(mappingValue(root, "profiles"), name); profile != nil {
+				node, path, msg = profile, "profiles."+name, tail
+			}
+		}
+	}
+	p := configProblem{Path: path, Line: node.Line, Column: node.Column, Message: msg}
+	if m := problemKeyPattern.FindStringSubmatch(msg); m != nil {
+		if value := mappingValue(node, m[1]); value != nil {
+			p.Path, p.Line, p.Column = joinConfigPath(path, m[1]), value.Line, value.Column
+			p.Message = strings.TrimPrefix(msg, m[1]+": ")
+		}
+	}
+	return p
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// definedAgents lists the agents a config or manifest declares, at the top
+// level and in its profiles.
+func definedAgents(root *yaml.Node) []string {
+	var names []string
+	settings := []*yaml.Node{root}
+	if profiles := mappingValue(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 1; i < len(profiles.Content); i += 2 {
+			settings = append(settings, profiles.Content[i])
+		}
+	}
+	for _, node := range settings {
+		agents := mappingValue(node, "agents")
+		if agents == nil || agents.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i < len(agents.Content); i += 2 {
+			names = append(names, agents.Content[i].Value)
+		}
+	}
+	return names
+}
+
+// allowAgents sets the agent names the agent keys accept.
+func (s *jsonSchema) allowAgents(names []string) {
+	if s == nil {
+		return
+	}
+	for key, prop := range s.Properties {
+		if key == "agent" && len(prop.listOf) > 0 {
+			prop.listOf = names
+		}
+		prop.allowAgents(names)
+	}
+	for _, def := range s.Defs {
+		def.allowAgents(names)
+	}
+	for _, alt := range s.OneOf {
+		alt.allowAgents(names)
+	}
+	s.Items.allowAgents(names)
+}
+
+func isConfigAction(action string) bool {
+	return action == configActionValidate || action == configActionSchema
+}
+
+// runConfigCommand runs outside the normal startup, which would stop at the
+// first broken setting without saying where it is.
+func runConfigCommand(opts options) int {
+	if opts.ConfigAction == configActionSchema {
+		schema, err := schemaFor(opts.Args[0])
+		if err != nil {
+			return exitCode(err)
+		}
+		data, err := marshalSchema(schema)
+		if err != nil {
+			return exitCode(err)
+		}
+		if opts.Out == "" || opts.Out == "-" {
+			_, err = os.Stdout.Write(data)
+			return exitCode(err)
+		}
+		return exitCode(os.WriteFile(opts.Out, data, 0o644))
+	}
+
+	root, err := findRepoRoot()
+	if err != nil {
+		if root, err = os.Getwd(); err != nil {
+			return exitCode(err)
+		}
+	}
+	type target struct{ kind, path string }
+	var targets []target
+	configPath := filepath.Join(root, defaultConfigPath)
+	if opts.ConfigFile != "" {
+		configPath = resolvePath(root, opts.ConfigFile)
+	}
+	if _, err := os.Stat(configPath); err == nil || opts.ConfigFile != "" {
+		targets = append(targets, target{schemaKindConfig, configPath})
+	}
+	if opts.Manifest != "" {
+		targets = append(targets, target{schemaKindManifest, resolvePath(root, opts.Manifest)})
+	}
+	if len(targets) == 0 {
+		return exitCode(fmt.Errorf("nothing to validate: no %s (pass --config or -f)", defaultConfigPath))
+	}
+
+	r := &runner{opts: opts, colors: newPalette(opts)}
+	total := 0
+	for _, t := range targets {
+		data, err := os.ReadFile(t.path)
+		if err != nil {
+			return exitCode(fmt.Errorf("read %s: %w", t.kind, err))
+		}
+		problems := validateConfigData(t.kind, data, t.path)
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", t.path, p)
+		}
+		if len(problems) == 0 {
+			r.printf(r.colors.Green, "%s: valid %s\n", t.path, t.kind)
+		}
+		total += len(problems)
+	}
+	if total > 0 {
+		return exitCode(fmt.Errorf("%d problem(s) found", total))
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishedSchemasAreCurrent(t *testing.T) {
+	t.Parallel()
+
+	for _, kind := range []string{schemaKindConfig, schemaKindManifest} {
+		schema, err := schemaFor(kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := marshalSchema(schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join("schema", kind+".schema.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("schema/%s.schema.json is out of date; run make schema", kind)
+		}
+	}
+}
+
+func TestSettingsSchemaDescribesEveryKey(t *testing.T) {
+	t.Parallel()
+
+	for key, prop := range settingsSchema() {
+		if prop.Description == "" {
+			t.Errorf("config key %s has no description (add a help line for its flag or a schemaDescriptions entry)", key)
+		}
+	}
+}
+
+func TestValidateConfigData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		kind string
+		data string
+		want []string
+	}{
+		{
+			name: "valid config",
+			kind: schemaKindConfig,
+			data: "agent: Codex\nparallel: 2\ncaches:\n  GOCACHE: .cache/go\nprofiles:\n  fast:\n    model: mini\n",
+		},
+		{
+			name: "schema problems are all reported with positions",
+			kind: schemaKindConfig,
+			data: "agent: claud\nmodle: x\nparallel: 0\ncreate_pr: \"yes\"\nprofiles:\n  fast:\n    verfy_cmd: make\n",
+			want: []string{
+				`1:8: agent: must be one of: claude, codex, gemini, cursor-agent, aider, opencode, copilot, qwen, api, local (got "claud")`,
+				`2:1: unknown key "modle" (did you mean "model"?)`,
+				`3:11: parallel: must be >= 1 (got 0)`,
+				`4:12: create_pr: expected boolean, got string "yes"`,
+				`7:5: profiles.fast: unknown key "verfy_cmd" (did you mean "verify_cmd"?)`,
+			},
+		},
+		{
+			name: "custom agents",
+			kind: schemaKindConfig,
+			data: "agent: claude,mytool\nagents:\n  mytool:\n    cmd: [mytool, \"{{PROMPT}}\"]\nprofiles:\n  piped:\n    agent: piped\n    agents:\n      piped:\n        cmd: [mytool]\n        prompt: file\n",
+			want: []string{`11:17: profiles.piped.agents.piped.prompt: must be one of: arg, stdin (got "file")`},
+		},
+		{
+			name: "load checks are placed at their key",
+			kind: schemaKindConfig,
+			data: "model: x\nprofiles:\n  fast:\n    timezone: Mars/Base\n",
+			want: []string{`4:15: profiles.fast.timezone: unknown time zone "Mars/Base"`},
+		},
+		{
+			name: "syntax error",
+			kind: schemaKindConfig,
+			data: "agent: claude\n  model: [\n",
+			want: []string{"2:1: mapping values are not allowed in this context"},
+		},
+		{
+			name: "manifest",
+			kind: schemaKindManifest,
+			data: "name: nightly\nprofiles: {}\nissues:\n  - 12\n  - id: 13\n    agnt: codex\n  - [1]\n",
+			want: []string{
+				`2:1: unknown key "profiles" (did you mean "profile"?)`,
+				`6:5: issues[1]: unknown key "agnt" (did you mean "agent"?)`,
+				`7:5: issues[2]: expected integer or string or object, got a list`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "file.yaml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range validateConfigData(tt.kind, []byte(tt.data), path) {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPlanManifestPassesValidation(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{opts: options{Agent: "claude", VerifyCmd: "go test ./..."}}
+	plan := []plannedIssue{
+		{Entry: issueEntry{ID: "12"}, Title: "Fix it", Agent: "codex", Model: "o3", CostUSD: 1.5, Basis: "all agents"},
+	}
+	data, err := r.planManifest(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if problems := validateConfigData(schemaKindManifest, data, path); len(problems) > 0 {
+		t.Fatalf("problems in emitted manifest: %v", problems)
+	}
+	if !strings.HasPrefix(string(data), "# yaml-language-server: $schema="+schemaBaseURL+"manifest.schema.json\n") {
+		t.Fatalf("manifest has no schema modeline:\n%s", data)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	verifyScopeFull    = "full"
+	verifyScopeChanged = "changed"
+
+	scopeGo     = "go"
+	scopeJS     = "js"
+	scopePython = "python"
+)
+
+var (
+	scopeLanguages = map[string]string{
+		".go":  scopeGo,
+		".js":  scopeJS,
+		".jsx": scopeJS,
+		".mjs": scopeJS,
+		".cjs": scopeJS,
+		".ts":  scopeJS,
+		".tsx": scopeJS,
+		".py":  scopePython,
+	}
+	// Changes to these never affect test results.
+	scopeIgnoredExts = map[string]bool{".md": true, ".txt": true, ".rst": true, ".adoc": true}
+)
+
+// scopedVerifyCommand narrows --verify-cmd to the tests the change since base
+// can affect: Go packages (and the packages importing them) in place of
+// ./..., --findRelatedTests for Jest, `vitest related` for Vitest and the
+// matching test files for pytest. When it cannot, it returns "" and why the
+// full suite runs instead.
+func (r *runner) scopedVerifyCommand(base string) (string, string) {
+	out, err := r.gitOutput("diff", "--name-only", base+"..HEAD")
+	if err != nil {
+		return "", fmt.Sprintf("cannot list changed files: %v", err)
+	}
+	var files []string
+	language := ""
+	for _, file := range strings.Split(out, "\n") {
+		file = strings.TrimSpace(file)
+		ext := path.Ext(file)
+		if file == "" || scopeIgnoredExts[strings.ToLower(ext)] {
+			continue
+		}
+		lang, ok := scopeLanguages[ext]
+		if !ok {
+			return "", fmt.Sprintf("%s is not a Go, JavaScript or Python source file", file)
+		}
+		if language != "" && lang != language {
+			return "", "the change touches more than one language"
+		}
+		language = lang
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return "", "no source files changed"
+	}
+
+	command := r.opts.VerifyCmd
+	switch language {
+	case scopeGo:
+		if !strings.Contains(command, "./...") {
+			return "", "the verify command does not test ./..."
+		}
+		listing, err := r.commandOutput("go", "list", "-e", "-f", goListFormat, "./...")
+		if err != nil {
+			return "", fmt.Sprintf("go list failed: %v", err)
+		}
+		pkgs := goAffectedPackages(listing, r.repoRoot, files)
+		if len(pkgs) == 0 {
+			return "", "the changed files belong to no package"
+		}
+		return strings.ReplaceAll(command, "./...", strings.Join(pkgs, " ")), ""
+	case scopeJS:
+		if !singleCommand(command) {
+			return "", "the verify command is not a single command"
+		}
+		switch {
+		case strings.Contains(command, "vitest"):
+			if strings.Contains(command, "vitest run") {
+				command = strings.Replace(command, "vitest run", "vitest related --run", 1)
+			} else {
+				command = strings.Replace(command, "vitest", "vitest related --run", 1)
+			}
+			return command + " " + quoteAll(files), ""
+		case strings.Contains(command, "jest"):
+			return command + " --findRelatedTests " + quoteAll(files), ""
+		}
+		return "", "the verify command runs neither Jest nor Vitest"
+	default:
+		if !singleCommand(command) || !strings.Contains(command, "pytest") {
+			return "", "the verify command is not a single pytest command"
+		}
+		tracked, err := r.gitOutput("ls-files", "*.py")
+		if err != nil {
+			return "", fmt.Sprintf("cannot list Python files: %v", err)
+		}
+		tests := pythonTestFiles(files, strings.Split(tracked, "\n"))
+		if len(tests) == 0 {
+			return "", "no test file matches the changed modules"
+		}
+		return command + " " + quoteAll(tests), ""
+	}
+}
+
+const goListFormat = `{{.ImportPath}}|{{.Dir}}|{{join .Imports " "}} {{join .TestImports " "}} {{join .XTestImports " "}}`
+
+// goAffectedPackages maps changed files to their packages and adds every
+// package that imports one of them, directly or not, as ./relative paths.
+func goAffectedPackages(listing, repoRoot string, files []string) []string {
+	dirs := make(map[string]string)
+	importers := make(map[string][]string)
+	for _, line := range strings.Split(listing, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		pkg := parts[0]
+		dirs[pkg] = parts[1]
+		for _, imported := range strings.Fields(parts[2]) {
+			if imported != pkg {
+				importers[imported] = append(importers[imported], pkg)
+			}
+		}
+	}
+	byDir := make(map[string]string, len(dirs))
+	for pkg, dir := range dirs {
+		byDir[filepath.Clean(dir)] = pkg
+	}
+
+	affected := make(map[string]bool)
+	var queue []string
+	for _, file := range files {
+		if pkg, ok := byDir[filepath.Join(repoRoot, filepath.Dir(file))]; ok && !affected[pkg] {
+			affected[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, importer := range importers[pkg] {
+			if !affected[importer] {
+				affected[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	var pkgs []string
+	for pkg := range affected {
+		rel, err := filepath.Rel(repoRoot, dirs[pkg])
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rel == "." {
+			pkgs = append(pkgs, ".")
+			continue
+		}
+		pkgs = append(pkgs, "./"+filepath.ToSlash(rel))
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// pythonTestFiles keeps changed test files and finds test_<name>.py or
+// <name>_test.py for every other changed module.
+func pythonTestFiles(changed, tracked []string) []string {
+	isTest := func(file string) bool {
+		base := path.Base(file)
+		return strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py")
+	}
+	byName := make(map[string][]string)
+	for _, file := range tracked {
+		if file = strings.TrimSpace(file); isTest(file) {
+			byName[path.Base(file)] = append(byName[path.Base(file)], file)
+		}
+	}
+	seen := make(map[string]bool)
+	var tests []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			tests = append(tests, file)
+		}
+	}
+	for _, file := range changed {
+		if isTest(file) {
+			add(file)
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(file), ".py")
+		for _, candidate := range append(byName["test_"+name+".py"], byName[name+"_test.py"]...) {
+			add(candidate)
+		}
+	}
+	sort.Strings(tests)
+	return tests
+}
+
+func singleCommand(command string) bool {
+	return !strings.ContainsAny(command, ";|&\n")
+}
+
+func quoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoAffectedPackages(t *testing.T) {
+	t.Parallel()
+
+	root := "/src/m"
+	listing := strings.Join([]string{
+		"m|/src/m|fmt m/b ",
+		"m/a|/src/m/a|strings ",
+		"m/b|/src/m/b|m/a testing",
+		"m/c|/src/m/c| m/b",
+		"m/d|/src/m/d|fmt ",
+		"go: warning: something on stderr",
+	}, "\n")
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{name: "leaf package pulls in importers", files: []string{"a/a.go"}, want: []string{".", "./a", "./b", "./c"}},
+		{name: "test-only importer", files: []string{"b/b.go"}, want: []string{".", "./b", "./c"}},
+		{name: "unrelated package", files: []string{"d/d.go"}, want: []string{"./d"}},
+		{name: "deleted package", files: []string{"gone/x.go"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := goAffectedPackages(listing, root, tt.files)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Fatalf("goAffectedPackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPythonTestFiles(t *testing.T) {
+	t.Parallel()
+
+	tracked := []string{"pkg/util.py", "tests/test_util.py", "pkg/parse.py", "pkg/parse_test.py", "tests/test_other.py"}
+	got := pythonTestFiles([]string{"pkg/util.py", "pkg/parse.py", "tests/test_new.py"}, tracked)
+	want := []string{"pkg/parse_test.py", "tests/test_new.py", "tests/test_util.py"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("pythonTestFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestScopedVerifyCommand(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	commitFile(t, repo, "go.mod", "module example.com/m\n\ngo 1.22\n")
+	commitFile(t, repo, "a.go", "package m\n")
+	for _, dir := range []string{"lib", "app", "other"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitFile(t, repo, "lib/lib.go", "package lib\n\nfunc N() int { return 1 }\n")
+	commitFile(t, repo, "app/app.go", "package app\n\nimport \"example.com/m/lib\"\n\nvar V = lib.N()\n")
+	commitFile(t, repo, "other/other.go", "package other\n")
+	base := runGit(t, repo, "rev-parse", "HEAD")
+
+	r := newTestRunner(t, repo, options{LogDir: filepath.Join(t.TempDir(), "logs"), DoneFile: filepath.Join(t.TempDir(), "done")})
+
+	commitFile(t, repo, "lib/lib.go", "package lib\n\nfunc N() int { return 2 }\n")
+	commitFile(t, repo, "NOTES.md", "notes\n")
+	tests := []struct {
+		verifyCmd  string
+		want       string
+		wantReason string
+	}{
+		{verifyCmd: "go vet ./... && go test -race ./...", want: "go vet ./app ./lib && go test -race ./app ./lib"},
+		{verifyCmd: "make test", wantReason: "does not test ./..."},
+		{verifyCmd: "npx jest", wantReason: "does not test ./..."},
+	}
+	for _, tt := range tests {
+		r.opts.VerifyCmd = tt.verifyCmd
+		got, reason := r.scopedVerifyCommand(base)
+		if got != tt.want || !strings.Contains(reason, tt.wantReason) {
+			t.Fatalf("%q: scopedVerifyCommand() = %q, %q; want %q, %q", tt.verifyCmd, got, reason, tt.want, tt.wantReason)
+		}
+	}
+
+	commitFile(t, repo, "go.mod", "module example.com/m\n\ngo 1.22.0\n")
+	r.opts.VerifyCmd = "go test ./..."
+	if got, reason := r.scopedVerifyCommand(base); got != "" || !strings.Contains(reason, "go.mod is not") {
+		t.Fatalf("go.mod change: scopedVerifyCommand() = %q, %q", got, reason)
+	}
+}
+
+func TestScopedVerifyCommandJS(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	base := runGit(t, repo, "rev-parse", "HEAD")
+	commitFile(t, repo, "src.ts", "export const x = 1\n")
+	r := newTestRunner(t, repo, options{LogDir: filepath.Join(t.TempDir(), "logs"), DoneFile: filepath.Join(t.TempDir(), "done")})
+	for cmd, want := range map[string]string{
+		"npx jest":                 "npx jest --findRelatedTests src.ts",
+		"npx vitest run":           "npx vitest related --run src.ts",
+		"npm run lint && npx jest": "",
+	} {
+		r.opts.VerifyCmd = cmd
+		if got, _ := r.scopedVerifyCommand(base); got != want {
+			t.Fatalf("%q: scopedVerifyCommand() = %q, want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestVerifyScopeOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--verify-scope", "some"}, wantErr: "--verify-scope must be one of"},
+		{args: []string{"--verify-scope", "changed"}, wantErr: "--verify-scope changed requires --verify-cmd"},
+		{args: []string{"--verify-full-at-end"}, wantErr: "--verify-full-at-end requires --verify-cmd"},
+		{args: []string{"--verify-scope", "changed", "--verify-full-at-end", "--verify-cmd", "go test ./..."}},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("options %v returned unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("options %v error = %v, want substring %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	sourceSentry           = "sentry"
+	defaultSentryURL       = "https://sentry.io"
+	defaultSentryLimit     = 10
+	sentryMaxFrames        = 30
+	sentryMaxBreadcrumbs   = 20
+	trackingIssuesFileName = "tracking-issues.json"
+)
+
+var createdIssuePattern = regexp.MustCompile(`/issues/(\d+)\s*$`)
+
+// sentryClient reads unresolved issues from the Sentry web API. The token
+// comes from SENTRY_AUTH_TOKEN and needs the event:read scope.
+type sentryClient struct {
+	baseURL string
+	token   string
+	org     string
+	project string
+	client  *http.Client
+}
+
+type sentryIssue struct {
+	ID        string `json:"id"`
+	ShortID   string `json:"shortId"`
+	Title     string `json:"title"`
+	Culprit   string `json:"culprit"`
+	Permalink string `json:"permalink"`
+	Level     string `json:"level"`
+	Count     string `json:"count"`
+	UserCount int    `json:"userCount"`
+	FirstSeen string `json:"firstSeen"`
+	LastSeen  string `json:"lastSeen"`
+}
+
+type sentryEvent struct {
+	EventID string `json:"eventID"`
+	Entries []struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	} `json:"entries"`
+	Tags []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"tags"`
+}
+
+type sentryException struct {
+	Values []struct {
+		Type       string `json:"type"`
+		Value      string `json:"value"`
+		Stacktrace *struct {
+			Frames []sentryFrame `json:"frames"`
+		} `json:"stacktrace"`
+	} `json:"values"`
+}
+
+type sentryFrame struct {
+	Filename string `json:"filename"`
+	AbsPath  string `json:"absPath"`
+	Function string `json:"function"`
+	LineNo   int    `json:"lineNo"`
+	InApp    bool   `json:"inApp"`
+}
+
+type sentryBreadcrumbs struct {
+	Values []struct {
+		Timestamp string `json:"timestamp"`
+		Category  string `json:"category"`
+		Level     string `json:"level"`
+		Message   string `json:"message"`
+	} `json:"values"`
+}
+
+func newSentryClient(opts options) (*sentryClient, error) {
+	org, project, ok := strings.Cut(opts.SentryProject, "/")
+	if !ok || org == "" || project == "" || strings.Contains(project, "/") {
+		return nil, fmt.Errorf("--sentry-project must be <org>/<project>: %q", opts.SentryProject)
+	}
+	token := os.Getenv("SENTRY_AUTH_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("SENTRY_AUTH_TOKEN is not set")
+	}
+	base := defaultSentryURL
+	if env := os.Getenv("SENTRY_URL"); env != "" {
+		base = env
+	}
+	return &sentryClient{
+		baseURL: strings.TrimRight(base, "/"),
+		token:   token,
+		org:     org,
+		project: project,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *sentryClient) get(path string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Detail string `json:"detail"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		if apiErr.Detail == "" {
+			apiErr.Detail = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("GET %s: %s: %s", path, resp.Status, apiErr.Detail)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (c *sentryClient) unresolvedIssues(limit int) ([]sentryIssue, error) {
+	query := url.Values{"query": {"is:unresolved"}, "sort": {"freq"}, "limit": {fmt.Sprint(limit)}}
+	var issues []sentryIssue
+	path := fmt.Sprintf("/api/0/projects/%s/%s/issues/?%s", url.PathEscape(c.org), url.PathEscape(c.project), query.Encode())
+	if err := c.get(path, &issues); err != nil {
+		return nil, fmt.Errorf("list Sentry issues: %w", err)
+	}
+	if len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues, nil
+}
+
+func (c *sentryClient) latestEvent(issueID string) (sentryEvent, error) {
+	var event sentryEvent
+	if err := c.get("/api/0/issues/"+url.PathEscape(issueID)+"/events/latest/", &event); err != nil {
+		return event, fmt.Errorf("latest event for Sentry issue %s: %w", issueID, err)
+	}
+	return event, nil
+}
+
+func (r *runner) loadSentryIssues() ([]issueEntry, error) {
+	client, err := newSentryClient(r.opts)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := client.issueEntries(r.opts.SentryLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no unresolved Sentry issues in %s", r.opts.SentryProject)
+	}
+	return entries, nil
+}
+
+func (c *sentryClient) issueEntries(limit int) ([]issueEntry, error) {
+	issues, err := c.unresolvedIssues(limit)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]issueEntry, 0, len(issues))
+	for _, issue := range issues {
+		event, err := c.latestEvent(issue.ID)
+		if err != nil {
+			return nil, err
+		}
+		title := issue.Title
+		if issue.ShortID != "" {
+			title = issue.ShortID + ": " + title
+		}
+		entries = append(entries, issueEntry{
+			ID:     syntheticID(sourceSentry, c.org, issue.ID),
+			Source: sourceSentry,
+			Title:  title,
+			Body:   renderSentryIssue(issue, event),
+		})
+	}
+	return entries, nil
+}
+
+func renderSentryIssue(issue sentryIssue, event sentryEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sentry issue: %s\n", firstNonEmpty(issue.Permalink, issue.ID))
+	if issue.Culprit != "" {
+		fmt.Fprintf(&b, "Culprit: %s\n", issue.Culprit)
+	}
+	fmt.Fprintf(&b, "Level: %s | Events: %s | Users: %d\n", firstNonEmpty(issue.Level, "error"), firstNonEmpty(issue.Count, "?"), issue.UserCount)
+	if issue.FirstSeen != "" || issue.LastSeen != "" {
+		fmt.Fprintf(&b, "First seen: %s | Last seen: %s\n", issue.FirstSeen, issue.LastSeen)
+	}
+	for _, tag := range event.Tags {
+		switch tag.Key {
+		case "release", "environment", "runtime", "os", "browser", "url", "transaction":
+			fmt.Fprintf(&b, "%s: %s\n", tag.Key, tag.Value)
+		}
+	}
+
+	for _, entry := range event.Entries {
+		switch entry.Type {
+		case "exception":
+			var exc sentryException
+			if json.Unmarshal(entry.Data, &exc) != nil {
+				continue
+			}
+			for _, value := range exc.Values {
+				fmt.Fprintf(&b, "\n## Exception: %s: %s\n\n", value.Type, value.Value)
+				if value.Stacktrace == nil || len(value.Stacktrace.Frames) == 0 {
+					continue
+				}
+				frames := value.Stacktrace.Frames
+				if len(frames) > sentryMaxFrames {
+					frames = frames[len(frames)-sentryMaxFrames:]
+				}
+				b.WriteString("Stack trace (most recent call last, [app] marks project code):\n\n```\n")
+				for _, f := range frames {
+					marker := "     "
+					if f.InApp {
+						marker = "[app]"
+					}
+					fmt.Fprintf(&b, "%s %s:%d in %s\n", marker, firstNonEmpty(f.Filename, f.AbsPath, "?"), f.LineNo, firstNonEmpty(f.Function, "?"))
+				}
+				b.WriteString("```\n")
+			}
+		case "breadcrumbs":
+			var crumbs sentryBreadcrumbs
+			if json.Unmarshal(entry.Data, &crumbs) != nil || len(crumbs.Values) == 0 {
+				continue
+			}
+			values := crumbs.Values
+			if len(values) > sentryMaxBreadcrumbs {
+				values = values[len(values)-sentryMaxBreadcrumbs:]
+			}
+			b.WriteString("\n## Breadcrumbs (oldest first)\n\n")
+			for _, c := range values {
+				fmt.Fprintf(&b, "- %s [%s] %s: %s\n", c.Timestamp, firstNonEmpty(c.Level, "info"), firstNonEmpty(c.Category, "default"), strings.TrimSpace(c.Message))
+			}
+		}
+	}
+
+	b.WriteString("\nReproduce the error first (ideally with a failing test), then fix the cause and keep the test.\n")
+	return b.String()
+}
+
+// trackingIssue returns the GitHub issue that tracks a synthetic task,
+// creating it on first use. The mapping is kept in the log dir so reruns
+// reuse the same issue.
+func (r *runner) trackingIssue(entry issueEntry) (string, error) {
+	path := filepath.Join(r.opts.LogDir, trackingIssuesFileName)
+	tracked := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tracked); err != nil {
+			return "", fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if number, ok := tracked[entry.ID]; ok {
+		return number, nil
+	}
+
+	body := fmt.Sprintf("Tracked by ghir as %s.\n\n%s", entry.ID, entry.Body)
+	out, err := r.ghWrite("issue", "create", "--title", entry.Title, "--body", body)
+	if err != nil {
+		return "", fmt.Errorf("create tracking issue: %w", err)
+	}
+	m := createdIssuePattern.FindStringSubmatch(strings.TrimSpace(out))
+	if m == nil {
+		return "", fmt.Errorf("create tracking issue: unexpected gh output %q", out)
+	}
+	tracked[entry.ID] = m[1]
+	encoded, err := json.MarshalIndent(tracked, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode tracking issues: %w", err)
+	}
+	if err := os.WriteFile(path, append(encoded, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	return m[1], nil
+}
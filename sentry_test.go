@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSentryIssueEntries(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, `{"detail":"bad token"}`, http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/api/0/projects/acme/web/issues/":
+			if req.URL.Query().Get("query") != "is:unresolved" {
+				t.Errorf("query = %q", req.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[{"id":"101","shortId":"WEB-1A","title":"KeyError: 'user'","culprit":"app.views in profile","permalink":"https://sentry.example/issues/101/","level":"error","count":"42","userCount":7}]`))
+		case "/api/0/issues/101/events/latest/":
+			_, _ = w.Write([]byte(`{"eventID":"e1","tags":[{"key":"release","value":"1.2.3"},{"key":"server_name","value":"web-7"}],"entries":[
+				{"type":"exception","data":{"values":[{"type":"KeyError","value":"'user'","stacktrace":{"frames":[
+					{"filename":"django/core/handlers.py","function":"inner","lineNo":47},
+					{"filename":"app/views.py","function":"profile","lineNo":12,"inApp":true}]}}]}},
+				{"type":"breadcrumbs","data":{"values":[{"timestamp":"2026-01-01T10:00:00Z","category":"http","level":"info","message":"GET /profile"}]}}]}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	client := &sentryClient{baseURL: srv.URL, token: "secret", org: "acme", project: "web", client: srv.Client()}
+	entries, err := client.issueEntries(5)
+	if err != nil {
+		t.Fatalf("issueEntries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	e := entries[0]
+	if e.ID != syntheticID(sourceSentry, "acme", "101") || e.Title != "WEB-1A: KeyError: 'user'" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	for _, want := range []string{
+		"Sentry issue: https://sentry.example/issues/101/",
+		"Level: error | Events: 42 | Users: 7",
+		"release: 1.2.3",
+		"## Exception: KeyError: 'user'",
+		"[app] app/views.py:12 in profile",
+		"      django/core/handlers.py:47 in inner",
+		"- 2026-01-01T10:00:00Z [info] http: GET /profile",
+		"Reproduce the error first",
+	} {
+		if !strings.Contains(e.Body, want) {
+			t.Fatalf("body missing %q:\n%s", want, e.Body)
+		}
+	}
+	if strings.Contains(e.Body, "web-7") {
+		t.Fatalf("body should only list selected tags:\n%s", e.Body)
+	}
+
+	client.token = "wrong"
+	if _, err := client.issueEntries(5); err == nil || !strings.Contains(err.Error(), "bad token") {
+		t.Fatalf("expected auth error, got %v", err)
+	}
+}
+
+func TestTrackingIssueIsCreatedOnce(t *testing.T) {
+	t.Parallel()
+
+	logDir := t.TempDir()
+	calls := filepath.Join(logDir, "calls")
+	gh := writeFakeBin(t, "gh", `echo "$@" >> `+calls+`
+echo https://github.com/acme/web/issues/77`)
+	r := &runner{opts: options{GHBin: gh, LogDir: logDir}}
+	entry := issueEntry{ID: "sentry-0123456789", Source: sourceSentry, Title: "WEB-1A: boom", Body: "trace"}
+
+	for i := 0; i < 2; i++ {
+		number, err := r.trackingIssue(entry)
+		if err != nil {
+			t.Fatalf("trackingIssue: %v", err)
+		}
+		if number != "77" {
+			t.Fatalf("number = %q", number)
+		}
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "issue create"); got != 1 {
+		t.Fatalf("gh issue create called %d times:\n%s", got, data)
+	}
+}
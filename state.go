@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStateFileName = "state.json"
+
+	statusPending     = "pending"
+	statusInProgress  = "in-progress"
+	statusDone        = "done"
+	statusFailed      = "failed"
+	statusNeedsReview = "needs-review"
+	statusSkipped     = "skipped"
+)
+
+type issueState struct {
+	Issue       string            `json:"issue"`
+	Title       string            `json:"title,omitempty"`
+	Status      string            `json:"status"`
+	Agent       string            `json:"agent,omitempty"`
+	Model       string            `json:"model,omitempty"`
+	Attempts    int               `json:"attempts,omitempty"`
+	StartedAt   string            `json:"started_at,omitempty"`
+	FinishedAt  string            `json:"finished_at,omitempty"`
+	DurationSec float64           `json:"duration_sec,omitempty"`
+	Commit      string            `json:"commit,omitempty"`
+	LogPath     string            `json:"log_path,omitempty"`
+	Tokens      int               `json:"tokens,omitempty"`
+	CostUSD     float64           `json:"cost_usd,omitempty"`
+	Failure     string            `json:"failure,omitempty"`
+	Bench       []benchDelta      `json:"bench,omitempty"`
+	Criteria    []criterionResult `json:"criteria,omitempty"`
+	Findings    []contentFinding  `json:"findings,omitempty"`
+	Artifacts   []string          `json:"artifacts,omitempty"`
+	Changes     *diffSummary      `json:"changes,omitempty"`
+	PromptPath  string            `json:"prompt_path,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	PullRequest string            `json:"pull_request,omitempty"`
+	PRState     string            `json:"pr_state,omitempty"`
+	PRClosedAt  string            `json:"pr_closed_at,omitempty"`
+}
+
+type stateStore struct {
+	path   string
+	mu     sync.Mutex
+	Issues map[string]*issueState `json:"issues"`
+}
+
+func loadStateStore(path string) (*stateStore, error) {
+	store := &stateStore{path: path, Issues: make(map[string]*issueState)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("read state file: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return store, nil
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("parse state file %s: %w", path, err)
+	}
+	if store.Issues == nil {
+		store.Issues = make(map[string]*issueState)
+	}
+	return store, nil
+}
+
+func (s *stateStore) get(issue string) (issueState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.Issues[issue]
+	if !ok {
+		return issueState{}, false
+	}
+	return *st, true
+}
+
+func (s *stateStore) snapshot() map[string]issueState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]issueState, len(s.Issues))
+	for id, st := range s.Issues {
+		out[id] = *st
+	}
+	return out
+}
+
+func (s *stateStore) update(issue string, fn func(st *issueState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.Issues[issue]
+	if !ok {
+		st = &issueState{Issue: issue, Status: statusPending}
+		s.Issues[issue] = st
+	}
+	fn(st)
+	return s.saveLocked()
+}
+
+func (s *stateStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write state: %w", err)
+	}
+	return nil
+}
+
+type issueAttempt struct {
+	issue       string
+	startedAt   time.Time
+	commit      string
+	needsReview bool
+	logPath     string
+	logOutput   string
+	failure     failureCategory
+	bench       []benchDelta
+	criteria    []criterionResult
+	findings    []contentFinding
+	artifacts   []string
+	changes     *diffSummary
+	promptPath  string
+	// completed is set once the issue is in the done file.
+	completed bool
+}
+
+func (r *runner) beginAttempt(issue string) *issueAttempt {
+	attempt := &issueAttempt{issue: issue, startedAt: time.Now()}
+	if r.state == nil {
+		return attempt
+	}
+	err := r.state.update(issue, func(st *issueState) {
+		st.Status = statusInProgress
+		st.Agent = r.opts.Agent
+		st.Model = r.opts.Model
+		st.Attempts++
+		st.StartedAt = r.timestamp(attempt.startedAt)
+		st.FinishedAt = ""
+	})
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not update state for #%s: %v\n", issue, err)
+	}
+	return attempt
+}
+
+func (r *runner) finishAttempt(attempt *issueAttempt, title string, result issueResult) {
+	if r.state == nil || attempt == nil {
+		return
+	}
+	finished := time.Now()
+	err := r.state.update(attempt.issue, func(st *issueState) {
+		if title != "" {
+			st.Title = title
+		}
+		st.Failure = ""
+		switch result {
+		case resultSuccess:
+			st.Status = statusDone
+		case resultRetry:
+			st.Status = statusPending
+		case resultSkipped:
+			if r.isCompleted(attempt.issue) {
+				st.Status = statusDone
+			} else {
+				st.Status = statusSkipped
+			}
+		default:
+			if attempt.needsReview {
+				st.Status = statusNeedsReview
+			} else {
+				st.Status = statusFailed
+			}
+			st.Failure = string(attempt.failure)
+		}
+		if attempt.commit != "" {
+			st.Commit = attempt.commit
+		}
+		if attempt.logPath != "" {
+			st.LogPath = attempt.logPath
+		}
+		if attempt.promptPath != "" {
+			st.PromptPath = attempt.promptPath
+		}
+		if r.envFingerprint != "" {
+			st.Environment = r.envFingerprint
+		}
+		if attempt.bench != nil {
+			st.Bench = attempt.bench
+		}
+		if attempt.criteria != nil {
+			st.Criteria = attempt.criteria
+		}
+		st.Findings = attempt.findings
+		st.Artifacts = attempt.artifacts
+		if attempt.changes != nil {
+			st.Changes = attempt.changes
+		}
+		if attempt.logOutput != "" {
+			st.Tokens += parseTokenUsage(attempt.logOutput, st.Agent)
+		}
+		st.FinishedAt = r.timestamp(finished)
+		st.DurationSec += finished.Sub(attempt.startedAt).Round(time.Second).Seconds()
+	})
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not update state for #%s: %v\n", attempt.issue, err)
+	}
+}
+
+func parseTokenUsage(logOutput, agent string) int {
+	total := 0
+	for _, raw := range strings.Split(logOutput, "\n") {
+		line := strings.TrimSpace(raw)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(line), &payload); err != nil {
+			continue
+		}
+		switch agent {
+		case "codex":
+			if getStringField(payload, "type") != "turn.completed" {
+				continue
+			}
+			usage := asAnyMap(payload["usage"])
+			input, _ := getIntField(usage, "input_tokens")
+			output, _ := getIntField(usage, "output_tokens")
+			total += input + output
+		case "gemini":
+			models := asAnyMap(asAnyMap(payload["stats"])["models"])
+			for _, model := range models {
+				tokens := asAnyMap(asAnyMap(model)["tokens"])
+				if count, ok := getIntField(tokens, "total"); ok {
+					total += count
+				}
+			}
+		default:
+			usage := asAnyMap(payload["usage"])
+			input, _ := getIntField(usage, "input_tokens")
+			output, _ := getIntField(usage, "output_tokens")
+			total += input + output
+		}
+	}
+	return total
+}
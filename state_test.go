@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateStorePersists(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), defaultStateFileName)
+	store, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("loadStateStore: %v", err)
+	}
+	if err := store.update("7", func(st *issueState) {
+		st.Status = statusDone
+		st.Agent = "codex"
+		st.Commit = "abc123"
+	}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	reloaded, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	got, ok := reloaded.get("7")
+	if !ok {
+		t.Fatal("issue 7 missing after reload")
+	}
+	if got.Status != statusDone || got.Agent != "codex" || got.Commit != "abc123" || got.Issue != "7" {
+		t.Fatalf("unexpected state: %+v", got)
+	}
+}
+
+func TestFinishAttemptStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		result      issueResult
+		needsReview bool
+		completed   bool
+		want        string
+	}{
+		{name: "success", result: resultSuccess, want: statusDone},
+		{name: "retry goes back to pending", result: resultRetry, want: statusPending},
+		{name: "plain failure", result: resultFailed, want: statusFailed},
+		{name: "failure after commit needs review", result: resultFailed, needsReview: true, want: statusNeedsReview},
+		{name: "skipped", result: resultSkipped, want: statusSkipped},
+		{name: "skipped but completed", result: resultSkipped, completed: true, want: statusDone},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := loadStateStore(filepath.Join(t.TempDir(), defaultStateFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &runner{
+				opts:    options{Agent: "claude"},
+				state:   store,
+				doneSet: map[string]doneRecord{},
+			}
+			if tt.completed {
+				r.doneSet["1"] = doneRecord{ID: "1"}
+			}
+
+			attempt := r.beginAttempt("1")
+			if st, _ := store.get("1"); st.Status != statusInProgress || st.Attempts != 1 {
+				t.Fatalf("unexpected in-progress state: %+v", st)
+			}
+			attempt.needsReview = tt.needsReview
+			if tt.result == resultFailed {
+				attempt.failure = failureVerification
+			}
+			r.finishAttempt(attempt, "Title", tt.result)
+
+			st, _ := store.get("1")
+			if st.Status != tt.want {
+				t.Fatalf("status = %q, want %q", st.Status, tt.want)
+			}
+			if st.Title != "Title" || st.FinishedAt == "" {
+				t.Fatalf("unexpected final state: %+v", st)
+			}
+			if (tt.result == resultFailed) != (st.Failure == string(failureVerification)) {
+				t.Fatalf("failure category = %q for result %v", st.Failure, tt.result)
+			}
+		})
+	}
+}
+
+func TestParseTokenUsage(t *testing.T) {
+	t.Parallel()
+
+	codexLog := `{"type":"item.completed"}
+{"type":"turn.completed","usage":{"input_tokens":1000,"cached_input_tokens":200,"output_tokens":50}}
+not json
+{"type":"turn.completed","usage":{"input_tokens":10,"output_tokens":5}}`
+	if got := parseTokenUsage(codexLog, "codex"); got != 1065 {
+		t.Fatalf("codex tokens = %d, want 1065", got)
+	}
+
+	geminiLog := `{"response":"ok","stats":{"models":{"gemini-2.5-pro":{"tokens":{"prompt":90,"candidates":10,"total":100}}}}}`
+	if got := parseTokenUsage(geminiLog, "gemini"); got != 100 {
+		t.Fatalf("gemini tokens = %d, want 100", got)
+	}
+
+	if got := parseTokenUsage("plain text", "claude"); got != 0 {
+		t.Fatalf("claude tokens = %d, want 0", got)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// doneRecord is one line of the done file. Older files hold bare issue ids,
+// which load as records without metadata.
+type doneRecord struct {
+	ID          string
+	CompletedAt string
+	Agent       string
+	Commit      string
+	LogPath     string
+}
+
+// parseDoneLine reads "<id>\tcompleted_at=...\tagent=...\tcommit=...\tlog=...".
+// Unknown keys are ignored so newer files stay readable.
+func parseDoneLine(line string) (doneRecord, bool) {
+	fields := strings.Split(strings.TrimSpace(line), "\t")
+	rec := doneRecord{ID: strings.TrimSpace(fields[0])}
+	if rec.ID == "" {
+		return doneRecord{}, false
+	}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "completed_at":
+			rec.CompletedAt = value
+		case "agent":
+			rec.Agent = value
+		case "commit":
+			rec.Commit = value
+		case "log":
+			rec.LogPath = value
+		}
+	}
+	return rec, true
+}
+
+func (rec doneRecord) line() string {
+	parts := []string{rec.ID}
+	add := func(key, value string) {
+		value = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, value)
+		if value != "" {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	add("completed_at", rec.CompletedAt)
+	add("agent", rec.Agent)
+	add("commit", rec.Commit)
+	add("log", rec.LogPath)
+	return strings.Join(parts, "\t")
+}
+
+type statusEntry struct {
+	Issue       string       `json:"issue"`
+	Title       string       `json:"title,omitempty"`
+	Status      string       `json:"status"`
+	CompletedAt string       `json:"completed_at,omitempty"`
+	Agent       string       `json:"agent,omitempty"`
+	Commit      string       `json:"commit,omitempty"`
+	LogPath     string       `json:"log_path,omitempty"`
+	Failure     string       `json:"failure,omitempty"`
+	Artifacts   []string     `json:"artifacts,omitempty"`
+	Changes     *diffSummary `json:"changes,omitempty"`
+}
+
+func (r *runner) statusEntries(issues []issueEntry) []statusEntry {
+	out := make([]statusEntry, 0, len(issues))
+	for _, entry := range issues {
+		se := statusEntry{Issue: entry.ID, Title: entry.Title, Status: statusPending}
+		if r.state != nil {
+			if st, ok := r.state.get(entry.ID); ok {
+				if st.Title != "" {
+					se.Title = st.Title
+				}
+				se.Agent = st.Agent
+				se.Commit = st.Commit
+				se.LogPath = st.LogPath
+				se.Artifacts = st.Artifacts
+				se.Changes = st.Changes
+			}
+		}
+		if rec, ok := r.doneSet[entry.ID]; ok {
+			se.Status = statusDone
+			se.CompletedAt = rec.CompletedAt
+			if rec.Agent != "" {
+				se.Agent = rec.Agent
+			}
+			if rec.Commit != "" {
+				se.Commit = rec.Commit
+			}
+			if rec.LogPath != "" {
+				se.LogPath = rec.LogPath
+			}
+		} else if r.isSkipped(entry.ID) {
+			se.Status = statusSkipped
+		} else if st, ok := r.lastFailure(entry.ID); ok {
+			se.Status = st.Status
+			se.Failure = st.Failure
+		}
+		out = append(out, se)
+	}
+	return out
+}
+
+func (r *runner) printStatusJSON(issues []issueEntry) error {
+	enc := json.NewEncoder(r.stdout())
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(struct {
+		Issues []statusEntry `json:"issues"`
+	}{r.statusEntries(issues)}); err != nil {
+		return fmt.Errorf("write status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDoneLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+		want doneRecord
+		ok   bool
+	}{
+		{name: "bare id from older files", line: "42\n", want: doneRecord{ID: "42"}, ok: true},
+		{name: "blank", line: "  ", ok: false},
+		{
+			name: "metadata",
+			line: "7\tcompleted_at=2026-01-02T03:04:05Z\tagent=codex\tcommit=abc123\tlog=.ticket-runs/7.log\tfuture=x",
+			want: doneRecord{ID: "7", CompletedAt: "2026-01-02T03:04:05Z", Agent: "codex", Commit: "abc123", LogPath: ".ticket-runs/7.log"},
+			ok:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := parseDoneLine(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Fatalf("parseDoneLine(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+			if ok {
+				if again, _ := parseDoneLine(got.line()); again != got {
+					t.Fatalf("round trip = %+v, want %+v", again, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusEntriesUseDoneMetadata(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	doneFile := filepath.Join(dir, defaultDoneFileName)
+	if err := os.WriteFile(doneFile, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := loadStateStore(filepath.Join(dir, defaultStateFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.update("3", func(st *issueState) {
+		st.Status = statusFailed
+		st.Failure = string(failureNoChanges)
+		st.LogPath = "3.log"
+	}); err != nil {
+		t.Fatal(err)
+	}
+	done, err := loadDoneSet(doneFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &runner{opts: options{Agent: "gemini"}, doneFile: doneFile, doneSet: done, state: store}
+
+	if err := r.markCompleted("2", &issueAttempt{issue: "2", commit: "deadbeef", logPath: "2.log"}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := loadDoneSet(doneFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := reloaded["2"]; rec.Agent != "gemini" || rec.Commit != "deadbeef" || rec.LogPath != "2.log" || rec.CompletedAt == "" {
+		t.Fatalf("done record not persisted: %+v", rec)
+	}
+
+	got := r.statusEntries(entriesFromIDs([]string{"1", "2", "3", "4"}))
+	want := []statusEntry{
+		{Issue: "1", Status: statusDone},
+		{Issue: "2", Status: statusDone, CompletedAt: reloaded["2"].CompletedAt, Agent: "gemini", Commit: "deadbeef", LogPath: "2.log"},
+		{Issue: "3", Status: statusFailed, LogPath: "3.log", Failure: string(failureNoChanges)},
+		{Issue: "4", Status: statusPending},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
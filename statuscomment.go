@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	statusCommentMarker = "<!-- ghir:status"
+	statusHistoryOpen   = "<details><summary>History</summary>"
+	statusHistoryClose  = "</details>"
+)
+
+// statusComment is ghir's comment on an issue as returned by the GitHub API.
+type statusComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// postStatus keeps one ghir comment per issue up to date: the first status
+// creates it, later ones (a session-limit pause, a failed attempt, a rerun,
+// the final summary) edit it in place and move the previous status into a
+// collapsed history, so multi-attempt issues do not fill the thread.
+func (r *runner) postStatus(target, status string) error {
+	existing, err := r.findStatusComment(target)
+	if err != nil {
+		return err
+	}
+	body := statusCommentBody(r.timestamp(r.now()), status, existing.Body)
+	if existing.ID == 0 {
+		_, err = r.ghWrite("issue", "comment", target, "--body", body)
+		return err
+	}
+	_, err = r.ghWrite("api", "-X", "PATCH", fmt.Sprintf("repos/{owner}/{repo}/issues/comments/%d", existing.ID), "-f", "body="+body)
+	return err
+}
+
+// findStatusComment returns the latest ghir status comment on the issue, or
+// a zero comment when there is none yet.
+func (r *runner) findStatusComment(target string) (statusComment, error) {
+	out, err := r.commandOutput(r.opts.GHBin, "api", "--paginate", fmt.Sprintf("repos/{owner}/{repo}/issues/%s/comments", target),
+		"--jq", `.[] | select(.body | startswith("`+statusCommentMarker+`")) | {id, body}`)
+	if err != nil {
+		return statusComment{}, fmt.Errorf("list comments: %w", err)
+	}
+	var found statusComment
+	decoder := json.NewDecoder(strings.NewReader(out))
+	for {
+		var c statusComment
+		if err := decoder.Decode(&c); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return statusComment{}, fmt.Errorf("parse comments: %w", err)
+		}
+		if c.ID != 0 && strings.HasPrefix(c.Body, statusCommentMarker) {
+			found = c
+		}
+	}
+	return found, nil
+}
+
+// statusCommentBody renders the comment: a hidden marker with the time of the
+// status, the status itself, and the headlines of earlier statuses, newest
+// first.
+func statusCommentBody(stamp, status, previous string) string {
+	var history []string
+	if oldStamp, oldStatus, oldHistory, ok := parseStatusComment(previous); ok {
+		history = append(history, fmt.Sprintf("- %s: %s", oldStamp, headline(oldStatus)))
+		history = append(history, oldHistory...)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s -->\n%s\n", statusCommentMarker, stamp, strings.TrimSpace(status))
+	if len(history) > 0 {
+		fmt.Fprintf(&b, "\n%s\n\n%s\n\n%s\n", statusHistoryOpen, strings.Join(history, "\n"), statusHistoryClose)
+	}
+	return b.String()
+}
+
+func parseStatusComment(body string) (stamp, status string, history []string, ok bool) {
+	first, rest, _ := strings.Cut(body, "\n")
+	if !strings.HasPrefix(first, statusCommentMarker) {
+		return "", "", nil, false
+	}
+	stamp = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(first, statusCommentMarker), "-->"))
+	status, details, _ := strings.Cut(rest, statusHistoryOpen)
+	details, _, _ = strings.Cut(details, statusHistoryClose)
+	for _, line := range strings.Split(details, "\n") {
+		if strings.HasPrefix(line, "- ") {
+			history = append(history, line)
+		}
+	}
+	return stamp, status, history, true
+}
+
+// headline is the first non-empty line of a status.
+func headline(status string) string {
+	for _, line := range strings.Split(status, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusCommentBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		previous string
+		want     string
+	}{
+		{
+			name: "first status",
+			want: "<!-- ghir:status 2026-03-02T10:00:00Z -->\nImplemented by ghir in abc.\n",
+		},
+		{
+			name:     "previous status moves to the history",
+			previous: "<!-- ghir:status 2026-03-01T09:00:00Z -->\nPaused by the Claude session limit.\n\nmore detail\n",
+			want: "<!-- ghir:status 2026-03-02T10:00:00Z -->\nImplemented by ghir in abc.\n\n" +
+				"<details><summary>History</summary>\n\n" +
+				"- 2026-03-01T09:00:00Z: Paused by the Claude session limit.\n\n" +
+				"</details>\n",
+		},
+		{
+			name: "history is kept newest first",
+			previous: "<!-- ghir:status 2026-03-01T09:00:00Z -->\nghir could not finish this issue: verification failure.\n\n" +
+				"<details><summary>History</summary>\n\n- 2026-03-01T08:00:00Z: Paused by the Claude session limit.\n\n</details>\n",
+			want: "<!-- ghir:status 2026-03-02T10:00:00Z -->\nImplemented by ghir in abc.\n\n" +
+				"<details><summary>History</summary>\n\n" +
+				"- 2026-03-01T09:00:00Z: ghir could not finish this issue: verification failure.\n" +
+				"- 2026-03-01T08:00:00Z: Paused by the Claude session limit.\n\n" +
+				"</details>\n",
+		},
+		{
+			name:     "a comment without the marker is ignored",
+			previous: "Thanks, looks good",
+			want:     "<!-- ghir:status 2026-03-02T10:00:00Z -->\nImplemented by ghir in abc.\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := statusCommentBody("2026-03-02T10:00:00Z", "Implemented by ghir in abc.\n", tt.previous); got != tt.want {
+				t.Fatalf("statusCommentBody() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostStatusEditsTheExistingComment(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "gh.calls")
+	gh := writeFakeBin(t, "gh", `printf '%s\n' "$*" >> `+calls+`
+if [ "$1 $2" = "api --paginate" ]; then
+  printf '%s\n' '{"id":7,"body":"<!-- ghir:status 2026-03-01T09:00:00Z -->\nPaused by the Claude session limit.\n"}'
+fi`)
+	r := &runner{opts: options{GHBin: gh, NoColor: true}, repoRoot: dir}
+
+	if err := r.postStatus("5", "ghir could not finish this issue: verification failure."); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitN(string(data), "\n", 2)
+	if !strings.HasPrefix(lines[0], "api --paginate repos/{owner}/{repo}/issues/5/comments") {
+		t.Fatalf("first call = %q, want the comment lookup", lines[0])
+	}
+	for _, want := range []string{"api -X PATCH repos/{owner}/{repo}/issues/comments/7 -f body=<!-- ghir:status ", "ghir could not finish this issue: verification failure.", "- 2026-03-01T09:00:00Z: Paused by the Claude session limit."} {
+		if !strings.Contains(lines[1], want) {
+			t.Fatalf("edit call missing %q:\n%s", want, lines[1])
+		}
+	}
+	if strings.Contains(string(data), "issue comment") {
+		t.Fatalf("posted a new comment instead of editing:\n%s", data)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// timestampWriter starts every non-empty line with an RFC3339 timestamp in
+// the runner's time zone. The stamp is written when the first byte of the
+// line arrives, so lines assembled from several writes get one stamp, taken
+// when the line started.
+type timestampWriter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	stamp   func() string
+	midLine bool
+}
+
+func newTimestampWriter(out io.Writer, stamp func() string) *timestampWriter {
+	return &timestampWriter{out: out, stamp: stamp}
+}
+
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	var buf bytes.Buffer
+	for len(p) > 0 {
+		if !w.midLine && p[0] != '\n' {
+			buf.WriteString(w.stamp())
+			buf.WriteByte(' ')
+			w.midLine = true
+		}
+		end := bytes.IndexByte(p, '\n')
+		if end < 0 {
+			buf.Write(p)
+			break
+		}
+		buf.Write(p[:end+1])
+		w.midLine = false
+		p = p[end+1:]
+	}
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// stampLog wraps a log file writer with timestamps when --timestamps is on.
+func (r *runner) stampLog(w io.Writer) io.Writer {
+	if !r.opts.Timestamps {
+		return w
+	}
+	return newTimestampWriter(w, func() string { return r.timestamp(r.now()) })
+}
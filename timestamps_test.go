@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTimestampWriterStampsLineStarts(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	n := 0
+	w := newTimestampWriter(&out, func() string {
+		n++
+		return "T" + string(rune('0'+n))
+	})
+	for _, chunk := range []string{"first ", "line\n\nsecond\nthi", "rd\n"} {
+		if written, err := w.Write([]byte(chunk)); err != nil || written != len(chunk) {
+			t.Fatalf("Write(%q) = %d, %v", chunk, written, err)
+		}
+	}
+	if want := "T1 first line\n\nT2 second\nT3 third\n"; out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPrintfResetsColorBeforeNewline(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	r := &runner{colors: palette{Red: "<red>", Reset: "<reset>"}}
+	r.stampedOut = newTimestampWriter(&out, func() string { return "T" })
+	r.printf(r.colors.Red, "FAILED: %s\n\n", "boom")
+	r.printf("", "next\n")
+	if want := "T <red>FAILED: boom<reset>\n\nT next\n"; out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunAgentStampsLogButReturnsRawOutput(t *testing.T) {
+	t.Parallel()
+
+	agent := fakeAgent(t, `cat >/dev/null
+echo '{"usage":{"input_tokens":3,"output_tokens":4}}'
+echo done`)
+	logDir := t.TempDir()
+	opts := options{
+		Agent:      "claude",
+		ClaudeBin:  agent,
+		LogDir:     logDir,
+		DoneFile:   filepath.Join(logDir, defaultDoneFileName),
+		Timestamps: true,
+	}
+	r := newTestRunner(t, t.TempDir(), opts)
+	logPath := filepath.Join(logDir, "1.log")
+	_, output, err := r.runAgent("prompt", logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parseTokenUsage(output, "claude") != 7 {
+		t.Fatalf("raw output lost its JSON lines: %q", output)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z `)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !stamped.MatchString(line) {
+			t.Fatalf("log line without timestamp: %q", line)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimezone = "UTC"
+	fileStampLayout = "20060102T150405Z0700"
+)
+
+// loadTimezone accepts an IANA name (Europe/Stockholm), UTC, or Local for
+// the machine's zone.
+func loadTimezone(name string) (*time.Location, error) {
+	switch {
+	case name == "":
+		return time.UTC, nil
+	case strings.EqualFold(name, "local"):
+		return time.Local, nil
+	case strings.EqualFold(name, "utc"):
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+func (r *runner) location() *time.Location {
+	if r.loc == nil {
+		return time.UTC
+	}
+	return r.loc
+}
+
+func (r *runner) now() time.Time {
+	return time.Now().In(r.location())
+}
+
+func (r *runner) timestamp(t time.Time) string {
+	return t.In(r.location()).Format(time.RFC3339)
+}
+
+func (r *runner) fileStamp(t time.Time) string {
+	return t.In(r.location()).Format(fileStampLayout)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: "UTC"},
+		{name: "utc", want: "UTC"},
+		{name: "Local", want: "Local"},
+		{name: "Europe/Stockholm", want: "Europe/Stockholm"},
+		{name: "Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			loc, err := loadTimezone(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", loc)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc.String() != tt.want {
+				t.Fatalf("location = %s, want %s", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunnerTimestamps(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	utc := &runner{}
+	if got := utc.timestamp(at); got != "2026-03-01T12:30:00Z" {
+		t.Fatalf("utc timestamp = %s", got)
+	}
+	if got := utc.fileStamp(at); got != "20260301T123000Z" {
+		t.Fatalf("utc file stamp = %s", got)
+	}
+
+	ist := &runner{loc: time.FixedZone("IST", 5*3600+1800)}
+	if got := ist.timestamp(at); got != "2026-03-01T18:00:00+05:30" {
+		t.Fatalf("ist timestamp = %s", got)
+	}
+	if got := ist.fileStamp(at); got != "20260301T180000+0530" {
+		t.Fatalf("ist file stamp = %s", got)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	sourceGitHub = "github"
+	sourceTodos  = "todos"
+
+	sourceTodo          = "todo"
+	defaultTodoTags     = "TODO,FIXME"
+	todoMaxFileBytes    = 1 << 20
+	todoContextLines    = 5
+	todoMaxTitleRunes   = 72
+	todoRunnerConfigDir = ".ticket-runner/"
+)
+
+type todoComment struct {
+	path string
+	line int
+	tag  string
+	text string
+}
+
+// todoPattern matches a tag only after a comment marker, so identifiers and
+// strings that merely contain "TODO" are not picked up.
+func todoPattern(tags []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		quoted = append(quoted, regexp.QuoteMeta(tag))
+	}
+	return regexp.MustCompile(`(?://|#|/\*|<!--|--|;|^\s*\*)\s*(` + strings.Join(quoted, "|") + `)\b(?:\([^)]*\))?:?\s*(.*)$`)
+}
+
+func parseTodoTags(csv string) ([]string, error) {
+	var tags []string
+	for _, part := range strings.Split(csv, ",") {
+		tag := strings.TrimSpace(part)
+		if tag == "" {
+			continue
+		}
+		if strings.ContainsAny(tag, " \t") {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags given")
+	}
+	return tags, nil
+}
+
+func parseTodoPaths(csv string) []string {
+	var paths []string
+	for _, part := range strings.Split(csv, ",") {
+		if p := strings.Trim(strings.TrimSpace(part), "/"); p != "" {
+			paths = append(paths, filepath.ToSlash(p))
+		}
+	}
+	return paths
+}
+
+// todoPathAllowed keeps files under one of the given directories, or matching
+// one of them as a glob (against the full path or the base name).
+func todoPathAllowed(file string, paths []string) bool {
+	if strings.HasPrefix(file, todoRunnerConfigDir) {
+		return false
+	}
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+		if ok, _ := filepath.Match(p, file); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, filepath.Base(file)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *runner) loadTodoIssues() ([]issueEntry, error) {
+	entries, err := r.scanRepoTodos()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no %s comments found", strings.ReplaceAll(r.opts.TodoTags, ",", "/"))
+	}
+	return entries, nil
+}
+
+func (r *runner) scanRepoTodos() ([]issueEntry, error) {
+	tags, err := parseTodoTags(r.opts.TodoTags)
+	if err != nil {
+		return nil, fmt.Errorf("--todo-tags: %w", err)
+	}
+	out, err := r.gitOutput("ls-files", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("list tracked files: %w", err)
+	}
+	paths := parseTodoPaths(r.opts.TodoPaths)
+	var files []string
+	for _, f := range strings.Split(out, "\x00") {
+		if f = strings.TrimSpace(f); f != "" && todoPathAllowed(f, paths) {
+			files = append(files, f)
+		}
+	}
+	return scanTodos(r.repoRoot, files, tags)
+}
+
+// scanTodos turns each comment into a task. The id hashes the file, tag and
+// text (plus an occurrence count for repeats) but not the line number, so it
+// survives edits elsewhere in the file and disappears once the comment is
+// removed.
+func scanTodos(root string, files, tags []string) ([]issueEntry, error) {
+	pattern := todoPattern(tags)
+	var entries []issueEntry
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		if len(data) > todoMaxFileBytes || bytes.IndexByte(data, 0) >= 0 {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		seen := make(map[string]int)
+		for i, line := range lines {
+			m := pattern.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			c := todoComment{path: file, line: i + 1, tag: m[1], text: cleanTodoText(m[2])}
+			key := c.tag + "\x00" + c.text
+			seen[key]++
+			entries = append(entries, issueEntry{
+				ID:     syntheticID(sourceTodo, c.path, c.tag, c.text, fmt.Sprint(seen[key])),
+				Source: sourceTodo,
+				Title:  c.title(),
+				Body:   c.body(lines),
+			})
+		}
+	}
+	return entries, nil
+}
+
+func cleanTodoText(text string) string {
+	text = strings.TrimSpace(text)
+	for _, suffix := range []string{"*/", "-->"} {
+		text = strings.TrimSpace(strings.TrimSuffix(text, suffix))
+	}
+	return text
+}
+
+func (c todoComment) title() string {
+	text := c.text
+	if text == "" {
+		text = "(no description)"
+	}
+	title := fmt.Sprintf("%s in %s:%d: %s", c.tag, c.path, c.line, text)
+	if runes := []rune(title); len(runes) > todoMaxTitleRunes {
+		title = string(runes[:todoMaxTitleRunes-3]) + "..."
+	}
+	return title
+}
+
+func (c todoComment) body(lines []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "A %s comment in the code describes work that was never done.\n\n", c.tag)
+	fmt.Fprintf(&b, "File: %s\nLine: %d\n", c.path, c.line)
+	if c.text != "" {
+		fmt.Fprintf(&b, "Comment: %s\n", c.text)
+	}
+	start := c.line - 1 - todoContextLines
+	if start < 0 {
+		start = 0
+	}
+	end := c.line + todoContextLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	b.WriteString("\nContext:\n\n```\n")
+	for i := start; i < end; i++ {
+		fmt.Fprintf(&b, "%5d  %s\n", i+1, lines[i])
+	}
+	b.WriteString("```\n\n")
+	fmt.Fprintf(&b, "Do what the comment asks, then delete the %s comment. The task only counts as done once the comment is gone.\n", c.tag)
+	return b.String()
+}
+
+func (r *runner) confirmTodoRemoved(entry issueEntry) bool {
+	resolved, err := r.todoResolved(entry)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: could not re-scan for the comment of %s: %v\n", entry.ID, err)
+		return false
+	}
+	if !resolved {
+		r.printf(r.colors.Red, "FAILED: the comment for %s is still in the code (%s)\n", entry.ID, entry.Title)
+	}
+	return resolved
+}
+
+// todoResolved reports whether a todo task's comment is gone from the tree.
+func (r *runner) todoResolved(entry issueEntry) (bool, error) {
+	if entry.Source != sourceTodo {
+		return true, nil
+	}
+	entries, err := r.scanRepoTodos()
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if e.ID == entry.ID {
+			return false, nil
+		}
+	}
+	return true, nil
+}
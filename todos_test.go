@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanTodos(t *testing.T) {
+	t.Parallel()
+
+	repo := t.TempDir()
+	files := map[string]string{
+		"main.go":         "package main\n\n// TODO: handle retries\nfunc main() {\n\tmsg := \"TODO not a comment\"\n\t_ = msg // FIXME(bob) leaks\n}\n",
+		"tools/build.py":  "# TODO: handle retries\nprint('x')\n",
+		"docs/index.html": "<!-- TODO link the changelog -->\n",
+		"notes.txt":       "HACK: ignored by default tags\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags, _ := parseTodoTags(defaultTodoTags)
+	entries, err := scanTodos(repo, []string{"docs/index.html", "main.go", "notes.txt", "tools/build.py"}, tags)
+	if err != nil {
+		t.Fatalf("scanTodos: %v", err)
+	}
+	var titles []string
+	for _, e := range entries {
+		titles = append(titles, e.Title)
+		if e.Source != sourceTodo || !isIssueID(e.ID) {
+			t.Fatalf("unexpected entry: %+v", e)
+		}
+	}
+	want := []string{
+		"TODO in docs/index.html:1: link the changelog",
+		"TODO in main.go:3: handle retries",
+		"FIXME in main.go:6: leaks",
+		"TODO in tools/build.py:1: handle retries",
+	}
+	if strings.Join(titles, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("titles = %q, want %q", titles, want)
+	}
+	if !strings.Contains(entries[1].Body, "    3  // TODO: handle retries") {
+		t.Fatalf("body lacks context:\n%s", entries[1].Body)
+	}
+	if entries[1].ID == entries[3].ID {
+		t.Fatal("same comment in different files must get different ids")
+	}
+
+	// Moving the comment to another line keeps its id.
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n\n\n\n// TODO: handle retries\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := scanTodos(repo, []string{"main.go"}, tags)
+	if err != nil || len(moved) != 1 || moved[0].ID != entries[1].ID {
+		t.Fatalf("id changed after moving the comment: %+v, %v", moved, err)
+	}
+}
+
+func TestTodoPathAllowed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file  string
+		paths string
+		want  bool
+	}{
+		{file: "main.go", want: true},
+		{file: ".ticket-runner/prompt.tmpl", want: false},
+		{file: "internal/api/handler.go", paths: "internal/", want: true},
+		{file: "cmd/main.go", paths: "internal", want: false},
+		{file: "cmd/main.go", paths: "internal,*.go", want: true},
+		{file: "web/app.ts", paths: "web/*.ts", want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.file+"|"+tt.paths, func(t *testing.T) {
+			t.Parallel()
+
+			if got := todoPathAllowed(tt.file, parseTodoPaths(tt.paths)); got != tt.want {
+				t.Fatalf("todoPathAllowed(%q, %q) = %v, want %v", tt.file, tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTodoResolved(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	path := filepath.Join(repo, "lib.go")
+	if err := os.WriteFile(path, []byte("package lib\n// FIXME: drop the cache\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "lib.go")
+
+	r := &runner{opts: options{TodoTags: defaultTodoTags}, repoRoot: repo}
+	entries, err := r.loadTodoIssues()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("loadTodoIssues = %+v, %v", entries, err)
+	}
+	if ok, err := r.todoResolved(entries[0]); err != nil || ok {
+		t.Fatalf("todoResolved before removal = %v, %v", ok, err)
+	}
+	if err := os.WriteFile(path, []byte("package lib\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.todoResolved(entries[0]); err != nil || !ok {
+		t.Fatalf("todoResolved after removal = %v, %v", ok, err)
+	}
+	if _, err := r.loadTodoIssues(); err == nil || !strings.Contains(err.Error(), "no TODO/FIXME comments") {
+		t.Fatalf("expected an empty-backlog error, got %v", err)
+	}
+}
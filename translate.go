@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	translationsDirName     = "translations"
+	defaultTranslateModel   = "haiku"
+	translationHeading      = "English translation (machine-translated; the original above is authoritative):"
+	translateMinLetters     = 20
+	translateMinWords       = 8
+	translateNonASCIIPct    = 20
+	translateEnglishWordPct = 12
+)
+
+const translatePrompt = `Translate the following GitHub issue text into English. Keep code, identifiers, file paths, URLs, commands and Markdown formatting unchanged. Reply with the translation only, without any introduction or notes.
+
+`
+
+var (
+	fencedCodePattern = regexp.MustCompile("(?s)```.*?```")
+	inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+	urlPattern        = regexp.MustCompile(`https?://\S+`)
+)
+
+// englishWords make up a large share of any English text and almost none of
+// other languages.
+var englishWords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true,
+	"to": true, "is": true, "are": true, "was": true, "be": true, "it": true,
+	"this": true, "that": true, "for": true, "on": true, "with": true, "as": true,
+	"at": true, "by": true, "from": true, "not": true, "when": true, "should": true,
+	"we": true, "you": true, "if": true, "can": true, "but": true, "have": true,
+	"has": true, "there": true, "which": true, "would": true, "does": true,
+}
+
+// looksNonEnglish guesses whether prose is in another language than English,
+// ignoring code and URLs: mostly non-ASCII letters (Cyrillic, CJK, ...), or
+// Latin script with hardly any common English words. Short texts count as
+// English.
+func looksNonEnglish(text string) bool {
+	text = fencedCodePattern.ReplaceAllString(text, " ")
+	text = inlineCodePattern.ReplaceAllString(text, " ")
+	text = urlPattern.ReplaceAllString(text, " ")
+
+	letters, nonASCII := 0, 0
+	for _, c := range text {
+		if unicode.IsLetter(c) {
+			letters++
+			if c > unicode.MaxASCII {
+				nonASCII++
+			}
+		}
+	}
+	if letters < translateMinLetters {
+		return false
+	}
+	if nonASCII*100 >= letters*translateNonASCIIPct {
+		return true
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(c rune) bool { return !unicode.IsLetter(c) })
+	if len(words) < translateMinWords {
+		return false
+	}
+	english := 0
+	for _, word := range words {
+		if englishWords[word] {
+			english++
+		}
+	}
+	return english*100 < len(words)*translateEnglishWordPct
+}
+
+// withTranslation returns the issue body with an English translation below
+// the original when the body looks non-English, or the body as it is. A
+// failed translation only warns: the agent still gets the original.
+func (r *runner) withTranslation(issue, body string) string {
+	if !r.opts.Translate || !looksNonEnglish(body) {
+		return body
+	}
+	translation, cached, err := r.translate(issue, body)
+	if err != nil {
+		r.printf(r.colors.Yellow, "WARNING: could not translate #%s, using the original text: %v\n", issue, err)
+		return body
+	}
+	if !cached {
+		r.printf(r.colors.Blue, "Translated the body of #%s to English\n", issue)
+	}
+	return strings.TrimRight(body, "\n") + "\n\n---\n\n" + translationHeading + "\n\n" + translation + "\n"
+}
+
+// translate asks the claude CLI with --translate-model for an English
+// version of text. Without --dangerously-skip-permissions it cannot edit
+// the repository. Translations are kept in <log-dir>/translations by issue
+// and text, so retries and later runs of an unchanged issue reuse them.
+func (r *runner) translate(issue, text string) (string, bool, error) {
+	sum := sha256.Sum256([]byte(r.opts.TranslateModel + "\x00" + text))
+	path := filepath.Join(r.opts.LogDir, translationsDirName, issue+"-"+hex.EncodeToString(sum[:])[:10]+".md")
+	if data, err := os.ReadFile(path); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		return strings.TrimSpace(string(data)), true, nil
+	}
+
+	args := []string{"--print", "--output-format", "text", "--model", r.opts.TranslateModel}
+	cmd := exec.Command(r.opts.ClaudeBin, args...)
+	cmd.Dir = r.repoRoot
+	cmd.Stdin = strings.NewReader(translatePrompt + text)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	started := r.now()
+	err := cmd.Run()
+	r.debugCommand(r.opts.ClaudeBin, args, started, err)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", false, fmt.Errorf("%s: %w: %s", r.opts.ClaudeBin, err, msg)
+		}
+		return "", false, fmt.Errorf("%s: %w", r.opts.ClaudeBin, err)
+	}
+	translation := strings.TrimSpace(stdout.String())
+	if translation == "" {
+		return "", false, errors.New("empty translation")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+		_ = os.WriteFile(path, []byte(translation+"\n"), 0o644)
+	}
+	return translation, false, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLooksNonEnglish(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "english", text: "The export button does nothing when the list is empty. It should show a message instead of failing silently.", want: false},
+		{name: "swedish", text: "Exportknappen gör ingenting när listan är tom. Den borde visa ett meddelande i stället för att misslyckas tyst.", want: true},
+		{name: "german", text: "Der Export-Knopf macht nichts, wenn die Liste leer ist. Es sollte stattdessen eine Meldung angezeigt werden.", want: true},
+		{name: "russian", text: "Кнопка экспорта ничего не делает, когда список пуст.", want: true},
+		{name: "japanese", text: "リストが空のとき、エクスポートボタンが何もしません。メッセージを表示してください。", want: true},
+		{name: "english with code", text: "The export fails:\n```\nfel: listan är tom och exporten avbröts\n```\nIt should return an error instead.", want: false},
+		{name: "too short", text: "Fixa knappen", want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := looksNonEnglish(tt.text); got != tt.want {
+				t.Fatalf("looksNonEnglish(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTranslation(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	calls := filepath.Join(t.TempDir(), "calls")
+	claude := writeFakeBin(t, "claude", `cat >/dev/null
+echo "$*" >>`+calls+`
+echo "The export button does nothing when the list is empty."`)
+	opts := options{ClaudeBin: claude, LogDir: filepath.Join(repo, defaultLogDirName), Translate: true, TranslateModel: defaultTranslateModel}
+	r := newTestRunner(t, repo, opts)
+
+	body := "Exportknappen gör ingenting när listan är tom. Den borde visa ett meddelande i stället."
+	want := body + "\n\n---\n\n" + translationHeading + "\n\nThe export button does nothing when the list is empty.\n"
+	for i := 0; i < 2; i++ {
+		if got := r.withTranslation("7", body); got != want {
+			t.Fatalf("withTranslation = %q, want %q", got, want)
+		}
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "--print --output-format text --model haiku" {
+		t.Fatalf("claude calls = %q, want one cached call", got)
+	}
+
+	english := "The export button does nothing when the list is empty, which is confusing."
+	if got := r.withTranslation("8", english); got != english {
+		t.Fatalf("english body changed: %q", got)
+	}
+}
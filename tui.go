@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tuiRefreshInterval = 500 * time.Millisecond
+	tuiSizeEvery       = 4
+	tuiOutputLines     = 1000
+	tuiDefaultWidth    = 100
+	tuiDefaultHeight   = 30
+	tuiMaxQueueWidth   = 36
+
+	tuiStatusPending = "pending"
+	tuiStatusRunning = "running"
+	tuiStatusWaiting = "waiting"
+	tuiStatusDone    = "done"
+	tuiStatusSkipped = "skipped"
+	tuiStatusFailed  = "failed"
+)
+
+// tuiScreen is a minimal full-screen view drawn with plain ANSI sequences.
+// It doubles as the runner's stdout while active, so everything the runner
+// prints lands in the output pane.
+type tuiScreen struct {
+	mu       sync.Mutex
+	out      io.Writer
+	header   string
+	ids      []string
+	titles   map[string]string
+	statuses map[string]string
+	current  string
+	lines    []string
+	partial  string
+	resumeAt time.Time
+	loc      *time.Location
+	width    int
+	height   int
+
+	stopOnce sync.Once
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newTUIScreen(out io.Writer, header string, entries []issueEntry, loc *time.Location) *tuiScreen {
+	s := &tuiScreen{
+		out:      out,
+		header:   header,
+		titles:   make(map[string]string),
+		statuses: make(map[string]string),
+		loc:      loc,
+		width:    tuiDefaultWidth,
+		height:   tuiDefaultHeight,
+		done:     make(chan struct{}),
+	}
+	for _, entry := range entries {
+		s.ids = append(s.ids, entry.ID)
+		s.statuses[entry.ID] = tuiStatusPending
+	}
+	return s
+}
+
+func (r *runner) startTUI(issues []issueEntry) error {
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return errors.New("--tui needs an interactive terminal")
+	}
+	header := fmt.Sprintf("Ticket Runner | %s", agentDisplayName(r.opts.Agent))
+	if r.opts.Model != "" {
+		header += " (" + r.opts.Model + ")"
+	}
+	s := newTUIScreen(os.Stdout, header, issues, r.location())
+	for _, entry := range issues {
+		switch {
+		case r.isCompleted(entry.ID):
+			s.setStatus(entry.ID, tuiStatusDone)
+		case r.isSkipped(entry.ID):
+			s.setStatus(entry.ID, tuiStatusSkipped)
+		}
+	}
+	s.start()
+	r.tui = s
+	return nil
+}
+
+func (r *runner) stopTUI() {
+	if r.tui == nil {
+		return
+	}
+	r.tui.stop()
+	r.tui = nil
+}
+
+func (r *runner) tuiStatus(issue, status string) {
+	if r.tui != nil {
+		r.tui.setStatus(issue, status)
+	}
+}
+
+func (s *tuiScreen) start() {
+	fmt.Fprint(s.out, "\x1b[?1049h\x1b[?25l")
+	s.resize()
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer signal.Stop(interrupts)
+		ticker := time.NewTicker(tuiRefreshInterval)
+		defer ticker.Stop()
+		for tick := 1; ; tick++ {
+			select {
+			case <-s.done:
+				return
+			case <-interrupts:
+				s.restore()
+				os.Exit(130)
+			case <-ticker.C:
+				if tick%tuiSizeEvery == 0 {
+					s.resize()
+				}
+				s.draw(time.Now())
+			}
+		}
+	}()
+}
+
+func (s *tuiScreen) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.restore()
+	})
+}
+
+func (s *tuiScreen) restore() {
+	fmt.Fprint(s.out, "\x1b[?25h\x1b[?1049l")
+}
+
+func (s *tuiScreen) resize() {
+	width, height := terminalSize()
+	s.mu.Lock()
+	s.width, s.height = width, height
+	s.mu.Unlock()
+}
+
+func (s *tuiScreen) draw(now time.Time) {
+	frame := s.render(now)
+	fmt.Fprint(s.out, frame)
+}
+
+func (s *tuiScreen) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partial += string(p)
+	for {
+		idx := strings.IndexByte(s.partial, '\n')
+		if idx < 0 {
+			break
+		}
+		s.lines = append(s.lines, plainText(s.partial[:idx]))
+		s.partial = s.partial[idx+1:]
+	}
+	if len(s.lines) > tuiOutputLines {
+		s.lines = append([]string(nil), s.lines[len(s.lines)-tuiOutputLines:]...)
+	}
+	return len(p), nil
+}
+
+func (s *tuiScreen) setStatus(issue, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[issue] = status
+	if status == tuiStatusRunning {
+		s.current = issue
+	}
+}
+
+func (s *tuiScreen) setCurrent(issue, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = issue
+	s.titles[issue] = title
+}
+
+func (s *tuiScreen) setResume(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resumeAt = at
+}
+
+// render returns one full frame: a header line, the queue pane beside the
+// output pane, and a status bar at the bottom.
+func (s *tuiScreen) render(now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	width, height := s.width, s.height
+	if width < 40 {
+		width = 40
+	}
+	if height < 6 {
+		height = 6
+	}
+	bodyHeight := height - 2
+	queueWidth := width / 3
+	if queueWidth > tuiMaxQueueWidth {
+		queueWidth = tuiMaxQueueWidth
+	}
+	outputWidth := width - queueWidth - 3
+
+	counts := map[string]int{}
+	for _, id := range s.ids {
+		counts[s.statuses[id]]++
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	header := fmt.Sprintf("%s | %d issues | done %d | failed %d | skipped %d",
+		s.header, len(s.ids), counts[tuiStatusDone], counts[tuiStatusFailed], counts[tuiStatusSkipped])
+	b.WriteString("\x1b[7m" + padRight(fitWidth(header, width), width) + "\x1b[0m\r\n")
+
+	queue := s.queueLines(bodyHeight, queueWidth)
+	output := s.outputLines(bodyHeight, outputWidth)
+	for i := 0; i < bodyHeight; i++ {
+		b.WriteString(padRight(queue[i], queueWidth))
+		b.WriteString(" | ")
+		b.WriteString(output[i])
+		b.WriteString("\x1b[K\r\n")
+	}
+
+	footer := "Ctrl-C to abort"
+	if !s.resumeAt.IsZero() {
+		left := s.resumeAt.Sub(now).Round(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		footer = fmt.Sprintf("SESSION LIMIT: resuming at %s (%s left) | %s",
+			s.resumeAt.In(s.loc).Format("15:04 MST"), left, footer)
+	}
+	b.WriteString("\x1b[7m" + padRight(fitWidth(footer, width), width) + "\x1b[0m\x1b[J")
+	return b.String()
+}
+
+func (s *tuiScreen) queueLines(height, width int) []string {
+	lines := make([]string, height)
+	start := 0
+	for i, id := range s.ids {
+		if id == s.current && i >= height/2 {
+			start = i - height/2
+		}
+	}
+	if start > len(s.ids)-height && len(s.ids) > height {
+		start = len(s.ids) - height
+	}
+	for i := 0; i < height && start+i < len(s.ids); i++ {
+		id := s.ids[start+i]
+		marker := " "
+		if id == s.current {
+			marker = ">"
+		}
+		line := fmt.Sprintf("%s %-8s #%s %s", marker, s.statuses[id], id, s.titles[id])
+		lines[i] = fitWidth(line, width)
+	}
+	return lines
+}
+
+func (s *tuiScreen) outputLines(height, width int) []string {
+	lines := make([]string, height)
+	title := "Output"
+	if s.current != "" {
+		title = fmt.Sprintf("#%s %s", s.current, s.titles[s.current])
+	}
+	lines[0] = fitWidth(title, width)
+	if height > 1 {
+		lines[1] = strings.Repeat("-", width)
+	}
+	visible := height - 2
+	tail := s.lines
+	if s.partial != "" {
+		tail = append(tail[:len(tail):len(tail)], plainText(s.partial))
+	}
+	if len(tail) > visible {
+		tail = tail[len(tail)-visible:]
+	}
+	for i, line := range tail {
+		lines[2+i] = fitWidth(line, width)
+	}
+	return lines
+}
+
+func fitWidth(value string, width int) string {
+	value = strings.ReplaceAll(value, "\t", "    ")
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func padRight(value string, width int) string {
+	if n := len([]rune(value)); n < width {
+		return value + strings.Repeat(" ", width-n)
+	}
+	return value
+}
+
+func terminalSize() (int, int) {
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		cmd := exec.Command("stty", "size")
+		cmd.Stdin = tty
+		if out, err := cmd.Output(); err == nil {
+			if fields := strings.Fields(string(out)); len(fields) == 2 {
+				rows, rowErr := strconv.Atoi(fields[0])
+				cols, colErr := strconv.Atoi(fields[1])
+				if rowErr == nil && colErr == nil && rows > 0 && cols > 0 {
+					return cols, rows
+				}
+			}
+		}
+	}
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	height, _ := strconv.Atoi(os.Getenv("LINES"))
+	if width <= 0 {
+		width = tuiDefaultWidth
+	}
+	if height <= 0 {
+		height = tuiDefaultHeight
+	}
+	return width, height
+}
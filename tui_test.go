@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTUIScreenRender(t *testing.T) {
+	t.Parallel()
+
+	entries := []issueEntry{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	s := newTUIScreen(nil, "Ticket Runner | Codex", entries, time.UTC)
+	s.width, s.height = 60, 8
+	s.setStatus("1", tuiStatusDone)
+	s.setStatus("2", tuiStatusRunning)
+	s.setCurrent("2", "Fix the parser")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(s, "\x1b[32mline %d\x1b[0m\n", i)
+	}
+	fmt.Fprint(s, "partial")
+
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	s.setResume(now.Add(90 * time.Second))
+	frame := s.render(now)
+
+	for _, want := range []string{
+		"3 issues | done 1 | failed 0",
+		"> running  #2 Fix...",
+		"  pending  #3",
+		"#2 Fix the parser",
+		"line 9",
+		"partial",
+		"resuming at 15:01 UTC (1m30s left)",
+	} {
+		if !strings.Contains(frame, want) {
+			t.Fatalf("frame missing %q:\n%s", want, frame)
+		}
+	}
+	if strings.Contains(frame, "line 5") || strings.Contains(frame, "\x1b[32m") {
+		t.Fatalf("frame should only show the newest plain output lines:\n%s", frame)
+	}
+	if rows := strings.Count(frame, "\r\n"); rows != 7 {
+		t.Fatalf("frame has %d rows, want 7", rows)
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	t.Parallel()
+
+	if got := fitWidth("abcdefgh", 5); got != "ab..." {
+		t.Fatalf("fitWidth = %q", got)
+	}
+	if got := fitWidth("äöü", 3); got != "äöü" {
+		t.Fatalf("fitWidth multibyte = %q", got)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	verbosityVerbose = 1
+	verbosityDebug   = 2
+)
+
+// debugf prints runner internals once -v (level 1) or -vv (level 2) asks
+// for them.
+func (r *runner) debugf(level int, format string, values ...any) {
+	if r.opts.Verbosity < level {
+		return
+	}
+	r.printf("", "[debug] "+format, values...)
+}
+
+// describeAgentCommand renders the agent argv for -v output. The prompt is
+// replaced by its size, whether it is passed as an argument or on stdin.
+func describeAgentCommand(cmd *exec.Cmd, prompt string) string {
+	placeholder := fmt.Sprintf("<prompt: %d bytes>", len(prompt))
+	parts := make([]string, 0, len(cmd.Args)+2)
+	for _, arg := range cmd.Args {
+		if arg == prompt {
+			parts = append(parts, placeholder)
+			continue
+		}
+		parts = append(parts, shellQuote(arg))
+	}
+	if cmd.Stdin != nil {
+		parts = append(parts, "<", placeholder)
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func (r *runner) debugCommand(name string, args []string, started time.Time, err error) {
+	if r.opts.Verbosity < verbosityDebug {
+		return
+	}
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, shellQuote(name))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	r.debugf(verbosityDebug, "$ %s (%s, %s)\n", strings.Join(quoted, " "), status, time.Since(started).Round(time.Millisecond))
+}
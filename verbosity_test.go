@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsVerbosity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		args      []string
+		verbosity int
+		quiet     bool
+		wantErr   string
+	}{
+		{name: "default", args: nil},
+		{name: "verbose", args: []string{"-v"}, verbosity: verbosityVerbose},
+		{name: "repeated", args: []string{"-v", "-v"}, verbosity: verbosityDebug},
+		{name: "capped", args: []string{"run", "-vv", "-v"}, verbosity: verbosityDebug},
+		{name: "quiet", args: []string{"status", "--quiet"}, quiet: true},
+		{name: "quiet and verbose", args: []string{"-q", "-v"}, wantErr: "--quiet cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := parseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			if opts.Verbosity != tt.verbosity || opts.Quiet != tt.quiet {
+				t.Fatalf("verbosity = %d, quiet = %v", opts.Verbosity, opts.Quiet)
+			}
+		})
+	}
+}
+
+func TestDescribeAgentCommand(t *testing.T) {
+	t.Parallel()
+
+	prompt := "Fix issue #1\nwith 'quotes'"
+
+	byArg := exec.Command("codex", "exec", "--json", prompt)
+	if got := describeAgentCommand(byArg, prompt); got != "codex exec --json <prompt: 26 bytes>" {
+		t.Fatalf("argument prompt: %q", got)
+	}
+
+	byStdin := exec.Command("claude", "--print", "--model", "opus 4")
+	byStdin.Stdin = strings.NewReader(prompt)
+	if got := describeAgentCommand(byStdin, prompt); got != "claude --print --model 'opus 4' < <prompt: 26 bytes>" {
+		t.Fatalf("stdin prompt: %q", got)
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type verifyResult struct {
+	Command   string
+	Passed    bool
+	ExitCode  int
+	Output    string
+	LogPath   string
+	Artifacts []string
+}
+
+func expandVerifyCommand(command, issue string) string {
+	return strings.ReplaceAll(command, "{{ISSUE_NUMBER}}", issue)
+}
+
+func (r *runner) runVerify(issue string) (verifyResult, error) {
+	return r.runVerifyIn(r.repoRoot, issue, filepath.Join(r.opts.LogDir, issue+".verify.log"))
+}
+
+func (r *runner) runVerifyIn(dir, issue, logPath string) (verifyResult, error) {
+	return r.runCheck(dir, expandVerifyCommand(r.opts.VerifyCmd, issue), logPath)
+}
+
+// runCheck runs a shell command in dir with its output going to logPath.
+func (r *runner) runCheck(dir, command, logPath string) (verifyResult, error) {
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return verifyResult{}, fmt.Errorf("create verify log: %w", err)
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	var buf bytes.Buffer
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = r.cacheEnv
+	cmd.Stdout = io.MultiWriter(r.stampLog(logFile), &buf)
+	cmd.Stderr = cmd.Stdout
+
+	result := verifyResult{Command: command, LogPath: logPath}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return result, fmt.Errorf("start %q: %w", command, err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+	result.Passed = result.ExitCode == 0
+	result.Output = buf.String()
+	return result, nil
+}
+
+// verifyIssue runs --verify-cmd for the change since base. With
+// --verify-scope changed the command is narrowed to what the change can
+// affect; an empty base always runs the full command.
+func (r *runner) verifyIssue(issue, base string) bool {
+	_, passed := r.verifyIssueResult(issue, base)
+	return passed
+}
+
+// verifyIssueResult is verifyIssue that also returns the failing run, so its
+// output can be handed back to the agent. The result is zero when the
+// command could not run at all.
+func (r *runner) verifyIssueResult(issue, base string) (result verifyResult, passed bool) {
+	if r.opts.VerifyCmd == "" {
+		return verifyResult{}, true
+	}
+	defer func() {
+		r.record(journalEntry{Event: journalVerified, Issue: issue, Passed: boolPtr(passed)})
+	}()
+
+	if r.opts.VerifyScope == verifyScopeChanged && base != "" {
+		command, reason := r.scopedVerifyCommand(base)
+		if command == "" {
+			r.printf(r.colors.Blue, "Running the full suite for #%s: %s\n", issue, reason)
+		} else {
+			scoped := *r
+			scoped.opts.VerifyCmd = command
+			r = &scoped
+		}
+	}
+
+	if dir, err := r.prepareArtifacts(issue); err != nil {
+		r.printf(r.colors.Yellow, "WARNING: %v\n", err)
+	} else {
+		env := r.cacheEnv
+		if env == nil {
+			env = os.Environ()
+		}
+		withArtifacts := *r
+		withArtifacts.cacheEnv = append(env[:len(env):len(env)], artifactsEnv+"="+dir)
+		r = &withArtifacts
+	}
+
+	r.printf(r.colors.Yellow, "Verifying issue #%s: %s\n", issue, expandVerifyCommand(r.opts.VerifyCmd, issue))
+	result, err := r.runVerify(issue)
+	result.Artifacts = r.collectArtifacts(issue)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: verification could not run for #%s: %v\n", issue, err)
+		return verifyResult{}, false
+	}
+	if result.Passed {
+		r.printf(r.colors.Green, "Verification passed for issue #%s\n", issue)
+		return result, true
+	}
+
+	if r.opts.Baseline != "" || r.snapshot != nil {
+		baseline, label, err := r.knownFailures(issue)
+		if err != nil {
+			r.printf(r.colors.Red, "FAILED: baseline verification could not run for #%s: %v\n", issue, err)
+			return result, false
+		}
+		newFailures, tolerated := compareFailures(baseline, result)
+		if tolerated {
+			r.printf(r.colors.Yellow, "Verification failed for #%s, but only with failures already present on %s (log: %s)\n", issue, label, result.LogPath)
+			return result, true
+		}
+		if len(newFailures) > 0 {
+			r.printf(r.colors.Red, "FAILED: issue #%s introduces %d new failure(s) compared to %s:\n", issue, len(newFailures), label)
+			for _, failure := range newFailures {
+				r.printf(r.colors.Red, "  %s\n", failure)
+			}
+			r.printf(r.colors.Red, "Check log: %s\n", result.LogPath)
+			return result, false
+		}
+	}
+
+	r.printf(r.colors.Red, "FAILED: verification exited with code %d for issue #%s\n", result.ExitCode, issue)
+	for _, line := range compactMultiline(tailLines(result.Output, 20), 20, 4000) {
+		r.printf(r.colors.Red, "  %s\n", line)
+	}
+	r.printf(r.colors.Red, "Check log: %s\n", result.LogPath)
+	return result, false
+}
+
+func (r *runner) knownFailures(issue string) (verifyResult, string, error) {
+	if r.snapshot != nil {
+		return *r.snapshot, "the batch-start snapshot", nil
+	}
+	baseline, err := r.baselineVerify(issue)
+	return baseline, r.opts.Baseline, err
+}
+
+type failureSnapshot struct {
+	CreatedAt string   `json:"created_at"`
+	Head      string   `json:"head"`
+	Command   string   `json:"command"`
+	ExitCode  int      `json:"exit_code"`
+	Failures  []string `json:"failures"`
+	LogPath   string   `json:"log_path"`
+}
+
+func (r *runner) takeFailureSnapshot() error {
+	dirty, err := r.workingTreeDirty()
+	if err != nil {
+		return fmt.Errorf("cannot determine git status: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("--snapshot-failures needs a clean working tree")
+	}
+	head, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("cannot determine git HEAD: %w", err)
+	}
+
+	r.printf(r.colors.Blue, "Snapshotting pre-existing failures: %s\n", r.opts.VerifyCmd)
+	result, err := r.runVerifyIn(r.repoRoot, "snapshot", filepath.Join(r.opts.LogDir, "snapshot.verify.log"))
+	if err != nil {
+		return fmt.Errorf("snapshot failures: %w", err)
+	}
+	r.snapshot = &result
+
+	snapshot := failureSnapshot{
+		CreatedAt: r.timestamp(time.Now()),
+		Head:      head,
+		Command:   r.opts.VerifyCmd,
+		ExitCode:  result.ExitCode,
+		Failures:  extractFailures(result.Output),
+		LogPath:   result.LogPath,
+	}
+	if snapshot.Failures == nil {
+		snapshot.Failures = []string{}
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode failure snapshot: %w", err)
+	}
+	snapshotPath := filepath.Join(r.opts.LogDir, "failure-snapshot.json")
+	if err := os.WriteFile(snapshotPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write failure snapshot: %w", err)
+	}
+
+	switch {
+	case result.Passed:
+		r.printf(r.colors.Green, "Clean tree verifies; any failure will be attributed to the agent\n")
+	case len(snapshot.Failures) > 0:
+		r.printf(r.colors.Yellow, "Tolerating %d pre-existing failure(s) (snapshot: %s)\n", len(snapshot.Failures), snapshotPath)
+	default:
+		r.printf(r.colors.Yellow, "Clean tree already fails verification (exit %d) without identifiable failures; see %s\n", result.ExitCode, result.LogPath)
+	}
+	fmt.Fprintln(r.stdout())
+	return nil
+}
+
+func (r *runner) baselineVerify(issue string) (verifyResult, error) {
+	command := expandVerifyCommand(r.opts.VerifyCmd, issue)
+	var cached verifyResult
+	var ok bool
+	r.locked(func() {
+		cached, ok = r.baselines[command]
+	})
+	if ok {
+		return cached, nil
+	}
+
+	if _, err := r.gitOutput("rev-parse", "--verify", "--quiet", r.opts.Baseline+"^{commit}"); err != nil {
+		return verifyResult{}, fmt.Errorf("unknown baseline ref %q", r.opts.Baseline)
+	}
+
+	dir, err := os.MkdirTemp("", "ghir-baseline-")
+	if err != nil {
+		return verifyResult{}, fmt.Errorf("create baseline worktree dir: %w", err)
+	}
+	defer func() {
+		_, _ = r.gitOutput("worktree", "remove", "--force", dir)
+		_ = os.RemoveAll(dir)
+	}()
+	if _, err := r.gitOutput("worktree", "add", "--detach", dir, r.opts.Baseline); err != nil {
+		return verifyResult{}, fmt.Errorf("create baseline worktree: %w", err)
+	}
+	r.linkSharedDirs(dir)
+
+	r.printf(r.colors.Blue, "Verifying baseline %s for comparison...\n", r.opts.Baseline)
+	result, err := r.runVerifyIn(dir, issue, filepath.Join(r.opts.LogDir, issue+".baseline.verify.log"))
+	if err != nil {
+		return verifyResult{}, err
+	}
+	r.locked(func() {
+		r.baselines[command] = result
+	})
+	return result, nil
+}
+
+func (r *runner) reverify() (int, error) {
+	issues, err := r.reverifyCandidates()
+	if err != nil {
+		return 0, err
+	}
+	if len(issues) == 0 {
+		r.printf(r.colors.Yellow, "No completed issues to re-verify\n")
+		return 0, nil
+	}
+
+	if dirty, err := r.workingTreeDirty(); err == nil && dirty {
+		r.printf(r.colors.Yellow, "WARNING: working tree has uncommitted changes; results reflect the dirty tree, not HEAD.\n")
+	}
+	head, err := r.gitOutput("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return 0, fmt.Errorf("cannot determine git HEAD: %w", err)
+	}
+
+	r.printf(r.colors.Blue, "Re-verifying %d completed issue(s) against %s\n", len(issues), head)
+
+	cache := make(map[string]verifyResult)
+	var regressed []string
+	for _, issue := range issues {
+		command := expandVerifyCommand(r.opts.VerifyCmd, issue)
+		result, cached := cache[command]
+		if !cached {
+			result, err = r.runVerify(issue)
+			if err != nil {
+				return len(regressed), err
+			}
+			cache[command] = result
+		}
+
+		if result.Passed {
+			r.printf(r.colors.Green, "  #%s ok\n", issue)
+			continue
+		}
+		r.printf(r.colors.Red, "  #%s REGRESSED (exit %d, log: %s)\n", issue, result.ExitCode, result.LogPath)
+		regressed = append(regressed, issue)
+	}
+
+	if len(regressed) == 0 {
+		r.printf(r.colors.Green, "All completed issues still verify\n")
+		return 0, nil
+	}
+
+	for _, issue := range regressed {
+		delete(r.doneSet, issue)
+	}
+	if err := r.markPending(regressed); err != nil {
+		return len(regressed), err
+	}
+	if err := r.rewriteDoneFile(fmt.Sprintf("Marked %d regressed issue(s) pending again\n", len(regressed))); err != nil {
+		return len(regressed), err
+	}
+
+	if r.opts.Reopen {
+		for _, issue := range regressed {
+			comment := fmt.Sprintf("Reopened by ghir: verification `%s` no longer passes at %s.", expandVerifyCommand(r.opts.VerifyCmd, issue), head)
+			if _, err := r.ghWrite("issue", "reopen", issue, "--comment", comment); err != nil {
+				r.printf(r.colors.Red, "WARNING: could not reopen #%s: %v\n", issue, err)
+				continue
+			}
+			r.printf(r.colors.Yellow, "Reopened #%s\n", issue)
+		}
+	}
+	return len(regressed), nil
+}
+
+func (r *runner) reverifyCandidates() ([]string, error) {
+	if r.opts.SingleIssue != "" || r.opts.IssuesCSV != "" {
+		issues, err := r.loadIssues()
+		if err != nil {
+			return nil, err
+		}
+		var completed []string
+		for _, entry := range issues {
+			if r.isCompleted(entry.ID) {
+				completed = append(completed, entry.ID)
+			}
+		}
+		return completed, nil
+	}
+
+	var completed []string
+	for id := range r.doneSet {
+		completed = append(completed, id)
+	}
+	sortStringsNumeric(completed)
+	return completed, nil
+}
+
+func tailLines(value string, n int) string {
+	lines := strings.Split(strings.TrimRight(value, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	goTestFailPattern    = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	goPackageFailPattern = regexp.MustCompile(`^FAIL\s+(\S+)(?:\s+\[[^\]]+\]|\s+[\d.]+s)\s*$`)
+	pytestFailPattern    = regexp.MustCompile(`^(?:FAILED|ERROR)\s+(\S+::\S+)`)
+	jestFailPattern      = regexp.MustCompile(`^\s*FAIL\s+(\S+\.[jt]sx?)\b`)
+	cargoFailPattern     = regexp.MustCompile(`^test\s+(\S+)\s+\.\.\.\s+FAILED`)
+	lintFindingPattern   = regexp.MustCompile(`^(\S+?\.[A-Za-z0-9]+):\d+(?::\d+)?:\s*(.+)$`)
+)
+
+func extractFailures(output string) []string {
+	var failures []string
+	seen := make(map[string]struct{})
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		var name string
+		for _, pattern := range []*regexp.Regexp{goTestFailPattern, goPackageFailPattern, pytestFailPattern, jestFailPattern, cargoFailPattern} {
+			if match := pattern.FindStringSubmatch(line); len(match) > 1 {
+				name = match[1]
+				break
+			}
+		}
+		if name == "" {
+			if match := lintFindingPattern.FindStringSubmatch(line); len(match) > 2 {
+				name = match[1] + ": " + strings.TrimSpace(match[2])
+			}
+		}
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[name]; exists {
+			continue
+		}
+		seen[name] = struct{}{}
+		failures = append(failures, name)
+	}
+	return failures
+}
+
+func compareFailures(baseline, current verifyResult) ([]string, bool) {
+	if current.Passed {
+		return nil, true
+	}
+	if baseline.Passed {
+		return extractFailures(current.Output), false
+	}
+
+	// A run whose failures cannot be named (a crash, a timeout, an unknown
+	// runner) is only tolerated when the baseline failed the same way.
+	currentFailures := extractFailures(current.Output)
+	baselineFailures := extractFailures(baseline.Output)
+	if len(currentFailures) == 0 {
+		return nil, len(baselineFailures) == 0 && baseline.ExitCode == current.ExitCode
+	}
+
+	known := make(map[string]struct{})
+	for _, failure := range baselineFailures {
+		known[failure] = struct{}{}
+	}
+	var introduced []string
+	for _, failure := range currentFailures {
+		if _, exists := known[failure]; !exists {
+			introduced = append(introduced, failure)
+		}
+	}
+	return introduced, len(introduced) == 0
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsReverify(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseArgs([]string{"reverify", "--verify-cmd", "go test ./...", "--reopen"})
+	if err != nil {
+		t.Fatalf("parseArgs returned unexpected error: %v", err)
+	}
+	if opts.Command != commandReverify {
+		t.Fatalf("command mismatch: got %q want %q", opts.Command, commandReverify)
+	}
+	if opts.VerifyCmd != "go test ./..." || !opts.Reopen {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	opts, err = parseArgs([]string{"reverify"})
+	if err != nil {
+		t.Fatalf("parseArgs returned unexpected error: %v", err)
+	}
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "reverify requires --verify-cmd") {
+		t.Fatalf("expected missing --verify-cmd error, got %v", err)
+	}
+}
+
+func TestReverifyFlagsRegressions(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	opts := options{
+		LogDir:    filepath.Join(repo, defaultLogDirName),
+		VerifyCmd: `test "{{ISSUE_NUMBER}}" != "2"`,
+	}
+	opts.DoneFile = filepath.Join(opts.LogDir, defaultDoneFileName)
+	if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(opts.DoneFile, []byte("1\n2\n3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRunner(t, repo, opts)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := r.state.update(id, func(s *issueState) { s.Status = statusDone }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	regressions, err := r.reverify()
+	if err != nil {
+		t.Fatalf("reverify: %v", err)
+	}
+	if regressions != 1 {
+		t.Fatalf("regressions = %d, want 1", regressions)
+	}
+	if r.isCompleted("2") || !r.isCompleted("1") || !r.isCompleted("3") {
+		t.Fatalf("unexpected done set: %v", r.doneSet)
+	}
+
+	data, err := os.ReadFile(opts.DoneFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "1\n3\n" {
+		t.Fatalf("done file mismatch: got %q", string(data))
+	}
+	if st, _ := r.state.get("2"); st.Status != statusPending {
+		t.Fatalf("regressed #2 state = %q, want pending", st.Status)
+	}
+	if got := boardStatus(t, r, "2"); got != statusPending {
+		t.Fatalf("regressed #2 board status = %q, want pending", got)
+	}
+	if got := boardStatus(t, r, "1"); got != statusDone {
+		t.Fatalf("#1 board status = %q, want done", got)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	t.Parallel()
+
+	if got := tailLines("a\nb\nc\n", 2); got != "b\nc" {
+		t.Fatalf("tailLines() = %q", got)
+	}
+	if got := tailLines("a", 5); got != "a" {
+		t.Fatalf("tailLines() = %q", got)
+	}
+}
+
+func TestExtractFailures(t *testing.T) {
+	t.Parallel()
+
+	output := strings.Join([]string{
+		"=== RUN   TestA",
+		"--- FAIL: TestA (0.00s)",
+		"    --- FAIL: TestA/sub_case (0.00s)",
+		"FAIL",
+		"FAIL\texample.com/pkg\t0.012s",
+		"FAIL\texample.com/broken [build failed]",
+		"FAILED tests/test_api.py::test_get - AssertionError",
+		"ERROR tests/test_db.py::test_conn",
+		" FAIL  src/app.test.tsx",
+		"test parser::tests::parses ... FAILED",
+		"internal/store.go:12:3: ineffectual assignment to err",
+		"--- FAIL: TestA (0.01s)",
+		"ok  \texample.com/other\t0.1s",
+	}, "\n")
+
+	want := []string{
+		"TestA",
+		"TestA/sub_case",
+		"example.com/pkg",
+		"example.com/broken",
+		"tests/test_api.py::test_get",
+		"tests/test_db.py::test_conn",
+		"src/app.test.tsx",
+		"parser::tests::parses",
+		"internal/store.go: ineffectual assignment to err",
+	}
+	if got := extractFailures(output); !slices.Equal(got, want) {
+		t.Fatalf("extractFailures() = %v, want %v", got, want)
+	}
+}
+
+func TestCompareFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		baseline      verifyResult
+		current       verifyResult
+		wantNew       []string
+		wantTolerated bool
+	}{
+		{
+			name:          "current passes",
+			baseline:      verifyResult{Passed: false, Output: "--- FAIL: TestOld"},
+			current:       verifyResult{Passed: true},
+			wantTolerated: true,
+		},
+		{
+			name:     "baseline green means every failure is new",
+			baseline: verifyResult{Passed: true},
+			current:  verifyResult{Output: "--- FAIL: TestNew (0.00s)"},
+			wantNew:  []string{"TestNew"},
+		},
+		{
+			name:          "only pre-existing failures",
+			baseline:      verifyResult{Output: "--- FAIL: TestOld (0.00s)\n--- FAIL: TestFlaky (0.00s)"},
+			current:       verifyResult{Output: "--- FAIL: TestOld (0.00s)"},
+			wantTolerated: true,
+		},
+		{
+			name:     "new failure on top of pre-existing ones",
+			baseline: verifyResult{Output: "--- FAIL: TestOld (0.00s)"},
+			current:  verifyResult{Output: "--- FAIL: TestOld (0.00s)\n--- FAIL: TestNew (0.00s)"},
+			wantNew:  []string{"TestNew"},
+		},
+		{
+			name:          "unparseable output on an already red baseline",
+			baseline:      verifyResult{ExitCode: 1, Output: "boom"},
+			current:       verifyResult{ExitCode: 1, Output: "boom"},
+			wantTolerated: true,
+		},
+		{
+			name:     "unparseable output with a different exit code",
+			baseline: verifyResult{ExitCode: 1, Output: "boom"},
+			current:  verifyResult{ExitCode: 124, Output: "killed"},
+		},
+		{
+			name:     "crash on a baseline with named failures",
+			baseline: verifyResult{ExitCode: 1, Output: "--- FAIL: TestOld (0.00s)"},
+			current:  verifyResult{ExitCode: 2, Output: "panic: runtime error"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotNew, gotTolerated := compareFailures(tt.baseline, tt.current)
+			if gotTolerated != tt.wantTolerated {
+				t.Fatalf("tolerated = %v, want %v", gotTolerated, tt.wantTolerated)
+			}
+			if !slices.Equal(gotNew, tt.wantNew) {
+				t.Fatalf("new failures = %v, want %v", gotNew, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestVerifyIssueAgainstBaseline(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	commitFile(t, repo, "results.txt", "--- FAIL: TestOld (0.00s)\n")
+	runGit(t, repo, "tag", "base")
+
+	opts := options{
+		LogDir:    filepath.Join(repo, defaultLogDirName),
+		VerifyCmd: "cat results.txt; ! grep -q FAIL results.txt",
+		Baseline:  "base",
+	}
+	r := newTestRunner(t, repo, opts)
+
+	if !r.verifyIssue("1", "") {
+		t.Fatal("pre-existing failure should be tolerated")
+	}
+
+	commitFile(t, repo, "results.txt", "--- FAIL: TestOld (0.00s)\n--- FAIL: TestNew (0.00s)\n")
+	if r.verifyIssue("1", "") {
+		t.Fatal("new failure should fail verification")
+	}
+}
+
+func TestFailureSnapshotToleratesPreexistingFailures(t *testing.T) {
+	t.Parallel()
+
+	repo := initTestRepo(t)
+	commitFile(t, repo, "results.txt", "pkg/a.go:3:1: exported func A should have comment\n")
+	commitFile(t, repo, ".gitignore", ".ticket-runs/\n")
+
+	opts := options{
+		LogDir:        filepath.Join(repo, defaultLogDirName),
+		VerifyCmd:     "cat results.txt; exit 1",
+		SnapshotFails: true,
+	}
+	r := newTestRunner(t, repo, opts)
+	if err := r.takeFailureSnapshot(); err != nil {
+		t.Fatalf("takeFailureSnapshot: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.opts.LogDir, "failure-snapshot.json"))
+	if err != nil {
+		t.Fatalf("read snapshot: %v", err)
+	}
+	if !strings.Contains(string(data), "pkg/a.go: exported func A should have comment") {
+		t.Fatalf("snapshot missing lint finding: %s", data)
+	}
+
+	commitFile(t, repo, "results.txt", "pkg/a.go:9:1: exported func A should have comment\n")
+	if !r.verifyIssue("1", "") {
+		t.Fatal("moved pre-existing finding should be tolerated")
+	}
+
+	commitFile(t, repo, "results.txt", "pkg/a.go:9:1: exported func A should have comment\npkg/b.go:1:1: unused import\n")
+	if r.verifyIssue("1", "") {
+		t.Fatal("new finding should fail verification")
+	}
+
+	commitFile(t, repo, "results.txt", "segmentation fault\n")
+	if r.verifyIssue("1", "") {
+		t.Fatal("a run without identifiable failures should fail verification")
+	}
+}
+
+func TestParseArgsSnapshotFailuresValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--snapshot-failures"}, wantErr: "--snapshot-failures requires --verify-cmd"},
+		{args: []string{"--snapshot-failures", "--verify-cmd", "make test", "--baseline", "main"}, wantErr: "cannot be combined with --baseline"},
+		{args: []string{"--snapshot-failures", "--verify-cmd", "make test-{{ISSUE_NUMBER}}"}, wantErr: "does not depend on {{ISSUE_NUMBER}}"},
+		{args: []string{"--snapshot-failures", "--verify-cmd", "make test"}},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args)
+		if err == nil {
+			err = validateOptions(opts)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Fatalf("options %v returned unexpected error: %v", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("options %v error = %v, want substring %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
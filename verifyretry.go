@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	verifyFeedbackLines    = 200
+	verifyFeedbackMaxBytes = 20000
+)
+
+// verifyWithRetries runs the verify command and, while it fails and
+// --verify-retries allows, hands the failure output back to the agent with
+// the original prompt so it can fix its own change.
+func (r *runner) verifyWithRetries(issue, startHead, prompt string, attempt *issueAttempt) bool {
+	result, passed := r.verifyIssueResult(issue, startHead)
+	attempt.artifacts = result.Artifacts
+	for retry := 1; !passed && retry <= r.opts.VerifyRetries; retry++ {
+		if result.Command == "" {
+			// The command could not run; the agent has nothing to fix.
+			return false
+		}
+		r.printf(r.colors.Yellow, "Asking %s to fix the verification failures of #%s (retry %d/%d)...\n", agentDisplayName(r.opts.Agent), issue, retry, r.opts.VerifyRetries)
+		if !r.fixVerifyFailures(issue, verifyFixPrompt(prompt, issue, result), attempt) {
+			return false
+		}
+		result, passed = r.verifyIssueResult(issue, startHead)
+		attempt.artifacts = result.Artifacts
+	}
+	return passed
+}
+
+// verifyFixPrompt is the original prompt followed by the tail of the failing
+// verify output.
+func verifyFixPrompt(prompt, issue string, result verifyResult) string {
+	output := tailLines(result.Output, verifyFeedbackLines)
+	if len(output) > verifyFeedbackMaxBytes {
+		output = output[len(output)-verifyFeedbackMaxBytes:]
+	}
+	return fmt.Sprintf("%s\n\n## Verification failures\n\nYour change for #%s is committed, but the verification command `%s` failed with exit code %d. Its output (last %d lines):\n\n```\n%s\n```\n\nFix these failures. Do not weaken or skip the failing checks. Commit the fix with a message that mentions #%s, and do not push.\n",
+		strings.TrimRight(prompt, "\n"), issue, result.Command, result.ExitCode, verifyFeedbackLines, strings.TrimRight(output, "\n"), issue)
+}
+
+// fixVerifyFailures runs the agent once more on the issue and commits what
+// it left uncommitted. It reports whether there is a new change to verify.
+func (r *runner) fixVerifyFailures(issue, prompt string, attempt *issueAttempt) bool {
+	before, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: cannot determine git HEAD: %v\n", err)
+		return false
+	}
+	logPath, err := r.attemptLogPath(issue)
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: cannot create log for #%s: %v\n", issue, err)
+		return false
+	}
+	r.printf("", "Log: %s\n", logPath)
+	r.savePrompt(logPath, prompt)
+	r.record(journalEntry{Event: journalAgentInvoked, Issue: issue, Agent: r.opts.Agent, Model: r.opts.Model, LogPath: logPath, PromptBytes: len(prompt)})
+	started := time.Now()
+	runnerFiles := r.snapshotRunnerFiles()
+	exitCode, logOutput, err := r.runAgent(prompt, logPath)
+	attempt.logPath = logPath
+	attempt.logOutput += "\n" + logOutput
+	if err != nil {
+		r.printf(r.colors.Red, "FAILED: %s invocation failed for #%s: %v\n", r.opts.Agent, issue, err)
+		r.record(journalEntry{Event: journalAgentExited, Issue: issue, DurationSec: time.Since(started).Round(time.Second).Seconds(), Error: err.Error()})
+		r.guardRunnerFiles(issue, before, runnerFiles)
+		return false
+	}
+	r.record(journalEntry{Event: journalAgentExited, Issue: issue, ExitCode: intPtr(exitCode), DurationSec: time.Since(started).Round(time.Second).Seconds()})
+	if !r.guardRunnerFiles(issue, before, runnerFiles) {
+		return false
+	}
+	if r.sessionLimitHit(logOutput, exitCode) {
+		r.printf(r.colors.Red, "FAILED: %s hit its session limit while fixing #%s\n", agentDisplayName(r.opts.Agent), issue)
+		return false
+	}
+	if exitCode != 0 {
+		r.printf(r.colors.Red, "FAILED: %s exited with code %d while fixing #%s (log: %s)\n", r.opts.Agent, exitCode, issue, logPath)
+		return false
+	}
+
+	if dirty, err := r.workingTreeDirty(); err == nil && dirty {
+		if !r.buildIssue(issue) {
+			r.shelveUnbuilt(issue)
+			return false
+		}
+		message := fmt.Sprintf(r.tr("fix: address verification failures for #%s"), issue) + r.coAuthorTrailer()
+		if err := r.commitAll(message); err != nil {
+			r.printf(r.colors.Red, "FAILED: could not commit the fix for #%s: %v\n", issue, err)
+			return false
+		}
+	}
+	r.formatCommits(issue, before)
+	head, err := r.gitOutput("rev-parse", "HEAD")
+	if err != nil || head == before {
+		r.printf(r.colors.Red, "FAILED: %s made no changes to fix #%s (log: %s)\n", agentDisplayName(r.opts.Agent), issue, logPath)
+		return false
+	}
+	attempt.commit = head
+	r.recordCommits(issue, before, head)
+	return true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyRetriesFeedFailuresBackToTheAgent(t *testing.T) {
+	t.Parallel()
+
+	// The agent commits a broken change; given the verify output it fixes
+	// the file but leaves the commit to the runner.
+	agentScript := `[ "$1" = --version ] && exit 0
+case "$(cat)" in
+  *"Verification failures"*"BROKEN: f.txt"*) echo good > f.txt ;;
+  *) echo bad > f.txt && git add f.txt && git commit -q -m "feat: add f (#5)" ;;
+esac`
+	tests := []struct {
+		name        string
+		retries     int
+		wantCode    int
+		wantSubject string
+	}{
+		{name: "fixed on retry", retries: 1, wantSubject: "fix: address verification failures for #5"},
+		{name: "no retries", retries: 0, wantCode: 1, wantSubject: "feat: add f (#5)"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := initTestRepo(t)
+			gh := fakeIssueGH(t, "Add f", "add f")
+			agent := fakeAgent(t, agentScript)
+			opts := options{
+				Agent:         "claude",
+				ClaudeBin:     agent,
+				GHBin:         gh,
+				SingleIssue:   "5",
+				VerifyCmd:     `if grep -q bad f.txt; then echo "BROKEN: f.txt"; exit 1; fi`,
+				VerifyRetries: tt.retries,
+				CoAuthor:      coAuthorNone,
+			}
+			r := newTestRunner(t, repo, opts)
+			if code := r.runQueue(); code != tt.wantCode {
+				t.Fatalf("runQueue() = %d, want %d", code, tt.wantCode)
+			}
+			if subject := runGit(t, repo, "log", "-1", "--pretty=%B"); strings.TrimSpace(subject) != tt.wantSubject {
+				t.Fatalf("HEAD message = %q, want %q", subject, tt.wantSubject)
+			}
+			if tt.retries > 0 {
+				prompts, _ := filepath.Glob(filepath.Join(r.runDir, "5.attempt-2.prompt.md"))
+				if len(prompts) != 1 {
+					t.Fatalf("fix prompt was not saved next to the second attempt log")
+				}
+				data, err := os.ReadFile(prompts[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(data), "## Issue: Add f") || !strings.Contains(string(data), "failed with exit code 1") {
+					t.Fatalf("fix prompt lacks the original prompt or the failure:\n%s", data)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyRetriesOptions(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseArgs([]string{"--verify-retries", "-1"}); err == nil {
+		t.Fatal("negative --verify-retries should fail")
+	}
+	opts, err := parseArgs([]string{"--verify-retries", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOptions(opts); err == nil || !strings.Contains(err.Error(), "requires --verify-cmd") {
+		t.Fatalf("validateOptions() = %v, want --verify-cmd requirement", err)
+	}
+}